@@ -19,12 +19,15 @@ package accumulation
 
 import (
 	"fmt"
+	"go/ast"
+	"go/types"
 	"reflect"
 	"runtime/debug"
 
 	"go.uber.org/nilaway/annotation"
 	"go.uber.org/nilaway/assertion"
 	"go.uber.org/nilaway/assertion/function/assertiontree"
+	"go.uber.org/nilaway/assertion/function/functioncontracts"
 	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/diagnostic"
 	"go.uber.org/nilaway/inference"
@@ -41,11 +44,13 @@ const _doc = "Read the assertions and annotations from this package as Results f
 var Analyzer = &analysis.Analyzer{
 	Name: "nilaway_accumulation_analyzer",
 	Doc:  _doc,
-	Run:  run,
+	Run:  config.Profiled(run),
 	FactTypes: []analysis.Fact{
 		new(inference.InferredMap),
+		new(CacheKey),
+		new(ExportsChanged),
 	},
-	Requires:   []*analysis.Analyzer{config.Analyzer, assertion.Analyzer, annotation.Analyzer},
+	Requires:   []*analysis.Analyzer{config.Analyzer, assertion.Analyzer, annotation.Analyzer, functioncontracts.Analyzer},
 	ResultType: reflect.TypeOf(([]analysis.Diagnostic)(nil)),
 }
 
@@ -92,6 +97,26 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	}()
 
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+
+	// The cache keys are computed for all packages (including the ones out of scope), since their
+	// changes may affect the analysis of the downstream packages.
+	cacheKey, cacheable := "", false
+	if conf.CacheDir != "" {
+		if cacheKey, cacheable = computeCacheKey(pass, conf); cacheable {
+			pass.ExportPackageFact(&CacheKey{Hash: cacheKey})
+		}
+	}
+	// The results of a focused package only cover the code in focus, hence they must neither be
+	// reused from nor stored to the cache.
+	if conf.HasFocus() && conf.IsPkgInFocus(pass) {
+		cacheable = false
+	}
+	// The sites are only dumped (or the sessions recorded) when the package is actually inferred,
+	// hence the cache must not be reused either.
+	if conf.DumpSites != nil || conf.RecordFile != "" {
+		cacheable = false
+	}
+
 	if !conf.IsPkgInScope(pass.Pkg) {
 		// Must return a typed nil since the driver is using reflection to retrieve the result.
 		return ([]analysis.Diagnostic)(nil), nil
@@ -99,6 +124,7 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 
 	assertionsResult := pass.ResultOf[assertion.Analyzer].(assertion.Result)
 	annotationsResult := pass.ResultOf[annotation.Analyzer].(annotation.Result)
+	contractsResult := pass.ResultOf[functioncontracts.Analyzer].(functioncontracts.Result)
 	var errs []error
 	for _, resultErrs := range [...][]error{assertionsResult.Errors, annotationsResult.Errors} {
 		errs = append(errs, resultErrs...)
@@ -111,11 +137,28 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		return errorsToDiagnostics(errs), nil
 	}
 
+	// Reuse the cached results if the package and its dependencies are unchanged since the last run.
+	if cacheable {
+		diagnostics, ok, err := loadCache(pass, conf.CacheDir, cacheKey)
+		if err != nil {
+			return nil, fmt.Errorf("load cache: %w", err)
+		}
+		if ok {
+			return filterUnchanged(pass, conf, filterUnfocused(pass, conf, diagnostics))
+		}
+	}
+
 	diagnosticEngine := diagnostic.NewEngine(pass)
 
 	// Create an inference engine and observe (load) information from upstream dependencies (i.e.,
 	// mappings between annotation sites and their inferred values).
 	inferenceEngine := inference.NewEngine(pass, diagnosticEngine)
+	inferenceEngine.LimitSites(conf.MaxSites)
+	inferenceEngine.DistrustPkgs(conf.IsPkgPathDistrusted)
+	var session *inference.Session
+	if conf.RecordFile != "" {
+		session = inferenceEngine.Record()
+	}
 	inferenceEngine.ObserveUpstream()
 
 	// Determine inference type based on comments in package doc string.
@@ -125,6 +168,33 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	// for FullInfer mode, otherwise all annotations for NoInfer)
 	inferenceEngine.ObserveAnnotations(annotationsResult.AnnotationMap, mode)
 
+	// Override the nilability of the sites with the stubs, which take precedence over the
+	// treatment of the external returns below.
+	if err := inferenceEngine.ObserveStubs(conf.Stubs, mode); err != nil {
+		return nil, fmt.Errorf("observe stubs: %w", err)
+	}
+
+	// If configured, treat the pointer results of the functions in out-of-scope (or distrusted)
+	// packages as nilable instead of leaving them to the optimistic (nonnil) default.
+	if conf.ExternalReturnsNilable() {
+		inScope := func(pkg *types.Package) bool {
+			return conf.IsPkgInScope(pkg) && !conf.IsPkgPathDistrusted(pkg.Path())
+		}
+		inferenceEngine.ObserveExternalReturns(assertionsResult.FullTriggers, inScope, mode)
+	}
+
+	// Similarly, if configured, seed the nilability of the pointer fields of the structs from
+	// their constructors.
+	if conf.ConstructorFields {
+		var files []*ast.File
+		for _, file := range pass.Files {
+			if conf.IsFileInScope(file) {
+				files = append(files, file)
+			}
+		}
+		inferenceEngine.ObserveConstructors(files)
+	}
+
 	var (
 		inferredMap *inference.InferredMap
 		diagnostics []analysis.Diagnostic
@@ -133,10 +203,16 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	case inference.FullInfer:
 		// Incorporate assertions from this package one-by-one into the inferredAnnotationMap, possibly
 		// determining local and upstream sites in the process. This is guaranteed not to determine any
-		// sites unless we really have a reason they have to be determined.
+		// sites unless we really have a reason they have to be determined. The implication
+		// contracts of the functions are observed first as additional edges in the graph.
+		inferenceEngine.ObserveImplicationContracts(contractsResult.ImplicationContracts)
 		inferenceEngine.ObservePackage(assertionsResult.FullTriggers)
 		inferredMap = inferenceEngine.InferredMap()
-		diagnostics = diagnosticEngine.Diagnostics(true /* grouping */)
+		if conf.GroupErrors {
+			diagnostics = diagnosticEngine.DiagnosticsGroupedByRoot()
+		} else {
+			diagnostics = diagnosticEngine.Diagnostics(true /* grouping */)
+		}
 
 	case inference.NoInfer:
 		// In non-inference case - use the classical assertionNode.CheckErrors method to determine error outputs
@@ -144,12 +220,56 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		checkErrors(assertionsResult.FullTriggers, inferredMap, diagnosticEngine)
 		// Retrieve the diagnostics from the engine. Note that we should not group the
 		// diagnostics for easier unit testing.
-		diagnostics = diagnosticEngine.Diagnostics(false /* grouping */)
+		if conf.GroupErrors {
+			diagnostics = diagnosticEngine.DiagnosticsGroupedByRoot()
+		} else {
+			diagnostics = diagnosticEngine.Diagnostics(false /* grouping */)
+		}
 
 	default:
 		panic("Invalid mode for running NilAway")
 	}
 
+	// Record the size of the inferred map for correlating it with the time spent on the package.
+	conf.RecordInferredSites(pass, inferredMap.Len())
+
+	if conf.ReportUndetermined {
+		// Report the public API surface whose nilability cannot be pinned down instead. Note that
+		// all local sites are determined by annotations or defaults in NoInfer mode.
+		diagnostics = diagnosticEngine.UndeterminedSiteDiagnostics(inferredMap.UndeterminedExportedSites(pass.Pkg.Path()))
+	} else if conf.StrictExported {
+		// In strict mode, the public API surface whose nilability is not determined is reported
+		// as errors along with the potential nil panics.
+		diagnostics = append(diagnostics, diagnosticEngine.StrictExportedDiagnostics(inferredMap.UndeterminedExportedSites(pass.Pkg.Path()))...)
+	}
+
+	// The malformed implication contracts and struct tags are reported as well, since they are
+	// otherwise silently ignored.
+	diagnostics = append(diagnostics, contractsResult.InvalidContracts...)
+	diagnostics = append(diagnostics, annotationsResult.InvalidTags...)
+
+	// The redundant nil checks are only reported in FullInfer mode, since the sites are determined
+	// by the defaults (instead of any evidence) in NoInfer mode.
+	if conf.ReportRedundantChecks && mode == inference.FullInfer {
+		diagnostics = append(diagnostics, diagnostic.RedundantNilCheckDiagnostics(pass, conf, inferredMap)...)
+	}
+
+	// If required, the nolint directives without a reason are reported as well, which still
+	// suppress the diagnostics though.
+	if conf.RequireSuppressionReason {
+		diagnostics = append(diagnostics, diagnostic.UnjustifiedSuppressionDiagnostics(pass, conf)...)
+	}
+
+	// Flag the truncated analysis with a single diagnostic on the package clause, such that the
+	// (possibly) missing errors are not silently hidden.
+	if inferenceEngine.Truncated() {
+		diagnostics = append(diagnostics, analysis.Diagnostic{
+			Pos: pass.Files[0].Package,
+			Message: fmt.Sprintf("analysis truncated: the number of inference sites exceeds the limit of %d "+
+				"(-%s), potential nil panics in this package may be missed", conf.MaxSites, config.MaxSitesFlag),
+		})
+	}
+
 	// Export the _incremental_ information from this inferred map for analysis of downstream
 	// packages via the Fact mechanism (which [uses gob encoding under the hood]). The custom
 	// GobEncode / GobDecode methods of InferredAnnotationMap ensure that only incremental
@@ -159,9 +279,36 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	//
 	// [uses gob encoding under the hood]: https://pkg.go.dev/golang.org/x/tools/go/analysis#hdr-Modular_analysis_with_Facts
 	// [gob encoding]: https://pkg.go.dev/encoding/gob#hdr-Basics
-	inferredMap.Export(pass)
+	//
+	// If ExportInScopeOnly is set, the leaf sites of the out-of-scope (e.g., third-party) packages
+	// are not exported either, trading their inferred nilability for smaller facts.
+	var inScope func(pkgPath string) bool
+	if conf.ExportInScopeOnly {
+		inScope = conf.IsPkgPathInScope
+	}
+	inferredMap.Export(pass, inScope)
+
+	if conf.DumpSites != nil {
+		if err := dumpSites(pass, conf, inferredMap); err != nil {
+			return nil, err
+		}
+	}
+
+	if session != nil {
+		if err := recordSession(conf.RecordFile, session); err != nil {
+			return nil, err
+		}
+	}
+
+	if cacheable {
+		if err := storeCache(pass, conf.CacheDir, cacheKey, diagnostics); err != nil {
+			return nil, fmt.Errorf("store cache: %w", err)
+		}
+	}
 
-	return diagnostics, nil
+	// If only the changed packages are reported, drop the diagnostics of the other packages after
+	// the results are cached (since the cache entries must not depend on the list).
+	return filterUnchanged(pass, conf, filterUnfocused(pass, conf, diagnostics))
 }
 
 // errorsToDiagnostics converts the internal errors to a slice of analysis.Diagnostic to be reported.
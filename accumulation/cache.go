@@ -0,0 +1,286 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _cacheVersion is the version of the cache entry format, which is part of the cache keys such
+// that entries written by incompatible versions are never read.
+const _cacheVersion = 2
+
+// CacheKey is the fact exported for every package when the cache is enabled, which stores the
+// content hash of the package and (transitively) its upstream dependencies. Downstream packages
+// include the hashes of their dependencies in their own hashes, such that their cached results
+// are invalidated whenever any of the dependencies changes.
+type CacheKey struct {
+	Hash string
+}
+
+// AFact is a function that enables the use of CacheKey as a fact in the analysis framework.
+func (*CacheKey) AFact() {}
+
+func (k *CacheKey) String() string {
+	return "CacheKey(" + k.Hash + ")"
+}
+
+// cacheEntry is the on-disk representation of the cached results of a package.
+type cacheEntry struct {
+	// Facts is the gob encoding of the InferredMap exported for the package, empty if nothing
+	// was exported.
+	Facts []byte `json:"facts,omitempty"`
+	// Diagnostics are the diagnostics generated for the package.
+	Diagnostics []cachedDiagnostic `json:"diagnostics"`
+}
+
+// cachedDiagnostic is an analysis.Diagnostic with positions independent of the file set.
+type cachedDiagnostic struct {
+	Position cachedPosition   `json:"position"`
+	Category string           `json:"category,omitempty"`
+	Message  string           `json:"message"`
+	Related  []cachedRelation `json:"related,omitempty"`
+}
+
+// cachedRelation is an analysis.RelatedInformation with positions independent of the file set.
+type cachedRelation struct {
+	Position cachedPosition `json:"position"`
+	Message  string         `json:"message"`
+}
+
+// cachedPosition is the position of a diagnostic, identified by the name of the file and the byte
+// offset in the file.
+type cachedPosition struct {
+	File   string `json:"file"`
+	Offset int    `json:"offset"`
+}
+
+// _executableHash caches the hash of the running executable, which is part of the cache keys such
+// that upgrading NilAway invalidates the cache.
+var _executableHash struct {
+	once sync.Once
+	hash string
+}
+
+// executableHash returns the hash of the running executable, or "" if it cannot be computed.
+func executableHash() string {
+	_executableHash.once.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return
+		}
+		_executableHash.hash = hex.EncodeToString(h.Sum(nil))
+	})
+	return _executableHash.hash
+}
+
+// computeCacheKey computes the content hash of the package from the analysis options, the
+// sources of the package, and the hashes of its upstream dependencies. It returns false if the
+// hash cannot be computed (e.g., the sources are not available).
+func computeCacheKey(pass *analysis.Pass, conf *config.Config) (string, bool) {
+	h := sha256.New()
+	fmt.Fprintf(h, "nilaway cache v%d\n%s\n%s\n%s\n%s\n",
+		_cacheVersion, runtime.Version(), executableHash(), conf.Fingerprint(), pass.Pkg.Path())
+
+	for _, name := range fileNames(pass) {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return "", false
+		}
+		fmt.Fprintf(h, "%s %d\n", filepath.Base(name), len(content))
+		h.Write(content)
+	}
+
+	// The facts of all (transitive) dependencies are available, we include all of them in a
+	// deterministic order.
+	var upstream []analysis.PackageFact
+	for _, f := range pass.AllPackageFacts() {
+		if _, ok := f.Fact.(*CacheKey); ok && f.Package != pass.Pkg {
+			upstream = append(upstream, f)
+		}
+	}
+	sort.Slice(upstream, func(i, j int) bool {
+		return upstream[i].Package.Path() < upstream[j].Package.Path()
+	})
+	for _, f := range upstream {
+		fmt.Fprintf(h, "%s %s\n", f.Package.Path(), f.Fact.(*CacheKey).Hash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// fileNames returns the names of the files of the package in the order of pass.Files.
+func fileNames(pass *analysis.Pass) []string {
+	names := make([]string, len(pass.Files))
+	for i, file := range pass.Files {
+		names[i] = pass.Fset.File(file.Pos()).Name()
+	}
+	return names
+}
+
+// cachePath returns the path of the cache entry for the key.
+func cachePath(dir, key string) string {
+	return filepath.Join(dir, key[:2], key+".json")
+}
+
+// loadCache loads the cached results for the key, exports the cached facts and returns the
+// cached diagnostics. It returns false if there is no (valid) cache entry for the key.
+func loadCache(pass *analysis.Pass, dir, key string) ([]analysis.Diagnostic, bool, error) {
+	content, err := os.ReadFile(cachePath(dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read cache entry: %w", err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		// A corrupted entry (e.g., due to an interrupted write) is simply ignored and overwritten.
+		return nil, false, nil
+	}
+
+	// The diagnostics are reported on the files of the package, but the related information
+	// may refer to the files of the upstream packages as well.
+	files := make(map[string]*token.File)
+	pass.Fset.Iterate(func(f *token.File) bool {
+		files[f.Name()] = f
+		return true
+	})
+	toPos := func(p cachedPosition) (token.Pos, bool) {
+		f, ok := files[p.File]
+		if !ok || p.Offset < 0 || p.Offset > f.Size() {
+			return token.NoPos, false
+		}
+		return f.Pos(p.Offset), true
+	}
+
+	diagnostics := make([]analysis.Diagnostic, 0, len(entry.Diagnostics))
+	for _, d := range entry.Diagnostics {
+		pos, ok := toPos(d.Position)
+		if !ok {
+			return nil, false, nil
+		}
+		diagnostic := analysis.Diagnostic{Pos: pos, Category: d.Category, Message: d.Message}
+		for _, r := range d.Related {
+			// Similar to the diagnostic engine, the related information whose position cannot be
+			// recovered is simply omitted.
+			if pos, ok := toPos(r.Position); ok {
+				diagnostic.Related = append(diagnostic.Related, analysis.RelatedInformation{Pos: pos, Message: r.Message})
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	if len(entry.Facts) > 0 {
+		m := new(inference.InferredMap)
+		if err := m.GobDecode(entry.Facts); err != nil {
+			return nil, false, nil
+		}
+		pass.ExportPackageFact(m)
+	}
+	return diagnostics, true, nil
+}
+
+// storeCache stores the facts exported for the package and the diagnostics as the cache entry for
+// the key. Packages with diagnostics reported outside their own files (e.g., on the fake files
+// created for upstream packages) are not cached since the positions cannot be restored reliably.
+func storeCache(pass *analysis.Pass, dir, key string, diagnostics []analysis.Diagnostic) error {
+	files := make(map[*token.File]bool, len(pass.Files))
+	for _, file := range pass.Files {
+		files[pass.Fset.File(file.Pos())] = true
+	}
+	toCached := func(pos token.Pos) cachedPosition {
+		file := pass.Fset.File(pos)
+		return cachedPosition{File: file.Name(), Offset: file.Offset(pos)}
+	}
+
+	entry := cacheEntry{Diagnostics: make([]cachedDiagnostic, 0, len(diagnostics))}
+	for _, d := range diagnostics {
+		if !files[pass.Fset.File(d.Pos)] {
+			return nil
+		}
+		cached := cachedDiagnostic{Position: toCached(d.Pos), Category: d.Category, Message: d.Message}
+		for _, r := range d.Related {
+			if pass.Fset.File(r.Pos) != nil {
+				cached.Related = append(cached.Related, cachedRelation{Position: toCached(r.Pos), Message: r.Message})
+			}
+		}
+		entry.Diagnostics = append(entry.Diagnostics, cached)
+	}
+
+	var m inference.InferredMap
+	if pass.ImportPackageFact(pass.Pkg, &m) {
+		facts, err := m.GobEncode()
+		if err != nil {
+			return fmt.Errorf("encode facts: %w", err)
+		}
+		entry.Facts = facts
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	if err := writeCacheFile(cachePath(dir, key), content); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// writeCacheFile writes the content to the file in the cache directory, creating the parent
+// directories if needed. It writes to a temporary file first and then renames it, such that
+// concurrent readers never observe a partially-written file.
+func writeCacheFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
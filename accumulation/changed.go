@@ -0,0 +1,126 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+	"golang.org/x/tools/go/analysis"
+)
+
+// ExportsChanged is the fact exported for the packages whose exported information (i.e., the
+// InferredMap facts) may have changed since the last run when only the changed packages are
+// reported (see config.Config.ShouldReanalyze). Downstream packages that see this fact on any of
+// their (transitive) dependencies are reported as well, since their diagnostics may have changed.
+type ExportsChanged struct {
+	// Hash is the hash of the new exported information of the package.
+	Hash string
+}
+
+// AFact is a function that enables the use of ExportsChanged as a fact in the analysis framework.
+func (*ExportsChanged) AFact() {}
+
+func (e *ExportsChanged) String() string {
+	return "ExportsChanged(" + e.Hash + ")"
+}
+
+// filterUnchanged returns the diagnostics of the package if it is in the configured list of
+// changed packages or is affected by the changes of its dependencies, and nil otherwise. It must be
+// called after the facts of the package are exported. If the package is reported, its exported
+// information is compared to the one recorded in the cache directory in the last run, and an
+// ExportsChanged fact is exported if they differ, such that the dependents are reported as well.
+// Without a cache directory the comparison is impossible, and we conservatively assume the exported
+// information of every reported package has changed.
+//
+// Note that the unreported packages are still analyzed (or loaded from the cache) since their facts
+// are needed for the analysis of the reported ones.
+func filterUnchanged(pass *analysis.Pass, conf *config.Config, diagnostics []analysis.Diagnostic) ([]analysis.Diagnostic, error) {
+	if !conf.HasChangedPkgs() {
+		return diagnostics, nil
+	}
+	report := conf.ShouldReanalyze(pass.Pkg) || affectedByChanges(pass)
+
+	hash, err := exportsHash(pass)
+	if err != nil {
+		return nil, err
+	}
+	changed := report
+	if conf.CacheDir != "" {
+		// Record the exported information for all packages, such that the next run can tell
+		// whether it changes.
+		if changed, err = recordExports(conf.CacheDir, pass.Pkg.Path(), hash); err != nil {
+			return nil, fmt.Errorf("record exports: %w", err)
+		}
+	}
+	if changed {
+		pass.ExportPackageFact(&ExportsChanged{Hash: hash})
+	}
+
+	if !report {
+		// Must return a typed nil since the driver is using reflection to retrieve the result.
+		return ([]analysis.Diagnostic)(nil), nil
+	}
+	return diagnostics, nil
+}
+
+// affectedByChanges returns true iff any of the (transitive) dependencies of the package has
+// exported an ExportsChanged fact.
+func affectedByChanges(pass *analysis.Pass) bool {
+	for _, f := range pass.AllPackageFacts() {
+		if _, ok := f.Fact.(*ExportsChanged); ok && f.Package != pass.Pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// exportsHash returns the hash of the InferredMap fact exported for the package, or the hash of
+// empty content if nothing is exported.
+func exportsHash(pass *analysis.Pass) (string, error) {
+	var content []byte
+	var m inference.InferredMap
+	if pass.ImportPackageFact(pass.Pkg, &m) {
+		var err error
+		if content, err = m.GobEncode(); err != nil {
+			return "", fmt.Errorf("encode facts: %w", err)
+		}
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordExports records the hash of the exported information of the package in the cache
+// directory, and returns true iff it differs from the one recorded in the last run (or there is
+// no such record).
+func recordExports(dir, pkgPath, hash string) (bool, error) {
+	sum := sha256.Sum256([]byte(pkgPath))
+	path := filepath.Join(dir, "exports", hex.EncodeToString(sum[:]))
+	previous, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if string(previous) == hash {
+		return false, nil
+	}
+	return true, writeCacheFile(path, []byte(hash))
+}
@@ -0,0 +1,67 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+	"golang.org/x/tools/go/analysis"
+)
+
+// siteDump is the file that the sites of all packages analyzed in the process are dumped to (see
+// config.Config.DumpSites), which is created (i.e., truncated) when it is first written.
+type siteDump struct {
+	mu   sync.Mutex
+	once sync.Once
+	file *os.File
+	err  error
+}
+
+// _siteDumps stores the siteDump for each dump file.
+var _siteDumps sync.Map
+
+// dumpSites writes the exported sites of the package that match config.Config.DumpSites to the
+// dump file, under a header line with the package path. Packages without any matching sites are
+// omitted, hence the dump is empty (but still created) if no sites match at all.
+func dumpSites(pass *analysis.Pass, conf *config.Config, inferredMap *inference.InferredMap) error {
+	v, _ := _siteDumps.LoadOrStore(conf.DumpSitesFile, &siteDump{})
+	dump := v.(*siteDump)
+	dump.once.Do(func() {
+		// The file is intentionally kept open until the process exits, since the drivers do not
+		// offer a hook at the end of the analysis.
+		dump.file, dump.err = os.Create(conf.DumpSitesFile)
+	})
+	if dump.err != nil {
+		return fmt.Errorf("create site dump: %w", dump.err)
+	}
+
+	var b bytes.Buffer
+	inferredMap.WriteMatchingText(&b, conf.DumpSites)
+	if b.Len() == 0 {
+		return nil
+	}
+
+	dump.mu.Lock()
+	defer dump.mu.Unlock()
+	if _, err := fmt.Fprintf(dump.file, "# %s\n%s", pass.Pkg.Path(), b.Bytes()); err != nil {
+		return fmt.Errorf("write site dump: %w", err)
+	}
+	return nil
+}
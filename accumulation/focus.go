@@ -0,0 +1,53 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulation
+
+import (
+	"go/ast"
+	"go/token"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// filterUnfocused drops the diagnostics out of the focus of the analysis (see config.IsInFocus),
+// i.e., all diagnostics of the packages out of focus and the ones outside the focused file or
+// function of the focused package. The diagnostics are returned as-is if no focus is configured.
+func filterUnfocused(pass *analysis.Pass, conf *config.Config, diagnostics []analysis.Diagnostic) []analysis.Diagnostic {
+	if !conf.HasFocus() {
+		return diagnostics
+	}
+	if !conf.IsPkgInFocus(pass) {
+		// Must return a typed nil since the driver is using reflection to retrieve the result.
+		return ([]analysis.Diagnostic)(nil)
+	}
+	var focused []analysis.Diagnostic
+	for _, d := range diagnostics {
+		if file := fileOf(pass, d.Pos); file != nil && conf.IsInFocus(pass, file, d.Pos) {
+			focused = append(focused, d)
+		}
+	}
+	return focused
+}
+
+// fileOf returns the file of the package containing the position, or nil if there is none.
+func fileOf(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, file := range pass.Files {
+		if file.FileStart <= pos && pos <= file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulation
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/nilaway/inference"
+)
+
+// sessionRecord is the file that the inference sessions of all packages analyzed in the process
+// are recorded to (see config.Config.RecordFile) as a stream of gob-encoded inference.Session,
+// which is created (i.e., truncated) when it is first written.
+type sessionRecord struct {
+	mu      sync.Mutex
+	once    sync.Once
+	file    *os.File
+	encoder *gob.Encoder
+	err     error
+}
+
+// _sessionRecords stores the sessionRecord for each record file.
+var _sessionRecords sync.Map
+
+// recordSession appends the session to the record file.
+func recordSession(path string, session *inference.Session) error {
+	v, _ := _sessionRecords.LoadOrStore(path, &sessionRecord{})
+	record := v.(*sessionRecord)
+	record.once.Do(func() {
+		// Similar to the site dumps, the file is intentionally kept open until the process exits.
+		record.file, record.err = os.Create(path)
+		if record.err == nil {
+			record.encoder = gob.NewEncoder(record.file)
+		}
+	})
+	if record.err != nil {
+		return fmt.Errorf("create session record: %w", record.err)
+	}
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	if err := record.encoder.Encode(session); err != nil {
+		return fmt.Errorf("record session of %q: %w", session.PkgPath, err)
+	}
+	return nil
+}
+
+// ReadSessions reads the inference sessions recorded to the file (see config.Config.RecordFile) in
+// the order they are recorded, which can then be replayed by inference.Replay.
+func ReadSessions(path string) ([]*inference.Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open session record: %w", err)
+	}
+	defer f.Close()
+
+	var sessions []*inference.Session
+	decoder := gob.NewDecoder(f)
+	for {
+		var session inference.Session
+		if err := decoder.Decode(&session); err != nil {
+			if errors.Is(err, io.EOF) {
+				return sessions, nil
+			}
+			return nil, fmt.Errorf("decode session %d: %w", len(sessions), err)
+		}
+		sessions = append(sessions, &session)
+	}
+}
@@ -0,0 +1,270 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"go.uber.org/nilaway/accumulation"
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// Result is the structured result of running NilAway programmatically (see Analyze).
+type Result struct {
+	// Packages are the results of the packages matching the patterns, where the dependencies come
+	// before their dependents.
+	Packages []*PackageResult
+	// Fset is the file set of the loaded packages, which resolves the positions in the
+	// diagnostics (e.g., of their related information).
+	Fset *token.FileSet
+}
+
+// PackageResult is the result of analyzing a single package.
+type PackageResult struct {
+	// Path is the path of the package.
+	Path string
+	// Diagnostics are the diagnostics reported for the package, in the order they are reported.
+	// Their messages are pretty printed (i.e., colored for terminals) if config.Config.PrettyPrint
+	// is set.
+	Diagnostics []Diagnostic
+	// InferredMap is the nilability of the sites of the package that is exported to its
+	// dependents, or nil if the package exports none (e.g., when it is out of scope).
+	InferredMap *inference.InferredMap
+}
+
+// Analyze loads the packages matching the patterns (in the same form accepted by `go list`, e.g.,
+// "./..."), runs NilAway on them with the given config and returns the diagnostics as structured
+// results, such that NilAway can be embedded in other tools without parsing the printed output.
+// The config is typically built with config.New, its exported fields and setters; the flags of
+// the config analyzer are not consulted. Similar to the standalone driver, the dependencies of the
+// packages are analyzed as well (to infer the nilability across packages) but not reported, hence
+// the include list of the config is the main knob to bound the analysis time.
+func Analyze(cfg *config.Config, patterns []string) (*Result, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+	var loadErrs []error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			loadErrs = append(loadErrs, e)
+		}
+	})
+	if len(loadErrs) > 0 {
+		return nil, fmt.Errorf("load packages: %w", errors.Join(loadErrs...))
+	}
+
+	d := &driver{
+		conf:  cfg,
+		roots: make(map[*packages.Package]bool, len(pkgs)),
+		facts: make(map[*analysis.Analyzer]map[*types.Package]map[reflect.Type]analysis.Fact),
+	}
+	for _, pkg := range pkgs {
+		d.roots[pkg] = true
+	}
+
+	// The dependencies are analyzed before their dependents (i.e., in post-order), such that the
+	// facts exported by the former are available to the latter.
+	result := &Result{}
+	if len(pkgs) > 0 {
+		result.Fset = pkgs[0].Fset
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if err != nil {
+			return
+		}
+		var r *PackageResult
+		if r, err = d.analyze(pkg); err == nil && r != nil {
+			result.Packages = append(result.Packages, r)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// driver is a minimal analysis driver for running NilAway in process (see Analyze), which supports
+// the package facts only since they are the only facts NilAway uses.
+type driver struct {
+	// conf is the config used for all packages in place of the result of the config analyzer.
+	conf *config.Config
+	// roots is the set of packages matching the patterns, i.e., the ones being reported.
+	roots map[*packages.Package]bool
+	// facts stores the package facts exported by each analyzer for each package analyzed so far,
+	// keyed by the types of the facts.
+	facts map[*analysis.Analyzer]map[*types.Package]map[reflect.Type]analysis.Fact
+}
+
+// analyze runs the analyzers on the package and returns the result if it is a root package. Like
+// the standard drivers, only the analyzers with facts (and their requirements) are run on the
+// dependencies, since only the facts of the dependencies are needed.
+func (d *driver) analyze(pkg *packages.Package) (*PackageResult, error) {
+	deps := transitiveDeps(pkg)
+	results := make(map[*analysis.Analyzer]any)
+	var diagnostics []analysis.Diagnostic
+
+	var run func(a *analysis.Analyzer) error
+	run = func(a *analysis.Analyzer) error {
+		if _, ok := results[a]; ok {
+			return nil
+		}
+		for _, req := range a.Requires {
+			if err := run(req); err != nil {
+				return err
+			}
+		}
+
+		facts := d.facts[a]
+		if facts == nil {
+			facts = make(map[*types.Package]map[reflect.Type]analysis.Fact)
+			d.facts[a] = facts
+		}
+		pass := &analysis.Pass{
+			Analyzer:     a,
+			Fset:         pkg.Fset,
+			Files:        pkg.Syntax,
+			OtherFiles:   pkg.OtherFiles,
+			IgnoredFiles: pkg.IgnoredFiles,
+			Pkg:          pkg.Types,
+			TypesInfo:    pkg.TypesInfo,
+			TypesSizes:   pkg.TypesSizes,
+			ResultOf:     results,
+			Report: func(diag analysis.Diagnostic) {
+				if a == Analyzer {
+					diagnostics = append(diagnostics, diag)
+				}
+			},
+			ImportObjectFact: func(types.Object, analysis.Fact) bool { return false },
+			ImportPackageFact: func(p *types.Package, fact analysis.Fact) bool {
+				if p != pkg.Types && !deps[p] {
+					return false
+				}
+				f, ok := facts[p][reflect.TypeOf(fact)]
+				if ok {
+					reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+				}
+				return ok
+			},
+			ExportObjectFact: func(types.Object, analysis.Fact) {
+				panic("object facts are not supported by the in-process driver")
+			},
+			ExportPackageFact: func(fact analysis.Fact) {
+				if facts[pkg.Types] == nil {
+					facts[pkg.Types] = make(map[reflect.Type]analysis.Fact)
+				}
+				facts[pkg.Types][reflect.TypeOf(fact)] = fact
+			},
+			AllPackageFacts: func() []analysis.PackageFact {
+				var all []analysis.PackageFact
+				for p, byType := range facts {
+					if p != pkg.Types && !deps[p] {
+						continue
+					}
+					for _, f := range byType {
+						all = append(all, analysis.PackageFact{Package: p, Fact: f})
+					}
+				}
+				return all
+			},
+			AllObjectFacts: func() []analysis.ObjectFact { return nil },
+		}
+
+		// The config analyzer is replaced by the given config, prepared for the package.
+		if a == config.Analyzer {
+			conf, err := d.conf.ForPackage(pass)
+			if err != nil {
+				return err
+			}
+			results[a] = conf
+			return nil
+		}
+		result, err := a.Run(pass)
+		if err != nil {
+			return fmt.Errorf("run analyzer %s on package %q: %w", a.Name, pkg.PkgPath, err)
+		}
+		results[a] = result
+		return nil
+	}
+
+	if !d.roots[pkg] {
+		for _, a := range factAnalyzers(Analyzer) {
+			if err := run(a); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	if err := run(Analyzer); err != nil {
+		return nil, err
+	}
+
+	r := &PackageResult{Path: pkg.PkgPath}
+	for _, diag := range diagnostics {
+		r.Diagnostics = append(r.Diagnostics, Diagnostic{
+			Diagnostic: diag,
+			Position:   pkg.Fset.Position(diag.Pos),
+			PkgPath:    pkg.PkgPath,
+		})
+	}
+	if m, ok := d.facts[accumulation.Analyzer][pkg.Types][reflect.TypeOf((*inference.InferredMap)(nil))]; ok {
+		r.InferredMap = m.(*inference.InferredMap)
+	}
+	return r, nil
+}
+
+// factAnalyzers returns the analyzers with facts in the requirement graph of the analyzer.
+func factAnalyzers(a *analysis.Analyzer) []*analysis.Analyzer {
+	var analyzers []*analysis.Analyzer
+	visited := make(map[*analysis.Analyzer]bool)
+	var visit func(a *analysis.Analyzer)
+	visit = func(a *analysis.Analyzer) {
+		if visited[a] {
+			return
+		}
+		visited[a] = true
+		for _, req := range a.Requires {
+			visit(req)
+		}
+		if len(a.FactTypes) > 0 {
+			analyzers = append(analyzers, a)
+		}
+	}
+	visit(a)
+	return analyzers
+}
+
+// transitiveDeps returns the set of the packages imported by the package, directly or indirectly.
+func transitiveDeps(pkg *packages.Package) map[*types.Package]bool {
+	deps := make(map[*types.Package]bool)
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		for _, imp := range p.Imports {
+			if !deps[imp.Types] {
+				deps[imp.Types] = true
+				visit(imp)
+			}
+		}
+	}
+	visit(pkg)
+	return deps
+}
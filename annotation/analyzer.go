@@ -30,6 +30,9 @@ const _doc = "Read the annotations for each struct, interface, and function in t
 type Result struct {
 	// AnnotationMap is the map generated from reading the annotations in the source code.
 	AnnotationMap *ObservedMap
+	// InvalidTags are the diagnostics for the struct tags with invalid nilability annotations
+	// (e.g., `nilaway:"maybe"`), which are otherwise silently ignored.
+	InvalidTags []analysis.Diagnostic
 	// Errors is the slice of errors if errors happened during analysis. We put the errors here as
 	// part of the result of this sub-analyzer so that the upper-level analyzers can decide what
 	// to do with them.
@@ -41,7 +44,7 @@ type Result struct {
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_annotation_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires:   []*analysis.Analyzer{config.Analyzer},
 }
@@ -68,5 +71,6 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		return Result{AnnotationMap: new(ObservedMap)}, nil
 	}
 
-	return Result{AnnotationMap: newObservedMap(pass, pass.Files)}, nil
+	annMap, invalidTags := newObservedMap(pass, pass.Files)
+	return Result{AnnotationMap: annMap, InvalidTags: invalidTags}, nil
 }
@@ -58,6 +58,15 @@ func (t TriggerIfNonNil) customPos() (token.Pos, bool)         { return 0, false
 func (t TriggerIfDeepNonNil) customPos() (token.Pos, bool)     { return 0, false }
 func (t ConsumeTriggerTautology) customPos() (token.Pos, bool) { return 0, false }
 
+// derefPos is the customPos of the consumers of dereferences (e.g., PtrLoad and FldAccess), which
+// record the position of the dereferencing node (e.g., the selector of a field access or the `[` of
+// an index expression) such that the diagnostics point at the exact column of the dereference
+// rather than the start of the dereferenced expression. The default position is used if the
+// dereferencing node is not recorded.
+func derefPos(pos token.Pos) (token.Pos, bool) {
+	return pos, pos.IsValid()
+}
+
 // Prestring is an interface used to encode objects that have compact on-the-wire encodings
 // (via gob) but can still be expanded into verbose string representations on demand using
 // type information. These are key for compact encoding of InferredAnnotationMaps
@@ -116,8 +125,14 @@ func (ConsumeTriggerTautology) CheckConsume(Map) bool {
 // PtrLoad is when a value flows to a point where it is loaded as a pointer
 type PtrLoad struct {
 	ConsumeTriggerTautology
+
+	// DerefPos is the position of the dereferencing `*`, see derefPos.
+	DerefPos token.Pos
 }
 
+// overriding position value to point to the dereferencing node instead of the start of the pointer
+func (p PtrLoad) customPos() (token.Pos, bool) { return derefPos(p.DerefPos) }
+
 // Prestring returns this PtrLoad as a Prestring
 func (p PtrLoad) Prestring() Prestring {
 	return PtrLoadPrestring{}
@@ -130,13 +145,68 @@ func (PtrLoadPrestring) String() string {
 	return "dereferenced"
 }
 
+// TypeAssertOperand is when a value flows to the operand of a type assertion in the single-result
+// form `x.(T)`, which panics if `x` is nil (in contrast to the `v, ok := x.(T)` form). For now, this
+// is only created for the values read from contexts, e.g., `ctx.Value(k).(*T)` (see ContextValue).
+type TypeAssertOperand struct {
+	ConsumeTriggerTautology
+
+	// DerefPos is the position of the `(` of the type assertion, see derefPos.
+	DerefPos token.Pos
+}
+
+// overriding position value to point to the type assertion instead of the start of the operand
+func (t TypeAssertOperand) customPos() (token.Pos, bool) { return derefPos(t.DerefPos) }
+
+// Prestring returns this TypeAssertOperand as a Prestring
+func (t TypeAssertOperand) Prestring() Prestring {
+	return TypeAssertOperandPrestring{}
+}
+
+// TypeAssertOperandPrestring is a Prestring storing the needed information to compactly encode a TypeAssertOperand
+type TypeAssertOperandPrestring struct{}
+
+func (TypeAssertOperandPrestring) String() string {
+	return "type-asserted without checking `ok`"
+}
+
+// FuncValueCall is when a function value (e.g., a function-typed variable, field or parameter, or
+// the result of a call) flows to a point where it is called, and thus must be non-nil
+type FuncValueCall struct {
+	ConsumeTriggerTautology
+
+	// DerefPos is the position of the `(` of the call, see derefPos.
+	DerefPos token.Pos
+}
+
+// overriding position value to point to the call instead of the start of the function value
+func (f FuncValueCall) customPos() (token.Pos, bool) { return derefPos(f.DerefPos) }
+
+// Prestring returns this FuncValueCall as a Prestring
+func (f FuncValueCall) Prestring() Prestring {
+	return FuncValueCallPrestring{}
+}
+
+// FuncValueCallPrestring is a Prestring storing the needed information to compactly encode a FuncValueCall
+type FuncValueCallPrestring struct{}
+
+func (FuncValueCallPrestring) String() string {
+	return "called as a function"
+}
+
 // MapAccess is when a map value flows to a point where it is indexed, and thus must be non-nil
 //
 // note: this trigger is produced only if config.ErrorOnNilableMapRead == true
 type MapAccess struct {
 	ConsumeTriggerTautology
+
+	// DerefPos is the position of the indexing `[`, see derefPos.
+	DerefPos token.Pos
 }
 
+// overriding position value to point to the index node instead of the start of the map
+func (i MapAccess) customPos() (token.Pos, bool) { return derefPos(i.DerefPos) }
+
 // Prestring returns this MapAccess as a Prestring
 func (i MapAccess) Prestring() Prestring {
 	return MapAccessPrestring{}
@@ -170,8 +240,14 @@ func (MapWrittenToPrestring) String() string {
 // SliceAccess is when a slice value flows to a point where it is sliced, and thus must be non-nil
 type SliceAccess struct {
 	ConsumeTriggerTautology
+
+	// DerefPos is the position of the indexing (or slicing) `[`, see derefPos.
+	DerefPos token.Pos
 }
 
+// overriding position value to point to the index node instead of the start of the slice
+func (s SliceAccess) customPos() (token.Pos, bool) { return derefPos(s.DerefPos) }
+
 // Prestring returns this SliceAccess as a Prestring
 func (s SliceAccess) Prestring() Prestring {
 	return SliceAccessPrestring{}
@@ -189,8 +265,13 @@ type FldAccess struct {
 	ConsumeTriggerTautology
 
 	Sel types.Object
+	// DerefPos is the position of the accessed selector, e.g., `c` in `a.b.c`, see derefPos.
+	DerefPos token.Pos
 }
 
+// overriding position value to point to the failing selector instead of the start of the receiver
+func (f FldAccess) customPos() (token.Pos, bool) { return derefPos(f.DerefPos) }
+
 // Prestring returns this FldAccess as a Prestring
 func (f FldAccess) Prestring() Prestring {
 	fieldName, methodName := "", ""
@@ -402,6 +483,17 @@ func (a ArgPassPrestring) String() string {
 	return sb.String()
 }
 
+// ContractParamPrestring is a Prestring describing the flow from a result of a function to its
+// param implied by an implication contract (`// nilaway:contract`) of the function.
+type ContractParamPrestring struct {
+	ParamNum int
+	FuncName string
+}
+
+func (c ContractParamPrestring) String() string {
+	return fmt.Sprintf("implies param %d of `%s()` nilable by the contract of the function", c.ParamNum, c.FuncName)
+}
+
 // RecvPass is when a receiver value flows to a point where it is used to invoke a method.
 // E.g., `s.foo()`, here `s` is a receiver and forms the RecvPass Consumer
 type RecvPass struct {
@@ -846,8 +938,14 @@ func (g GlobalVarAssignDeepPrestring) String() string {
 // ChanAccess is when a channel is accessed for sending, and thus must be non-nil
 type ChanAccess struct {
 	ConsumeTriggerTautology
+
+	// DerefPos is the position of the `<-` operator, see derefPos.
+	DerefPos token.Pos
 }
 
+// overriding position value to point to the channel operator instead of the start of the channel
+func (c ChanAccess) customPos() (token.Pos, bool) { return derefPos(c.DerefPos) }
+
 // Prestring returns this MapWrittenTo as a Prestring
 func (c ChanAccess) Prestring() Prestring {
 	return ChanAccessPrestring{}
@@ -880,6 +978,27 @@ func (l LocalVarAssignDeepPrestring) String() string {
 	return fmt.Sprintf("assigned deeply into local variable `%s`", l.VarName)
 }
 
+// ElemAssignDeep is when a value flows to a point where it is assigned deeply into the elements of
+// a container site, e.g., `x[i][j] = v` for a `[][]*T` parameter `x` (see ElemAnnotationKey)
+type ElemAssignDeep struct {
+	TriggerIfDeepNonNil
+}
+
+// Prestring returns this ElemAssignDeep as a Prestring
+func (e ElemAssignDeep) Prestring() Prestring {
+	key := e.Ann.(ElemAnnotationKey)
+	return ElemAssignDeepPrestring{key.ContainerString()}
+}
+
+// ElemAssignDeepPrestring is a Prestring storing the needed information to compactly encode a ElemAssignDeep
+type ElemAssignDeepPrestring struct {
+	ContainerName string
+}
+
+func (e ElemAssignDeepPrestring) String() string {
+	return fmt.Sprintf("assigned deeply into the elements of %s", e.ContainerName)
+}
+
 // ChanSend is when a value flows to a point where it is sent to a channel
 type ChanSend struct {
 	TriggerIfDeepNonNil
@@ -1091,6 +1210,18 @@ func (c *ConsumeTrigger) Pos() token.Pos {
 	return c.Expr.Pos()
 }
 
+// ReportPos returns the position where the diagnostics for the consumer are reported. For the
+// dereferences (e.g., PtrLoad and FldAccess), this is the position of the dereferencing node (see
+// derefPos), and otherwise the start of the consumer's expression.
+func (c *ConsumeTrigger) ReportPos() token.Pos {
+	switch c.Annotation.(type) {
+	case PtrLoad, MapAccess, SliceAccess, FldAccess, ChanAccess:
+		return c.Pos()
+	default:
+		return c.Expr.Pos()
+	}
+}
+
 // MergeConsumeTriggerSlices merges two slices of `ConsumeTrigger`s
 // its semantics are slightly unexpected only in its treatment of guarding:
 // it intersects guard sets
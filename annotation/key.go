@@ -336,6 +336,49 @@ func (tk TypeNameAnnotationKey) String() string {
 	return fmt.Sprintf("Type %s", tk.TypeDecl.Name())
 }
 
+// ElemAnnotationKey allows the Lookup of the nilability of the elements of a container site, e.g.,
+// the inner slices of a `[][]*T` parameter, such that nested containers (e.g., `[][]`, `map[K][]`
+// and `[]map[K]`) are tracked one level deeper than the deep nilability of the container site
+// itself. The shallow nilability of the key is the deep nilability of the container site, and its
+// deep nilability is the nilability of the elements of the elements (e.g., the `*T` values of the
+// `[][]*T` parameter). Only two levels are tracked, i.e., Container is never an ElemAnnotationKey.
+type ElemAnnotationKey struct {
+	Container Key
+}
+
+// Lookup looks this key up in the passed map, returning a Val
+func (ek ElemAnnotationKey) Lookup(annMap Map) (Val, bool) {
+	if val, ok := annMap.CheckElemAnn(ek); ok {
+		return val, true
+	}
+	return nonAnnotatedDefault, false
+}
+
+// Object returns the types.Object that this annotation can best be interpreted as annotating
+func (ek ElemAnnotationKey) Object() types.Object {
+	return ek.Container.Object()
+}
+
+func (ek ElemAnnotationKey) String() string {
+	return fmt.Sprintf("Elements of %s", ek.Container.String())
+}
+
+// ContainerString returns a short, user-facing description of the container site of this key
+// (e.g., "parameter `x`"), which is used in the diagnostics.
+func (ek ElemAnnotationKey) ContainerString() string {
+	switch c := ek.Container.(type) {
+	case ParamAnnotationKey:
+		return fmt.Sprintf("parameter `%s`", c.ParamNameString())
+	case RetAnnotationKey:
+		return fmt.Sprintf("result %d of `%s()`", c.RetNum, c.FuncDecl.Name())
+	case FieldAnnotationKey:
+		return fmt.Sprintf("field `%s`", c.FieldDecl.Name())
+	case GlobalVarAnnotationKey:
+		return fmt.Sprintf("global variable `%s`", c.VarDecl.Name())
+	}
+	return fmt.Sprintf("`%s`", ek.Container.Object().Name())
+}
+
 // GlobalVarAnnotationKey allows the Lookup of a global variable's annotations in the Annotation Map
 type GlobalVarAnnotationKey struct {
 	VarDecl *types.Var
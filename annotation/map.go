@@ -19,7 +19,9 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"go.uber.org/nilaway/config"
@@ -37,6 +39,7 @@ type Map interface {
 	CheckGlobalVarAnn(*types.Var) (Val, bool)
 	CheckFuncCallSiteParamAnn(key CallSiteParamAnnotationKey) (Val, bool)
 	CheckFuncCallSiteRetAnn(key CallSiteRetAnnotationKey) (Val, bool)
+	CheckElemAnn(key ElemAnnotationKey) (Val, bool)
 }
 
 // Val is a possible value of an Annotation
@@ -406,11 +409,11 @@ func TypeIsDeepDefaultNilable(t types.Type) bool {
 }
 
 // checkNilability for a nilabilitySet checks to see if a string is mapped to an Annotation by that
-// set. If it is, then that Annotation is returned. If not, then `nonNil` is returned.
+// set. If it is, then that Annotation is returned. If not, then `defaultVal` is returned.
 // the type of the Annotation site is also passed, and it can possibly serve to mark a site
 // as `nilable` when its Annotation doesn't indicate so.
-func (set nilabilitySet) checkNilability(name string, t types.Type) Val {
-	val := EmptyVal
+func (set nilabilitySet) checkNilability(name string, t types.Type, defaultVal Val) Val {
+	val := defaultVal
 	if v, ok := set[name]; ok {
 		val = v
 	}
@@ -424,7 +427,56 @@ func (set nilabilitySet) checkNilability(name string, t types.Type) Val {
 	return val
 }
 
-func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
+// structTagKey is the key of the struct tags that annotate the nilability of the fields, e.g.,
+// `nilaway:"nilable"` or `nilaway:"nonnil"`.
+const structTagKey = "nilaway"
+
+// nilabilityFromTag reads the nilability annotated by the struct tag of a field (see structTagKey),
+// returning whether the field is nilable and whether the tag annotates the field at all. An error
+// is returned if the tag has a value other than nilableKeyword and nonNilKeyword.
+func nilabilityFromTag(tag *ast.BasicLit) (isNilable bool, ok bool, err error) {
+	if tag == nil {
+		return false, false, nil
+	}
+	raw, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		// The tag literal is checked by the type checker, so this should never happen.
+		return false, false, fmt.Errorf("unquote struct tag %s: %w", tag.Value, err)
+	}
+	v, ok := reflect.StructTag(raw).Lookup(structTagKey)
+	if !ok {
+		return false, false, nil
+	}
+	switch v {
+	case nilableKeyword:
+		return true, true, nil
+	case nonNilKeyword:
+		return false, true, nil
+	default:
+		return false, false, fmt.Errorf("invalid value %q for struct tag %q, must be %q or %q",
+			v, structTagKey, nilableKeyword, nonNilKeyword)
+	}
+}
+
+// isNilablePackage returns true iff the package doc comment of any of the files contains the
+// nilable package marker (see config.NilAwayNilablePackageString).
+func isNilablePackage(files []*ast.File) bool {
+	for _, file := range files {
+		if file.Doc == nil {
+			continue
+		}
+		for _, comment := range file.Doc.List {
+			if strings.Contains(comment.Text, config.NilAwayNilablePackageString) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newObservedMap reads the annotations from the files, returning the diagnostics for the invalid
+// struct tags (see nilabilityFromTag) along with the map.
+func newObservedMap(pass *analysis.Pass, files []*ast.File) (*ObservedMap, []analysis.Diagnostic) {
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
 	// TODO - only store annotations for fields/vars/parameters of types that do not bar nilness
 
@@ -434,16 +486,43 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 	funcRecvAnnMap := make(map[*types.Func]Val)
 	paramIndexMap := make(map[*types.Var]int)
 	deepTypeAnnMap := make(map[*types.TypeName]Val)
+	// definedTypeSources maps the unannotated defined types of other named types in this package
+	// (e.g., `T` for `type T U`) to the type names they are defined from (e.g., `U`).
+	definedTypeSources := make(map[*types.TypeName]*types.TypeName)
 	globalVarsAnnMap := make(map[*types.Var]Val)
 
 	funcObjToFuncDecl := make(map[*types.Func]*ast.FuncDecl)
 	funcCallSiteParamAnnMap := make(map[CallSite][]ArgLocAndVal)
 	funcCallSiteRetAnnMap := make(map[CallSite][]Val)
+	var invalidTags []analysis.Diagnostic
 
 	typeOf := func(expr ast.Expr) types.Type {
 		return pass.TypesInfo.Types[expr].Type
 	}
 
+	// defaultOf returns the default value for the unannotated sites of the type, where the
+	// precedence is (from highest to lowest):
+	// (1) the explicit annotations of the sites, which always win over the defaults (see
+	//     checkNilability);
+	// (2) the nilable package marker (see config.NilAwayNilablePackageString), which makes the
+	//     sites (except for the receivers) whose types are inhabited by nil nilable as if they
+	//     were annotated, such that they seed the inference as well;
+	// (3) the package defaults of the config, which are only consulted when the nilability of the
+	//     sites is not inferred (e.g., in packages with inference disabled);
+	// (4) the global defaults, i.e., nonnil unless the types are nilable by default (see
+	//     TypeIsDefaultNilable).
+	defaultVal := EmptyVal
+	if nilable, ok := conf.PackageDefaultNilable(pass.Pkg); ok && nilable {
+		defaultVal = defaultVal.makeNilable(false)
+	}
+	nilablePackage := isNilablePackage(files)
+	defaultOf := func(t types.Type) Val {
+		if nilablePackage && !util.TypeBarsNilness(t) {
+			return EmptyVal.makeNilable(true)
+		}
+		return defaultVal
+	}
+
 	// for a function declaration, accumulate its parameters from an *ast.Fieldlist object
 	// listing them, look them up in the docstring, and return an equally long list of
 	// annotationVals
@@ -467,7 +546,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 					lookupKey = resultStr(len(annVals))
 				}
 
-				annVals = append(annVals, set.checkNilability(lookupKey, typeOf(field.Type)))
+				annVals = append(annVals, set.checkNilability(lookupKey, typeOf(field.Type), defaultOf(typeOf(field.Type))))
 			} else {
 				for _, name := range field.Names {
 					declFld := pass.TypesInfo.ObjectOf(name).(*types.Var)
@@ -494,7 +573,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 					} else {
 						lookupKey = name.Name
 					}
-					annVals = append(annVals, set.checkNilability(lookupKey, fieldType))
+					annVals = append(annVals, set.checkNilability(lookupKey, fieldType, defaultOf(fieldType)))
 				}
 			}
 		}
@@ -506,7 +585,18 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 			if len(decl.Recv.List) > 1 {
 				panic(fmt.Sprintf("Multiple receivers found for method %s", decl.Name))
 			}
-			return accFromFieldList(set, decl.Recv, false, false)[0]
+			val := accFromFieldList(set, decl.Recv, false, false)[0]
+			if nilablePackage {
+				// The nilable package marker does not apply to the receivers, which are nonnil
+				// for the vast majority of the method calls, so they fall back to the other
+				// defaults unless annotated.
+				field, key := decl.Recv.List[0], resultStr(0)
+				if len(field.Names) > 0 {
+					key = field.Names[0].Name
+				}
+				val = set.checkNilability(key, typeOf(field.Type), defaultVal)
+			}
+			return val
 		}
 		return nonAnnotatedDefault
 	}
@@ -550,7 +640,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 								for _, name := range spec.Names {
 									varObj := pass.TypesInfo.ObjectOf(name).(*types.Var)
 									globalVarsAnnMap[varObj] =
-										docNilabilitySet.checkNilability(name.Name, typeOf(spec.Type))
+										docNilabilitySet.checkNilability(name.Name, typeOf(spec.Type), defaultOf(typeOf(spec.Type)))
 								}
 							}
 						case *ast.TypeSpec:
@@ -562,17 +652,41 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 							// pointers to see if their contained values are nilable
 							readDeepNilability := func() {
 								typeName := pass.TypesInfo.ObjectOf(spec.Name).(*types.TypeName)
+								// The package defaults only concern the shallow nilability of the
+								// sites, so they do not apply to the deep nilability of types.
 								deepTypeAnnMap[typeName] =
-									docNilabilitySet.checkNilability(spec.Name.Name, typeOf(spec.Type))
+									docNilabilitySet.checkNilability(spec.Name.Name, typeOf(spec.Type), EmptyVal)
 							}
 							var handleTypeVal func(expr ast.Expr)
 							handleTypeVal = func(expr ast.Expr) {
 								switch typeVal := expr.(type) {
 								case *ast.StructType:
 									for _, field := range typeVal.Fields.List {
-										for _, name := range field.Names {
-											fieldAnnMap[pass.TypesInfo.ObjectOf(name).(*types.Var)] =
-												docNilabilitySet.checkNilability(name.Name, typeOf(field.Type))
+										names := field.Names
+										if len(names) == 0 {
+											// Embedded fields are annotated by their implicit
+											// names, i.e., the names of their types.
+											if name := embeddedFieldIdent(field.Type); name != nil {
+												names = []*ast.Ident{name}
+											}
+										}
+										tagNilable, tagged, err := nilabilityFromTag(field.Tag)
+										if err != nil {
+											invalidTags = append(invalidTags, analysis.Diagnostic{
+												Pos:     field.Tag.Pos(),
+												Message: err.Error(),
+											})
+										}
+										for _, name := range names {
+											val := docNilabilitySet.checkNilability(name.Name, typeOf(field.Type), defaultOf(typeOf(field.Type)))
+											if tagged {
+												// The struct tag is attached to the field itself, so it takes
+												// precedence over the (shallow) annotation of the field in
+												// the doc comment of the struct. The deep nilability can only
+												// be annotated by the doc comment.
+												val.IsNilable, val.IsNilableSet = tagNilable, true
+											}
+											fieldAnnMap[pass.TypesInfo.ObjectOf(name).(*types.Var)] = val
 										}
 									}
 								case *ast.InterfaceType:
@@ -601,8 +715,22 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 									readDeepNilability()
 								case *ast.ArrayType:
 									readDeepNilability()
-								case *ast.Ident: // type alias - do nothing
-								case *ast.SelectorExpr: // type alias - do nothing
+								case *ast.Ident, *ast.SelectorExpr:
+									// An alias (`type T = U`) denotes U itself, i.e., the type checker
+									// resolves its uses to U, so it has no site of its own and any
+									// annotation on it is ignored. In contrast, a defined type
+									// (`type T U`) is a distinct site: it takes its own deep annotation
+									// if any, and otherwise inherits the one of U if U is declared in
+									// this package.
+									if spec.Assign.IsValid() || !util.TypeIsDeep(typeOf(spec.Type).Underlying()) {
+										break
+									}
+									readDeepNilability()
+									if val := docNilabilitySet[spec.Name.Name]; !val.IsDeepNilableSet {
+										if source, ok := typeOf(spec.Type).(*types.Named); ok && source.Obj().Pkg() == pass.Pkg {
+											definedTypeSources[pass.TypesInfo.ObjectOf(spec.Name).(*types.TypeName)] = source.Obj()
+										}
+									}
 								case *ast.FuncType: // function type - do nothing (for now)
 								case *ast.ChanType:
 									// TODO - treat channel types as deeply nilable at the typedef level
@@ -625,6 +753,23 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 		}
 	}
 
+	// The defined types without their own deep annotations inherit the ones of the types they are
+	// defined from, following the chains of such definitions (e.g., `type T U` and `type U V`).
+	for typeName, source := range definedTypeSources {
+		for i := 0; i < len(definedTypeSources); i++ {
+			next, ok := definedTypeSources[source]
+			if !ok {
+				break
+			}
+			source = next
+		}
+		if sourceVal, ok := deepTypeAnnMap[source]; ok && sourceVal.IsDeepNilableSet {
+			val := deepTypeAnnMap[typeName]
+			val.IsDeepNilable, val.IsDeepNilableSet = sourceVal.IsDeepNilable, true
+			deepTypeAnnMap[typeName] = val
+		}
+	}
+
 	// Parse inline annotations at call sites.
 	for _, file := range files {
 		if !conf.IsFileInScope(file) {
@@ -698,9 +843,31 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 		globalVarsAnnMap:        globalVarsAnnMap,
 		funcCallSiteParamAnnMap: funcCallSiteParamAnnMap,
 		funcCallSiteRetAnnMap:   funcCallSiteRetAnnMap,
-	}
+	}, invalidTags
 }
 
 func getLineFromPos(pos token.Pos, pass *analysis.Pass) int {
 	return pass.Fset.Position(pos).Line
 }
+
+// embeddedFieldIdent returns the identifier of the type of an embedded field (e.g., `T` for `*T`,
+// `pkg.T` and `T[int]`), which is the implicit name of the field and is mapped to the field in
+// types.Info.Defs. It returns nil if the type expression is not of a recognized form.
+func embeddedFieldIdent(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.StarExpr:
+		return embeddedFieldIdent(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel
+	case *ast.IndexExpr:
+		return embeddedFieldIdent(expr.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldIdent(expr.X)
+	case *ast.ParenExpr:
+		return embeddedFieldIdent(expr.X)
+	default:
+		return nil
+	}
+}
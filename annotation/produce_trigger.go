@@ -325,6 +325,30 @@ func (n NoVarAssignPrestring) String() string {
 	return fmt.Sprintf("unassigned variable `%s`", n.VarName)
 }
 
+// UnassignedArrayElem is when a value is determined to flow from an element of a local array that
+// was never assigned to, either because the array itself was never assigned or because the element
+// was left out of the composite literal initializing it, and hence holds the zero value `nil`
+type UnassignedArrayElem struct {
+	ProduceTriggerTautology
+	VarObj *types.Var
+}
+
+// Prestring returns this UnassignedArrayElem as a Prestring
+func (u UnassignedArrayElem) Prestring() Prestring {
+	return UnassignedArrayElemPrestring{
+		VarName: u.VarObj.Name(),
+	}
+}
+
+// UnassignedArrayElemPrestring is a Prestring storing the needed information to compactly encode a UnassignedArrayElem
+type UnassignedArrayElemPrestring struct {
+	VarName string
+}
+
+func (u UnassignedArrayElemPrestring) String() string {
+	return fmt.Sprintf("unassigned element of array `%s`", u.VarName)
+}
+
 // BlankVarReturn is when a value is determined to flow from a blank variable ('_') to a return of the function
 type BlankVarReturn struct {
 	ProduceTriggerTautology
@@ -820,6 +844,189 @@ func (c ChanRecv) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
 	return c
 }
 
+// TypeAssertion is when a value is determined to flow from a type assertion in the
+// `v, ok := x.(T)` form, where `v` is nil if the assertion fails. Hence, it never produces nil
+// when guarded by a check on `ok`, and is replaced with GuardMissing otherwise. It should always
+// be instantiated with NeedsGuard = true.
+type TypeAssertion struct {
+	ProduceTriggerNever
+	TypeName   string
+	NeedsGuard bool
+}
+
+// Prestring returns this TypeAssertion as a Prestring
+func (t TypeAssertion) Prestring() Prestring {
+	return TypeAssertionPrestring{t.TypeName}
+}
+
+// TypeAssertionPrestring is a Prestring storing the needed information to compactly encode a TypeAssertion
+type TypeAssertionPrestring struct {
+	TypeName string
+}
+
+func (t TypeAssertionPrestring) String() string {
+	return fmt.Sprintf("result of a type assertion to `%s`", t.TypeName)
+}
+
+// NeedsGuardMatch for a TypeAssertion reads the field NeedsGuard of the struct
+func (t TypeAssertion) NeedsGuardMatch() bool { return t.NeedsGuard }
+
+// SetNeedsGuard for a TypeAssertion sets the field NeedsGuard
+func (t TypeAssertion) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
+	t.NeedsGuard = b
+	return t
+}
+
+// OkFuncReturn is when a value is determined to flow from the value result of a library function
+// with `(value, ok)` semantics (e.g., `v, ok := m.Load(k)` for a `sync.Map`), where `v` is nil if
+// `ok` is false. Similar to TypeAssertion, it never produces nil when guarded by a check on `ok`,
+// and is replaced with GuardMissing otherwise. It should always be instantiated with
+// NeedsGuard = true.
+type OkFuncReturn struct {
+	ProduceTriggerNever
+	FuncName   string
+	NeedsGuard bool
+}
+
+// Prestring returns this OkFuncReturn as a Prestring
+func (o OkFuncReturn) Prestring() Prestring {
+	return OkFuncReturnPrestring{o.FuncName}
+}
+
+// OkFuncReturnPrestring is a Prestring storing the needed information to compactly encode a OkFuncReturn
+type OkFuncReturnPrestring struct {
+	FuncName string
+}
+
+func (o OkFuncReturnPrestring) String() string {
+	return fmt.Sprintf("value returned from `%s`", o.FuncName)
+}
+
+// NeedsGuardMatch for a OkFuncReturn reads the field NeedsGuard of the struct
+func (o OkFuncReturn) NeedsGuardMatch() bool { return o.NeedsGuard }
+
+// SetNeedsGuard for a OkFuncReturn sets the field NeedsGuard
+func (o OkFuncReturn) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
+	o.NeedsGuard = b
+	return o
+}
+
+// ErrorsAsTarget is used when a value is assigned to the target of `errors.As(err, &target)`, which
+// is left unchanged (e.g., nil for a zero-valued target) if no error in the chain of `err` matches.
+// Hence, the target is nilable unless it is guarded by a check that the call returned true.
+// These should always be instantiated with NeedsGuard = true
+type ErrorsAsTarget struct {
+	ProduceTriggerNever
+	NeedsGuard bool
+}
+
+// Prestring returns this ErrorsAsTarget as a Prestring
+func (ErrorsAsTarget) Prestring() Prestring {
+	return ErrorsAsTargetPrestring{}
+}
+
+// ErrorsAsTargetPrestring is a Prestring storing the needed information to compactly encode a ErrorsAsTarget
+type ErrorsAsTargetPrestring struct{}
+
+func (ErrorsAsTargetPrestring) String() string {
+	return "value assigned to the target of `errors.As`"
+}
+
+// NeedsGuardMatch for a ErrorsAsTarget reads the field NeedsGuard of the struct
+func (e ErrorsAsTarget) NeedsGuardMatch() bool { return e.NeedsGuard }
+
+// SetNeedsGuard for a ErrorsAsTarget sets the field NeedsGuard
+func (e ErrorsAsTarget) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
+	e.NeedsGuard = b
+	return e
+}
+
+// ContextValue is used when a value is read from a context by `context.Context.Value`, which
+// returns nil if the key is absent from the context.
+type ContextValue struct {
+	ProduceTriggerTautology
+}
+
+// Prestring returns this ContextValue as a Prestring
+func (ContextValue) Prestring() Prestring {
+	return ContextValuePrestring{}
+}
+
+// ContextValuePrestring is a Prestring storing the needed information to compactly encode a ContextValue
+type ContextValuePrestring struct{}
+
+func (ContextValuePrestring) String() string {
+	return "value read from `context.Context.Value`, which is nil if the key is absent"
+}
+
+// ReflectInterface is used when the dynamic value of a `reflect.Value` (i.e., the result of
+// `reflect.Value.Interface`) is asserted to a pointer type, which is nil if the reflected value is
+// a nil pointer. Since reflection is not tracked, such pointers are always considered nilable.
+type ReflectInterface struct {
+	ProduceTriggerTautology
+	TypeName string
+}
+
+// Prestring returns this ReflectInterface as a Prestring
+func (r ReflectInterface) Prestring() Prestring {
+	return ReflectInterfacePrestring{r.TypeName}
+}
+
+// ReflectInterfacePrestring is a Prestring storing the needed information to compactly encode a ReflectInterface
+type ReflectInterfacePrestring struct {
+	TypeName string
+}
+
+func (r ReflectInterfacePrestring) String() string {
+	return fmt.Sprintf("value of `reflect.Value.Interface()` asserted to `%s`", r.TypeName)
+}
+
+// UnmarshalledField is used when a pointer field is populated by an unmarshal function (e.g.,
+// `json.Unmarshal`), which leaves the field nil if its key is absent from the input. Since the
+// input is unknown statically, the field is always considered nilable.
+type UnmarshalledField struct {
+	ProduceTriggerTautology
+	FuncName string
+}
+
+// Prestring returns this UnmarshalledField as a Prestring
+func (u UnmarshalledField) Prestring() Prestring {
+	return UnmarshalledFieldPrestring{u.FuncName}
+}
+
+// UnmarshalledFieldPrestring is a Prestring storing the needed information to compactly encode a UnmarshalledField
+type UnmarshalledFieldPrestring struct {
+	FuncName string
+}
+
+func (u UnmarshalledFieldPrestring) String() string {
+	return fmt.Sprintf("value possibly absent from the input of `%s`", u.FuncName)
+}
+
+// NilStoredThroughParam is used when the address of a field is passed to a function that directly
+// assigns nil through the corresponding pointer parameter (e.g., `*p = nil`), which may leave the
+// field nil after the call.
+type NilStoredThroughParam struct {
+	ProduceTriggerTautology
+	FuncName  string
+	ParamName string
+}
+
+// Prestring returns this NilStoredThroughParam as a Prestring
+func (n NilStoredThroughParam) Prestring() Prestring {
+	return NilStoredThroughParamPrestring{n.FuncName, n.ParamName}
+}
+
+// NilStoredThroughParamPrestring is a Prestring storing the needed information to compactly encode a NilStoredThroughParam
+type NilStoredThroughParamPrestring struct {
+	FuncName  string
+	ParamName string
+}
+
+func (n NilStoredThroughParamPrestring) String() string {
+	return fmt.Sprintf("nil assigned through parameter `%s` by `%s()`", n.ParamName, n.FuncName)
+}
+
 // FuncParamDeep is used when a value is determined to flow deeply from a function parameter
 type FuncParamDeep struct {
 	TriggerIfDeepNilable
@@ -1011,6 +1218,38 @@ func (g GlobalVarReadDeep) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
 	return g
 }
 
+// ElemReadDeep is when a value is determined to flow deeply from the elements of a container site,
+// e.g., `x[i][j]` for a `[][]*T` parameter `x` (see ElemAnnotationKey)
+type ElemReadDeep struct {
+	TriggerIfDeepNilable
+	NeedsGuard bool
+}
+
+// Prestring returns this ElemReadDeep as a Prestring
+func (e ElemReadDeep) Prestring() Prestring {
+	key := e.Ann.(ElemAnnotationKey)
+	return ElemReadDeepPrestring{key.ContainerString()}
+}
+
+// ElemReadDeepPrestring is a Prestring storing the needed information to compactly encode a ElemReadDeep
+type ElemReadDeepPrestring struct {
+	ContainerName string
+}
+
+func (e ElemReadDeepPrestring) String() string {
+	return fmt.Sprintf("deep read from the elements of %s", e.ContainerName)
+}
+
+// NeedsGuardMatch for an ElemReadDeep reads the field NeedsGuard of the
+// struct - set to indicate whether the elements are of type `map` or `channel`
+func (e ElemReadDeep) NeedsGuardMatch() bool { return e.NeedsGuard }
+
+// SetNeedsGuard for an ElemReadDeep sets the field NeedsGuard
+func (e ElemReadDeep) SetNeedsGuard(b bool) ProducingAnnotationTrigger {
+	e.NeedsGuard = b
+	return e
+}
+
 // GuardMissing is when a value is determined to flow from a site that requires a guard,
 // to a site that is not guarded by that guard.
 //
@@ -69,6 +69,49 @@ func DeepNilabilityOfFuncRet(fn *types.Func, retNum int) ProducingAnnotationTrig
 	return DeepNilabilityAsNamedType(retType)
 }
 
+// DeepNilabilityOfElem returns the producing trigger for the deep nilability of the elements read
+// from a container, i.e., the nilability of the values read from the elements of type `elemType`,
+// given the trigger `containerDeep` producing the deep nilability of the container (i.e., the
+// nilability of the elements themselves). For example, for `x[i][j]` where `x` is a `[][]*T`
+// parameter, `containerDeep` is the deep nilability of `x` and the returned trigger reads the
+// deep nilability of the elements of `x` (see ElemAnnotationKey). The named element types are
+// handled by DeepNilabilityAsNamedType as before, and the elements of the elements are not tracked
+// beyond two levels.
+func DeepNilabilityOfElem(containerDeep ProducingAnnotationTrigger, elemType types.Type) ProducingAnnotationTrigger {
+	if !util.TypeIsDeep(elemType) || containerDeep.Kind() != DeepConditional {
+		return DeepNilabilityAsNamedType(elemType)
+	}
+	container := containerDeep.UnderlyingSite()
+	if _, ok := container.(ElemAnnotationKey); ok || container == nil {
+		return ProduceTriggerNever{}
+	}
+	return ElemReadDeep{
+		TriggerIfDeepNilable: TriggerIfDeepNilable{
+			Ann: ElemAnnotationKey{Container: container}},
+		NeedsGuard: util.TypeIsDeeplyMap(elemType)}
+}
+
+// DeepAssignToElem returns the consuming trigger for a value assigned deeply into the elements
+// of a container, given the trigger `containerDeep` consuming the values assigned deeply into the
+// container itself. For example, for `x[i][j] = v` where `x` is a `[][]*T` parameter,
+// `containerDeep` consumes the values assigned into `x[i]`, and the returned trigger consumes the
+// values assigned into the elements of `x` (see ElemAnnotationKey). It returns nil if the
+// container is not linked to an annotation site, or if the elements are nested beyond two levels.
+//
+// nilable(result 0)
+func DeepAssignToElem(containerDeep ConsumingAnnotationTrigger) ConsumingAnnotationTrigger {
+	if containerDeep == nil || containerDeep.Kind() != DeepConditional {
+		return nil
+	}
+	container := containerDeep.UnderlyingSite()
+	if _, ok := container.(ElemAnnotationKey); ok || container == nil {
+		return nil
+	}
+	return ElemAssignDeep{
+		TriggerIfDeepNonNil: TriggerIfDeepNonNil{
+			Ann: ElemAnnotationKey{Container: container}}}
+}
+
 // DeepNilabilityOfFld inspects a struct field for deep nilability annotation
 func DeepNilabilityOfFld(fld *types.Var) ProducingAnnotationTrigger {
 	if util.TypeIsDeep(fld.Type()) {
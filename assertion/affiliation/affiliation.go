@@ -138,8 +138,11 @@ func (a *Affiliation) computeTriggersForCastingSites(pass *analysis.Pass, upstre
 						if declObj := pass.TypesInfo.Uses[ident]; declObj != nil {
 							if fdecl, ok := declObj.(*types.Func); ok {
 								fsig := fdecl.Type().(*types.Signature)
-								for i := 0; i < fsig.Params().Len() && i < len(node.Args); i++ {
-									lhsType := fsig.Params().At(i).Type()      // receiver param of method declaration
+								for i := 0; i < len(node.Args); i++ {
+									lhsType := paramTypeOfArg(fsig, i, node.Ellipsis.IsValid()) // receiver param of method declaration
+									if lhsType == nil {
+										break
+									}
 									rhsType := util.TypeOf(pass, node.Args[i]) // caller param
 									appendTypeToTypeTriggers(lhsType, rhsType)
 								}
@@ -156,6 +159,13 @@ func (a *Affiliation) computeTriggersForCastingSites(pass *analysis.Pass, upstre
 						}
 					}
 
+				case *ast.SendStmt:
+					// channel is declared of type interface, and a struct is sent on it
+					// e.g., var ch chan I, ch <- &S{}
+					if chanType, ok := util.TypeOf(pass, node.Chan).Underlying().(*types.Chan); ok {
+						appendTypeToTypeTriggers(chanType.Elem(), util.TypeOf(pass, node.Value))
+					}
+
 				case *ast.TypeAssertExpr:
 					// e.g., v, ok := i.(*S)
 					lhsType := util.TypeOf(pass, node.X)
@@ -234,6 +244,28 @@ func (a *Affiliation) computeTriggersForCastingSites(pass *analysis.Pass, upstre
 	}
 }
 
+// paramTypeOfArg returns the type of the parameter that receives the i-th argument of a call to a
+// function with the signature, or nil if there is no such parameter. For a variadic function, the
+// arguments beyond the regular parameters are received as elements of the variadic parameter,
+// unless the call spreads a slice (i.e., `f(xs...)`) into it.
+func paramTypeOfArg(sig *types.Signature, i int, spread bool) types.Type {
+	n := sig.Params().Len()
+	if !sig.Variadic() || i < n-1 {
+		if i < n {
+			return sig.Params().At(i).Type()
+		}
+		return nil
+	}
+	last := sig.Params().At(n - 1).Type()
+	if spread {
+		return last
+	}
+	if slice, ok := last.(*types.Slice); ok {
+		return slice.Elem()
+	}
+	return nil
+}
+
 // computeTriggersForTypes finds corresponding concrete implementation and their declared methods and populates them in a map
 func (a *Affiliation) computeTriggersForTypes(lhsType types.Type, rhsType types.Type, upstreamCache ImplementedDeclaredTypesCache, currentCache ImplementedDeclaredTypesCache) []annotation.FullTrigger {
 	if lhsType == nil || rhsType == nil {
@@ -44,7 +44,7 @@ type Result struct {
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_affiliation_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	FactTypes:  []analysis.Fact{new(AffliliationCache)},
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires:   []*analysis.Analyzer{config.Analyzer},
@@ -48,7 +48,7 @@ type Result struct {
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_assertion_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires:   []*analysis.Analyzer{config.Analyzer, function.Analyzer, affiliation.Analyzer, global.Analyzer},
 }
@@ -35,7 +35,7 @@ const _doc = "Collect variables from closure that are being assigned and/or acce
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_anonymous_func_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires:   []*analysis.Analyzer{config.Analyzer},
 }
@@ -56,7 +56,7 @@ type Result struct {
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_function_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires: []*analysis.Analyzer{
 		config.Analyzer,
@@ -122,6 +122,11 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		pkgFakeIdentMap[info.FakeFuncDecl.Name] = info.FakeFuncObj
 	}
 
+	// Collect the parameters that nil is directly assigned through for modeling the fields whose
+	// addresses are passed to them, which must be done upfront since the callees are analyzed
+	// concurrently.
+	nilStoringParams := assertiontree.CollectNilStoringParams(pass)
+
 	// Set up variables for synchronization and communication.
 	ctx, cancel := context.WithTimeout(context.Background(), config.BackpropTimeout)
 	defer cancel()
@@ -130,6 +135,11 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	// We use this to keep track of the index of the function declaration we are analyzing.
 	// TODO: remove this once  is done.
 	var funcIndex int
+	// If the analysis is focused on a file or a function in this package, the functions out of
+	// focus are not analyzed, i.e., their sites are only determined by annotations (and the facts
+	// of the upstream packages) and are never re-derived. Packages out of focus are still analyzed
+	// such that their facts are available downstream.
+	focused := conf.HasFocus() && conf.IsPkgInFocus(pass)
 	for _, file := range pass.Files {
 		// Skip if a file is marked to be ignored, or it is not in scope of our analysis.
 		if !conf.IsFileInScope(file) {
@@ -146,6 +156,9 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 			functionConfig.StructInitCheckType = util.DocContainsStructInitCheck(file.Doc)
 			functionConfig.EnableAnonymousFunc = util.DocContainsAnonymousFuncCheck(file.Doc)
 		}
+		functionConfig.RelaxedTestRules = conf.IsRelaxedTestFile(file)
+		functionConfig.UnmarshalNilable = conf.UnmarshalNilable
+		functionConfig.ReflectNilable = conf.ReflectNilable
 
 		// Collect all function declarations and function literals if anonymous function support
 		// is enabled.
@@ -200,11 +213,15 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 			if funcSizeInBytes > _maxFuncSizeInBytes {
 				continue
 			}
+			// Skip if the function is out of the focus of the analysis.
+			if focused && !conf.IsInFocus(pass, file, fun.Pos()) {
+				continue
+			}
 
 			// Now, analyze the function declarations concurrently.
 			wg.Add(1)
 			funcContext := assertiontree.NewFunctionContext(
-				pass, funcDecl, funcLit, functionConfig, funcLitMap, pkgFakeIdentMap, funcContracts)
+				pass, funcDecl, funcLit, functionConfig, funcLitMap, pkgFakeIdentMap, funcContracts, nilStoringParams)
 			go analyzeFunc(ctx, pass, funcDecl, funcContext, graph, funcIndex, funcChan, &wg)
 			funcIndex++
 		}
@@ -70,7 +70,7 @@ func TestTimeout(t *testing.T) {
 	emptyPkgFakeIdentMap := make(map[*ast.Ident]types.Object)
 	emptyFuncContracts := make(functioncontracts.Map)
 	funcContext := assertiontree.NewFunctionContext(pass, funcDecl, nil, /* funcLit */
-		funcConfig, emptyFuncLitMap, emptyPkgFakeIdentMap, emptyFuncContracts)
+		funcConfig, emptyFuncLitMap, emptyPkgFakeIdentMap, emptyFuncContracts, nil /* nilStoringParams */)
 	// (3) Set up synchronization and communication for the goroutine we are going to spawn.
 	resultChan := make(chan functionResult)
 	wg := new(sync.WaitGroup)
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 
@@ -80,6 +81,10 @@ func backpropAcrossNode(rootNode *RootAssertionNode, node ast.Node) error {
 		rootNode.AddComputation(n.Call)
 	case *ast.IncDecStmt:
 		rootNode.AddComputation(n.X)
+		// `m[k]++` writes to the map just like `m[k] += 1` does.
+		if consumer := exprAsConsumedByAssignment(rootNode, n.X); consumer != nil {
+			rootNode.AddConsumption(consumer)
+		}
 
 	case *ast.SelectorExpr:
 		rootNode.AddComputation(n)
@@ -118,7 +123,7 @@ func backpropAcrossNode(rootNode *RootAssertionNode, node ast.Node) error {
 func backpropAcrossSend(rootNode *RootAssertionNode, node *ast.SendStmt) error {
 	// Added this consumer since sending over a nil channel can cause panic
 	rootNode.AddConsumption(&annotation.ConsumeTrigger{
-		Annotation: annotation.ChanAccess{},
+		Annotation: annotation.ChanAccess{DerefPos: node.Arrow},
 		Expr:       node.Chan,
 		Guards:     util.NoGuards(),
 	})
@@ -151,6 +156,9 @@ func backpropAcrossReturn(rootNode *RootAssertionNode, node *ast.ReturnStmt) err
 		rootNode.addConsumptionsForFieldsOfParams()
 	}
 
+	// The function literals deferred before the return are invoked after it.
+	consumeDeferredClosureVars(rootNode, node)
+
 	if len(node.Results) == 1 {
 		if call, ok := node.Results[0].(*ast.CallExpr); ok {
 			var fident *ast.Ident
@@ -297,7 +305,9 @@ func backpropAcrossAssignment(rootNode *RootAssertionNode, lhs, rhs []ast.Expr)
 		// currently handle the following cases in NilAway:
 		// 1. Map read: `v, ok := m[k]`
 		// 2. Channel receive: `v, ok := <-ch`
-		// TODO: 3. Type assertion: `v, ok := y.(*type)`
+		// 3. Type assertion: `v, ok := y.(*type)`
+		// 4. Calls to library functions with `(value, ok)` semantics: `v, ok := m.Load(k)`, which
+		//    fall through to backpropAcrossManyToOneAssignment (see okFuncs).
 		if len(lhs) == 2 {
 			rootNode.AddGuardMatch(lhs[0], ContinueTracking)
 
@@ -332,9 +342,30 @@ func backpropAcrossAssignment(rootNode *RootAssertionNode, lhs, rhs []ast.Expr)
 			}
 
 			// Type assertion
+			// Unlike the panicking form `v := y.(*type)`, which always produces a nonnil `v`, `v` is
+			// nil if the assertion fails in the `ok` form. Hence, we produce `v` as nilable unless
+			// it is guarded by a check on `ok` (see TypeAssertOkRead).
 			if r, ok := rhsNode.(*ast.TypeAssertExpr); ok && r.Type != nil {
-				// TODO: properly handle type assertions' "OK" contract
-				return backpropAcrossOneToOneAssignment(rootNode, lhs[0:1], rhs)
+				typ := rootNode.Pass().TypesInfo.TypeOf(r.Type)
+				if util.IsEmptyExpr(lhs[0]) || util.TypeBarsNilness(typ) {
+					return backpropAcrossOneToOneAssignment(rootNode, lhs[0:1], rhs)
+				}
+				var ann annotation.ProducingAnnotationTrigger = annotation.TypeAssertion{
+					TypeName:   types.TypeString(typ, types.RelativeTo(rootNode.Pass().Pkg)),
+					NeedsGuard: true,
+				}
+				// The pointers asserted from `reflect.Value`s may be nil even if `ok` is true.
+				if p := rootNode.reflectInterfaceProducer(r); p != nil {
+					ann = p.Annotation
+				}
+				rootNode.AddProduction(&annotation.ProduceTrigger{
+					Annotation: ann,
+					Expr:       lhs[0],
+				})
+				// Unlike the panicking form, the `ok` form never panics on a nil operand, so phase 3
+				// only computes the operand instead of the assertion itself (see AddComputation).
+				rhs = []ast.Expr{r.X}
+				return nil
 			}
 		}
 	}
@@ -465,8 +496,16 @@ func backpropAcrossTypeSwitch(rootNode *RootAssertionNode, lhs *ast.Ident, rhs a
 							Expr:       lhs,
 						})
 					case 1:
+						producer := rhsProducers[0].GetShallow().Annotation
+						// A value read from a context is nil only if the key is absent, in which case
+						// it is a nil interface that matches no case with a type. The variable has
+						// the type of the case in exactly such cases.
+						if _, ok := producer.(annotation.ContextValue); ok &&
+							!types.Identical(varChild.decl.Type(), rootNode.Pass().TypesInfo.TypeOf(rhs)) {
+							producer = annotation.ProduceTriggerNever{}
+						}
 						rootNode.triggerProductions(liftedChild, &annotation.ProduceTrigger{
-							Annotation: rhsProducers[0].GetShallow().Annotation,
+							Annotation: producer,
 							Expr:       lhs,
 						}, rhsProducers[0].GetDeepSlice()...)
 					default:
@@ -592,11 +631,19 @@ buildShadowMask:
 					// We're in case B
 					switch len(rproducers) {
 					case 0:
+						var deeperProducers []*annotation.ProduceTrigger
+						if lit, ok := util.StripParens(rhsVal).(*ast.CompositeLit); ok && n == 1 {
+							var err error
+							deeperProducers, err = backpropAcrossArrayLitAssignment(rootNode, lhsVal, lit)
+							if err != nil {
+								return err
+							}
+						}
 						// lhsVal expression will never be nil here because rhsVal will never be nil
 						rootNode.AddProduction(&annotation.ProduceTrigger{
 							Annotation: annotation.ProduceTriggerNever{},
 							Expr:       lhsVal,
-						})
+						}, deeperProducers...)
 					case 1:
 						if rootNode.functionContext.isDepthOneFieldCheck() {
 							fieldProducers := rproducers[0].GetFieldProducers()
@@ -644,6 +691,74 @@ buildShadowMask:
 	return nil
 }
 
+// backpropAcrossArrayLitAssignment handles the elements of a local array variable `lhsVal` being
+// assigned the composite literal `lit` (e.g., "arr := [3]*T{x, y}"). Since such an assignment is
+// equivalent to assigning each of the elements in turn (e.g., "arr[0], arr[1], arr[2] = x, y, nil"),
+// the elements tracked at constant indices set by the literal are produced by the respective
+// elements of the literal. If the literal leaves any element out, a deeper producer for the
+// remaining elements of the array is returned, to be passed along with the production of `lhsVal`.
+func backpropAcrossArrayLitAssignment(
+	rootNode *RootAssertionNode, lhsVal ast.Expr, lit *ast.CompositeLit) ([]*annotation.ProduceTrigger, error) {
+	ident, ok := util.StripParens(lhsVal).(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	varObj, ok := rootNode.ObjectOf(ident).(*types.Var)
+	if !ok || !isLocalArrayOfNilable(rootNode.FuncObj(), varObj) {
+		return nil, nil
+	}
+
+	// map each index set by the literal to its element, keyed elements reset the running index
+	elems := make(map[int64]ast.Expr, len(lit.Elts))
+	var next int64
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, ok := constant.Int64Val(rootNode.Pass().TypesInfo.Types[kv.Key].Value)
+			if !ok {
+				return nil, nil
+			}
+			next, elt = key, kv.Value
+		}
+		elems[next] = elt
+		next++
+	}
+
+	var deeperProducers []*annotation.ProduceTrigger
+	if int64(len(elems)) < varObj.Type().Underlying().(*types.Array).Len() {
+		deeperProducers = append(deeperProducers, &annotation.ProduceTrigger{
+			Annotation: annotation.UnassignedArrayElem{VarObj: varObj},
+			Expr:       lit,
+		})
+	}
+
+	path, _ := rootNode.ParseExprAsProducer(lhsVal, false)
+	node, _ := rootNode.lookupPath(path)
+	if node == nil {
+		return deeperProducers, nil
+	}
+	var lhs, rhs []ast.Expr
+	for _, child := range node.Children() {
+		child, ok := child.(*indexAssertionNode)
+		if !ok {
+			continue
+		}
+		index, ok := constant.Int64Val(rootNode.Pass().TypesInfo.Types[child.index].Value)
+		if !ok {
+			continue
+		}
+		if elem, ok := elems[index]; ok {
+			lhs = append(lhs, child.BuildExpr(rootNode.Pass(), lhsVal))
+			rhs = append(rhs, elem)
+		}
+	}
+	if len(lhs) > 0 {
+		if err := backpropAcrossOneToOneAssignment(rootNode, lhs, rhs); err != nil {
+			return nil, err
+		}
+	}
+	return deeperProducers, nil
+}
+
 // backpropAcrossManyToOneAssignment handles normal many-to-one assignment (e.g, "a, b := foo()"),
 // it is designed to be called from backpropAcrossAssignment as a finer-grained handler for
 // many-to-one normal assignments.
@@ -666,15 +781,26 @@ func backpropAcrossManyToOneAssignment(rootNode *RootAssertionNode, lhs, rhs []a
 		return errors.New("rhsVal function returned different number of results than expression " +
 			"present on lhs of assignment")
 	}
-	for i := range producers {
-
-		lhsVal := lhs[i]
-
+	for i, lhsVal := range lhs {
 		// Eliminates checking of the `_` instances in the lhs of a multiple assignment
 		if util.IsEmptyExpr(lhsVal) {
 			continue
 		}
 
+		if len(producers) == 0 {
+			// The results of the call are unknown (e.g., for calls to function variables), so each
+			// lhsVal is bound to a value that is never nil, similar to the 1-1 assignments from such
+			// calls. Otherwise, the assertions on lhsVal would survive past its assignment.
+			rootNode.AddProduction(&annotation.ProduceTrigger{
+				Annotation: annotation.ProduceTriggerNever{},
+				Expr:       lhsVal,
+			})
+			if consumer := exprAsConsumedByAssignment(rootNode, lhsVal); consumer != nil {
+				rootNode.AddConsumption(consumer)
+			}
+			continue
+		}
+
 		// Phase 1
 		if rootNode.functionContext.isDepthOneFieldCheck() {
 			fieldProducers := producers[i].GetFieldProducers()
@@ -918,9 +1044,21 @@ func BackpropAcrossFunc(ctx context.Context, pass *analysis.Pass, decl *ast.Func
 		currRootAssertionNode, nextRootAssertionNode = nextRootAssertionNode, nil
 	}
 
+	// The named results assigned in the recovery blocks of the deferred function literals are
+	// returned as well, which is not reflected in the CFG.
+	extra := recoveredReturnTriggers(pass, decl)
+	// Similarly, the assignments of the fields populated by `json.Unmarshal` are not visible in
+	// the function body.
+	if functionContext.functionConfig.UnmarshalNilable {
+		extra = append(extra, unmarshalledFieldTriggers(pass, decl, functionContext.functionConfig.EnableAnonymousFunc)...)
+	}
+	// The same goes for the fields whose addresses are passed to the functions assigning nil
+	// through them.
+	extra = append(extra, nilStoredFieldTriggers(pass, decl, functionContext.nilStoringParams, functionContext.functionConfig.EnableAnonymousFunc)...)
+
 	// Return the generated full triggers at the entry block; we're done!
 	if currRootAssertionNode == nil {
-		return nil, nil
+		return extra, nil
 	}
-	return currRootAssertionNode.triggers, nil
+	return append(currRootAssertionNode.triggers, extra...), nil
 }
@@ -378,7 +378,9 @@ func typeIsString(t types.Type) bool {
 }
 
 // some expressions consume their subexpressions specifically when assigned to - for now, we are
-// aware only of map indices written to as having this behavior
+// aware only of map indices written to as having this behavior: writing to a nil map (via `m[k] = v`,
+// `m[k] op= v` or `m[k]++`) panics, while reading from it (`m[k]`, `len(m)` or ranging over it)
+// simply yields the zero values, and `delete(m, k)` (as well as `clear(m)`) is a no-op on a nil map
 // exprAsConsumedByAssignment recognizes these cases, and returns the corresponding consumeTrigger
 // if one is found, otherwise returning `nil, false`
 // nilable(result 0)
@@ -457,7 +459,8 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 			return nil
 		}
 
-	handleDeepAssignmentToExpr :=
+	var handleDeepAssignmentToExpr func(expr ast.Expr) (annotation.ConsumingAnnotationTrigger, error)
+	handleDeepAssignmentToExpr =
 		func(expr ast.Expr) (annotation.ConsumingAnnotationTrigger, error) {
 
 			switch expr := expr.(type) {
@@ -484,7 +487,10 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 			case *ast.CallExpr:
 				// check if this is a call to a function by name
 				if ident := util.FuncIdentFromCallExpr(expr); ident != nil {
-					obj := rootNode.ObjectOf(ident).(*types.Func)
+					obj, ok := rootNode.ObjectOf(ident).(*types.Func)
+					if !ok {
+						return nil, errors.New("call to a non-function treated as assignment consumer")
+					}
 					if obj.Type().(*types.Signature).Results().Len() != 1 {
 						return nil, errors.New("multiply returning function treated as assignment consumer")
 					}
@@ -495,14 +501,23 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 					}, nil
 				}
 			case *ast.IndexExpr:
-				return exprAsAssignmentConsumer(rootNode, expr.X, exprRHS)
+				// this is an assignment into the elements of the elements of a container, e.g.,
+				// `x[i][j] = v`, which is consumed by the deep nilability of the elements of `x`
+				// (unless the elements are of a named type, which is handled below)
+				if _, ok := rootNode.Pass().TypesInfo.Types[expr].Type.(*types.Named); !ok {
+					consumer, err := handleDeepAssignmentToExpr(expr.X)
+					if err != nil {
+						return nil, err
+					}
+					return annotation.DeepAssignToElem(consumer), nil
+				}
 			}
 
 			nameAsDeepTrigger := func(name *types.TypeName) annotation.TriggerIfDeepNonNil {
 				return annotation.TriggerIfDeepNonNil{Ann: annotation.TypeNameAnnotationKey{TypeDecl: name}}
 			}
 
-			exprType := rootNode.Pass().TypesInfo.Types[expr].Type
+			exprType := rootNode.Pass().TypesInfo.TypeOf(expr)
 
 			if named, ok := exprType.(*types.Named); ok {
 				// Calling Underlying on [types.Named] will always return the unnamed type, so we
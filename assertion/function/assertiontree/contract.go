@@ -22,6 +22,8 @@ import (
 
 	"go.uber.org/nilaway/annotation"
 	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 // A RichCheckEffect is the fact that a certain check is associated with an effect that can
@@ -110,6 +112,7 @@ func (f *FuncErrRet) equals(effect RichCheckEffect) bool {
 // Concrete examples of patterns supported are:
 // - map ok read: `v, ok := m[k]`
 // - channel ok receive: `v, ok := <-ch`
+// - type assertion ok: `v, ok := x.(T)`
 // - function error return: `r0, r1, r2, ..., err := f()`
 // nonnil(value, ok)
 type okRead struct {
@@ -204,6 +207,82 @@ func (r *ChannelOkRecvRefl) String() string {
 		r.value.MinimalString(), r.ok.MinimalString())
 }
 
+// A TypeAssertOkRead is a RichCheckEffect for the `ok` in `v, ok := x.(T)` assignment. To match such an
+// assignment, both the `v` and the `ok` must be trackable, and to have the intended effect, an `if ok { }`
+// must be encountered before an assignment to either `v` or `ok`.
+type TypeAssertOkRead struct {
+	okRead
+}
+
+func (r *TypeAssertOkRead) String() string {
+	return fmt.Sprintf("<TypeAssertOkRead: {val: %s, ok: %s}>",
+		r.value.MinimalString(), r.ok.MinimalString())
+}
+
+// A FuncOkRead is a RichCheckEffect for the `ok` in `v, ok := f()` assignment, where `f` is a trusted
+// library function with `(value, ok)` semantics (e.g., `sync.Map.Load`). Similar to TypeAssertOkRead,
+// both the `v` and the `ok` must be trackable, and to have the intended effect, an `if ok { }` must be
+// encountered before an assignment to either `v` or `ok`.
+type FuncOkRead struct {
+	okRead
+}
+
+func (r *FuncOkRead) String() string {
+	return fmt.Sprintf("<FuncOkRead: {val: %s, ok: %s}>",
+		r.value.MinimalString(), r.ok.MinimalString())
+}
+
+// An ErrorsAsCheck is a RichCheckEffect for the result of a call `errors.As(err, &target)`, which
+// assigns to `target` only if it returns true. The call must either be the checked expression itself,
+// as in `if errors.As(err, &target) { }`, or be assigned to a trackable `ok` as in
+// `ok := errors.As(err, &target)`, in which case an `if ok { }` must be encountered before an
+// assignment to either `target` or `ok`.
+type ErrorsAsCheck struct {
+	root   *RootAssertionNode // an associated root node
+	call   *ast.CallExpr      // the call to `errors.As`
+	ok     TrackableExpr      // the variable the result of the call is assigned to, or nil if checked directly
+	target TrackableExpr      // the target of the call
+	guard  util.GuardNonce    // the guard to be applied on a matching check
+}
+
+func (e *ErrorsAsCheck) isTriggeredBy(expr ast.Expr) bool {
+	if e.ok == nil {
+		return expr == e.call
+	}
+	return exprMatchesTrackableExpr(e.root, expr, e.ok)
+}
+
+func (e *ErrorsAsCheck) isInvalidatedBy(node ast.Node) bool {
+	return e.ok != nil && nodeAssignsOneWithoutOther(e.root, node, e.ok, e.target)
+}
+
+func (e *ErrorsAsCheck) effectIfTrue(node *RootAssertionNode) {
+	guardExpr(node, e.target, e.guard)
+}
+
+func (e *ErrorsAsCheck) effectIfFalse(*RootAssertionNode) {
+	// no-op
+}
+
+func (*ErrorsAsCheck) isNoop() bool { return false }
+
+func (e *ErrorsAsCheck) String() string {
+	if e.ok == nil {
+		return fmt.Sprintf("<ErrorsAsCheck: {target: %s}>", e.target.MinimalString())
+	}
+	return fmt.Sprintf("<ErrorsAsCheck: {target: %s, ok: %s}>",
+		e.target.MinimalString(), e.ok.MinimalString())
+}
+
+func (e *ErrorsAsCheck) equals(effect RichCheckEffect) bool {
+	other, ok := effect.(*ErrorsAsCheck)
+	if !ok || e.call != other.call || (e.ok == nil) != (other.ok == nil) {
+		return false
+	}
+	return (e.ok == nil || e.root.Equal(e.ok, other.ok)) &&
+		e.root.Equal(e.target, other.target) && e.guard == other.guard
+}
+
 // A RichCheckNoop is a placeholder instance of RichCheckEffect that functions as a total noop.
 // It is used to allow in place modification of collections of RichCheckEffects.
 type RichCheckNoop struct{}
@@ -238,6 +317,9 @@ func RichCheckFromNode(rootNode *RootAssertionNode, nonceGenerator *util.GuardNo
 	if funcEffects, ok := NodeTriggersFuncErrRet(rootNode, nonceGenerator, node); ok {
 		effects, someEffects = append(effects, funcEffects...), true
 	}
+	if errorsAsEffects, ok := NodeTriggersErrorsAsCheck(rootNode, nonceGenerator, node); ok {
+		effects, someEffects = append(effects, errorsAsEffects...), true
+	}
 	return effects, someEffects
 }
 
@@ -261,8 +343,9 @@ func parseExpr(rootNode *RootAssertionNode, expr ast.Expr) TrackableExpr {
 	return parsed
 }
 
-// NodeTriggersOkRead is a case of a node creating a rich bool effect for map read and channel receive in the "ok" form.
-// It matches on `AssignStmt`s of the form `v, ok := mp[k]` and `v, ok := <-ch`
+// NodeTriggersOkRead is a case of a node creating a rich bool effect for map read, channel receive, type
+// assertion and trusted library function calls in the "ok" form. It matches on `AssignStmt`s of the form
+// `v, ok := mp[k]`, `v, ok := <-ch`, `v, ok := x.(T)` and `v, ok := m.Load(k)`
 // nilable(result 0)
 func NodeTriggersOkRead(rootNode *RootAssertionNode, nonceGenerator *util.GuardNonceGenerator, node ast.Node) ([]RichCheckEffect, bool) {
 	assignStmt, ok := node.(*ast.AssignStmt)
@@ -333,6 +416,28 @@ func NodeTriggersOkRead(rootNode *RootAssertionNode, nonceGenerator *util.GuardN
 					}})
 			}
 		}
+	case *ast.TypeAssertExpr:
+		if rhs.Type != nil && lhsValueParsed != nil {
+			// here, the lhs `value` operand is trackable
+			effects = append(effects, &TypeAssertOkRead{
+				okRead{
+					root:  rootNode,
+					value: lhsValueParsed,
+					ok:    lhsOkParsed,
+					guard: nonceGenerator.Next(valueExpr),
+				}})
+		}
+	case *ast.CallExpr:
+		if lhsValueParsed != nil && isOkFuncCall(rhs, rootNode.Pass()) {
+			// here, the lhs `value` operand is trackable
+			effects = append(effects, &FuncOkRead{
+				okRead{
+					root:  rootNode,
+					value: lhsValueParsed,
+					ok:    lhsOkParsed,
+					guard: nonceGenerator.Next(valueExpr),
+				}})
+		}
 	}
 	if len(effects) > 0 {
 		return effects, true
@@ -414,6 +519,71 @@ func NodeTriggersFuncErrRet(rootNode *RootAssertionNode, nonceGenerator *util.Gu
 	return effects, someEffect
 }
 
+// NodeTriggersErrorsAsCheck is a case of a node creating a rich check effect for the target of a call
+// to `errors.As`. It matches on the call itself (i.e., when it is a checked conditional) and on
+// `AssignStmt`s of the form `ok := errors.As(err, &target)`.
+// nilable(result 0)
+func NodeTriggersErrorsAsCheck(rootNode *RootAssertionNode, nonceGenerator *util.GuardNonceGenerator, node ast.Node) ([]RichCheckEffect, bool) {
+	var call *ast.CallExpr
+	var okParsed TrackableExpr
+	switch node := node.(type) {
+	case *ast.CallExpr:
+		call = node
+	case *ast.AssignStmt:
+		if len(node.Lhs) != 1 || len(node.Rhs) != 1 {
+			return nil, false
+		}
+		c, ok := node.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return nil, false
+		}
+		if okParsed = parseExpr(rootNode, node.Lhs[0]); okParsed == nil {
+			return nil, false
+		}
+		call = c
+	default:
+		return nil, false
+	}
+
+	target := errorsAsTarget(rootNode.Pass(), call)
+	if target == nil {
+		return nil, false
+	}
+	targetParsed := parseExpr(rootNode, target)
+	if targetParsed == nil {
+		return nil, false
+	}
+	return []RichCheckEffect{&ErrorsAsCheck{
+		root:   rootNode,
+		call:   call,
+		ok:     okParsed,
+		target: targetParsed,
+		guard:  nonceGenerator.Next(target),
+	}}, true
+}
+
+// errorsAsTarget returns `target` if the call is of the form `errors.As(err, &target)` with a
+// nilable target, and nil otherwise.
+// nilable(result 0)
+func errorsAsTarget(pass *analysis.Pass, call *ast.CallExpr) ast.Expr {
+	if len(call.Args) != 2 {
+		return nil
+	}
+	callIdent := util.FuncIdentFromCallExpr(call)
+	if callIdent == nil {
+		return nil
+	}
+	funcObj, ok := pass.TypesInfo.ObjectOf(callIdent).(*types.Func)
+	if !ok || funcObj.FullName() != "errors.As" {
+		return nil
+	}
+	addr, ok := astutil.Unparen(call.Args[1]).(*ast.UnaryExpr)
+	if !ok || addr.Op != token.AND || util.ExprBarsNilness(pass, addr.X) {
+		return nil
+	}
+	return addr.X
+}
+
 // nodeIsAssignmentTo(pass, node, one, other) returns true if `node` is an assignment to the variable
 // `one` but not an assignment to the variable `other`
 func nodeAssignsOneWithoutOther(rootNode *RootAssertionNode, node ast.Node, one, other TrackableExpr) bool {
@@ -0,0 +1,73 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"go/types"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/util"
+)
+
+// consumeDeferredClosureVars adds the consumptions of the closure variables of the function
+// literals deferred (directly in the body of the function) before the return statement, e.g.,
+//
+//	func foo() {
+//		p := &T{}
+//		defer func() { print(p.f) }()
+//		p = nil
+//	}
+//
+// The deferred function literals are invoked after the return, where they read the values of the
+// captured variables at the return instead of the ones at the defer statements. Hence, the
+// closure variables are passed to the fake functions created for the function literals (see
+// anonymousfunc.FuncLitInfo) at the return, similar to the regular calls of the function
+// literals. The defer statements directly in the body are executed on every path to the returns
+// after them, so no spurious flows are introduced for the paths that do not defer the calls.
+// Note that this is only effective if anonymous function support is enabled.
+func consumeDeferredClosureVars(rootNode *RootAssertionNode, node *ast.ReturnStmt) {
+	body := rootNode.functionContext.funcDecl.Body
+	if body == nil || len(rootNode.functionContext.funcLitMap) == 0 {
+		return
+	}
+	for _, stmt := range body.List {
+		deferStmt, ok := stmt.(*ast.DeferStmt)
+		if !ok || deferStmt.Pos() >= node.Pos() {
+			continue
+		}
+		funcLit, ok := deferStmt.Call.Fun.(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+		info, ok := rootNode.functionContext.funcLitMap[funcLit]
+		// The closure variables come after the regular parameters of the fake function, which
+		// cannot be addressed by the argument numbers if the function literal is variadic.
+		if !ok || info.FakeFuncObj.Type().(*types.Signature).Variadic() {
+			continue
+		}
+		numParams := info.FakeFuncObj.Type().(*types.Signature).Params().Len() - len(info.ClosureVars)
+		for i, closure := range info.ClosureVars {
+			rootNode.AddConsumption(&annotation.ConsumeTrigger{
+				Annotation: annotation.ArgPass{
+					TriggerIfNonNil: annotation.TriggerIfNonNil{
+						Ann: annotation.ParamKeyFromArgNum(info.FakeFuncObj, numParams+i),
+					}},
+				Expr:   closure.Ident,
+				Guards: util.NoGuards(),
+			})
+		}
+	}
+}
@@ -64,6 +64,10 @@ type FunctionContext struct {
 
 	// funcContracts stores the function contracts of all the functions.
 	funcContracts functioncontracts.Map
+
+	// nilStoringParams stores the parameters of the functions in the package that nil is directly
+	// assigned through.
+	nilStoringParams NilStoringParams
 }
 
 // FunctionConfig is meant to hold all the user set configuration for analyzing a function
@@ -72,6 +76,15 @@ type FunctionConfig struct {
 	StructInitCheckType config.StructInitCheckType
 	// EnableAnonymousFunc is a flag to enable checking anonymous functions
 	EnableAnonymousFunc bool
+	// RelaxedTestRules is a flag to analyze the function with the relaxed rules for test files
+	// (see config.TestFileModeRelaxed)
+	RelaxedTestRules bool
+	// UnmarshalNilable is a flag to consider the pointer fields populated by `json.Unmarshal` as
+	// nilable (see config.Config.UnmarshalNilable)
+	UnmarshalNilable bool
+	// ReflectNilable is a flag to consider the pointers asserted from the dynamic values of
+	// `reflect.Value`s as nilable (see config.Config.ReflectNilable)
+	ReflectNilable bool
 }
 
 // NewFunctionContext returns a new FunctionContext and initializes all the maps
@@ -83,6 +96,7 @@ func NewFunctionContext(
 	funcLitMap map[*ast.FuncLit]*anonymousfunc.FuncLitInfo,
 	pkgFakeIdentMap map[*ast.Ident]types.Object,
 	funcContracts functioncontracts.Map,
+	nilStoringParams NilStoringParams,
 ) FunctionContext {
 	return FunctionContext{
 		pass:                    pass,
@@ -94,6 +108,7 @@ func NewFunctionContext(
 		funcLitMap:              funcLitMap,
 		pkgFakeIdentMap:         pkgFakeIdentMap,
 		funcContracts:           funcContracts,
+		nilStoringParams:        nilStoringParams,
 	}
 }
 
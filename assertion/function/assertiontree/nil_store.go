@@ -0,0 +1,191 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// NilStoringParams maps the functions declared in a package to the (sorted) indices of their
+// pointer parameters that nil is directly assigned through (see CollectNilStoringParams).
+type NilStoringParams map[*types.Func][]int
+
+// CollectNilStoringParams returns the pointer parameters of the functions declared in the package
+// that nil is directly assigned through, e.g., `p` in
+//
+//	func reset(p **int) {
+//		*p = nil
+//	}
+//
+// To stay conservative, only the literal `nil` assigned through the parameter itself is considered,
+// and the parameters that are reassigned (or whose addresses are taken) are skipped since they may
+// no longer point to the arguments. The assignments in the function literals are skipped as well,
+// since the literals may never be called.
+func CollectNilStoringParams(pass *analysis.Pass) NilStoringParams {
+	params := make(NilStoringParams)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+			funcObj, ok := pass.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+			if !ok {
+				continue
+			}
+			if indices := nilStoringParamIndices(pass, funcDecl, funcObj); len(indices) > 0 {
+				params[funcObj] = indices
+			}
+		}
+	}
+	return params
+}
+
+// nilStoringParamIndices returns the sorted indices of the pointer parameters of the function that
+// nil is directly assigned through, see CollectNilStoringParams.
+func nilStoringParamIndices(pass *analysis.Pass, decl *ast.FuncDecl, funcObj *types.Func) []int {
+	sig := funcObj.Type().(*types.Signature)
+	paramIndex := func(expr ast.Expr) int {
+		ident, ok := astutil.Unparen(expr).(*ast.Ident)
+		if !ok {
+			return -1
+		}
+		obj := pass.TypesInfo.ObjectOf(ident)
+		for i := 0; i < sig.Params().Len(); i++ {
+			if obj != nil && sig.Params().At(i) == obj {
+				if _, ok := sig.Params().At(i).Type().Underlying().(*types.Pointer); ok {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	stores, escaped := make(map[int]bool), make(map[int]bool)
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.UnaryExpr:
+			if i := paramIndex(node.X); i >= 0 && node.Op == token.AND {
+				escaped[i] = true
+			}
+		case *ast.IncDecStmt:
+			if i := paramIndex(node.X); i >= 0 {
+				escaped[i] = true
+			}
+		case *ast.AssignStmt:
+			for j, lhs := range node.Lhs {
+				if i := paramIndex(lhs); i >= 0 {
+					escaped[i] = true
+					continue
+				}
+				star, ok := astutil.Unparen(lhs).(*ast.StarExpr)
+				if !ok || node.Tok != token.ASSIGN || len(node.Lhs) != len(node.Rhs) {
+					continue
+				}
+				if i := paramIndex(star.X); i >= 0 && util.IsLiteral(astutil.Unparen(node.Rhs[j]), "nil") {
+					stores[i] = true
+				}
+			}
+		}
+		return true
+	})
+	var indices []int
+	for i := 0; i < sig.Params().Len(); i++ {
+		if stores[i] && !escaped[i] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// nilStoredFieldTriggers returns the full triggers for the fields whose addresses are passed to the
+// parameters that nil is directly assigned through (see CollectNilStoringParams) in the function,
+// e.g., `t.p` in `reset(&t.p)`. Such fields may be left nil by the calls, hence they are modeled as
+// being assigned nilable values at the calls (see annotation.NilStoredThroughParam). If
+// skipFuncLits is set, the calls in the function literals are skipped since the literals are
+// analyzed separately.
+func nilStoredFieldTriggers(pass *analysis.Pass, decl *ast.FuncDecl, params NilStoringParams, skipFuncLits bool) []annotation.FullTrigger {
+	if decl.Body == nil || len(params) == 0 {
+		return nil
+	}
+
+	var triggers []annotation.FullTrigger
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FuncLit); ok && skipFuncLits {
+			return false
+		}
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		ident := util.FuncIdentFromCallExpr(call)
+		if ident == nil {
+			return true
+		}
+		funcObj, ok := pass.TypesInfo.ObjectOf(ident).(*types.Func)
+		if !ok {
+			return true
+		}
+		funcObj = funcObj.Origin()
+		sig := funcObj.Type().(*types.Signature)
+		for _, i := range params[funcObj] {
+			if i >= len(call.Args) {
+				continue
+			}
+			arg := astutil.Unparen(call.Args[i])
+			addr, ok := arg.(*ast.UnaryExpr)
+			if !ok || addr.Op != token.AND {
+				continue
+			}
+			sel, ok := astutil.Unparen(addr.X).(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			field, ok := pass.TypesInfo.ObjectOf(sel.Sel).(*types.Var)
+			if !ok || !field.IsField() {
+				continue
+			}
+			triggers = append(triggers, annotation.FullTrigger{
+				Producer: &annotation.ProduceTrigger{
+					Annotation: annotation.NilStoredThroughParam{
+						FuncName:  funcObj.Name(),
+						ParamName: sig.Params().At(i).Name(),
+					},
+					Expr: call,
+				},
+				Consumer: &annotation.ConsumeTrigger{
+					Annotation: annotation.FldAssign{
+						TriggerIfNonNil: annotation.TriggerIfNonNil{
+							Ann: annotation.FieldAnnotationKey{FieldDecl: field},
+						},
+					},
+					Expr:   arg,
+					Guards: util.NoGuards(),
+				},
+			})
+		}
+		return true
+	})
+	return triggers
+}
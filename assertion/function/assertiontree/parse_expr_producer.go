@@ -165,10 +165,10 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 					Annotation: rproducers[0].GetDeep().Annotation,
 					Expr:       expr,
 				},
-				// there is no possible source for a doubly deep nilability annotation except
-				// the named type of the expression
+				// the doubly deep nilability is either that of the named type of the expression,
+				// or that of the elements of the container site (see annotation.ElemAnnotationKey)
 				DeepProducer: &annotation.ProduceTrigger{
-					Annotation: annotation.DeepNilabilityAsNamedType(r.Pass().TypesInfo.Types[expr].Type),
+					Annotation: annotation.DeepNilabilityOfElem(rproducers[0].GetDeep().Annotation, r.Pass().TypesInfo.Types[expr].Type),
 					Expr:       expr,
 				},
 			}}
@@ -220,7 +220,13 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 			return nil, fldReadProduce()
 		}
 
-		if recv, _ := r.ParseExprAsProducer(expr.X, false); recv != nil {
+		// A promoted field is tracked along the path of its explicit form (e.g., `x.Base.f` for
+		// `x.f`), see explicitEmbeddedSelector.
+		recvExpr := expr.X
+		if explicit := r.explicitEmbeddedSelector(expr); explicit != nil {
+			recvExpr = explicit.X
+		}
+		if recv, _ := r.ParseExprAsProducer(recvExpr, false); recv != nil {
 			// trackable access to a field
 			return append(recv, &fldAssertionNode{decl: r.ObjectOf(expr.Sel).(*types.Var),
 				functionContext: r.functionContext}), nil
@@ -240,11 +246,26 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 		}
 
 		if ret, ok := AsTrustedFuncAction(expr, r.Pass()); ok {
-			if prod, ok := ret.(*annotation.ProduceTrigger); ok {
-				return nil, []producer.ParsedProducer{producer.ShallowParsedProducer{Producer: prod}}
+			switch ret := ret.(type) {
+			case *annotation.ProduceTrigger:
+				return nil, []producer.ParsedProducer{producer.ShallowParsedProducer{Producer: ret}}
+			case []producer.ParsedProducer:
+				return nil, ret
 			}
 		}
 
+		// The conversions between pointers and unsafe.Pointer (e.g., `(*T)(unsafe.Pointer(p))`)
+		// preserve the pointer value, hence the result is as nilable as the converted expression.
+		// Only the shallow nilability is preserved for the non-trackable expressions, since the
+		// deep nilability of the two pointer types is unrelated.
+		if arg := r.unsafePointerConversionArg(expr); arg != nil {
+			rec, producers := r.ParseExprAsProducer(arg, doNotTrack)
+			for i, p := range producers {
+				producers[i] = producer.ShallowParsedProducer{Producer: p.GetShallow()}
+			}
+			return rec, producers
+		}
+
 		// the cases of a function and method call are different enough here that it would be useless
 		// to try to subsume this switch with funcIdentFromCallExpr
 		switch fun := expr.Fun.(type) {
@@ -313,6 +334,14 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 			// function call has non-literal args, so is not literal, use its return annotation
 			return nil, r.getFuncReturnProducers(fun.Sel, expr)
 
+		case *ast.IndexExpr, *ast.IndexListExpr: // call to an explicitly instantiated generic function
+			if ident := util.FuncIdentFromCallExpr(expr); ident != nil && r.isFunc(ident) {
+				return nil, r.getFuncReturnProducers(ident, expr)
+			}
+			// this could be a call to a function stored in a slice or map, such as fs[0](3),
+			// which is left unhandled as in the default case below
+			return nil, nil
+
 		default:
 			// this could result from calling a function returned anonymously from another function, such as f(4)(3), and
 			// although theoretically we should track that, we're going to leave it as an unhandled edge case for now
@@ -400,6 +429,11 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 			}
 		}
 		return nil, nil
+	case *ast.TypeAssertExpr:
+		if p := r.reflectInterfaceProducer(expr); p != nil {
+			return nil, []producer.ParsedProducer{producer.ShallowParsedProducer{Producer: p}}
+		}
+		return nil, nil
 	}
 	// TODO: right now this default case assumes that unhandled expressions are non-nil, consider changing this
 	return nil, nil
@@ -36,6 +36,9 @@ func preprocess(graph *cfg.CFG, fc FunctionContext) (*cfg.CFG, [][]RichCheckEffe
 	// modify them directly. Here, we make a copy of the graph (and all blocks in it) and modify
 	// the copied graph instead.
 	graph = copyGraph(graph)
+	if fc.functionConfig.RelaxedTestRules {
+		cutBlocksOnTestTerminators(graph, fc.pass)
+	}
 	restructureBlocks(graph, fc.pass)
 	richCheckBlocks, exprNonceMap := genInitialRichCheckEffects(graph, fc)
 	richCheckBlocks = propagateRichChecks(graph, richCheckBlocks)
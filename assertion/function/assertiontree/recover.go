@@ -0,0 +1,198 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// recoveredReturnTriggers returns the full triggers for the named results of the function that
+// are assigned `nil` in the recovery blocks of its deferred function literals, e.g.,
+//
+//	func foo() (p *int, err error) {
+//		defer func() {
+//			if r := recover(); r != nil {
+//				p = nil
+//			}
+//		}()
+//		...
+//	}
+//
+// Such blocks are only reachable after a panic is recovered, in which case the function returns
+// the values of its named results as assigned in the blocks. Since the deferred function
+// literals are otherwise not analyzed along with the function, the assignments are modeled here
+// as returns of the named results at the assignments. Following the error contract, the
+// non-error results of error-returning functions are not constrained by the blocks that also
+// assign a non-nil value to the error result, which is the common pattern of converting the
+// recovered panics into errors. Note that only the literal `nil` values are handled, the other
+// values are optimistically assumed to be nonnil.
+func recoveredReturnTriggers(pass *analysis.Pass, decl *ast.FuncDecl) []annotation.FullTrigger {
+	if decl.Body == nil || decl.Type.Results == nil {
+		return nil
+	}
+	funcObj, ok := pass.TypesInfo.ObjectOf(decl.Name).(*types.Func)
+	if !ok {
+		return nil
+	}
+	results := funcObj.Type().(*types.Signature).Results()
+	resultIndex := func(expr ast.Expr) int {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return -1
+		}
+		obj := pass.TypesInfo.ObjectOf(ident)
+		for i := 0; i < results.Len(); i++ {
+			if obj != nil && results.At(i) == obj {
+				return i
+			}
+		}
+		return -1
+	}
+	errIndex := -1
+	if util.FuncIsErrReturning(funcObj) {
+		errIndex = results.Len() - 1
+	}
+
+	var triggers []annotation.FullTrigger
+	for _, block := range recoveryBlocks(pass, decl.Body) {
+		// First check if the block assigns a non-nil error, which makes the non-error results
+		// irrelevant by the error contract.
+		assignsErr := false
+		for _, stmt := range block.List {
+			if assign, ok := stmt.(*ast.AssignStmt); ok && len(assign.Lhs) == len(assign.Rhs) {
+				for i := range assign.Lhs {
+					if errIndex >= 0 && resultIndex(assign.Lhs[i]) == errIndex && !util.IsLiteral(assign.Rhs[i], "nil") {
+						assignsErr = true
+					}
+				}
+			}
+		}
+		if assignsErr {
+			continue
+		}
+
+		for _, stmt := range block.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) {
+				continue
+			}
+			for i := range assign.Lhs {
+				index := resultIndex(assign.Lhs[i])
+				if index < 0 || index == errIndex || !util.IsLiteral(assign.Rhs[i], "nil") {
+					continue
+				}
+				triggers = append(triggers, annotation.FullTrigger{
+					Producer: &annotation.ProduceTrigger{
+						Annotation: annotation.ConstNil{},
+						Expr:       assign.Rhs[i],
+					},
+					Consumer: &annotation.ConsumeTrigger{
+						Annotation: annotation.UseAsReturn{
+							TriggerIfNonNil: annotation.TriggerIfNonNil{
+								Ann: annotation.RetKeyFromRetNum(funcObj, index),
+							},
+							IsNamedReturn: true,
+							// The result is returned when the deferred function finishes, we
+							// point to the assignment instead since there is no return statement.
+							RetStmt: &ast.ReturnStmt{Return: assign.Pos()},
+						},
+						Expr:   assign.Lhs[i],
+						Guards: util.NoGuards(),
+					},
+				})
+			}
+		}
+	}
+	return triggers
+}
+
+// recoveryBlocks returns the bodies of the `if` statements in the deferred function literals
+// directly in the body that are only reachable after a panic is recovered, i.e., the ones whose
+// conditions are `recover() != nil`, or `r != nil` where `r` is assigned the result of `recover()`.
+func recoveryBlocks(pass *analysis.Pass, body *ast.BlockStmt) []*ast.BlockStmt {
+	isRecoverCall := func(expr ast.Expr) bool {
+		call, ok := astutil.Unparen(expr).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		ident, ok := astutil.Unparen(call.Fun).(*ast.Ident)
+		if !ok {
+			return false
+		}
+		_, ok = pass.TypesInfo.ObjectOf(ident).(*types.Builtin)
+		return ok && ident.Name == "recover"
+	}
+
+	var blocks []*ast.BlockStmt
+	for _, stmt := range body.List {
+		deferStmt, ok := stmt.(*ast.DeferStmt)
+		if !ok {
+			continue
+		}
+		funcLit, ok := deferStmt.Call.Fun.(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+
+		recovered := make(map[types.Object]bool)
+		ast.Inspect(funcLit.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncLit:
+				// The nested function literals are not deferred.
+				return false
+			case *ast.AssignStmt:
+				if len(node.Lhs) == 1 && len(node.Rhs) == 1 && isRecoverCall(node.Rhs[0]) {
+					if ident, ok := node.Lhs[0].(*ast.Ident); ok {
+						recovered[pass.TypesInfo.ObjectOf(ident)] = true
+					}
+				}
+			case *ast.IfStmt:
+				if node.Init != nil {
+					ast.Inspect(node.Init, func(n ast.Node) bool {
+						if assign, ok := n.(*ast.AssignStmt); ok && len(assign.Lhs) == 1 && len(assign.Rhs) == 1 && isRecoverCall(assign.Rhs[0]) {
+							if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+								recovered[pass.TypesInfo.ObjectOf(ident)] = true
+							}
+						}
+						return true
+					})
+				}
+				cond, ok := node.Cond.(*ast.BinaryExpr)
+				if !ok || cond.Op != token.NEQ {
+					return true
+				}
+				for _, pair := range [...][2]ast.Expr{{cond.X, cond.Y}, {cond.Y, cond.X}} {
+					if !util.IsLiteral(pair[1], "nil") {
+						continue
+					}
+					if isRecoverCall(pair[0]) {
+						blocks = append(blocks, node.Body)
+					} else if ident, ok := pair[0].(*ast.Ident); ok && recovered[pass.TypesInfo.ObjectOf(ident)] {
+						blocks = append(blocks, node.Body)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return blocks
+}
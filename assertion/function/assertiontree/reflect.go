@@ -0,0 +1,56 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"go/types"
+
+	"go.uber.org/nilaway/annotation"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// _reflectInterfaceFunc is the full name of `reflect.Value.Interface`, which returns the dynamic
+// value of a `reflect.Value` as an interface.
+const _reflectInterfaceFunc = "(reflect.Value).Interface"
+
+// reflectInterfaceProducer returns the producer for the type assertion if it asserts the result of
+// `reflect.Value.Interface` to a pointer type (e.g., `reflect.ValueOf(p).Interface().(*T)`) and the
+// pointers asserted from reflection are configured to be nilable (see ReflectInterface), and nil
+// otherwise.
+func (r *RootAssertionNode) reflectInterfaceProducer(expr *ast.TypeAssertExpr) *annotation.ProduceTrigger {
+	if !r.functionContext.functionConfig.ReflectNilable || expr.Type == nil {
+		return nil
+	}
+	typ := r.Pass().TypesInfo.TypeOf(expr.Type)
+	if _, ok := typ.Underlying().(*types.Pointer); !ok {
+		return nil
+	}
+	call, ok := astutil.Unparen(expr.X).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if fn, ok := r.ObjectOf(sel.Sel).(*types.Func); !ok || fn.FullName() != _reflectInterfaceFunc {
+		return nil
+	}
+	return &annotation.ProduceTrigger{
+		Annotation: annotation.ReflectInterface{TypeName: types.TypeString(typ, types.RelativeTo(r.Pass().Pkg))},
+		Expr:       expr,
+	}
+}
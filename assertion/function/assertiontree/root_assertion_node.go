@@ -25,6 +25,7 @@ import (
 	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 // RootAssertionNode is the object that will be directly handled by the propagation algorithm,
@@ -516,11 +517,11 @@ func (r *RootAssertionNode) AddGuardMatch(expr ast.Expr, behavior GuardMatchBeha
 	currNode.SetConsumeTriggers(consumers)
 }
 
-func (r *RootAssertionNode) consumeIndexExpr(expr ast.Expr) {
+func (r *RootAssertionNode) consumeIndexExpr(expr ast.Expr, lbrack token.Pos) {
 	t := r.Pass().TypesInfo.Types[expr].Type
 	if util.TypeIsDeeplySlice(t) {
 		r.AddConsumption(&annotation.ConsumeTrigger{
-			Annotation: annotation.SliceAccess{},
+			Annotation: annotation.SliceAccess{DerefPos: lbrack},
 			Expr:       expr,
 			Guards:     util.NoGuards(),
 		})
@@ -530,7 +531,7 @@ func (r *RootAssertionNode) consumeIndexExpr(expr ast.Expr) {
 	// encodes this optionality and is currently set to false
 	if config.ErrorOnNilableMapRead && util.TypeIsDeeplyMap(t) {
 		r.AddConsumption(&annotation.ConsumeTrigger{
-			Annotation: annotation.MapAccess{},
+			Annotation: annotation.MapAccess{DerefPos: lbrack},
 			Expr:       expr,
 			Guards:     util.NoGuards(),
 		})
@@ -568,7 +569,43 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 
 		r.AddComputation(expr.X)
 	case *ast.CallExpr:
+		// `errors.As(err, &target)` assigns to target, which is nilable unless the call is checked
+		// to return true (see ErrorsAsCheck).
+		if target := errorsAsTarget(r.Pass(), expr); target != nil {
+			r.AddGuardMatch(target, ContinueTracking)
+			r.AddProduction(&annotation.ProduceTrigger{
+				Annotation: annotation.ErrorsAsTarget{NeedsGuard: true},
+				Expr:       target,
+			})
+		}
+
+		// Calling a nil function value panics, so the function value must be nonnil.
+		if r.isFuncValue(expr.Fun) {
+			r.AddConsumption(&annotation.ConsumeTrigger{
+				Annotation: annotation.FuncValueCall{DerefPos: expr.Lparen},
+				Expr:       expr.Fun,
+				Guards:     util.NoGuards(),
+			})
+		}
 		r.AddComputation(expr.Fun)
+
+		// For a call of a method expression (e.g., `(*T).foo(s)`), the first argument is the
+		// receiver (and is excluded from the arguments passed to the params, see
+		// funcArgsFromCallExpr), which is consumed the same way as `s` in `s.foo()`.
+		if sel, ok := expr.Fun.(*ast.SelectorExpr); ok && r.isType(sel.X) && len(expr.Args) > 0 {
+			if funcObj, ok := r.ObjectOf(sel.Sel).(*types.Func); ok {
+				recv := expr.Args[0]
+				if !r.consumeRecv(funcObj, recv, util.TypeOf(r.Pass(), sel.X)) {
+					r.AddConsumption(&annotation.ConsumeTrigger{
+						Annotation: annotation.FldAccess{Sel: funcObj, DerefPos: sel.Sel.Pos()},
+						Expr:       recv,
+						Guards:     util.NoGuards(),
+					})
+				}
+				r.AddComputation(recv)
+			}
+		}
+
 		exprArgs := r.funcArgsFromCallExpr(expr)
 		var consumeArg func(int, ast.Expr)
 		consumeArgNoop := func(int, ast.Expr) {}
@@ -637,43 +674,60 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 					}
 				}
 			}
-			return func(i int, arg ast.Expr) {
-				if expr.Ellipsis != token.NoPos && i == len(expr.Args)-1 {
-					// this is an unpacking of a variadic argument: i.e. the call `foo(_, _, a...)`
-					r.AddNewTriggers(annotation.FullTrigger{
-						Producer: &annotation.ProduceTrigger{
-							Annotation: exprAsDeepProducer(r, arg),
-							Expr:       arg,
-						},
-						Consumer: &annotation.ConsumeTrigger{
-							Annotation: annotation.ArgPass{
-								TriggerIfNonNil: annotation.TriggerIfNonNil{
-									Ann: annotation.ParamKeyFromArgNum(fdecl, i),
-								}},
-							Expr:   arg,
-							Guards: util.NoGuards(),
-						},
-					})
+			// consumeParam adds a consumption of the argument by the i-th parameter of `fdecl`.
+			consumeParam := func(i int, arg ast.Expr) {
+				var paramKey annotation.Key
+				if r.HasContract(fdecl) {
+					// Creates a new param site with location information at every call site
+					// for a function with contracts. The param site is unique at every call
+					// site, even with the same function called.
+					paramKey = annotation.NewCallSiteParamKey(fdecl, i, r.LocationOf(arg))
 				} else {
-					var paramKey annotation.Key
-					if r.HasContract(fdecl) {
-						// Creates a new param site with location information at every call site
-						// for a function with contracts. The param site is unique at every call
-						// site, even with the same function called.
-						paramKey = annotation.NewCallSiteParamKey(fdecl, i, r.LocationOf(arg))
-					} else {
-						paramKey = annotation.ParamKeyFromArgNum(fdecl, i)
+					paramKey = annotation.ParamKeyFromArgNum(fdecl, i)
+				}
+				consumer := annotation.ConsumeTrigger{
+					Annotation: annotation.ArgPass{
+						TriggerIfNonNil: annotation.TriggerIfNonNil{
+							Ann: paramKey,
+						}},
+					Expr:   arg,
+					Guards: util.NoGuards(),
+				}
+				r.AddConsumption(&consumer)
+			}
+			return func(i int, arg ast.Expr) {
+				if expr.Ellipsis == token.NoPos || i != len(expr.Args)-1 {
+					consumeParam(i, arg)
+					return
+				}
+
+				// this is an unpacking of a variadic argument: i.e. the call `foo(_, _, a...)`. The
+				// site of a variadic parameter stands for its elements, so the elements of a slice
+				// literal are consumed one by one, i.e., `foo(_, []*T{b, c}...)` is treated as
+				// `foo(_, b, c)`, since the deep nilability of literals is not tracked otherwise.
+				if lit, ok := astutil.Unparen(arg).(*ast.CompositeLit); ok {
+					for _, elt := range lit.Elts {
+						if kv, ok := elt.(*ast.KeyValueExpr); ok {
+							elt = kv.Value
+						}
+						consumeParam(i, elt)
 					}
-					consumer := annotation.ConsumeTrigger{
+					return
+				}
+				r.AddNewTriggers(annotation.FullTrigger{
+					Producer: &annotation.ProduceTrigger{
+						Annotation: exprAsDeepProducer(r, arg),
+						Expr:       arg,
+					},
+					Consumer: &annotation.ConsumeTrigger{
 						Annotation: annotation.ArgPass{
 							TriggerIfNonNil: annotation.TriggerIfNonNil{
-								Ann: paramKey,
+								Ann: annotation.ParamKeyFromArgNum(fdecl, i),
 							}},
 						Expr:   arg,
 						Guards: util.NoGuards(),
-					}
-					r.AddConsumption(&consumer)
-				}
+					},
+				})
 			}
 		}
 
@@ -709,7 +763,7 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 			r.AddComputation(elt)
 		}
 	case *ast.IndexExpr:
-		r.consumeIndexExpr(expr.X)
+		r.consumeIndexExpr(expr.X, expr.Lbrack)
 		r.AddComputation(expr.X)
 		r.AddComputation(expr.Index)
 	case *ast.KeyValueExpr:
@@ -725,52 +779,52 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 			}
 		}
 
+		// For a promoted field (e.g., `x.f` for `x.Base.f`), we add the computation of the explicit
+		// selector instead, such that the implicit dereferences of the embedded pointers are
+		// consumed as well.
+		if explicit := r.explicitEmbeddedSelector(expr); explicit != nil {
+			r.AddComputation(explicit)
+			return
+		}
+
 		// A selector expression (`X.Sel`, where X is an expression and Sel is a selector) can be handled in the following two ways:
 		// - (1) Allow the expression X to be nilable by creating a TriggerIfNonNil consumer for it. This is a special case,
-		//       with so far the only known case being of method invocations for supporting nilable receivers. Our support
+		//       with so far the only known case being of method invocations for supporting nilable receivers, since
+		//       calling a pointer-receiver method on a nil receiver is legal unless the method dereferences the receiver
+		//       without a nil guard (which is decided by the nilability of the receiver site of the method). Our support
 		//       is currently limited to enabling this analysis only if the below criteria is satisfied.
 		//       - Check 1: selector expression is a method invocation (e.g., `s.foo()`)
+		//       - Check 2: the invoking expression is not a pointer, or the invoked method has a pointer receiver.
+		//         Otherwise, the pointer is implicitly dereferenced to call the value-receiver method, which panics
+		//         regardless of the method body.
 		//       - In-scope flow:
-		//       	- Check 2: the invoked method is in scope
-		//       	- Check 3: the invoking expression (caller) is of struct type. (We are restricting support only for structs
-		//            due to the challenges of secret nil for interfaces.)
+		//       	- Check 3: the invoked method is in scope
+		//       	- Check 4: the invoking expression (caller) is not of interface type. (We are restricting support only
+		//            for concrete types due to the challenges of secret nil for interfaces.) A pointer invoking
+		//            expression creates a RecvPass consumer, and a non-pointer one (e.g., a struct or a slice) does
+		//            not need to be nonnil at all since the method is invoked on a copy of it or on its address.
 		//       - Out-of-scope flow:
-		//          - Check 4: consider the criteria satisfied to support optimistic default
+		//          - Check 5: consider the criteria satisfied to support optimistic default
 		//
 		// - (2) Don't allow the expression X to be nilable by creating a FldAccess (ConsumeTriggerTautology) consumer for it.
 		//       This is default behavior which gets triggered if the above special case is not satisfied.
+		//
+		// Note that the same applies to method values (e.g., `f := s.foo`), where the receiver is
+		// evaluated and captured when the method value is created, while method expressions (e.g.,
+		// `(*T).foo`) evaluate nothing: their receivers are passed as the first arguments when they
+		// are called (see the case of *ast.CallExpr).
+		if r.isType(expr.X) {
+			return
+		}
 
 		allowNilable := false
-		if funcObj, ok := r.ObjectOf(expr.Sel).(*types.Func); ok { // Check 1:  selector expression is a method invocation
-			conf := r.Pass().ResultOf[config.Analyzer].(*config.Config)
-			if conf.IsPkgInScope(funcObj.Pkg()) { // Check 2: invoked method is in scope
-				t := util.TypeOf(r.Pass(), expr.X)
-				// Here, `t` can only be of type struct or interface, of which we only support for structs.
-				if util.TypeAsDeeplyStruct(t) != nil { // Check 3: invoking expression (caller) is of struct type
-					allowNilable = true
-					// We are in the special case of supporting nilable receivers! Can be nilable depending on declaration annotation/inferred nilability.
-					r.AddConsumption(&annotation.ConsumeTrigger{
-						Annotation: annotation.RecvPass{
-							TriggerIfNonNil: annotation.TriggerIfNonNil{
-								Ann: annotation.RecvAnnotationKey{
-									FuncDecl: funcObj,
-								},
-							}},
-						Expr:   expr.X,
-						Guards: util.NoGuards(),
-					})
-				}
-			} else { // Check 4: invoked method is out of scope
-				// We are setting an optimistic default here for methods out of scope, specifically to avoid
-				// false positives being reported for methods in generated code. It means that such external
-				// methods are assumed to be safely handling nil receivers
-				allowNilable = true
-			}
+		if funcObj, ok := r.ObjectOf(expr.Sel).(*types.Func); ok && funcObj.Type().(*types.Signature).Recv() != nil { // Check 1:  selector expression is a method invocation
+			allowNilable = r.consumeRecv(funcObj, expr.X, util.TypeOf(r.Pass(), expr.X))
 		}
 		if !allowNilable {
 			// We are in the default case -- it's a field/method access! Must be non-nil.
 			r.AddConsumption(&annotation.ConsumeTrigger{
-				Annotation: annotation.FldAccess{Sel: r.ObjectOf(expr.Sel)},
+				Annotation: annotation.FldAccess{Sel: r.ObjectOf(expr.Sel), DerefPos: expr.Sel.Pos()},
 				Expr:       expr.X,
 				Guards:     util.NoGuards(),
 			})
@@ -785,7 +839,7 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 			// For all the other slicing, the slice must be nonnil, so we create a consumer
 			// trigger.
 			r.AddConsumption(&annotation.ConsumeTrigger{
-				Annotation: annotation.SliceAccess{},
+				Annotation: annotation.SliceAccess{DerefPos: expr.Lbrack},
 				Expr:       expr.X,
 				Guards:     util.NoGuards(),
 			})
@@ -798,7 +852,7 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 	case *ast.StarExpr:
 		// pointer load! definitely must be non-nil
 		r.AddConsumption(&annotation.ConsumeTrigger{
-			Annotation: annotation.PtrLoad{},
+			Annotation: annotation.PtrLoad{DerefPos: expr.Star},
 			Expr:       expr.X,
 			Guards:     util.NoGuards(),
 		})
@@ -806,16 +860,31 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 	case *ast.TypeAssertExpr:
 		// doesn't need to be non-nil, but really should be
 		r.AddComputation(expr.X)
+		// the single-result form panics if the operand is nil, which is likely for the values read
+		// from contexts (e.g., `ctx.Value(k).(*T)`), since the key may be absent from the context.
+		// The `v, ok := ctx.Value(k).(*T)` form instead produces a guarded `v`, see backprop.
+		if call, ok := astutil.Unparen(expr.X).(*ast.CallExpr); ok && expr.Type != nil && isContextValueCall(call, r.Pass()) {
+			r.AddConsumption(&annotation.ConsumeTrigger{
+				Annotation: annotation.TypeAssertOperand{DerefPos: expr.Lparen},
+				Expr:       expr.X,
+				Guards:     util.NoGuards(),
+			})
+		}
 	case *ast.UnaryExpr:
 		// channel receive case
 		if expr.Op == token.ARROW {
 			// added this consumer since receiving over a nil channel can cause panic
 			r.AddConsumption(&annotation.ConsumeTrigger{
-				Annotation: annotation.ChanAccess{},
+				Annotation: annotation.ChanAccess{DerefPos: expr.OpPos},
 				Expr:       expr.X,
 				Guards:     util.NoGuards(),
 			})
 		}
+		// Note that no consumer is added for ranging (i.e., the `range x` expressions inserted by
+		// markRangeStatements), since ranging over nil slices and maps is safe. Similarly, the
+		// builtins `len`, `cap`, `append` and `copy` accept nil slices, and only indexing and
+		// non-zero slicing consume them as nonnil (see consumeIndexExpr and the *ast.SliceExpr
+		// case above).
 		r.AddComputation(expr.X)
 	case *ast.FuncLit:
 		// TODO: analyze the bodies of anonymous functions
@@ -825,6 +894,42 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 	}
 }
 
+// consumeRecv adds the consumption of the receiver `recv` of type t used to invoke the method
+// funcObj if the receiver is allowed to be nilable (see Check 2-5 in the case of *ast.SelectorExpr
+// in AddComputation), and returns whether it is allowed. Otherwise, the caller is responsible for
+// consuming the receiver as a nonnil one.
+func (r *RootAssertionNode) consumeRecv(funcObj *types.Func, recv ast.Expr, t types.Type) bool {
+	_, isPtr := t.Underlying().(*types.Pointer)
+	_, isPtrRecv := funcObj.Type().(*types.Signature).Recv().Type().(*types.Pointer)
+	conf := r.Pass().ResultOf[config.Analyzer].(*config.Config)
+	switch {
+	case isPtr && !isPtrRecv: // Check 2: value-receiver method is invoked via a pointer
+		return false
+	case !conf.IsPkgInScope(funcObj.Pkg()): // Check 5: invoked method is out of scope
+		// We are setting an optimistic default here for methods out of scope, specifically to avoid
+		// false positives being reported for methods in generated code. It means that such external
+		// methods are assumed to be safely handling nil receivers
+		return true
+	case types.IsInterface(t): // Check 4: invoking expression (caller) is of interface type
+		return false
+	case isPtr:
+		// We are in the special case of supporting nilable receivers! Can be nilable depending on declaration annotation/inferred nilability.
+		r.AddConsumption(&annotation.ConsumeTrigger{
+			Annotation: annotation.RecvPass{
+				TriggerIfNonNil: annotation.TriggerIfNonNil{
+					Ann: annotation.RecvAnnotationKey{
+						FuncDecl: funcObj,
+					},
+				}},
+			Expr:   recv,
+			Guards: util.NoGuards(),
+		})
+		return true
+	default:
+		return true
+	}
+}
+
 // getFuncIdent returns the function identified from a call expression. If the function
 // is an anonymous function, it will return the fake function declaration created in the
 // function analyzer
@@ -946,10 +1051,19 @@ func (r *RootAssertionNode) ProcessEntry() {
 			r.addProductionsForParamFields(child, builtExpr)
 		}
 
+		// the elements of a local array that is never assigned are all zero values, i.e., nil
+		var deeperProducers []*annotation.ProduceTrigger
+		if v, ok := child.(*varAssertionNode); ok && isLocalArrayOfNilable(r.FuncObj(), v.decl) {
+			deeperProducers = append(deeperProducers, &annotation.ProduceTrigger{
+				Annotation: annotation.UnassignedArrayElem{VarObj: v.decl},
+				Expr:       builtExpr,
+			})
+		}
+
 		r.AddProduction(&annotation.ProduceTrigger{
 			Annotation: child.DefaultTrigger(),
 			Expr:       builtExpr,
-		})
+		}, deeperProducers...)
 	}
 
 	// filter triggers for error return handling -- intra-procedural
@@ -1099,6 +1213,48 @@ func (r *RootAssertionNode) isFunc(ident *ast.Ident) bool {
 	return ok
 }
 
+// isFuncValue returns true iff the callee of a call is a function value that can be nil, i.e.,
+// neither a declared function or method (possibly instantiated), a builtin, a type conversion nor
+// a function literal
+func (r *RootAssertionNode) isFuncValue(fun ast.Expr) bool {
+	fun = astutil.Unparen(fun)
+	if tv, ok := r.Pass().TypesInfo.Types[fun]; ok && (tv.IsType() || tv.IsBuiltin()) {
+		return false
+	}
+	isDeclaredFunc := func(expr ast.Expr) bool {
+		switch expr := astutil.Unparen(expr).(type) {
+		case *ast.Ident:
+			return r.isFunc(expr)
+		case *ast.SelectorExpr:
+			return r.isFunc(expr.Sel)
+		}
+		return false
+	}
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		_, ok := r.ObjectOf(fun).(*types.Var)
+		return ok
+	case *ast.SelectorExpr:
+		if sel, ok := r.Pass().TypesInfo.Selections[fun]; ok {
+			// method values are never nil, even if the receiver is (which is checked separately)
+			return sel.Kind() == types.FieldVal
+		}
+		// this is a qualified identifier, e.g., `pkg.Var`
+		_, ok := r.ObjectOf(fun.Sel).(*types.Var)
+		return ok
+	case *ast.IndexExpr:
+		// this is either an instantiation of a generic function, e.g., `f[int]`, or an element
+		// read from a container of function values, e.g., `handlers[i]`
+		return !isDeclaredFunc(fun.X)
+	case *ast.IndexListExpr:
+		return !isDeclaredFunc(fun.X)
+	case *ast.FuncLit:
+		return false
+	}
+	// all other function values (e.g., the results of calls) can be nil
+	return true
+}
+
 // checks if this is a package name
 func (r *RootAssertionNode) isPkgName(expr ast.Expr) bool {
 	if ident, ok := expr.(*ast.Ident); ok {
@@ -1122,6 +1278,57 @@ func (r *RootAssertionNode) isType(expr ast.Expr) bool {
 	return r.Pass().TypesInfo.Types[expr].IsType()
 }
 
+// unsafePointerConversionArg returns the converted expression if the call is a conversion from a
+// pointer to unsafe.Pointer or vice versa (including the conversions between unsafe.Pointer
+// values), and nil otherwise. The conversions involving uintptr are not considered since the
+// pointer arithmetic on them does not preserve the nilability.
+func (r *RootAssertionNode) unsafePointerConversionArg(expr *ast.CallExpr) ast.Expr {
+	if len(expr.Args) != 1 || !r.isType(astutil.Unparen(expr.Fun)) {
+		return nil
+	}
+	isUnsafePointer := func(t types.Type) bool {
+		basic, ok := t.Underlying().(*types.Basic)
+		return ok && basic.Kind() == types.UnsafePointer
+	}
+	isPointer := func(t types.Type) bool {
+		_, ok := t.Underlying().(*types.Pointer)
+		return ok || isUnsafePointer(t)
+	}
+	to, from := r.Pass().TypesInfo.TypeOf(expr), r.Pass().TypesInfo.TypeOf(expr.Args[0])
+	if to == nil || from == nil || !isPointer(to) || !isPointer(from) {
+		return nil
+	}
+	if !isUnsafePointer(to) && !isUnsafePointer(from) {
+		return nil
+	}
+	return expr.Args[0]
+}
+
+// explicitEmbeddedSelector returns the explicit form of the selector expression if it selects a
+// field promoted through (possibly multiple levels of) embedded structs, e.g., `x.Base.Inner.f`
+// for `x.f`, and nil otherwise. The intermediate selectors are artificial (see getSelectorExpr)
+// while the final one reuses the original `Sel`, such that the implicit dereferences of the
+// embedded pointers are consumed, and the promoted selectors are tracked along the same paths as
+// their explicit forms (e.g., `x.Inner` in `if x.Inner != nil { x.f }`).
+func (r *RootAssertionNode) explicitEmbeddedSelector(expr *ast.SelectorExpr) *ast.SelectorExpr {
+	selection, ok := r.Pass().TypesInfo.Selections[expr]
+	if !ok || selection.Kind() != types.FieldVal || len(selection.Index()) < 2 {
+		return nil
+	}
+
+	// Walk the embedding chain, i.e., all but the last index, which is the promoted field itself.
+	fieldOf, typ := expr.X, selection.Recv()
+	for _, index := range selection.Index()[:len(selection.Index())-1] {
+		structType := util.TypeAsDeeplyStruct(typ)
+		if structType == nil {
+			return nil
+		}
+		embedded := structType.Field(index)
+		fieldOf, typ = r.getSelectorExpr(embedded, fieldOf), embedded.Type()
+	}
+	return &ast.SelectorExpr{X: fieldOf, Sel: expr.Sel}
+}
+
 // isZeroSlicing returns if the given slice expression is a special case that will not cause panic
 // even when the slice itself is nil, i.e, one of [:0] [0:0] [0:] [:] [0:0:0]
 func (r *RootAssertionNode) isZeroSlicing(expr *ast.SliceExpr) bool {
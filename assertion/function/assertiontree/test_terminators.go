@@ -0,0 +1,97 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/cfg"
+)
+
+// testTerminators is the list of signatures of the calls that stop a test early (e.g., `t.Fatal`
+// and `require.FailNow`), which are treated as if they never return in the test files analyzed
+// with relaxed rules (see config.TestFileModeRelaxed). Calls made directly on `*testing.T` are
+// mostly known to never return by the CFG already, but the ones made via interfaces (e.g.,
+// `testing.TB` or testify's `TestingT`) are not. New patterns can simply be appended here.
+var testTerminators = []trustedFuncSig{
+	// `testing.T`, `testing.B`, `testing.F` and `testing.TB`, where the methods of the former
+	// are promoted from the unexported `testing.common`.
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`^testing\.(common|T|B|F|TB)$`),
+		funcNameRegex:  regexp.MustCompile(`^(Fatal(f)?|FailNow|Skip(f|Now)?)$`),
+	},
+	// testify's `require` and `assert` packages (the latter only for the ones stopping the test).
+	{
+		kind:           _func,
+		enclosingRegex: regexp.MustCompile(`github\.com/stretchr/testify/require$`),
+		funcNameRegex:  regexp.MustCompile(`^(Fail(f)?|FailNow(f)?)$`),
+	},
+	{
+		kind:           _func,
+		enclosingRegex: regexp.MustCompile(`github\.com/stretchr/testify/assert$`),
+		funcNameRegex:  regexp.MustCompile(`^FailNow(f)?$`),
+	},
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`github\.com/stretchr/testify/(require\.Assertions|require\.TestingT)$`),
+		funcNameRegex:  regexp.MustCompile(`^(Fail(f)?|FailNow(f)?)$`),
+	},
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`github\.com/stretchr/testify/(suite\.Suite|assert\.Assertions)$`),
+		funcNameRegex:  regexp.MustCompile(`^FailNow(f)?$`),
+	},
+}
+
+// isTestTerminator returns true iff the node is a call statement that stops the test early (see
+// testTerminators).
+func isTestTerminator(node ast.Node, pass *analysis.Pass) bool {
+	expr, ok := node.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	for i := range testTerminators {
+		if testTerminators[i].match(call, pass) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutBlocksOnTestTerminators cuts the blocks of the CFG right after the calls that stop the test
+// early (see testTerminators), such that the blocks end there without any successors, similar to
+// the calls to `panic`. The dereferences that are only reachable via such calls are hence
+// unreachable, e.g., `*x` after `if x == nil { t.Fatal() }`.
+func cutBlocksOnTestTerminators(graph *cfg.CFG, pass *analysis.Pass) {
+	for _, block := range graph.Blocks {
+		if !block.Live {
+			continue
+		}
+		for i, node := range block.Nodes {
+			if isTestTerminator(node, pass) {
+				block.Nodes = block.Nodes[:i+1]
+				block.Succs = nil
+				break
+			}
+		}
+	}
+}
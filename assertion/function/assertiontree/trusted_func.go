@@ -22,6 +22,7 @@ import (
 	"regexp"
 
 	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/assertion/function/producer"
 	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
 )
@@ -152,6 +153,65 @@ var nonnilProducer action = func(call *ast.CallExpr, argIndex int, _ *analysis.P
 	}
 }
 
+// contextValueProducer returns the producer for `ctx.Value(k)` of a `context.Context`, which is
+// nil if the key is absent from the context (see ContextValue).
+var contextValueProducer action = func(call *ast.CallExpr, _ int, _ *analysis.Pass) any {
+	return &annotation.ProduceTrigger{
+		Annotation: annotation.ContextValue{},
+		Expr:       call,
+	}
+}
+
+// isContextValueCall returns true if the call is `ctx.Value(k)` of a `context.Context` (see
+// contextValueProducer).
+func isContextValueCall(call *ast.CallExpr, p *analysis.Pass) bool {
+	ret, ok := AsTrustedFuncAction(call, p)
+	if !ok {
+		return false
+	}
+	trigger, ok := ret.(*annotation.ProduceTrigger)
+	if !ok {
+		return false
+	}
+	_, ok = trigger.Annotation.(annotation.ContextValue)
+	return ok
+}
+
+// okFuncProducers returns the producers for the two results of a library function with `(value, ok)`
+// semantics, e.g., `v, ok := m.Load(k)` for a `sync.Map`, where `v` is nil if `ok` is false. Hence,
+// `v` is produced as nilable unless it is guarded by a check on `ok` (see FuncOkRead).
+var okFuncProducers action = func(call *ast.CallExpr, _ int, p *analysis.Pass) any {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	funcObj, ok := p.TypesInfo.ObjectOf(sel.Sel).(*types.Func)
+	if !ok || funcObj.Type().(*types.Signature).Results().Len() != 2 {
+		return nil
+	}
+	return []producer.ParsedProducer{
+		producer.ShallowParsedProducer{Producer: &annotation.ProduceTrigger{
+			Annotation: annotation.OkFuncReturn{FuncName: funcObj.FullName(), NeedsGuard: true},
+			Expr:       call,
+		}},
+		producer.ShallowParsedProducer{Producer: &annotation.ProduceTrigger{
+			Annotation: annotation.ProduceTriggerNever{},
+			Expr:       call,
+		}},
+	}
+}
+
+// isOkFuncCall returns true if the call is to one of the trusted library functions with
+// `(value, ok)` semantics (see okFuncProducers).
+func isOkFuncCall(call *ast.CallExpr, p *analysis.Pass) bool {
+	ret, ok := AsTrustedFuncAction(call, p)
+	if !ok {
+		return false
+	}
+	_, ok = ret.([]producer.ParsedProducer)
+	return ok
+}
+
 func newNilBinaryExpr(arg ast.Expr, op token.Token) *ast.BinaryExpr {
 	return &ast.BinaryExpr{
 		X:     arg,
@@ -469,6 +529,38 @@ var trustedFuncs = map[trustedFuncSig]trustedFuncAction{
 		enclosingRegex: regexp.MustCompile(`github\.com/stretchr/testify/(suite\.Suite|assert\.Assertions|require\.Assertions)$`),
 		funcNameRegex:  regexp.MustCompile(`^(Empty(f)?|NotEmpty(f)?)$`),
 	}: {action: requireZeroComparators, argIndex: 0},
+
+	// `context.Context.Value`
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`^context\.Context$`),
+		funcNameRegex:  regexp.MustCompile(`^Value$`),
+	}: {action: contextValueProducer, argIndex: -1},
+}
+
+// okFuncs lists the library functions with `(value, ok)` semantics, where the value is nil if
+// `ok` is false. Map reads and channel receives in the `ok` form follow the same semantics, but
+// they are language constructs and hence handled directly in backpropAcrossAssignment. New
+// entries only need to be added here.
+var okFuncs = []trustedFuncSig{
+	// `sync.Map`
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`^sync\.Map$`),
+		funcNameRegex:  regexp.MustCompile(`^(Load|LoadAndDelete)$`),
+	},
+	// `math/big`, where `SetString` returns nil if the string cannot be parsed
+	{
+		kind:           _method,
+		enclosingRegex: regexp.MustCompile(`^math/big\.(Int|Rat|Float)$`),
+		funcNameRegex:  regexp.MustCompile(`^SetString$`),
+	},
+}
+
+func init() {
+	for _, f := range okFuncs {
+		trustedFuncs[f] = trustedFuncAction{action: okFuncProducers, argIndex: -1}
+	}
 }
 
 // BuiltinAppend is used to check the builtin append method for slice
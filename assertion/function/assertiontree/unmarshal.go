@@ -0,0 +1,150 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertiontree
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// _unmarshalFuncs maps the unmarshal functions (in the form of "pkgpath.Func" or
+// "pkgpath.(*Recv).Method", see config.FuncName) to the index of the argument they populate.
+var _unmarshalFuncs = map[string]int{
+	"encoding/json.Unmarshal":         1,
+	"encoding/json.(*Decoder).Decode": 0,
+}
+
+// unmarshalledFieldTriggers returns the full triggers for the pointer fields of the structs
+// populated by the calls to the unmarshal functions (see _unmarshalFuncs) in the function, e.g.,
+//
+//	var v T
+//	if err := json.Unmarshal(data, &v); err != nil { ... }
+//	print(*v.P) // v.P is nil if the key is absent from data
+//
+// Such fields are left nil for the keys absent from the input, hence they are modeled as being
+// assigned nilable values at the calls (see annotation.UnmarshalledField). The fields of the nested
+// structs (embedded or pointed to) are populated as well. Note that only the exported fields that
+// are not skipped by the `json:"-"` tag are considered, since the others are never populated.
+// If skipFuncLits is set, the calls in the function literals are skipped since the literals are
+// analyzed separately.
+func unmarshalledFieldTriggers(pass *analysis.Pass, decl *ast.FuncDecl, skipFuncLits bool) []annotation.FullTrigger {
+	if decl.Body == nil {
+		return nil
+	}
+
+	var triggers []annotation.FullTrigger
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FuncLit); ok && skipFuncLits {
+			return false
+		}
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		ident := util.FuncIdentFromCallExpr(call)
+		if ident == nil {
+			return true
+		}
+		funcObj, ok := pass.TypesInfo.ObjectOf(ident).(*types.Func)
+		if !ok {
+			return true
+		}
+		index, ok := _unmarshalFuncs[config.FuncName(funcObj)]
+		if !ok || index >= len(call.Args) {
+			return true
+		}
+
+		// The function is printed as, e.g., "json.Unmarshal" or "json.Decoder.Decode".
+		funcName := funcObj.Pkg().Name() + "." + funcObj.Name()
+		if recv := funcObj.Type().(*types.Signature).Recv(); recv != nil {
+			if named, ok := util.UnwrapPtr(recv.Type()).(*types.Named); ok {
+				funcName = funcObj.Pkg().Name() + "." + named.Obj().Name() + "." + funcObj.Name()
+			}
+		}
+		arg := astutil.Unparen(call.Args[index])
+		for _, field := range unmarshalledFields(pass.TypesInfo.TypeOf(arg)) {
+			triggers = append(triggers, annotation.FullTrigger{
+				Producer: &annotation.ProduceTrigger{
+					Annotation: annotation.UnmarshalledField{FuncName: funcName},
+					Expr:       call,
+				},
+				Consumer: &annotation.ConsumeTrigger{
+					Annotation: annotation.FldAssign{
+						TriggerIfNonNil: annotation.TriggerIfNonNil{
+							Ann: annotation.FieldAnnotationKey{FieldDecl: field},
+						},
+					},
+					Expr:   arg,
+					Guards: util.NoGuards(),
+				},
+			})
+		}
+		return true
+	})
+	return triggers
+}
+
+// unmarshalledFields returns the pointer fields (possibly of the nested structs) of the struct
+// pointed to by the passed type, which are populated by the unmarshal functions.
+func unmarshalledFields(t types.Type) []*types.Var {
+	var fields []*types.Var
+	visited := make(map[*types.Struct]bool)
+	var collect func(t types.Type)
+	collect = func(t types.Type) {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok || visited[st] {
+			return
+		}
+		visited[st] = true
+		for i := 0; i < st.NumFields(); i++ {
+			f := st.Field(i)
+			if reflect.StructTag(st.Tag(i)).Get("json") == "-" {
+				continue
+			}
+			// The fields of the embedded structs are promoted even if the embedded struct itself
+			// is unexported.
+			if !f.Exported() {
+				if f.Embedded() {
+					collect(f.Type())
+				}
+				continue
+			}
+			if _, ok := f.Type().Underlying().(*types.Pointer); ok {
+				fields = append(fields, f)
+			}
+			collect(f.Type())
+		}
+	}
+
+	// Only the values passed by pointers can be populated.
+	if t == nil {
+		return nil
+	}
+	if _, ok := t.Underlying().(*types.Pointer); !ok {
+		return nil
+	}
+	collect(t)
+	return fields
+}
@@ -84,3 +84,14 @@ func (v *varAssertionNode) BuildExpr(_ *analysis.Pass, _ ast.Expr) ast.Expr {
 	}
 	return v.Root().GetDeclaringIdent(v.decl)
 }
+
+// isLocalArrayOfNilable returns true iff `v` is a local variable of `fdecl` (i.e., neither one of
+// its parameters or receiver nor a global variable) of array type whose elements can be nil. Unlike
+// slices or maps, such an array is never nil itself, but its unassigned elements are.
+func isLocalArrayOfNilable(fdecl *types.Func, v *types.Var) bool {
+	if annotation.VarIsParam(fdecl, v) || annotation.VarIsRecv(fdecl, v) || annotation.VarIsGlobal(v) {
+		return false
+	}
+	arr, ok := v.Type().Underlying().(*types.Array)
+	return ok && !util.TypeBarsNilness(arr.Elem())
+}
@@ -32,6 +32,12 @@ type Result struct {
 	// FunctionContractsMap is the map generated from reading the function contracts in the source
 	// code.
 	FunctionContracts Map
+	// ImplicationContracts are the contracts written as `// nilaway:contract` comments in source
+	// order, which are translated into implication edges during inference.
+	ImplicationContracts []*ImplicationContract
+	// InvalidContracts are the diagnostics for the malformed implication contracts, which are
+	// reported to the users along with the other diagnostics.
+	InvalidContracts []analysis.Diagnostic
 	// Errors is the slice of errors if errors happened during analysis. We put the errors here as
 	// part of the result of this sub-analyzer so that the upper-level analyzers can decide what
 	// to do with them.
@@ -42,7 +48,7 @@ type Result struct {
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_function_contracts_analyzer",
 	Doc:        _doc,
-	Run:        run,
+	Run:        config.Profiled(run),
 	ResultType: reflect.TypeOf((*Result)(nil)).Elem(),
 	Requires:   []*analysis.Analyzer{config.Analyzer},
 }
@@ -70,5 +76,10 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		return Result{FunctionContracts: Map{}}, nil
 	}
 
-	return Result{FunctionContracts: collectFunctionContracts(pass)}, nil
+	implicationContracts, invalid := collectImplicationContracts(pass)
+	return Result{
+		FunctionContracts:    collectFunctionContracts(pass),
+		ImplicationContracts: implicationContracts,
+		InvalidContracts:     invalid,
+	}, nil
 }
@@ -72,6 +72,42 @@ func TestAnalyzer(t *testing.T) {
 	}
 }
 
+func TestImplicationContracts(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+
+	r := analysistest.Run(t, testdata, Analyzer, "go.uber.org/implicationcontracts")
+	require.Equal(t, 1, len(r))
+	pass, result := r[0].Pass, r[0].Result.(Result)
+
+	type contract struct {
+		name string
+		FunctionContract
+	}
+	var contracts []contract
+	for _, c := range result.ImplicationContracts {
+		contracts = append(contracts, contract{name: c.Func.Name(), FunctionContract: *c.Contract})
+	}
+	require.Equal(t, []contract{
+		{"single", FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}}},
+		{"multiple", FunctionContract{Ins: []ContractVal{NonNil, Any}, Outs: []ContractVal{NonNil, True}}},
+		{"multiple", FunctionContract{Ins: []ContractVal{Any, NonNil}, Outs: []ContractVal{NonNil, True}}},
+	}, contracts)
+
+	var invalid []string
+	for _, d := range result.InvalidContracts {
+		invalid = append(invalid, fmt.Sprintf("%d: %s", pass.Fset.Position(d.Pos).Line, d.Message))
+	}
+	require.Equal(t, []string{
+		"28: invalid contract for `wrongParams()`: contract has 2 param value(s) but the function has 1 param(s)",
+		"33: invalid contract for `wrongResults()`: contract has 2 result value(s) but the function has 1 result(s)",
+		"38: invalid contract for `malformed()`: malformed contract \"nilaway:contract nilable -> nonnil\", expected " +
+			"\"nilaway:contract\" followed by comma-separated values (one of nonnil, false, true, _) for the params " +
+			"and results separated by \"->\"",
+	}, invalid)
+}
+
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
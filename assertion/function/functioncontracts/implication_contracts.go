@@ -0,0 +1,119 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functioncontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+const _implicationContractKeyword = "nilaway:contract"
+
+// _implicationContractPrefixRE matches any comment line starting with the keyword of the
+// implication contracts, such that malformed contracts can be reported instead of being ignored.
+var _implicationContractPrefixRE = regexp.MustCompile(fmt.Sprintf("^\\s*//\\s*%s\\b", _implicationContractKeyword))
+
+// _implicationContractRE matches a single implication contract in its own line, which looks like
+// `nilaway:contract VALUE(,VALUE)* -> VALUE(,VALUE)*`. The RE captures the two lists of VALUEs,
+// i.e., the part before and after `->`.
+var _implicationContractRE = regexp.MustCompile(
+	fmt.Sprintf("^\\s*//\\s*%s\\s+((?:%s)(?:\\s*,\\s*(?:%s))*)\\s*->\\s*((?:%s)(?:\\s*,\\s*(?:%s))*)\\s*$",
+		_implicationContractKeyword, _contractValKeyword, _contractValKeyword, _contractValKeyword, _contractValKeyword))
+
+// ImplicationContract is a function contract written as a `// nilaway:contract ins -> outs`
+// comment before the function declaration. Unlike the contracts written as `// contract(...)`,
+// which are instantiated at each call site of the function, an implication contract is translated
+// into implication edges between the sites of the function itself: for every parameter i and
+// result j that are both "nonnil" in the contract, "param i nonnil implies result j nonnil" is
+// encoded as an edge from result j to param i (i.e., if result j is nilable, so is param i).
+type ImplicationContract struct {
+	// Func is the function that the contract is declared for.
+	Func *types.Func
+	// Contract is the parsed contract, whose arity matches the signature of Func.
+	Contract *FunctionContract
+	// Pos is the position of the comment declaring the contract.
+	Pos token.Pos
+}
+
+// collectImplicationContracts collects the implication contracts of all functions in the package
+// in source order, along with the diagnostics for the malformed ones (e.g., the ones whose arity
+// does not match the signature of the function). One function can have multiple contracts.
+func collectImplicationContracts(pass *analysis.Pass) ([]*ImplicationContract, []analysis.Diagnostic) {
+	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+
+	var (
+		contracts []*ImplicationContract
+		invalid   []analysis.Diagnostic
+	)
+	for _, file := range pass.Files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Doc == nil {
+				continue
+			}
+			funcObj := pass.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+			sig := funcObj.Type().(*types.Signature)
+			for _, comment := range funcDecl.Doc.List {
+				if !_implicationContractPrefixRE.MatchString(comment.Text) {
+					continue
+				}
+				contract, err := parseImplicationContract(comment.Text, sig)
+				if err != nil {
+					invalid = append(invalid, analysis.Diagnostic{
+						Pos:     comment.Pos(),
+						Message: fmt.Sprintf("invalid contract for `%s()`: %s", funcObj.Name(), err),
+					})
+					continue
+				}
+				contracts = append(contracts, &ImplicationContract{Func: funcObj, Contract: contract, Pos: comment.Pos()})
+			}
+		}
+	}
+	return contracts, invalid
+}
+
+// parseImplicationContract parses the implication contract in the comment line and validates its
+// arity against the signature of the function.
+func parseImplicationContract(text string, sig *types.Signature) (*FunctionContract, error) {
+	matching := _implicationContractRE.FindStringSubmatch(text)
+	if matching == nil {
+		return nil, fmt.Errorf("malformed contract %q, expected %q followed by comma-separated values "+
+			"(one of %s) for the params and results separated by \"->\"", strings.TrimSpace(strings.TrimPrefix(text, "//")),
+			_implicationContractKeyword, strings.ReplaceAll(string(_contractValKeyword), "|", ", "))
+	}
+	contract := &FunctionContract{
+		Ins:  parseListOfContractValues(matching[1]),
+		Outs: parseListOfContractValues(matching[2]),
+	}
+	if len(contract.Ins) != sig.Params().Len() {
+		return nil, fmt.Errorf("contract has %d param value(s) but the function has %d param(s)",
+			len(contract.Ins), sig.Params().Len())
+	}
+	if len(contract.Outs) != sig.Results().Len() {
+		return nil, fmt.Errorf("contract has %d result value(s) but the function has %d result(s)",
+			len(contract.Outs), sig.Results().Len())
+	}
+	return contract, nil
+}
@@ -0,0 +1,46 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package implicationcontracts
+
+// nilaway:contract nonnil -> nonnil
+func single(x *int) *int {
+	return x
+}
+
+// nilaway:contract nonnil, _ -> nonnil, true
+// nilaway:contract _, nonnil -> nonnil, true
+func multiple(x, y *int) (*int, bool) {
+	return x, y != nil
+}
+
+// nilaway:contract nonnil, nonnil -> nonnil
+func wrongParams(x *int) *int {
+	return x
+}
+
+// nilaway:contract nonnil -> nonnil, true
+func wrongResults(x *int) *int {
+	return x
+}
+
+// nilaway:contract nilable -> nonnil
+func malformed(x *int) *int {
+	return x
+}
+
+// contract(nonnil -> nonnil)
+func otherSyntax(x *int) *int {
+	return x
+}
@@ -0,0 +1,324 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _baselineVersion is the version of the baseline file format.
+const _baselineVersion = 1
+
+// _messagePositionRegexp matches the positions (e.g., "foo/bar.go:12:3") of the nil flow nodes in
+// the diagnostic messages, which are stripped when normalizing the messages since they drift.
+var _messagePositionRegexp = regexp.MustCompile(`(\.go):\d+:\d+`)
+
+// baselineFile is the on-disk representation of the baseline file.
+type baselineFile struct {
+	Version int             `json:"version"`
+	Entries []baselineEntry `json:"entries"`
+}
+
+// baselineEntry is a normalized diagnostic recorded in the baseline. Instead of the exact line,
+// the entry is anchored to the source code of the line such that edits elsewhere in the file do
+// not resurface the diagnostic.
+type baselineEntry struct {
+	// File is the path of the file (in slash-separated form) relative to the directory of the
+	// baseline file.
+	File string `json:"file"`
+	// Line is the line of the diagnostic when the baseline was written. It is only used to pick
+	// the closest diagnostic when multiple diagnostics share the same file, anchor and message.
+	Line int `json:"line"`
+	// Anchor is the source code of the line of the diagnostic, with surrounding whitespace trimmed.
+	Anchor string `json:"anchor"`
+	// Message is the diagnostic message with the positions stripped.
+	Message string `json:"message"`
+}
+
+// baselineKey is the key for matching the diagnostics against the baseline entries.
+type baselineKey struct {
+	file, anchor, message string
+}
+
+func (e baselineEntry) key() baselineKey {
+	return baselineKey{file: e.File, anchor: e.Anchor, message: e.Message}
+}
+
+// baselineEntries converts the diagnostics to baseline entries relative to the directory dir.
+func baselineEntries(dir string, pass *analysis.Pass, diagnostics []analysis.Diagnostic) []baselineEntry {
+	// Cache the lines of the source files since a file usually has multiple diagnostics.
+	sources := make(map[string][]string)
+	entries := make([]baselineEntry, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		position := pass.Fset.Position(d.Pos)
+		lines, ok := sources[position.Filename]
+		if !ok {
+			// The anchor is simply left empty if the source is not available, in which case the
+			// matching falls back to the message only.
+			if content, err := os.ReadFile(position.Filename); err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			sources[position.Filename] = lines
+		}
+
+		entry := baselineEntry{
+			File:    position.Filename,
+			Line:    position.Line,
+			Message: _messagePositionRegexp.ReplaceAllString(d.Message, "$1"),
+		}
+		if rel, err := filepath.Rel(dir, position.Filename); err == nil {
+			entry.File = filepath.ToSlash(rel)
+		}
+		if position.Line >= 1 && position.Line <= len(lines) {
+			entry.Anchor = strings.TrimSpace(lines[position.Line-1])
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// baselineDir returns the absolute directory of the baseline file, which the file paths in the
+// baseline entries are relative to.
+func baselineDir(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve baseline file %q: %w", path, err)
+	}
+	return filepath.Dir(abs), nil
+}
+
+// loadedBaseline is the baseline file loaded into memory, where the lines of the entries are
+// grouped by their keys.
+type loadedBaseline struct {
+	once  sync.Once
+	lines map[baselineKey][]int
+	err   error
+}
+
+// _loadedBaselines caches the loaded baseline for each baseline file, since the top-level
+// analyzer is run once for every package.
+var _loadedBaselines sync.Map
+
+// loadBaseline reads and parses the baseline file at the given path.
+func loadBaseline(path string) (map[baselineKey][]int, error) {
+	v, _ := _loadedBaselines.LoadOrStore(path, &loadedBaseline{})
+	b := v.(*loadedBaseline)
+	b.once.Do(func() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			b.err = fmt.Errorf("read baseline file: %w", err)
+			return
+		}
+		var f baselineFile
+		if err := json.Unmarshal(content, &f); err != nil {
+			b.err = fmt.Errorf("parse baseline file %q: %w", path, err)
+			return
+		}
+		if f.Version != _baselineVersion {
+			b.err = fmt.Errorf("unsupported baseline file version %d (expected %d)", f.Version, _baselineVersion)
+			return
+		}
+		b.lines = make(map[baselineKey][]int, len(f.Entries))
+		for _, e := range f.Entries {
+			b.lines[e.key()] = append(b.lines[e.key()], e.Line)
+		}
+	})
+	return b.lines, b.err
+}
+
+// filterBaseline returns the diagnostics that are not recorded in the baseline file at the given
+// path. Each baseline entry suppresses at most one diagnostic with the same file, anchor and
+// message, where the diagnostics closest to the recorded lines are suppressed first.
+func filterBaseline(path string, pass *analysis.Pass, diagnostics []analysis.Diagnostic) ([]analysis.Diagnostic, error) {
+	baseline, err := loadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := baselineDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the indices of the diagnostics by their keys.
+	entries := baselineEntries(dir, pass, diagnostics)
+	groups := make(map[baselineKey][]int)
+	for i, e := range entries {
+		groups[e.key()] = append(groups[e.key()], i)
+	}
+
+	suppressed := make([]bool, len(diagnostics))
+	for key, indices := range groups {
+		// Copy the lines since the cached baseline is shared across packages.
+		lines := append([]int(nil), baseline[key]...)
+		for len(lines) > 0 && len(indices) > 0 {
+			// Greedily match the closest pair of baseline line and diagnostic.
+			bestLine, bestIndex := 0, 0
+			for l := range lines {
+				for i := range indices {
+					if abs(lines[l]-entries[indices[i]].Line) < abs(lines[bestLine]-entries[indices[bestIndex]].Line) {
+						bestLine, bestIndex = l, i
+					}
+				}
+			}
+			suppressed[indices[bestIndex]] = true
+			lines = append(lines[:bestLine], lines[bestLine+1:]...)
+			indices = append(indices[:bestIndex], indices[bestIndex+1:]...)
+		}
+	}
+
+	filtered := make([]analysis.Diagnostic, 0, len(diagnostics))
+	for i, d := range diagnostics {
+		if !suppressed[i] {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// baselineWriter accumulates the baseline entries of all packages analyzed in the process and
+// writes them to a single file. Similar to sarifWriter, the complete file is rewritten whenever
+// new entries are added.
+type baselineWriter struct {
+	mu   sync.Mutex
+	path string
+	// entries stores the entries keyed by their JSON encoding, which also de-duplicates the
+	// entries for files that belong to multiple packages (e.g., "foo" and "foo.test").
+	entries map[string]baselineEntry
+	// written indicates whether the file has been written at least once.
+	written bool
+}
+
+// _baselineWriters stores the baselineWriter for each baseline file.
+var _baselineWriters sync.Map
+
+// writeBaseline adds the diagnostics of the pass to the baseline file at the given path.
+func writeBaseline(path string, pass *analysis.Pass, diagnostics []analysis.Diagnostic) error {
+	dir, err := baselineDir(path)
+	if err != nil {
+		return err
+	}
+	w, _ := _baselineWriters.LoadOrStore(path, &baselineWriter{path: path, entries: make(map[string]baselineEntry)})
+	return w.(*baselineWriter).add(baselineEntries(dir, pass, diagnostics))
+}
+
+// add adds the entries and rewrites the file if there are new entries.
+func (w *baselineWriter) add(entries []baselineEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := !w.written
+	for _, e := range entries {
+		key, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encode baseline entry: %w", err)
+		}
+		if _, ok := w.entries[string(key)]; !ok {
+			w.entries[string(key)] = e
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	// Sort the entries for deterministic output since packages are analyzed in parallel.
+	f := baselineFile{Version: _baselineVersion, Entries: make([]baselineEntry, 0, len(w.entries))}
+	for _, e := range w.entries {
+		f.Entries = append(f.Entries, e)
+	}
+	sort.Slice(f.Entries, func(i, j int) bool {
+		a, b := f.Entries[i], f.Entries[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Message != b.Message {
+			return a.Message < b.Message
+		}
+		return a.Anchor < b.Anchor
+	})
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode baseline file: %w", err)
+	}
+	if err := writeFileAtomically(w.path, content); err != nil {
+		return fmt.Errorf("write baseline file: %w", err)
+	}
+	w.written = true
+	return nil
+}
+
+// RelocateBaseline writes the baseline file at src to dst, where the file paths of the entries
+// (relative to the directory of src) are interpreted relative to the directory dir instead. This
+// makes a baseline written in another checkout of the same repository (e.g., a git worktree of a
+// base ref) applicable to the current one, where dir is the counterpart of the directory of src.
+// Entries with absolute paths (i.e., files outside the directory of src) are kept as is.
+func RelocateBaseline(src, dst, dir string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("read baseline file: %w", err)
+	}
+	var f baselineFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return fmt.Errorf("parse baseline file %q: %w", src, err)
+	}
+	if f.Version != _baselineVersion {
+		return fmt.Errorf("unsupported baseline file version %d (expected %d)", f.Version, _baselineVersion)
+	}
+	dstDir, err := baselineDir(dst)
+	if err != nil {
+		return err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve directory %q: %w", dir, err)
+	}
+
+	for i, e := range f.Entries {
+		file := filepath.FromSlash(e.File)
+		if filepath.IsAbs(file) {
+			continue
+		}
+		if rel, err := filepath.Rel(dstDir, filepath.Join(dir, file)); err == nil {
+			f.Entries[i].File = filepath.ToSlash(rel)
+		}
+	}
+	content, err = json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode baseline file: %w", err)
+	}
+	if err := writeFileAtomically(dst, content); err != nil {
+		return fmt.Errorf("write baseline file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/nilaway"
+	"go.uber.org/nilaway/config"
+)
+
+// _compareRefFlag is the driver flag for the git ref to compare against, such that only the
+// diagnostics newly introduced since the ref are reported.
+const _compareRefFlag = "compare-ref"
+
+// _compareRef is the value of the -compare-ref flag. It is only registered for the usage message,
+// since the flag is handled before the flags are parsed (see runAgainstRef).
+var _compareRef string
+
+// runAgainstRef runs the driver such that only the diagnostics newly introduced since the git ref
+// are reported, and returns the exit code. The ref is checked out in a temporary git worktree,
+// where the driver is run with the same arguments to write a baseline (see config.Baseline) of
+// the diagnostics at the ref. The driver is then run in the current checkout against the
+// relocated baseline, where the diagnostics are matched by their files, source lines and messages
+// (see nilaway.RelocateBaseline), such that moved but otherwise unchanged code is not reported.
+// If -cache-dir is specified, both runs share the cache such that the packages unchanged since
+// the ref are not re-analyzed.
+func runAgainstRef(ref string, args []string) int {
+	code, err := compareAgainstRef(ref, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nilaway: compare against %q: %v\n", ref, err)
+		return 1
+	}
+	return code
+}
+
+// compareAgainstRef implements runAgainstRef, and returns the exit code of the run in the current
+// checkout.
+func compareAgainstRef(ref string, args []string) (int, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return 0, fmt.Errorf("get working directory: %w", err)
+	}
+	root, err := git(wd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return 0, err
+	}
+	rel, err := filepath.Rel(root, wd)
+	if err != nil {
+		return 0, fmt.Errorf("resolve working directory in repository: %w", err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("find executable: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp("", "nilaway-compare-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	worktree := filepath.Join(tmp, "base")
+	if _, err := git(wd, "worktree", "add", "--detach", worktree, ref); err != nil {
+		return 0, err
+	}
+	defer func() { _, _ = git(wd, "worktree", "remove", "--force", worktree) }()
+
+	// Write the baseline of the diagnostics at the ref. The run is expected to exit with
+	// _diagnosticsExitCode if there are any diagnostics, which are not printed (singlechecker
+	// prints them to stderr, so its output is only printed if the run fails otherwise).
+	baseDir := filepath.Join(worktree, rel)
+	base := filepath.Join(baseDir, "nilaway-compare-baseline.json")
+	cmd := exec.Command(executable, childArgs(args,
+		"-"+config.BaselineFlag+"="+base, "-"+config.WriteBaselineFlag+"=true")...)
+	var output bytes.Buffer
+	cmd.Dir, cmd.Stdout, cmd.Stderr = baseDir, &output, &output
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != _diagnosticsExitCode {
+			_, _ = io.Copy(os.Stderr, &output)
+			return 0, fmt.Errorf("run nilaway at %q: %w", ref, err)
+		}
+	}
+
+	baseline := filepath.Join(tmp, "baseline.json")
+	if err := nilaway.RelocateBaseline(base, baseline, wd); err != nil {
+		return 0, err
+	}
+	cmd = exec.Command(executable, childArgs(args, "-"+config.BaselineFlag+"="+baseline)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("run nilaway: %w", err)
+	}
+	return 0, nil
+}
+
+// git runs the git command in the directory and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// childArgs returns the command line args for the child processes of runAgainstRef, where the
+// flags are prepended (since the flags must precede the package patterns) and the flags that
+// would interfere with the comparison are removed.
+func childArgs(args []string, flags ...string) []string {
+	args = stripFlag(args, _compareRefFlag, false)
+	args = stripFlag(args, config.BaselineFlag, false)
+	args = stripFlag(args, config.WriteBaselineFlag, true)
+	return append(flags, args...)
+}
+
+// stripFlag returns the command line args without the flag with the given name, in the
+// "-name=value" form and, unless it is a bool flag, the "-name value" form.
+func stripFlag(args []string, name string, isBool bool) []string {
+	var stripped []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(stripped, args[i:]...)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			stripped = append(stripped, arg)
+			continue
+		}
+		n, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if n != name {
+			stripped = append(stripped, arg)
+			continue
+		}
+		if !hasValue && !isBool {
+			// Skip the value in the next arg as well.
+			i++
+		}
+	}
+	return stripped
+}
+
+// flagValue returns the value of the string flag with the given name in the command line args,
+// in either the "-name=value" or the "-name value" form.
+func flagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		n, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if n != name {
+			continue
+		}
+		if hasValue {
+			return v
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
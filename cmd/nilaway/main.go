@@ -20,6 +20,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"go/token"
 	"os"
 	"path/filepath"
 	"strings"
@@ -47,6 +48,8 @@ var (
 	_includeErrorsInFiles string
 	// _excludeErrorsInFiles is a driver flag for specifying the list of file prefixes to not report errors.
 	_excludeErrorsInFiles string
+	// _failOn is a driver flag for specifying the maximum number of errors that are tolerated.
+	_failOn int
 )
 
 func run(pass *analysis.Pass) (interface{}, error) {
@@ -67,26 +70,66 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		return nil, fmt.Errorf("parse file prefixes for error exclusion: %w", err)
 	}
 
-	// Override the report function to add error filtering logic.
+	// Override the report function to add error filtering logic, where the reported errors are
+	// counted for the summary of the run. The diagnostics of the warning categories are printed
+	// to stderr instead (unless in quiet mode), since any diagnostic reported to singlechecker
+	// affects the exit code.
+	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+	reported := 0
+	sources := make(map[string]int)
 	report := pass.Report
-	pass.Report = func(d analysis.Diagnostic) {
-		p := pass.Fset.File(d.Pos).Name()
+	included := func(pos token.Pos) bool {
+		p := pass.Fset.File(pos).Name()
 		for _, e := range excludes {
 			if strings.HasPrefix(p, e) {
-				return
+				return false
 			}
 		}
-
 		for _, i := range includes {
 			if strings.HasPrefix(p, i) {
-				report(d)
+				return true
+			}
+		}
+		return false
+	}
+	pass.Report = func(d analysis.Diagnostic) {
+		if !included(d.Pos) {
+			return
+		}
+		if conf.IsWarning(d.Category) {
+			if conf.Quiet {
 				return
 			}
+			position := pass.Fset.Position(d.Pos)
+			position.Filename = conf.RelativePath(position.Filename)
+			fmt.Fprintf(os.Stderr, "%s: warning: %s\n", position, d.Message)
+			return
 		}
+		reported++
+		source, count := rootSource(pass.Fset, d, conf.RelativePath)
+		sources[source] += count
+		report(d)
 	}
 
 	// Delegate the real analysis run to the original nilaway analyzer.
-	return nilaway.Analyzer.Run(pass)
+	result, err := nilaway.Analyzer.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	// The errors dropped by the per-package limit are counted as well, such that the summary and
+	// the error threshold reflect the true total.
+	for _, pos := range conf.TruncatedErrors() {
+		if included(pos) {
+			reported++
+		}
+	}
+
+	// The profiles are not recorded in quiet mode such that the parent process does not print them.
+	profile := conf.ProfilePackages && !conf.Quiet
+	if err := _summary.add(reported, sources, conf.SummaryFile, os.Getenv(_failOnStatusEnv), profile); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // parseFilePrefixes parses the comma-separated list of file prefixes, converts them to absolute
@@ -109,6 +152,25 @@ func parseFilePrefixes(s string) ([]string, error) {
 }
 
 func main() {
+	// Report only the diagnostics newly introduced since the git ref, see runAgainstRef. The runs
+	// in the child processes do not have the flag anymore.
+	if ref := flagValue(os.Args[1:], _compareRefFlag); ref != "" {
+		os.Exit(runAgainstRef(ref, os.Args[1:]))
+	}
+
+	// Replay the recorded inference sessions without analyzing any packages, see runReplay.
+	if path := flagValue(os.Args[1:], _replayFlag); path != "" {
+		os.Exit(runReplay(path))
+	}
+
+	// Run the driver in a child process to enforce the error threshold or to print the profiles
+	// of the packages (or the nil sources to fix first) at the end of the run, see
+	// runInChildProcess.
+	if (hasFlag(os.Args[1:], _failOnFlag) || hasFlag(os.Args[1:], config.ProfilePackagesFlag) ||
+		hasFlag(os.Args[1:], _suggestFixesFlag)) && os.Getenv(_failOnStatusEnv) == "" {
+		os.Exit(runInChildProcess())
+	}
+
 	// For better UX, we lift the flags from config.Analyzer to the top level so that users can
 	// specify them without having to specify the analyzer name ("nilaway_config").
 	// For example, without lifting the flags, we will have to use `multichecker` to run the
@@ -128,7 +190,7 @@ func main() {
 		})
 	})
 
-	// Add two more flags to the driver for error suppression since singlechecker does not support it.
+	// Add more flags to the driver for error suppression and exit code since singlechecker does not support them.
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to get working directory: %v\n", err)
@@ -136,6 +198,14 @@ func main() {
 	}
 	flag.StringVar(&_includeErrorsInFiles, "include-errors-in-files", wd, "A comma-separated list of file prefixes to report errors, default is current working directory.")
 	flag.StringVar(&_excludeErrorsInFiles, "exclude-errors-in-files", "", "A comma-separated list of file prefixes to exclude from error reporting. This takes precedence over include-errors-in-files.")
+	flag.StringVar(&_compareRef, _compareRefFlag, "", "Report only the diagnostics newly introduced since "+
+		"the given git ref (e.g., \"origin/main\"), by comparing against the diagnostics of the ref analyzed "+
+		"in a temporary git worktree.")
+	flag.StringVar(&_replay, _replayFlag, "", "Replay the inference sessions recorded to the given file by -"+
+		config.RecordFlag+" without the source, printing the conflicts found instead of analyzing any packages.")
+	flag.IntVar(&_failOn, _failOnFlag, 0, "Exit with a non-zero code (3) only if more than this number of errors are reported.")
+	flag.IntVar(&_suggestFixes, _suggestFixesFlag, 0, "Print the given number of nil sources causing the most "+
+		"errors at the end of the run, such that the fixes with the highest leverage can be made first.")
 
 	singlechecker.Main(Analyzer)
 }
@@ -0,0 +1,71 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/nilaway/accumulation"
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+)
+
+// _replayFlag is the driver flag for the path of a file recorded by the -record flag (see
+// config.RecordFlag), whose inference sessions are replayed instead of analyzing any packages.
+const _replayFlag = "replay"
+
+// _replay is the value of the -replay flag. It is only registered for the usage message, since
+// the flag is handled before the flags are parsed (see runReplay).
+var _replay string
+
+// runReplay replays the inference sessions recorded to the file without the source, prints the
+// conflicts found for each package and returns the exit code, which is _diagnosticsExitCode if
+// any conflicts are found. Since the full triggers are not recorded, the conflicts are printed in
+// their primitive forms instead of the regular diagnostics.
+func runReplay(path string) int {
+	sessions, err := accumulation.ReadSessions(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nilaway: replay %q: %v\n", path, err)
+		return 1
+	}
+	if writeReplay(os.Stdout, sessions) > 0 {
+		return _diagnosticsExitCode
+	}
+	return 0
+}
+
+// writeReplay replays the sessions and writes the conflicts found under a header line with the
+// package path, and returns the total number of conflicts.
+func writeReplay(w io.Writer, sessions []*inference.Session) int {
+	total := 0
+	for _, session := range sessions {
+		result := inference.Replay(session)
+		if len(result.Conflicts) == 0 && !result.Truncated {
+			continue
+		}
+		fmt.Fprintf(w, "# %s\n", session.PkgPath)
+		for _, c := range result.Conflicts {
+			fmt.Fprintf(w, "%s: %s\n", c.Position, c.Message)
+		}
+		if result.Truncated {
+			fmt.Fprintf(w, "analysis truncated: the number of inference sites exceeds the limit of %d (-%s)\n",
+				session.MaxSites, config.MaxSitesFlag)
+		}
+		total += len(result.Conflicts)
+	}
+	return total
+}
@@ -0,0 +1,78 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+
+	"go.uber.org/nilaway/diagnostic"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _suggestFixesFlag is the driver flag for the number of nil sources to print at the end of the
+// run, ranked by the number of dereferences they cause (see writeFixSuggestions).
+const _suggestFixesFlag = "suggest-fixes"
+
+// _suggestFixes is the number of nil sources to suggest fixing, where zero disables suggestions.
+var _suggestFixes int
+
+// rootSource returns the description ("position: reason") of the nil source of the diagnostic,
+// i.e., the first node of its nil flow, which is where the chain of implications leading to the
+// error starts, along with the number of dereferences it causes (including the ones grouped with
+// the diagnostic). Fixing the source (e.g., by returning a non-nil value or adding a nil check
+// there) eliminates all of them. The diagnostic itself is used if its flow is not available.
+func rootSource(fset *token.FileSet, d analysis.Diagnostic, relativePath func(string) string) (string, int) {
+	pos, reason, count, found := d.Pos, d.Message, 1, false
+	for _, r := range d.Related {
+		switch {
+		case r.Message == diagnostic.SameSourceMessage:
+			count++
+		case !found:
+			pos, reason, found = r.Pos, r.Message, true
+		}
+	}
+	reason, _, _ = strings.Cut(reason, "\n")
+	position := fset.Position(pos)
+	position.Filename = relativePath(position.Filename)
+	return position.String() + ": " + reason, count
+}
+
+// writeFixSuggestions writes the (at most) n nil sources causing the most dereferences to w, one
+// per line along with the number of dereferences, where the ties are broken by the sources for stable output.
+func writeFixSuggestions(w io.Writer, sources map[string]int, n int) error {
+	ranked := make([]string, 0, len(sources))
+	for s := range sources {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if sources[ranked[i]] != sources[ranked[j]] {
+			return sources[ranked[i]] > sources[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	for _, s := range ranked {
+		if _, err := fmt.Fprintf(w, "%6d  %s\n", sources[s], s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+)
+
+const (
+	// _failOnFlag is the driver flag for the maximum number of errors that are tolerated.
+	_failOnFlag = "fail-on"
+	// _failOnStatusEnv is the environment variable for the path of the status file, which is set
+	// by the parent process for the child process (see runInChildProcess).
+	_failOnStatusEnv = "NILAWAY_FAIL_ON_STATUS_FILE"
+	// _diagnosticsExitCode is the exit code of the checker if diagnostics are reported.
+	_diagnosticsExitCode = 3
+)
+
+// summary is the summary of the run written to the summary file.
+type summary struct {
+	Errors   int `json:"errors"`
+	Packages int `json:"packages"`
+	// FailOn is the maximum number of errors that are tolerated (see _failOnFlag).
+	FailOn int `json:"fail-on"`
+	// Sources maps the nil sources of the reported errors to the number of dereferences they cause
+	// (see rootSource).
+	Sources map[string]int `json:"sources,omitempty"`
+}
+
+// childStatus is the status written by the child process to the status file, such that the
+// parent process can decide the exit code and print the profiles of the packages.
+type childStatus struct {
+	Errors   int                     `json:"errors"`
+	FailOn   int                     `json:"fail-on"`
+	Profiles []config.PackageProfile `json:"profiles,omitempty"`
+	// Sources maps the nil sources of the reported errors to the number of dereferences they cause,
+	// which is only written if fixes are to be suggested (see _suggestFixesFlag).
+	Sources map[string]int `json:"sources,omitempty"`
+}
+
+// summaryRecorder accumulates the summary of all packages analyzed in the process. Since
+// singlechecker does not offer a hook at the end of the analysis, the files are rewritten after
+// every package such that they contain the complete summary when the process exits.
+type summaryRecorder struct {
+	mu      sync.Mutex
+	summary summary
+}
+
+// _summary is the summary recorder of the process.
+var _summary summaryRecorder
+
+// add records the number of errors reported for a package (along with their nil sources, see
+// rootSource) and rewrites the summary file and the status file if their paths are not empty. The
+// status file includes the profiles of the packages analyzed so far if profiling is enabled, and
+// the nil sources if fixes are to be suggested.
+func (r *summaryRecorder) add(errors int, sources map[string]int, summaryFile, statusFile string, profile bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.summary.Errors += errors
+	for s, count := range sources {
+		if r.summary.Sources == nil {
+			r.summary.Sources = make(map[string]int)
+		}
+		r.summary.Sources[s] += count
+	}
+	r.summary.Packages++
+	r.summary.FailOn = _failOn
+	if summaryFile != "" {
+		if err := writeJSON(summaryFile, r.summary); err != nil {
+			return fmt.Errorf("write summary file: %w", err)
+		}
+	}
+	if statusFile != "" {
+		status := childStatus{Errors: r.summary.Errors, FailOn: _failOn}
+		if profile {
+			status.Profiles = config.PackageProfiles()
+		}
+		if _suggestFixes > 0 {
+			status.Sources = r.summary.Sources
+		}
+		if err := writeJSON(statusFile, status); err != nil {
+			return fmt.Errorf("write status file: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeJSON encodes the value in JSON and writes it to a temporary file first and then renames it
+// to the given path, such that readers never observe a partially-written file.
+func writeJSON(path string, v any) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// hasFlag returns true iff the flag with the given name is specified in the command line args.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if n, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "="); n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runInChildProcess runs the driver in a child process and returns the exit code for the -fail-on
+// flag. This is needed since singlechecker always exits with code 3 if there are any
+// diagnostics, and it exits the process directly such that we cannot override the exit code (or
+// print anything at the end of the run) in the same process. The child process writes the number
+// of reported errors to a status file, which the parent process reads to decide the exit code: the
+// exit code of the child process is kept if the errors exceed the threshold, and 0 is used
+// otherwise. The profiles of the packages (-profile-packages) and the nil sources to fix first
+// (-suggest-fixes) are printed from the status file as well.
+func runInChildProcess() int {
+	f, err := os.CreateTemp("", "nilaway-status-*.json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create status file: %v\n", err)
+		return 1
+	}
+	statusFile := f.Name()
+	_ = f.Close()
+	defer os.Remove(statusFile)
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to find executable: %v\n", err)
+		return 1
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), _failOnStatusEnv+"="+statusFile)
+
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Fprintf(os.Stderr, "failed to run nilaway: %v\n", err)
+			return 1
+		}
+		code = exitErr.ExitCode()
+	}
+	// The exit code of the child process is kept if it did not leave a (valid) status behind.
+	status, err := readStatus(statusFile)
+	if err != nil {
+		return code
+	}
+	if len(status.Profiles) > 0 {
+		fmt.Fprintln(os.Stderr, "nilaway: time spent per package:")
+		if err := config.WriteProfileSummary(os.Stderr, status.Profiles); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print package profiles: %v\n", err)
+		}
+	}
+	// The flags are not parsed in the parent process, see main.
+	if n, _ := strconv.Atoi(flagValue(os.Args[1:], _suggestFixesFlag)); n > 0 && len(status.Sources) > 0 {
+		fmt.Fprintln(os.Stderr, "nilaway: nil sources causing the most potential nil panics (fix these first):")
+		if err := writeFixSuggestions(os.Stderr, status.Sources, n); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print fix suggestions: %v\n", err)
+		}
+	}
+
+	return failOnExitCode(code, status)
+}
+
+// readStatus reads the status written by the child process from the status file.
+func readStatus(path string) (childStatus, error) {
+	var status childStatus
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return status, err
+	}
+	err = json.Unmarshal(content, &status)
+	return status, err
+}
+
+// failOnExitCode returns the exit code of the run given the exit code of the child process and
+// the status it wrote, i.e., 0 unless the reported errors exceed the threshold.
+func failOnExitCode(code int, status childStatus) int {
+	// Other exit codes (e.g., 1 for analysis failures) are kept as is.
+	if code != _diagnosticsExitCode {
+		return code
+	}
+	if status.Errors == 0 || status.Errors <= status.FailOn {
+		return 0
+	}
+	return code
+}
@@ -0,0 +1,178 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// _fakeChildEnv is the environment variable that makes the test binary act as the child process
+// of runInChildProcess (see TestMain), in the form of "<exit code>:<status file content>", where
+// the status file is removed if the content is empty.
+const _fakeChildEnv = "NILAWAY_TEST_FAKE_CHILD"
+
+func TestFailOnExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		code   int
+		status childStatus
+		want   int
+	}{
+		{name: "below threshold", code: 3, status: childStatus{Errors: 1, FailOn: 2}, want: 0},
+		{name: "at threshold", code: 3, status: childStatus{Errors: 2, FailOn: 2}, want: 0},
+		{name: "above threshold", code: 3, status: childStatus{Errors: 3, FailOn: 2}, want: 3},
+		{name: "zero threshold", code: 3, status: childStatus{Errors: 1}, want: 3},
+		{name: "zero diagnostics", code: 0, status: childStatus{}, want: 0},
+		{name: "zero errors with negative threshold", code: 3, status: childStatus{FailOn: -1}, want: 0},
+		{name: "analysis failure", code: 1, status: childStatus{}, want: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, failOnExitCode(tt.code, tt.status))
+		})
+	}
+}
+
+func TestReadStatus(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := readStatus(filepath.Join(dir, "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// A directory cannot be read as a file.
+	_, err = readStatus(dir)
+	require.Error(t, err)
+
+	malformed := filepath.Join(dir, "malformed.json")
+	require.NoError(t, os.WriteFile(malformed, []byte(`{"errors": `), 0o600))
+	_, err = readStatus(malformed)
+	require.Error(t, err)
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{"errors": 3, "fail-on": 2}`), 0o600))
+	status, err := readStatus(valid)
+	require.NoError(t, err)
+	require.Equal(t, childStatus{Errors: 3, FailOn: 2}, status)
+}
+
+// TestSummaryRecorder modifies the driver flags, so it cannot be run in parallel with the tests
+// that rely on them.
+func TestSummaryRecorder(t *testing.T) { //nolint:paralleltest
+	defer func(failOn, suggestFixes int) { _failOn, _suggestFixes = failOn, suggestFixes }(_failOn, _suggestFixes)
+	_failOn, _suggestFixes = 2, 0
+
+	dir := t.TempDir()
+	summaryFile, statusFile := filepath.Join(dir, "summary.json"), filepath.Join(dir, "status.json")
+	var r summaryRecorder
+	require.NoError(t, r.add(2, map[string]int{"a.go:1:1: nil": 2}, summaryFile, statusFile, false /* profile */))
+	require.NoError(t, r.add(0, nil, summaryFile, statusFile, false /* profile */))
+	require.NoError(t, r.add(1, map[string]int{"a.go:1:1: nil": 1, "b.go:2:1: nil": 1}, summaryFile, statusFile, false /* profile */))
+
+	// The summary file contains the totals of all packages so far, along with the counts per source
+	// and the error threshold.
+	content, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"errors": 3,
+		"packages": 3,
+		"fail-on": 2,
+		"sources": {"a.go:1:1: nil": 3, "b.go:2:1: nil": 1}
+	}`, string(content))
+
+	// The status file for the parent process only contains the sources if fixes are to be
+	// suggested.
+	status, err := readStatus(statusFile)
+	require.NoError(t, err)
+	require.Equal(t, childStatus{Errors: 3, FailOn: 2}, status)
+
+	_suggestFixes = 1
+	require.NoError(t, r.add(0, nil, "" /* summaryFile */, statusFile, false /* profile */))
+	status, err = readStatus(statusFile)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a.go:1:1: nil": 3, "b.go:2:1: nil": 1}, status.Sources)
+
+	// The summary file of zero diagnostics still records the threshold, but no sources.
+	emptyFile := filepath.Join(dir, "empty.json")
+	require.NoError(t, (&summaryRecorder{}).add(0, nil, emptyFile, "" /* statusFile */, false /* profile */))
+	content, err = os.ReadFile(emptyFile)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"errors": 0, "packages": 1, "fail-on": 2}`, string(content))
+}
+
+// TestRunInChildProcess sets the environment variables of the process, so it cannot be run in
+// parallel.
+func TestRunInChildProcess(t *testing.T) { //nolint:paralleltest
+	tests := []struct {
+		name string
+		// code is the exit code of the fake child process.
+		code int
+		// status is the content of the status file written by the fake child process, which is
+		// removed instead if empty.
+		status string
+		want   int
+	}{
+		{name: "below threshold", code: 3, status: `{"errors": 1, "fail-on": 2}`, want: 0},
+		{name: "at threshold", code: 3, status: `{"errors": 2, "fail-on": 2}`, want: 0},
+		{name: "above threshold", code: 3, status: `{"errors": 3, "fail-on": 2}`, want: 3},
+		{name: "zero diagnostics", code: 0, status: `{"errors": 0, "fail-on": 0}`, want: 0},
+		{name: "missing status file", code: 3, want: 3},
+		{name: "unreadable status file", code: 3, status: `{"errors": `, want: 3},
+		{name: "analysis failure", code: 1, status: `{"errors": 0, "fail-on": 2}`, want: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(_fakeChildEnv, strconv.Itoa(tt.code)+":"+tt.status)
+			require.Equal(t, tt.want, runInChildProcess())
+		})
+	}
+}
+
+// runFakeChild acts as the child process of runInChildProcess as instructed by _fakeChildEnv,
+// i.e., it writes (or removes) the status file and returns the exit code.
+func runFakeChild(instruction string) int {
+	code, status, _ := strings.Cut(instruction, ":")
+	statusFile := os.Getenv(_failOnStatusEnv)
+	if status == "" {
+		_ = os.Remove(statusFile)
+	} else if err := os.WriteFile(statusFile, []byte(status), 0o600); err != nil {
+		return 1
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+func TestMain(m *testing.M) {
+	// runInChildProcess runs the current executable (i.e., the test binary) as the child process.
+	if instruction := os.Getenv(_fakeChildEnv); instruction != "" {
+		os.Exit(runFakeChild(instruction))
+	}
+	goleak.VerifyTestMain(m)
+}
@@ -0,0 +1,77 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+
+	"golang.org/x/exp/slices"
+)
+
+// _unixOS is the set of GOOS values matched by the "unix" build tag, mirroring the list in the
+// go/build package.
+var _unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+	"illumos": true, "ios": true, "linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// matchBuildConstraints returns true iff the build constraints (i.e., "//go:build" and
+// "// +build" lines before the package clause) of the file are satisfied by the current build
+// context. Files without any build constraints are always satisfied.
+func matchBuildConstraints(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				// Malformed constraints are reported by the compiler, so we simply ignore them.
+				continue
+			}
+			if !expr.Eval(hasBuildTag) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasBuildTag returns true iff the build tag is satisfied by the default build context, following
+// the same rules as the go/build package.
+func hasBuildTag(tag string) bool {
+	ctx := build.Default
+	switch tag {
+	case ctx.GOOS, ctx.GOARCH, ctx.Compiler:
+		return true
+	case "unix":
+		return _unixOS[ctx.GOOS]
+	case "cgo":
+		return ctx.CgoEnabled
+	case "linux":
+		return ctx.GOOS == "android"
+	case "solaris":
+		return ctx.GOOS == "illumos"
+	case "darwin":
+		return ctx.GOOS == "ios"
+	}
+	return slices.Contains(ctx.BuildTags, tag) || slices.Contains(ctx.ToolTags, tag) ||
+		slices.Contains(ctx.ReleaseTags, tag)
+}
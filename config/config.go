@@ -17,10 +17,19 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/analysis"
 )
@@ -29,43 +38,751 @@ import (
 type Config struct {
 	// PrettyPrint indicates whether the error messages should be pretty printed.
 	PrettyPrint bool
+	// Quiet indicates whether the informational output (e.g., the diagnostics of the warning
+	// categories printed to stderr and the summary of the package profiles) is suppressed, such
+	// that only the diagnostics are printed (or nothing if there are none). Pretty printing still
+	// applies to the diagnostics, and the exit code is not affected. Note that the informational
+	// output is only printed by the standalone NilAway driver.
+	Quiet bool
+	// CollapseDuplicates indicates whether the diagnostics with the same category and message in
+	// the same file are reported as a single diagnostic at the first occurrence, listing the lines
+	// of the others. This only affects the reported diagnostics, the structured outputs (e.g.,
+	// SARIF) still contain all of them.
+	CollapseDuplicates bool
+	// MessageTemplate is a text/template string that the messages of the reported diagnostics are
+	// formatted with, given the MessageFields of each diagnostic (e.g.,
+	// "{{.Position}}: [{{.Category}}] {{.Message}}"). Empty means the messages are reported as-is.
+	// Like CollapseDuplicates, this does not affect the structured outputs.
+	MessageTemplate string
+	// messageTemplate is the template parsed from MessageTemplate (see FormatMessage).
+	messageTemplate *template.Template
 	// includePkgs is the list of packages to analyze.
-	includePkgs []string
+	includePkgs []pkgPattern
 	// excludePkgs is the list of packages to exclude from analysis. Exclude list takes
 	// precedence over the include list.
-	excludePkgs []string
+	excludePkgs []pkgPattern
+	// pkgRules is the ordered list of include / exclude rules refining the include and exclude
+	// lists, where the last matching rule wins (see IsPkgInScope).
+	pkgRules []pkgRule
+	// distrustPkgs is the list of packages whose sites are ignored in the facts imported from the
+	// upstream packages (see IsPkgPathDistrusted).
+	distrustPkgs []pkgPattern
 	// excludeFileDocStrings is the list of doc strings that, if they appear in the file doc
 	// string, will cause the file to be excluded from analysis. Examples include "@generated" and
 	// "Code generated by".
 	excludeFileDocStrings []string
+	// docStringIgnoreCase and docStringWholeWord are the matching modes of excludeFileDocStrings,
+	// which apply globally to all docstrings in the list (see matchDocString).
+	docStringIgnoreCase, docStringWholeWord bool
+	// excludeFuncs is the set of qualified names (see FuncName) of the functions whose
+	// diagnostics should not be reported.
+	excludeFuncs map[string]bool
+	// changedPkgs is the set of paths of the packages changed since the last run, whose
+	// diagnostics (as well as the ones of their affected dependents) are reported. Nil means all
+	// packages are reported (see ShouldReanalyze).
+	changedPkgs map[string]bool
+	// packageDefaults is the list of rules for the default nilability of the unannotated sites in
+	// the matching packages. Similar to the global defaults, they are only consulted when the
+	// nilability of the sites is not inferred (e.g., in packages with inference disabled).
+	packageDefaults []packageDefault
+	// respectBuildTags indicates whether files whose build constraints are not satisfied by the
+	// current build context should be excluded from analysis.
+	respectBuildTags bool
+	// skipVendor indicates whether the vendored packages (i.e., the ones with a "vendor" segment in
+	// their paths) are out of scope regardless of the include list.
+	skipVendor bool
+	// externalReturnsNilable indicates whether the pointer results of the functions in packages
+	// that are out of scope (see IsPkgInScope) should be treated as nilable instead of nonnil.
+	externalReturnsNilable bool
+	// testFileMode is the mode of analyzing the test files (see the TestFileMode* constants).
+	testFileMode string
+	// fset is the file set of the package being analyzed, which is used to identify the test files.
+	fset *token.FileSet
+	// stats is the time spent in the sub-analyzers, the number of inferred sites and the truncated
+	// errors of the package being analyzed (see AnalysisTime, InferredSites and TruncatedErrors).
+	stats *packageStats
+	// warnCategories is the set of diagnostic categories (see the diagnostic.Category*
+	// constants) that are reported as warnings instead of errors.
+	warnCategories map[string]bool
+	// OutputFormat is the format of the additional output of the diagnostics (see the
+	// OutputFormat* constants), the diagnostics are always reported to the driver as well.
+	OutputFormat string
+	// OutputFile is the path of the file that the additional output is written to.
+	OutputFile string
+	// SortOrder is the order of the diagnostics of each package, in which they are written to the
+	// additional output and reported to the driver (see the Sort* constants).
+	SortOrder string
+	// MaxErrorsPerPackage is the maximum number of diagnostics of each package reported to the
+	// driver, where the rest are dropped with a note appended to the last reported one. The errors
+	// are kept in preference to the warnings, and the additional outputs are not truncated. The
+	// dropped errors are recorded (see TruncatedErrors) such that the drivers can still count the
+	// true total. Zero means no limit.
+	MaxErrorsPerPackage int
+	// Baseline is the path of the baseline file, where the diagnostics recorded in it are
+	// suppressed such that only new diagnostics are reported. Empty means no baseline is used.
+	Baseline string
+	// WriteBaseline indicates whether all current diagnostics should be written to the baseline
+	// file (instead of being suppressed by it).
+	WriteBaseline bool
+	// SummaryFile is the path of the file that the summary of the run (i.e., the numbers of the
+	// reported errors and the analyzed packages, the error threshold, and the number of
+	// dereferences per nil source) is written to in JSON. Empty means no summary is written. Note
+	// that the summary is only written by the standalone NilAway driver.
+	SummaryFile string
+	// ReportHTML is the path of the file that a browsable HTML report of the diagnostics (grouped
+	// by package, along with their nil flows and the source snippets) is written to. Empty means
+	// no report is written.
+	ReportHTML string
+	// PathBase is the directory that the file paths in the diagnostics are printed relative to, or
+	// PathBaseModule for the root of the module of the package being analyzed. Paths outside of it
+	// are printed as absolute paths. Empty means the paths are printed as-is.
+	PathBase string
+	// pathBaseDir is the absolute directory resolved from PathBase (see RelativePath).
+	pathBaseDir string
+	// CacheDir is the directory where the results of the inference of each package are cached
+	// across runs, keyed by the content hash of the package and its upstream dependencies. Empty
+	// means no cache is used.
+	CacheDir string
+	// ReportUndetermined indicates whether the exported sites whose nilability cannot be
+	// inferred should be reported (instead of the regular diagnostics), such that users know
+	// where to add annotations first.
+	ReportUndetermined bool
+	// StrictExported indicates whether the exported params, results, receivers and fields whose
+	// nilability is not determined after inference should be reported as errors (in addition to
+	// the regular diagnostics), such that the public API carries explicit nilability decisions
+	// instead of relying on the defaults.
+	StrictExported bool
+	// BoundaryOnly indicates whether only the potential nil panics concerning the exported sites
+	// (e.g., nil passed to an exported nonnil param, or nil returned from an exported function for
+	// a nonnil result) are reported, suppressing the ones involving internal sites only. This is
+	// useful for reviewing the nilability contracts of the public API.
+	BoundaryOnly bool
+	// Explain indicates whether each potential nil panic is reported with a verbose explanation
+	// of how NilAway reached the conclusion, i.e., the nil source, the assertion behind each step
+	// of the nil flow, and the dereference point. This is meant for understanding (or debugging)
+	// the surprising diagnostics, and is disabled by default to keep the diagnostics concise.
+	Explain bool
+	// Stubs are the nilability overrides loaded from the stub file (see Stub).
+	Stubs []Stub
+	// GroupErrors indicates whether the diagnostics caused by the same root nil source should be
+	// reported as a single diagnostic, with the other conflict points listed as related locations.
+	GroupErrors bool
+	// UnmarshalNilable indicates whether the pointer fields of the structs populated by
+	// `json.Unmarshal` (or `json.Decoder.Decode`) are considered nilable, since they are left nil
+	// for the keys absent from the input. This is a heuristic limited to the calls that can be
+	// identified syntactically, hence it is opt-in.
+	UnmarshalNilable bool
+	// ReflectNilable indicates whether the pointers asserted from the dynamic values of
+	// `reflect.Value`s (e.g., `reflect.ValueOf(p).Interface().(*T)`) are considered nilable, since
+	// the nilability of the reflected values is not tracked. Similar to UnmarshalNilable, this is a
+	// heuristic limited to the patterns that can be identified syntactically, hence it is opt-in.
+	ReflectNilable bool
+	// ConstructorFields indicates whether the nilability of the pointer fields of the structs built
+	// by constructors (e.g., `func NewT() *T { return &T{...} }`) is seeded from the composite
+	// literals they return: a field set by every constructor is nonnil, and a field left unset by
+	// any of them is nilable. This is a heuristic assuming the structs are never built otherwise,
+	// hence it is opt-in.
+	ConstructorFields bool
+	// ExportInScopeOnly indicates whether the leaf sites of the out-of-scope packages (i.e., the
+	// sites without implications to or from the other exported sites) are dropped from the
+	// exported facts, which shrinks the build artifacts when many third-party packages are
+	// referenced. The trade-off is that the nilability of such sites inferred in this package is
+	// not visible to the downstream packages, which may hence infer it differently.
+	ExportInScopeOnly bool
+	// MaxSites is the maximum number of sites in the inferred map of a package, beyond which no
+	// new undetermined sites are added and the analysis of the package is truncated. This trades
+	// the completeness of the analysis for bounded memory usage on very large packages. Zero means
+	// no limit.
+	MaxSites int
+	// MaxChainDepth is the maximum number of hops (i.e., assertions) in the nil flow of a potential
+	// nil panic, from the determined nil source to the point of dereference, beyond which the
+	// diagnostic is considered a long chain (see diagnostic.CategoryLongChain). Such diagnostics
+	// are suppressed, unless the category is reported as a warning (see IsWarning). Zero means no
+	// limit.
+	MaxChainDepth int
+	// ProfilePackages indicates whether the wall-clock time spent in each sub-analyzer is recorded
+	// for each package (see Profiled and PackageProfiles), such that the packages dominating the
+	// runtime can be identified. Note that the summary is only printed by the standalone NilAway
+	// driver.
+	ProfilePackages bool
+	// RequireSuppressionReason indicates whether the nolint directives suppressing NilAway
+	// diagnostics must be justified by a reason, i.e., SuppressionReasonDelimiter followed by at
+	// least SuppressionReasonMinLength characters after the directive (e.g.,
+	// "//nolint:nilaway // external API, see JIRA-123"). Unjustified directives still suppress
+	// diagnostics, but are reported themselves.
+	RequireSuppressionReason bool
+	// SuppressionReasonDelimiter is the delimiter that introduces the reason of a nolint directive.
+	SuppressionReasonDelimiter string
+	// SuppressionReasonMinLength is the minimum length of the reason of a nolint directive.
+	SuppressionReasonMinLength int
+	// ReportRedundantChecks indicates whether the nil checks that can never be true (or never be
+	// false), i.e., the ones on the sites determined to be nonnil by inference, are reported as
+	// cleanup hints (see diagnostic.CategoryRedundantCheck).
+	ReportRedundantChecks bool
+	// Focus is the path of the file that the analysis is focused on for fast local feedback (see
+	// IsInFocus). Empty means no file is focused.
+	Focus string
+	// focusFile is the absolute path resolved from Focus.
+	focusFile string
+	// FocusFunc is the qualified name of the function (see FuncName) that the analysis is focused
+	// on for fast local feedback (see IsInFocus). Empty means no function is focused.
+	FocusFunc string
+	// DumpSites is the regular expression of the sites (matched against their full string
+	// representations) that are dumped to DumpSitesFile after the selection of the sites to export,
+	// such that the facts of a specific cross-package issue can be inspected. Nil means no sites
+	// are dumped.
+	DumpSites *regexp.Regexp
+	// DumpSitesFile is the path of the file that the sites matching DumpSites are written to.
+	DumpSitesFile string
+	// RecordFile is the path of the file that the complete inputs of the inference of all packages
+	// are recorded to (see inference.Session), such that the inference can be replayed offline
+	// without the source. Empty means nothing is recorded.
+	RecordFile string
+}
+
+const (
+	// OutputFormatText is the default output format, where diagnostics are only reported to the
+	// driver to be printed in human-readable text.
+	OutputFormatText = "text"
+	// OutputFormatSARIF additionally writes the diagnostics to OutputFile in SARIF 2.1.0 format.
+	OutputFormatSARIF = "sarif"
+	// OutputFormatJSONL additionally streams the diagnostics to OutputFile (or stdout if it is
+	// "-") in JSON Lines format, one self-contained JSON object per diagnostic, as soon as the
+	// analysis of each package finishes.
+	OutputFormatJSONL = "jsonl"
+)
+
+// _defaultOutputFiles is the default output file for each output format that writes to a file.
+var _defaultOutputFiles = map[string]string{
+	OutputFormatSARIF: "nilaway.sarif",
+	OutputFormatJSONL: "nilaway.jsonl",
+}
+
+const (
+	// ExternalReturnsNonnil is the default nilability of the pointer results of the functions in
+	// packages that are out of scope.
+	ExternalReturnsNonnil = "nonnil"
+	// ExternalReturnsNilable treats the pointer results of the functions in packages that are out
+	// of scope as nilable.
+	ExternalReturnsNilable = "nilable"
+)
+
+const (
+	// TestFileModeAnalyze is the default mode, where the test files are analyzed like any other files.
+	TestFileModeAnalyze = "analyze"
+	// TestFileModeSkip excludes the test files from analysis.
+	TestFileModeSkip = "skip"
+	// TestFileModeRelaxed analyzes the test files with relaxed rules, where the calls that stop the
+	// test early (e.g., `t.Fatal` and `require.FailNow`) are treated as if they never return, such
+	// that the dereferences guarded by them are not reported.
+	TestFileModeRelaxed = "relaxed"
+)
+
+const (
+	// SortPosition is the default order of the diagnostics, by file path, line, column and then
+	// message.
+	SortPosition = "position"
+	// SortCategory orders the diagnostics by their categories (see the diagnostic.Category*
+	// constants) first, and then by position.
+	SortCategory = "category"
+	// SortSeverity orders the errors before the warnings (see WarnCategoriesFlag), and then by
+	// position.
+	SortSeverity = "severity"
+)
+
+// PathBaseModule is the value of PathBase that makes the file paths in the diagnostics relative to
+// the root of the module (i.e., the directory containing "go.mod") of the package being analyzed.
+const PathBaseModule = "module"
+
+// _defaultBaselineFile is the default path of the baseline file when writing the baseline.
+const _defaultBaselineFile = "nilaway-baseline.json"
+
+// setOutput validates and sets the output format and the output file, where an empty file falls
+// back to the default file of the format.
+func (c *Config) setOutput(format, file string) error {
+	if format != OutputFormatText {
+		if _, ok := _defaultOutputFiles[format]; !ok {
+			return fmt.Errorf("unknown output format %q", format)
+		}
+	}
+	if file == "" {
+		file = _defaultOutputFiles[format]
+	}
+	c.OutputFormat, c.OutputFile = format, file
+	return nil
+}
+
+// _regexpPrefix is the prefix for entries in the include / exclude package lists that should be
+// interpreted as regular expressions instead of package prefixes.
+const _regexpPrefix = "re:"
+
+// pkgPattern is an entry in the include / exclude package lists. It is either a plain package
+// prefix, or a regular expression (for entries prefixed with "re:") matched against the full
+// package path.
+type pkgPattern struct {
+	// prefix is the package prefix to match, only used if re is nil.
+	prefix string
+	// re is the compiled regular expression, cached here to avoid recompiling on every match.
+	re *regexp.Regexp
+}
+
+// match returns true iff the package path matches the pattern.
+func (p pkgPattern) match(path string) bool {
+	if p.re != nil {
+		return p.re.MatchString(path)
+	}
+	return strings.HasPrefix(path, p.prefix)
+}
+
+// parsePkgPatterns parses the list of entries to package patterns, returning an error if any
+// regular expression is invalid.
+func parsePkgPatterns(entries []string) ([]pkgPattern, error) {
+	patterns := make([]pkgPattern, 0, len(entries))
+	for _, e := range entries {
+		expr, ok := strings.CutPrefix(e, _regexpPrefix)
+		if !ok {
+			patterns = append(patterns, pkgPattern{prefix: e})
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q in package list: %w", expr, err)
+		}
+		patterns = append(patterns, pkgPattern{re: re})
+	}
+	return patterns, nil
+}
+
+// pkgRule is an entry in the ordered list of package rules, which includes (or excludes) the
+// packages matching its pattern.
+type pkgRule struct {
+	pattern pkgPattern
+	include bool
+}
+
+// String returns the rule in the form it is configured, e.g., "+github.com/acme" or
+// "-re:_mock$".
+func (r pkgRule) String() string {
+	sign := "-"
+	if r.include {
+		sign = "+"
+	}
+	if r.pattern.re != nil {
+		return sign + _regexpPrefix + r.pattern.re.String()
+	}
+	return sign + r.pattern.prefix
+}
+
+// parsePkgRules parses the list of entries of the form "+<pattern>" (include) or "-<pattern>"
+// (exclude) to package rules, where the patterns follow the same syntax as the entries in the
+// include / exclude package lists (see parsePkgPatterns).
+func parsePkgRules(entries []string) ([]pkgRule, error) {
+	rules := make([]pkgRule, 0, len(entries))
+	for _, e := range entries {
+		if e == "" || (e[0] != '+' && e[0] != '-') {
+			return nil, fmt.Errorf("invalid package rule %q, expected \"+<pattern>\" or \"-<pattern>\"", e)
+		}
+		patterns, err := parsePkgPatterns([]string{e[1:]})
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pkgRule{pattern: patterns[0], include: e[0] == '+'})
+	}
+	return rules, nil
+}
+
+// packageDefault is a rule setting the default nilability of the unannotated sites in the
+// packages with the given prefix.
+type packageDefault struct {
+	prefix  string
+	nilable bool
+}
+
+// parsePackageDefaults parses the list of entries of the form "<package prefix>:nilable" or
+// "<package prefix>:nonnil" to package default rules.
+func parsePackageDefaults(entries []string) ([]packageDefault, error) {
+	defaults := make([]packageDefault, 0, len(entries))
+	for _, e := range entries {
+		prefix, nilability, ok := strings.Cut(e, ":")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("invalid package default %q, expected \"<package prefix>:nilable|nonnil\"", e)
+		}
+		switch nilability {
+		case "nilable":
+			defaults = append(defaults, packageDefault{prefix: prefix, nilable: true})
+		case "nonnil":
+			defaults = append(defaults, packageDefault{prefix: prefix, nilable: false})
+		default:
+			return nil, fmt.Errorf("invalid nilability %q in package default %q, expected \"nilable\" or \"nonnil\"", nilability, e)
+		}
+	}
+	return defaults, nil
+}
+
+// PackageDefaultNilable returns the default nilability of the unannotated sites in the passed
+// package as configured by the most specific (i.e., longest) matching package prefix. The ok
+// result is false if no rule matches, in which case the global defaults apply.
+func (c *Config) PackageDefaultNilable(pkg *types.Package) (nilable bool, ok bool) {
+	if pkg == nil {
+		return false, false
+	}
+
+	longest := -1
+	for _, d := range c.packageDefaults {
+		if strings.HasPrefix(pkg.Path(), d.prefix) && len(d.prefix) > longest {
+			longest, nilable = len(d.prefix), d.nilable
+		}
+	}
+	return nilable, longest >= 0
+}
+
+// parseExternalReturns parses the nilability of the pointer results of the functions in packages
+// that are out of scope, which is either "nilable" or "nonnil".
+func parseExternalReturns(nilability string) (bool, error) {
+	switch nilability {
+	case ExternalReturnsNilable:
+		return true, nil
+	case ExternalReturnsNonnil:
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid external returns %q, expected %q or %q",
+			nilability, ExternalReturnsNilable, ExternalReturnsNonnil)
+	}
+}
+
+// parseTestFileMode validates the mode of analyzing the test files.
+func parseTestFileMode(mode string) (string, error) {
+	switch mode {
+	case TestFileModeAnalyze, TestFileModeSkip, TestFileModeRelaxed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid test file mode %q, expected %q, %q or %q",
+			mode, TestFileModeAnalyze, TestFileModeSkip, TestFileModeRelaxed)
+	}
+}
+
+// isTestFile returns true iff the file is a test file, i.e., its name ends with "_test.go".
+func (c *Config) isTestFile(file *ast.File) bool {
+	if c.fset == nil {
+		return false
+	}
+	f := c.fset.File(file.Pos())
+	return f != nil && strings.HasSuffix(f.Name(), "_test.go")
+}
+
+// IsRelaxedTestFile returns true iff the file is a test file that should be analyzed with relaxed
+// rules (see TestFileModeRelaxed).
+func (c *Config) IsRelaxedTestFile(file *ast.File) bool {
+	return c.testFileMode == TestFileModeRelaxed && c.isTestFile(file)
+}
+
+// resolvePathBase resolves PathBase to an absolute directory for the package being analyzed.
+func (c *Config) resolvePathBase(pass *analysis.Pass) error {
+	c.pathBaseDir = ""
+	switch c.PathBase {
+	case "":
+		return nil
+	case PathBaseModule:
+		if len(pass.Files) == 0 {
+			return nil
+		}
+		file := pass.Fset.File(pass.Files[0].Pos())
+		if file == nil {
+			return nil
+		}
+		root, ok := findModuleRoot(filepath.Dir(file.Name()))
+		if !ok {
+			return fmt.Errorf("cannot find the module root of package %q", pass.Pkg.Path())
+		}
+		c.pathBaseDir = root
+	default:
+		dir, err := filepath.Abs(c.PathBase)
+		if err != nil {
+			return fmt.Errorf("resolve path base %q: %w", c.PathBase, err)
+		}
+		c.pathBaseDir = dir
+	}
+	return nil
+}
+
+// RelativePath returns the file path relative to the path base (see PathBase), or the absolute
+// path if the file is outside of it, such that the paths are never printed as chains of "..".
+// The file path is returned as-is if no path base is configured, or if it cannot be made absolute.
+func (c *Config) RelativePath(filename string) string {
+	if c.pathBaseDir == "" {
+		return filename
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(c.pathBaseDir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return rel
+}
+
+// ExternalReturnsNilable returns true iff the pointer results of the functions in packages that
+// are out of scope (see IsPkgInScope) should be treated as nilable. Otherwise, they are
+// optimistically treated as nonnil since their implementations are not analyzed.
+func (c *Config) ExternalReturnsNilable() bool {
+	return c.externalReturnsNilable
 }
 
 // IsPkgInScope returns true iff the passed package is in scope for analysis, i.e., it is in the
-// configured include list but not in the exclude list.
+// configured include list but not in the exclude list. If skipVendor is set, the vendored
+// packages are never in scope.
 func (c *Config) IsPkgInScope(pkg *types.Package) bool {
 	if pkg == nil {
 		return false
 	}
+	return c.IsPkgPathInScope(pkg.Path())
+}
 
+// IsPkgPathInScope is the same as IsPkgInScope, but takes the path of the package instead.
+func (c *Config) IsPkgPathInScope(path string) bool {
+	if c.skipVendor && isVendored(path) {
+		return false
+	}
+
+	// The scope is decided by an ordered list of include / exclude rules, where the last rule
+	// matching the package wins and the packages matching no rule are out of scope. The list is
+	// evaluated in the following order (from lowest to highest precedence):
+	// (1) the include list, where each entry is an include rule;
+	// (2) the exclude list, where each entry is an exclude rule, such that the exclude list always
+	//     takes precedence over the include list;
+	// (3) the package rules (see PkgRulesFlag) in the configured order, which can re-include the
+	//     packages excluded before, e.g., "-github.com/acme/internal,+github.com/acme/internal/public".
+	inScope := false
 	for _, include := range c.includePkgs {
-		if !strings.HasPrefix(pkg.Path(), include) {
-			continue
+		if include.match(path) {
+			inScope = true
+			break
+		}
+	}
+	for _, exclude := range c.excludePkgs {
+		if exclude.match(path) {
+			inScope = false
+			break
+		}
+	}
+	for _, rule := range c.pkgRules {
+		if rule.pattern.match(path) {
+			inScope = rule.include
 		}
+	}
+	return inScope
+}
 
-		for _, exclude := range c.excludePkgs {
-			if strings.HasPrefix(pkg.Path(), exclude) {
-				return false
-			}
+// IsPkgPathDistrusted returns true iff the package is distrusted, i.e., the nilability of its sites
+// recorded in the facts imported from the upstream packages (e.g., the over-permissive nilable
+// annotations shipped by a third-party package) is ignored. The sites of the distrusted packages
+// are then treated like those of the packages never analyzed, e.g., their pointer results follow
+// ExternalReturnsNilable.
+func (c *Config) IsPkgPathDistrusted(path string) bool {
+	for _, distrust := range c.distrustPkgs {
+		if distrust.match(path) {
+			return true
 		}
+	}
+	return false
+}
+
+// isVendored returns true iff the package path has a "vendor" segment (e.g., "foo/vendor/bar" or
+// "vendor/bar"), where the segments merely starting with "vendor" (e.g., "foo/vendored") do not
+// count.
+func isVendored(pkgPath string) bool {
+	return strings.HasPrefix(pkgPath, "vendor/") || strings.Contains(pkgPath, "/vendor/")
+}
+
+// FuncName returns the qualified name of the function in the form of "pkgpath.FuncName" for
+// functions and "pkgpath.(*Recv).Method" (or "pkgpath.(Recv).Method" for value receivers) for
+// methods, which is used for matching the entries of the exclude function list. For methods of
+// generic types, the type parameters of the receiver are omitted.
+func FuncName(fn *types.Func) string {
+	pkgPath := ""
+	if fn.Pkg() != nil {
+		pkgPath = fn.Pkg().Path()
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return pkgPath + "." + fn.Name()
+	}
+
+	recv, ptr := sig.Recv().Type(), ""
+	if p, ok := recv.(*types.Pointer); ok {
+		recv, ptr = p.Elem(), "*"
+	}
+	recvName := recv.String()
+	if named, ok := recv.(*types.Named); ok {
+		recvName = named.Obj().Name()
+	}
+	return pkgPath + ".(" + ptr + recvName + ")." + fn.Name()
+}
+
+// IsFuncInScope returns true iff the diagnostics reported within the passed function should be
+// reported, i.e., the function is not in the configured exclude function list. Note that only
+// the diagnostics reported within the function body are affected, the inferred nilability of the
+// function (e.g., of its results) is still used for the analysis of other functions.
+func (c *Config) IsFuncInScope(fn *types.Func) bool {
+	if fn == nil || len(c.excludeFuncs) == 0 {
 		return true
 	}
+	return !c.excludeFuncs[FuncName(fn)]
+}
 
+// HasFocus returns true iff the analysis is focused on a file or a function (see IsInFocus).
+func (c *Config) HasFocus() bool {
+	return c.Focus != "" || c.FocusFunc != ""
+}
+
+// IsPkgInFocus returns true iff the package of the pass contains the focused file or function
+// (see IsInFocus), or no focus is configured. The packages out of focus are analyzed as usual such
+// that their facts are available for the focused package, but none of their diagnostics are
+// reported.
+func (c *Config) IsPkgInFocus(pass *analysis.Pass) bool {
+	if !c.HasFocus() {
+		return true
+	}
+	for _, file := range pass.Files {
+		if c.IsInFocus(pass, file, file.Package) {
+			return true
+		}
+		for _, decl := range file.Decls {
+			if c.IsInFocus(pass, file, decl.Pos()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsInFocus returns true iff the position in the file is within the focused scope, i.e., in the
+// focused file (Focus) and within the declaration of the focused function (FocusFunc), if
+// configured. It always returns true if no focus is configured.
+func (c *Config) IsInFocus(pass *analysis.Pass, file *ast.File, pos token.Pos) bool {
+	if !c.HasFocus() {
+		return true
+	}
+	if c.focusFile != "" {
+		if tf := pass.Fset.File(file.Pos()); tf == nil || tf.Name() != c.focusFile {
+			return false
+		}
+	}
+	if c.FocusFunc == "" {
+		return true
+	}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || pos < funcDecl.Pos() || pos >= funcDecl.End() {
+			continue
+		}
+		fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+		return ok && FuncName(fn) == c.FocusFunc
+	}
 	return false
 }
 
+// IsWarning returns true iff the diagnostics of the passed category should be reported as
+// warnings, i.e., the category is in the configured list of warning categories. Uncategorized
+// diagnostics (i.e., with an empty category) are always errors.
+func (c *Config) IsWarning(category string) bool {
+	return category != "" && c.warnCategories[category]
+}
+
+// HasChangedPkgs returns true iff the list of changed packages is configured, i.e., only the
+// diagnostics of the changed packages and their affected dependents should be reported.
+func (c *Config) HasChangedPkgs() bool {
+	return c.changedPkgs != nil
+}
+
+// ShouldReanalyze returns true iff the passed package is in the configured list of changed
+// packages, or if the list is not configured at all. Note that the dependents of the changed
+// packages must be reanalyzed as well if the exported sites of the changed packages are altered,
+// which cannot be decided here and is handled by the accumulation analyzer instead.
+func (c *Config) ShouldReanalyze(pkg *types.Package) bool {
+	if c.changedPkgs == nil {
+		return true
+	}
+	return pkg != nil && c.changedPkgs[pkg.Path()]
+}
+
+// parseChangedPkgs parses the value of the changed package flag, which is either a
+// comma-separated list of package paths, or "@<file>" to read the list (separated by commas or
+// whitespaces) from the file.
+func parseChangedPkgs(value string) (map[string]bool, error) {
+	if name, ok := strings.CutPrefix(value, "@"); ok {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("read changed package list: %w", err)
+		}
+		value = string(content)
+	}
+	pkgs := make(map[string]bool)
+	for _, p := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		pkgs[p] = true
+	}
+	return pkgs, nil
+}
+
+// parseNameSet parses the list of names (e.g., qualified function names) to a set, where the
+// surrounding spaces are trimmed and the empty entries are skipped.
+func parseNameSet(entries []string) map[string]bool {
+	funcs := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e = strings.TrimSpace(e); e != "" {
+			funcs[e] = true
+		}
+	}
+	return funcs
+}
+
+// Fingerprint returns a string that uniquely identifies the options affecting the analysis
+// results (in contrast to, e.g., the output options), which is used for keying the cached results.
+func (c *Config) Fingerprint() string {
+	patterns := func(ps []pkgPattern) []string {
+		strs := make([]string, len(ps))
+		for i, p := range ps {
+			if p.re != nil {
+				strs[i] = _regexpPrefix + p.re.String()
+			} else {
+				strs[i] = p.prefix
+			}
+		}
+		return strs
+	}
+	funcs := make([]string, 0, len(c.excludeFuncs))
+	for f := range c.excludeFuncs {
+		funcs = append(funcs, f)
+	}
+	sort.Strings(funcs)
+
+	rules := make([]string, len(c.pkgRules))
+	for i, r := range c.pkgRules {
+		rules[i] = r.String()
+	}
+
+	return fmt.Sprintf("include=%q exclude=%q rules=%q distrust=%q docstrings=%q docstring-modes=%t,%t defaults=%v build-tags=%t skip-vendor=%t external-returns=%t funcs=%q report-undetermined=%t strict-exported=%t boundary-only=%t explain=%t group-errors=%t stubs=%v unmarshal-nilable=%t reflect-nilable=%t constructor-fields=%t export-in-scope-only=%t max-sites=%d max-chain-depth=%d test-file-mode=%s path-base=%q suppression-reason=%t,%q,%d redundant-checks=%t",
+		patterns(c.includePkgs), patterns(c.excludePkgs), rules, patterns(c.distrustPkgs), c.excludeFileDocStrings,
+		c.docStringIgnoreCase, c.docStringWholeWord, c.packageDefaults,
+		c.respectBuildTags, c.skipVendor, c.externalReturnsNilable, funcs, c.ReportUndetermined, c.StrictExported, c.BoundaryOnly, c.Explain, c.GroupErrors, c.Stubs, c.UnmarshalNilable, c.ReflectNilable, c.ConstructorFields, c.ExportInScopeOnly, c.MaxSites, c.MaxChainDepth, c.testFileMode, c.pathBaseDir,
+		c.RequireSuppressionReason, c.SuppressionReasonDelimiter, c.SuppressionReasonMinLength, c.ReportRedundantChecks)
+}
+
 // IsFileInScope returns true iff we should analyze the file. It checks the docstring of the file
-// and returns false if any of the strings in ExcludeFileDocStrings appear in the file docstring.
+// and returns false if any of the strings in ExcludeFileDocStrings appear in the file docstring
+// (see matchDocString for the matching modes).
+// If respectBuildTags is set, it also returns false if the build constraints of the file are not
+// satisfied by the current build context.
 func (c *Config) IsFileInScope(file *ast.File) bool {
+	if c.respectBuildTags && !matchBuildConstraints(file) {
+		return false
+	}
+	if c.testFileMode == TestFileModeSkip && c.isTestFile(file) {
+		return false
+	}
+
 	// Fast return if there is no exclude list.
 	if len(c.excludeFileDocStrings) == 0 {
 		return true
@@ -78,8 +795,9 @@ func (c *Config) IsFileInScope(file *ast.File) bool {
 			continue
 		}
 
+		text := comment.Text()
 		for _, exclude := range c.excludeFileDocStrings {
-			if strings.Contains(comment.Text(), exclude) {
+			if matchDocString(text, exclude, c.docStringIgnoreCase, c.docStringWholeWord) {
 				return false
 			}
 		}
@@ -87,6 +805,43 @@ func (c *Config) IsFileInScope(file *ast.File) bool {
 	return true
 }
 
+// matchDocString returns true iff the docstring appears in the text. By default, the docstring may
+// appear anywhere as a substring (e.g., "generated" matches "regenerated"). If ignoreCase is set,
+// the letters are compared case-insensitively. If wholeWord is set, the docstring must appear as a
+// standalone token, i.e., it must not be adjacent to letters, digits or underscores in the text
+// (e.g., "@generated" matches "// @generated" but neither "x@generated" nor "@generated_by").
+// Note that the modes are configured globally for all docstrings rather than per docstring.
+func matchDocString(text, docString string, ignoreCase, wholeWord bool) bool {
+	if ignoreCase {
+		text, docString = strings.ToLower(text), strings.ToLower(docString)
+	}
+	if !wholeWord {
+		return strings.Contains(text, docString)
+	}
+	if docString == "" {
+		return true
+	}
+
+	isWordChar := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], docString)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(docString)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		// DecodeRune returns RuneError for empty strings, which is not a word character.
+		if !isWordChar(before) && !isWordChar(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+}
+
 const _doc = `nilaway_config analyzer is responsible to take configurations (flags) for NilAway execution.
 It does not run any analysis and is only meant to be used as a dependency for the sub-analyzers of 
 NilAway to share the same configurations. 
@@ -115,12 +870,143 @@ var Analyzer = &analysis.Analyzer{
 const (
 	// PrettyPrintFlag is the flag for pretty printing the error messages.
 	PrettyPrintFlag = "pretty-print"
+	// QuietFlag is the flag for suppressing the output other than the diagnostics.
+	QuietFlag = "quiet"
+	// CollapseDuplicatesFlag is the flag for collapsing the diagnostics with the same message in
+	// the same file.
+	CollapseDuplicatesFlag = "collapse-duplicates"
+	// MessageTemplateFlag is the flag for the template of the messages of the reported diagnostics.
+	MessageTemplateFlag = "message-template"
 	// IncludePkgsFlag is the flag name for include package prefixes.
 	IncludePkgsFlag = "include-pkgs"
 	// ExcludePkgsFlag is the flag name for exclude package prefixes.
 	ExcludePkgsFlag = "exclude-pkgs"
+	// PkgRulesFlag is the flag name for the ordered include / exclude package rules.
+	PkgRulesFlag = "pkg-rules"
+	// DistrustPkgsFlag is the flag name for the packages whose sites are ignored in the upstream
+	// facts.
+	DistrustPkgsFlag = "distrust-pkgs"
 	// ExcludeFileDocStringsFlag is the flag name for the docstrings that exclude files from analysis.
 	ExcludeFileDocStringsFlag = "exclude-file-docstrings"
+	// ExcludeFileDocStringsIgnoreCaseFlag is the flag name for matching the docstrings that exclude
+	// files from analysis case-insensitively.
+	ExcludeFileDocStringsIgnoreCaseFlag = "exclude-file-docstrings-ignore-case"
+	// ExcludeFileDocStringsWholeWordFlag is the flag name for matching the docstrings that exclude
+	// files from analysis only as standalone tokens.
+	ExcludeFileDocStringsWholeWordFlag = "exclude-file-docstrings-whole-word"
+	// ExcludeFuncsFlag is the flag name for the qualified names of the functions to exclude from
+	// reporting.
+	ExcludeFuncsFlag = "exclude-funcs"
+	// WarnCategoriesFlag is the flag name for the diagnostic categories reported as warnings.
+	WarnCategoriesFlag = "warn-categories"
+	// ChangedPkgsFlag is the flag name for the list of packages changed since the last run.
+	ChangedPkgsFlag = "changed-pkgs"
+	// ConfigFileFlag is the flag name for the path to the configuration file.
+	ConfigFileFlag = "config"
+	// StubsFlag is the flag name for the path to the stub file.
+	StubsFlag = "stubs"
+	// PackageDefaultsFlag is the flag name for the per-package default nilability rules.
+	PackageDefaultsFlag = "package-defaults"
+	// RespectBuildTagsFlag is the flag name for excluding files whose build constraints are not
+	// satisfied.
+	RespectBuildTagsFlag = "respect-build-tags"
+	// SkipVendorFlag is the flag name for excluding the vendored packages from analysis.
+	SkipVendorFlag = "skip-vendor"
+	// SortFlag is the flag name for the order of the diagnostics.
+	SortFlag = "sort"
+	// MaxErrorsPerPackageFlag is the flag name for the maximum number of diagnostics of each
+	// package reported to the driver.
+	MaxErrorsPerPackageFlag = "max-errors-per-package"
+	// OutputFormatFlag is the flag name for the format of the additional output.
+	OutputFormatFlag = "output-format"
+	// OutputFileFlag is the flag name for the path of the additional output file.
+	OutputFileFlag = "output-file"
+	// BaselineFlag is the flag name for the path of the baseline file.
+	BaselineFlag = "baseline"
+	// WriteBaselineFlag is the flag name for writing the current diagnostics to the baseline file.
+	WriteBaselineFlag = "write-baseline"
+	// SummaryFileFlag is the flag name for the path of the summary file.
+	SummaryFileFlag = "summary-file"
+	// ReportHTMLFlag is the flag name for the path of the HTML report.
+	ReportHTMLFlag = "report-html"
+	// CacheDirFlag is the flag name for the directory of the cached inference results.
+	CacheDirFlag = "cache-dir"
+	// ReportUndeterminedFlag is the flag name for reporting the exported sites whose nilability
+	// cannot be inferred.
+	ReportUndeterminedFlag = "report-undetermined"
+	// StrictExportedFlag is the flag name for reporting the exported sites whose nilability is
+	// not determined after inference as errors.
+	StrictExportedFlag = "strict-exported"
+	// BoundaryOnlyFlag is the flag name for reporting the potential nil panics concerning the
+	// exported sites only.
+	BoundaryOnlyFlag = "boundary-only"
+	// ExplainFlag is the flag name for explaining each potential nil panic verbosely.
+	ExplainFlag = "explain"
+	// GroupErrorsFlag is the flag name for grouping the diagnostics by their root nil sources.
+	GroupErrorsFlag = "group-errors"
+	// TestFileModeFlag is the flag name for the mode of analyzing the test files.
+	TestFileModeFlag = "test-file-mode"
+	// PathBaseFlag is the flag name for the directory that the file paths in the diagnostics are
+	// printed relative to.
+	PathBaseFlag = "path-base"
+	// UnmarshalNilableFlag is the flag name for considering the pointer fields populated by
+	// `json.Unmarshal` as nilable.
+	UnmarshalNilableFlag = "unmarshal-nilable"
+	// ReflectNilableFlag is the flag name for considering the pointers asserted from the dynamic
+	// values of `reflect.Value`s as nilable.
+	ReflectNilableFlag = "reflect-nilable"
+	// ConstructorFieldsFlag is the flag name for seeding the nilability of the pointer fields from
+	// the constructors of the structs.
+	ConstructorFieldsFlag = "constructor-fields"
+	// ExportInScopeOnlyFlag is the flag name for dropping the leaf sites of the out-of-scope
+	// packages from the exported facts.
+	ExportInScopeOnlyFlag = "export-in-scope-only"
+	// MaxSitesFlag is the flag name for the maximum number of sites in the inferred map of a package.
+	MaxSitesFlag = "max-sites"
+	// MaxChainDepthFlag is the flag name for the maximum number of hops in the nil flows of the
+	// reported diagnostics.
+	MaxChainDepthFlag = "max-chain-depth"
+	// ExternalReturnsFlag is the flag name for the nilability of the pointer results of the
+	// functions in packages that are out of scope.
+	ExternalReturnsFlag = "external-returns"
+	// ProfilePackagesFlag is the flag name for recording the time spent in each sub-analyzer per
+	// package.
+	ProfilePackagesFlag = "profile-packages"
+	// RequireSuppressionReasonFlag is the flag name for reporting the nolint directives without a
+	// reason.
+	RequireSuppressionReasonFlag = "require-suppression-reason"
+	// SuppressionReasonDelimiterFlag is the flag name for the delimiter introducing the reason of
+	// a nolint directive.
+	SuppressionReasonDelimiterFlag = "suppression-reason-delimiter"
+	// SuppressionReasonMinLengthFlag is the flag name for the minimum length of the reason of a
+	// nolint directive.
+	SuppressionReasonMinLengthFlag = "suppression-reason-min-length"
+	// ReportRedundantChecksFlag is the flag name for reporting the nil checks on the sites
+	// determined to be nonnil.
+	ReportRedundantChecksFlag = "report-redundant-checks"
+	// FocusFlag is the flag name for the file that the analysis is focused on.
+	FocusFlag = "focus"
+	// FocusFuncFlag is the flag name for the function that the analysis is focused on.
+	FocusFuncFlag = "focus-func"
+	// DumpSitesFlag is the flag name for the regular expression of the exported sites to dump.
+	DumpSitesFlag = "dump-sites"
+	// DumpSitesFileFlag is the flag name for the path of the file that the sites are dumped to.
+	DumpSitesFileFlag = "dump-sites-file"
+	// RecordFlag is the flag name for the path of the file that the inference sessions are
+	// recorded to.
+	RecordFlag = "record"
+)
+
+const (
+	// DefaultSuppressionReasonDelimiter is the default delimiter introducing the reason of a nolint
+	// directive, following the convention of golangci-lint (e.g., "//nolint:nilaway // reason").
+	DefaultSuppressionReasonDelimiter = "//"
+	// DefaultSuppressionReasonMinLength is the default minimum length of the reason of a nolint
+	// directive.
+	DefaultSuppressionReasonMinLength = 1
+	// DefaultDumpSitesFile is the default path of the file that the sites matching DumpSites are
+	// written to.
+	DefaultDumpSitesFile = "nilaway-sites.txt"
 )
 
 // newFlagSet returns a flag set to be used in the nilaway config analyzer.
@@ -130,35 +1016,548 @@ func newFlagSet() flag.FlagSet {
 	// We do not keep the returned pointer to the flags because we will not use them directly here.
 	// Instead, we will use the flags through the analyzer's Flags field later.
 	_ = fs.Bool(PrettyPrintFlag, true, "Pretty print the error messages")
-	_ = fs.String(IncludePkgsFlag, "", "Comma-separated list of packages to analyze")
-	_ = fs.String(ExcludePkgsFlag, "", "Comma-separated list of packages to exclude from analysis")
-	_ = fs.String(ExcludeFileDocStringsFlag, "", "Comma-separated list of docstrings to exclude from analysis")
+	_ = fs.Bool(QuietFlag, false, "Suppress the output other than the diagnostics (e.g., the warnings "+
+		"and the package profiles), the exit code is not affected")
+	_ = fs.Bool(CollapseDuplicatesFlag, false, "Report the diagnostics with the same category and message "+
+		"in the same file only once at the first occurrence, along with the number and the lines of the others")
+	_ = fs.String(MessageTemplateFlag, "", "Go text/template that the messages of the reported diagnostics "+
+		"are formatted with, using the fields {{.Position}}, {{.Category}}, {{.RootSite}} (the position of "+
+		"the nil source) and {{.Message}}")
+	_ = fs.String(IncludePkgsFlag, "", "Comma-separated list of packages to analyze, entries "+
+		"prefixed with \"re:\" are interpreted as regular expressions instead of package prefixes, and "+
+		"entries of the form \"@<file>\" are replaced by the lines of the file")
+	_ = fs.String(ExcludePkgsFlag, "", "Comma-separated list of packages to exclude from analysis, "+
+		"entries prefixed with \"re:\" are interpreted as regular expressions instead of package prefixes, "+
+		"and entries of the form \"@<file>\" are replaced by the lines of the file")
+	_ = fs.String(PkgRulesFlag, "", "Comma-separated ordered list of package rules of the form \"+<pattern>\" "+
+		"(include) or \"-<pattern>\" (exclude), evaluated after the include and exclude lists where the last "+
+		"matching rule wins, e.g., \"-github.com/acme/internal,+github.com/acme/internal/public\"")
+	_ = fs.String(DistrustPkgsFlag, "", "Comma-separated list of packages whose nilability recorded in the "+
+		"facts imported from the upstream packages is ignored, such that their sites are treated like those "+
+		"of the packages not analyzed, entries prefixed with \"re:\" are interpreted as regular expressions "+
+		"instead of package prefixes, and entries of the form \"@<file>\" are replaced by the lines of the file")
+	_ = fs.String(ExcludeFileDocStringsFlag, "", "Comma-separated list of docstrings to exclude from analysis, "+
+		"entries of the form \"@@<file>\" are replaced by the lines of the file (a single \"@\" is kept as is "+
+		"since docstrings such as \"@generated\" start with it)")
+	_ = fs.Bool(ExcludeFileDocStringsIgnoreCaseFlag, false, "Match the docstrings to exclude from "+
+		"analysis case-insensitively")
+	_ = fs.Bool(ExcludeFileDocStringsWholeWordFlag, false, "Match the docstrings to exclude from "+
+		"analysis only as standalone tokens (e.g., \"generated\" does not match \"regenerated\")")
+	_ = fs.String(ExcludeFuncsFlag, "", "Comma-separated list of functions whose diagnostics are not "+
+		"reported, of the form \"pkgpath.FuncName\" or \"pkgpath.(*Recv).Method\" (closures are "+
+		"covered by their enclosing functions)")
+	_ = fs.String(StubsFlag, "", "Path to a YAML or JSON stub file that overrides the nilability of "+
+		"the functions, fields and global variables (e.g., of the libraries that are not analyzed)")
+	_ = fs.String(WarnCategoriesFlag, "", "Comma-separated list of diagnostic categories (\"mapread\", "+
+		"\"funcret\", \"fieldaccess\", \"redundantcheck\" and \"longchain\") that are reported as warnings, which do not "+
+		"affect the exit code")
+	_ = fs.String(ChangedPkgsFlag, "", "Comma-separated list (or \"@<file>\" to read the list from a file) "+
+		"of the packages changed since the last run, only the diagnostics of these packages and their "+
+		"dependents affected by the changes are reported")
+	_ = fs.String(PackageDefaultsFlag, "", "Comma-separated list of per-package default nilability "+
+		"rules of the form \"<package prefix>:nilable\" or \"<package prefix>:nonnil\" for unannotated "+
+		"sites, where the longest matching prefix wins")
+	_ = fs.Bool(RespectBuildTagsFlag, false, "Exclude files whose build constraints (\"//go:build\" "+
+		"or \"// +build\" lines) are not satisfied by the current build environment from analysis")
+	_ = fs.Bool(SkipVendorFlag, true, "Exclude the vendored packages (i.e., the ones with a \"vendor\" "+
+		"segment in their paths) from analysis regardless of the include list, if set to false they "+
+		"follow the include and exclude lists like any other packages")
+	_ = fs.String(ConfigFileFlag, "", "Path to a YAML or JSON configuration file, default is "+
+		"to look for \".nilaway.yaml\", \".nilaway.yml\" or \".nilaway.json\" in the module root")
+	_ = fs.String(OutputFormatFlag, OutputFormatText, "Format of the additional output of the diagnostics, "+
+		"one of \"text\" (no additional output), \"sarif\" and \"jsonl\" (JSON Lines streamed per package)")
+	_ = fs.String(SortFlag, SortPosition, "Order of the diagnostics of each package, one of \"position\" "+
+		"(by file path, line and column), \"category\" (by category and then position) and \"severity\" "+
+		"(errors before warnings, and then by position)")
+	_ = fs.Int(MaxErrorsPerPackageFlag, 0, "Maximum number of diagnostics of each package reported to the "+
+		"driver (errors are kept in preference to warnings), where the rest are summarized by a note "+
+		"appended to the last reported one, default is 0 (no limit)")
+	_ = fs.String(OutputFileFlag, "", "Path of the file that the additional output is written to, "+
+		"default is \"nilaway.sarif\" for the \"sarif\" output format and \"nilaway.jsonl\" for the "+
+		"\"jsonl\" output format (which writes to stdout if set to \"-\")")
+	_ = fs.String(BaselineFlag, "", "Path of the baseline file, diagnostics recorded in it are "+
+		"suppressed such that only new diagnostics are reported")
+	_ = fs.Bool(WriteBaselineFlag, false, "Write all current diagnostics to the baseline file "+
+		"instead of suppressing them, default file is \""+_defaultBaselineFile+"\"")
+	_ = fs.String(SummaryFileFlag, "", "Path of the file that the summary of the run is written to "+
+		"in JSON, i.e., {\"errors\": <number of errors>, \"packages\": <number of packages>, "+
+		"\"fail-on\": <error threshold>, \"sources\": {<nil source>: <number of dereferences>}}")
+	_ = fs.String(ReportHTMLFlag, "", "Path of the file that a self-contained HTML report of the "+
+		"diagnostics is written to, grouped by package and showing the nil flows with source snippets")
+	_ = fs.String(CacheDirFlag, "", "Directory where the inference results of the packages are cached "+
+		"across runs, such that unchanged packages (with unchanged dependencies) are not analyzed again")
+	_ = fs.Bool(ReportUndeterminedFlag, false, "Report the exported sites (params, results, fields "+
+		"and receivers) whose nilability cannot be inferred instead of the potential nil panics, such "+
+		"that annotations can be added on the public API first")
+	_ = fs.Bool(StrictExportedFlag, false, "Report the exported params, results, receivers and fields "+
+		"whose nilability is not determined after inference as errors, in addition to the potential "+
+		"nil panics, such that the public API carries explicit nilability annotations")
+	_ = fs.Bool(BoundaryOnlyFlag, false, "Report only the potential nil panics concerning the exported "+
+		"sites (e.g., nil passed to an exported nonnil param or returned for an exported nonnil result), "+
+		"suppressing the ones involving internal sites only")
+	_ = fs.Bool(ExplainFlag, false, "Append to each potential nil panic a verbose explanation of the "+
+		"nil source, the assertion behind each step of the nil flow, and the dereference point")
+	_ = fs.Bool(GroupErrorsFlag, false, "Report a single diagnostic for all potential nil panics "+
+		"caused by the same nil source, with the other panic sites listed as related locations")
+	_ = fs.String(TestFileModeFlag, TestFileModeAnalyze, "Mode of analyzing the test files (\"_test.go\"), "+
+		"one of \"analyze\", \"skip\" and \"relaxed\" (where the calls that stop the test early, e.g., "+
+		"`t.Fatal`, are treated as if they never return)")
+	_ = fs.String(PathBaseFlag, "", "Directory that the file paths in the diagnostics are printed "+
+		"relative to, or \"module\" for the module root of the analyzed packages (paths outside of it "+
+		"are printed as absolute paths), default is to print the paths as-is")
+	_ = fs.Bool(UnmarshalNilableFlag, false, "Consider the (exported) pointer fields of the structs "+
+		"populated by `json.Unmarshal` or `json.Decoder.Decode` as nilable, since they are left nil for the "+
+		"keys absent from the input")
+	_ = fs.Bool(ReflectNilableFlag, false, "Consider the pointers asserted from the dynamic values of "+
+		"`reflect.Value`s (e.g., `reflect.ValueOf(p).Interface().(*T)`) as nilable, since the nilability "+
+		"of the reflected values is not tracked")
+	_ = fs.Bool(ConstructorFieldsFlag, false, "Seed the nilability of the pointer fields of the structs "+
+		"from their constructors (i.e., functions named \"New...\" that always return composite literals of "+
+		"the structs), where the fields set by every constructor are nonnil and the fields left unset by any "+
+		"of them are nilable")
+	_ = fs.Bool(ExportInScopeOnlyFlag, false, "Drop the sites of the out-of-scope packages that have no "+
+		"implications to or from the other exported sites from the exported facts to shrink the build "+
+		"artifacts, at the cost of hiding the nilability inferred for them from the downstream packages")
+	_ = fs.Int(MaxSitesFlag, 0, "Maximum number of sites in the inferred map of a package, beyond which "+
+		"the analysis of the package is truncated (and reported as such) to bound the memory usage, 0 "+
+		"means no limit")
+	_ = fs.Int(MaxChainDepthFlag, 0, "Maximum number of hops in the nil flow of a diagnostic, from the nil "+
+		"source to the point of dereference, beyond which the diagnostic is suppressed (or reported as a warning "+
+		"of category \"longchain\", see -"+WarnCategoriesFlag+") to focus on local findings, 0 means no limit")
+	_ = fs.String(ExternalReturnsFlag, ExternalReturnsNonnil, "Nilability of the pointer results of the "+
+		"functions in packages that are not analyzed (i.e., out of the include list or in the exclude "+
+		"list), one of \"nonnil\" and \"nilable\"")
+	_ = fs.Bool(ProfilePackagesFlag, false, "Record the wall-clock time spent in each sub-analyzer "+
+		"per package and print a summary sorted by the total time (along with the number of inferred "+
+		"sites of each package) at the end of the run")
+	_ = fs.Bool(RequireSuppressionReasonFlag, false, "Report the \"//nolint:nilaway\" (and \"//nolint\") "+
+		"directives that are not justified by a reason after the directive, e.g., "+
+		"\"//nolint:nilaway // external API\"")
+	_ = fs.String(SuppressionReasonDelimiterFlag, DefaultSuppressionReasonDelimiter, "Delimiter that "+
+		"introduces the reason of a nolint directive (see -"+RequireSuppressionReasonFlag+"), e.g., \"reason:\"")
+	_ = fs.Int(SuppressionReasonMinLengthFlag, DefaultSuppressionReasonMinLength, "Minimum length of "+
+		"the reason of a nolint directive (see -"+RequireSuppressionReasonFlag+")")
+	_ = fs.Bool(ReportRedundantChecksFlag, false, "Report the nil checks that can never be true (or "+
+		"never be false) since the checked values are determined to be nonnil by inference, as "+
+		"cleanup hints of category \"redundantcheck\"")
+	_ = fs.String(FocusFlag, "", "Path of the file to focus the analysis on for fast local feedback, "+
+		"where the functions in the other files of its package are not analyzed (their nilability is "+
+		"only known by annotations) and only the diagnostics within the file are reported")
+	_ = fs.String(FocusFuncFlag, "", "Qualified name of the function (e.g., \"github.com/acme/foo.Bar\" "+
+		"or \"github.com/acme/foo.(*T).Method\") to focus the analysis on for fast local feedback, see -"+FocusFlag)
+	_ = fs.String(DumpSitesFlag, "", "Regular expression of the sites to dump for inspecting the facts of a "+
+		"specific cross-package issue, where the sites exported by each package whose string representations "+
+		"match it are written to the file of -"+DumpSitesFileFlag+" (a developer aid that disables the cache)")
+	_ = fs.String(DumpSitesFileFlag, DefaultDumpSitesFile, "Path of the file that the sites matching -"+
+		DumpSitesFlag+" are written to")
+	_ = fs.String(RecordFlag, "", "Path of the file to record the complete inputs of the inference of all "+
+		"packages to, such that the inference can be replayed offline without the source by the -replay "+
+		"flag of the standalone checker for reproducing bug reports (a developer aid that disables the cache)")
 
+	// Record which flags are set explicitly, such that they override the configuration file even
+	// if they are set to their default values (see flagValue).
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Value = &trackedValue{Getter: f.Value.(flag.Getter)}
+	})
 	return *fs
 }
 
+// trackedValue wraps the value of a flag to record whether it has been set. Note that the drivers
+// usually set the values of the analyzer flags directly (e.g., by registering them in their own
+// flag sets) instead of via flag.FlagSet.Set, hence flag.FlagSet.Visit cannot be used instead.
+type trackedValue struct {
+	flag.Getter
+	set bool
+}
+
+// Set sets the value of the flag and records that it has been set.
+func (v *trackedValue) Set(s string) error {
+	if err := v.Getter.Set(s); err != nil {
+		return err
+	}
+	v.set = true
+	return nil
+}
+
+// String returns the value of the flag, which must also work for the zero trackedValue since the
+// flag package calls it to find out the zero values of the flags when printing the defaults.
+func (v *trackedValue) String() string {
+	if v.Getter == nil {
+		return ""
+	}
+	return v.Getter.String()
+}
+
+// IsBoolFlag returns true iff the wrapped flag is a boolean flag, such that it can still be
+// specified without a value (e.g., "-pretty-print").
+func (v *trackedValue) IsBoolFlag() bool {
+	b, ok := v.Getter.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
 func run(pass *analysis.Pass) (any, error) {
-	// Set up default values for the config.
-	conf := &Config{
-		PrettyPrint: true,
-		// If the user does not provide an include list, we give an empty package prefix to catch
-		// all packages.
-		includePkgs: []string{""},
+	// Set up default values for the config, possibly overridden by the configuration file.
+	path, _ := pass.Analyzer.Flags.Lookup(ConfigFileFlag).Value.(flag.Getter).Get().(string)
+	if path == "" {
+		path = discoverConfigFile(pass)
+	}
+	conf := newDefaultConfig()
+	if path != "" {
+		c, err := loadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		conf = c
 	}
 
-	// Override default values if the user provides flags.
-	if prettyPrint, ok := pass.Analyzer.Flags.Lookup(PrettyPrintFlag).Value.(flag.Getter).Get().(bool); ok {
+	// Override the values if the user provides flags. Note that we only consider flags that are
+	// explicitly specified (see flagValue), such that the configuration file is not overridden by
+	// the defaults of the other flags.
+	if prettyPrint, ok := flagValue(pass, PrettyPrintFlag).(bool); ok {
 		conf.PrettyPrint = prettyPrint
 	}
-	if include, ok := pass.Analyzer.Flags.Lookup(IncludePkgsFlag).Value.(flag.Getter).Get().(string); ok && include != "" {
-		conf.includePkgs = strings.Split(include, ",")
+	if quiet, ok := flagValue(pass, QuietFlag).(bool); ok {
+		conf.Quiet = quiet
+	}
+	if collapse, ok := flagValue(pass, CollapseDuplicatesFlag).(bool); ok {
+		conf.CollapseDuplicates = collapse
+	}
+	if messageTemplate, ok := flagValue(pass, MessageTemplateFlag).(string); ok {
+		conf.MessageTemplate = messageTemplate
+	}
+	if include, ok := flagValue(pass, IncludePkgsFlag).(string); ok && include != "" {
+		entries, err := splitList(include, _listFilePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", IncludePkgsFlag, err)
+		}
+		patterns, err := parsePkgPatterns(entries)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", IncludePkgsFlag, err)
+		}
+		conf.includePkgs = patterns
+	}
+	if exclude, ok := flagValue(pass, ExcludePkgsFlag).(string); ok && exclude != "" {
+		entries, err := splitList(exclude, _listFilePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", ExcludePkgsFlag, err)
+		}
+		patterns, err := parsePkgPatterns(entries)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", ExcludePkgsFlag, err)
+		}
+		conf.excludePkgs = patterns
+	}
+	if rules, ok := flagValue(pass, PkgRulesFlag).(string); ok && rules != "" {
+		parsed, err := parsePkgRules(strings.Split(rules, ","))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", PkgRulesFlag, err)
+		}
+		conf.pkgRules = parsed
+	}
+	if distrust, ok := flagValue(pass, DistrustPkgsFlag).(string); ok && distrust != "" {
+		entries, err := splitList(distrust, _listFilePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", DistrustPkgsFlag, err)
+		}
+		patterns, err := parsePkgPatterns(entries)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", DistrustPkgsFlag, err)
+		}
+		conf.distrustPkgs = patterns
+	}
+	if docstrings, ok := flagValue(pass, ExcludeFileDocStringsFlag).(string); ok && docstrings != "" {
+		entries, err := splitList(docstrings, _docStringListFilePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", ExcludeFileDocStringsFlag, err)
+		}
+		conf.excludeFileDocStrings = entries
+	}
+	if ignoreCase, ok := flagValue(pass, ExcludeFileDocStringsIgnoreCaseFlag).(bool); ok {
+		conf.docStringIgnoreCase = ignoreCase
+	}
+	if wholeWord, ok := flagValue(pass, ExcludeFileDocStringsWholeWordFlag).(bool); ok {
+		conf.docStringWholeWord = wholeWord
+	}
+	if funcs, ok := flagValue(pass, ExcludeFuncsFlag).(string); ok && funcs != "" {
+		conf.excludeFuncs = parseNameSet(strings.Split(funcs, ","))
+	}
+	if stubs, ok := flagValue(pass, StubsFlag).(string); ok && stubs != "" {
+		loaded, err := loadStubs(stubs)
+		if err != nil {
+			return nil, err
+		}
+		conf.Stubs = loaded
+	}
+	if categories, ok := flagValue(pass, WarnCategoriesFlag).(string); ok && categories != "" {
+		conf.warnCategories = parseNameSet(strings.Split(categories, ","))
+	}
+	if changed, ok := flagValue(pass, ChangedPkgsFlag).(string); ok && changed != "" {
+		pkgs, err := parseChangedPkgs(changed)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", ChangedPkgsFlag, err)
+		}
+		conf.changedPkgs = pkgs
+	}
+	if defaults, ok := flagValue(pass, PackageDefaultsFlag).(string); ok && defaults != "" {
+		rules, err := parsePackageDefaults(strings.Split(defaults, ","))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", PackageDefaultsFlag, err)
+		}
+		conf.packageDefaults = rules
+	}
+	if respectBuildTags, ok := flagValue(pass, RespectBuildTagsFlag).(bool); ok {
+		conf.respectBuildTags = respectBuildTags
+	}
+	if skipVendor, ok := flagValue(pass, SkipVendorFlag).(bool); ok {
+		conf.skipVendor = skipVendor
+	}
+	format, file := conf.OutputFormat, conf.OutputFile
+	if f, ok := flagValue(pass, OutputFormatFlag).(string); ok {
+		format = f
+	}
+	if f, ok := flagValue(pass, OutputFileFlag).(string); ok {
+		file = f
 	}
-	if exclude, ok := pass.Analyzer.Flags.Lookup(ExcludePkgsFlag).Value.(flag.Getter).Get().(string); ok && exclude != "" {
-		conf.excludePkgs = strings.Split(exclude, ",")
+	if err := conf.setOutput(format, file); err != nil {
+		return nil, fmt.Errorf("parse %s flag: %w", OutputFormatFlag, err)
 	}
-	if docstrings, ok := pass.Analyzer.Flags.Lookup(ExcludeFileDocStringsFlag).Value.(flag.Getter).Get().(string); ok && docstrings != "" {
-		conf.excludeFileDocStrings = strings.Split(docstrings, ",")
+	if order, ok := flagValue(pass, SortFlag).(string); ok {
+		conf.SortOrder = order
+	}
+	if maxErrors, ok := flagValue(pass, MaxErrorsPerPackageFlag).(int); ok {
+		conf.MaxErrorsPerPackage = maxErrors
+	}
+	if baseline, ok := flagValue(pass, BaselineFlag).(string); ok {
+		conf.Baseline = baseline
+	}
+	if writeBaseline, ok := flagValue(pass, WriteBaselineFlag).(bool); ok {
+		conf.WriteBaseline = writeBaseline
+	}
+	if summaryFile, ok := flagValue(pass, SummaryFileFlag).(string); ok {
+		conf.SummaryFile = summaryFile
+	}
+	if reportHTML, ok := flagValue(pass, ReportHTMLFlag).(string); ok {
+		conf.ReportHTML = reportHTML
+	}
+	if cacheDir, ok := flagValue(pass, CacheDirFlag).(string); ok {
+		conf.CacheDir = cacheDir
+	}
+	if reportUndetermined, ok := flagValue(pass, ReportUndeterminedFlag).(bool); ok {
+		conf.ReportUndetermined = reportUndetermined
+	}
+	if strictExported, ok := flagValue(pass, StrictExportedFlag).(bool); ok {
+		conf.StrictExported = strictExported
+	}
+	if boundaryOnly, ok := flagValue(pass, BoundaryOnlyFlag).(bool); ok {
+		conf.BoundaryOnly = boundaryOnly
+	}
+	if explain, ok := flagValue(pass, ExplainFlag).(bool); ok {
+		conf.Explain = explain
+	}
+	if groupErrors, ok := flagValue(pass, GroupErrorsFlag).(bool); ok {
+		conf.GroupErrors = groupErrors
+	}
+	if mode, ok := flagValue(pass, TestFileModeFlag).(string); ok {
+		parsed, err := parseTestFileMode(mode)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", TestFileModeFlag, err)
+		}
+		conf.testFileMode = parsed
+	}
+	if maxChainDepth, ok := flagValue(pass, MaxChainDepthFlag).(int); ok {
+		conf.MaxChainDepth = maxChainDepth
+	}
+	if unmarshalNilable, ok := flagValue(pass, UnmarshalNilableFlag).(bool); ok {
+		conf.UnmarshalNilable = unmarshalNilable
+	}
+	if reflectNilable, ok := flagValue(pass, ReflectNilableFlag).(bool); ok {
+		conf.ReflectNilable = reflectNilable
+	}
+	if constructorFields, ok := flagValue(pass, ConstructorFieldsFlag).(bool); ok {
+		conf.ConstructorFields = constructorFields
+	}
+	if exportInScopeOnly, ok := flagValue(pass, ExportInScopeOnlyFlag).(bool); ok {
+		conf.ExportInScopeOnly = exportInScopeOnly
+	}
+	if maxSites, ok := flagValue(pass, MaxSitesFlag).(int); ok {
+		conf.MaxSites = maxSites
+	}
+	if externalReturns, ok := flagValue(pass, ExternalReturnsFlag).(string); ok {
+		nilable, err := parseExternalReturns(externalReturns)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", ExternalReturnsFlag, err)
+		}
+		conf.externalReturnsNilable = nilable
+	}
+	if profilePackages, ok := flagValue(pass, ProfilePackagesFlag).(bool); ok {
+		conf.ProfilePackages = profilePackages
+	}
+	if focus, ok := flagValue(pass, FocusFlag).(string); ok {
+		conf.Focus = focus
+	}
+	if focusFunc, ok := flagValue(pass, FocusFuncFlag).(string); ok {
+		conf.FocusFunc = focusFunc
+	}
+	if dumpSites, ok := flagValue(pass, DumpSitesFlag).(string); ok && dumpSites != "" {
+		re, err := regexp.Compile(dumpSites)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s flag: %w", DumpSitesFlag, err)
+		}
+		conf.DumpSites = re
+	}
+	if dumpSitesFile, ok := flagValue(pass, DumpSitesFileFlag).(string); ok {
+		conf.DumpSitesFile = dumpSitesFile
+	}
+	if record, ok := flagValue(pass, RecordFlag).(string); ok {
+		conf.RecordFile = record
+	}
+	if redundantChecks, ok := flagValue(pass, ReportRedundantChecksFlag).(bool); ok {
+		conf.ReportRedundantChecks = redundantChecks
+	}
+	if requireReason, ok := flagValue(pass, RequireSuppressionReasonFlag).(bool); ok {
+		conf.RequireSuppressionReason = requireReason
+	}
+	if delimiter, ok := flagValue(pass, SuppressionReasonDelimiterFlag).(string); ok {
+		conf.SuppressionReasonDelimiter = delimiter
+	}
+	if minLength, ok := flagValue(pass, SuppressionReasonMinLengthFlag).(int); ok {
+		conf.SuppressionReasonMinLength = minLength
+	}
+	if pathBase, ok := flagValue(pass, PathBaseFlag).(string); ok {
+		conf.PathBase = pathBase
+	}
+
+	return conf.ForPackage(pass)
+}
+
+// ForPackage validates the config and returns a copy of it prepared for analyzing the package of
+// the pass, i.e., with the package-specific states (e.g., the resolved path base and the
+// statistics) initialized. Analyzer does this for the configs built from the flags, while the
+// drivers providing their own configs (see nilaway.Analyze) must do this for each package.
+func (c *Config) ForPackage(pass *analysis.Pass) (*Config, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
 	}
 
-	return conf, nil
+	conf := *c
+	conf.stats = &packageStats{}
+	conf.fset = pass.Fset
+	conf.focusFile = ""
+	if conf.Focus != "" {
+		abs, err := filepath.Abs(conf.Focus)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s %q: %w", FocusFlag, conf.Focus, err)
+		}
+		conf.focusFile = abs
+	}
+	if err := conf.resolvePathBase(pass); err != nil {
+		return nil, fmt.Errorf("parse %s flag: %w", PathBaseFlag, err)
+	}
+	if conf.WriteBaseline && conf.Baseline == "" {
+		conf.Baseline = _defaultBaselineFile
+	}
+	conf.messageTemplate = nil
+	if conf.MessageTemplate != "" {
+		tmpl, err := parseMessageTemplate(conf.MessageTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", MessageTemplateFlag, err)
+		}
+		conf.messageTemplate = tmpl
+	}
+	return &conf, nil
+}
+
+// validate returns an error if any of the numeric or required options has an invalid value,
+// which cannot be rejected while parsing the flags since the values may come from the
+// configuration file or be set programmatically.
+func (c *Config) validate() error {
+	if c.MaxChainDepth < 0 {
+		return fmt.Errorf("invalid %s %d, must be non-negative", MaxChainDepthFlag, c.MaxChainDepth)
+	}
+	if c.MaxSites < 0 {
+		return fmt.Errorf("invalid %s %d, must be non-negative", MaxSitesFlag, c.MaxSites)
+	}
+	if c.MaxErrorsPerPackage < 0 {
+		return fmt.Errorf("invalid %s %d, must be non-negative", MaxErrorsPerPackageFlag, c.MaxErrorsPerPackage)
+	}
+	if c.SuppressionReasonDelimiter == "" {
+		return fmt.Errorf("invalid %s, must not be empty", SuppressionReasonDelimiterFlag)
+	}
+	if c.SuppressionReasonMinLength < 0 {
+		return fmt.Errorf("invalid %s %d, must be non-negative", SuppressionReasonMinLengthFlag, c.SuppressionReasonMinLength)
+	}
+	switch c.SortOrder {
+	case SortPosition, SortCategory, SortSeverity:
+	default:
+		return fmt.Errorf("invalid %s %q, expected %q, %q or %q", SortFlag, c.SortOrder, SortPosition, SortCategory, SortSeverity)
+	}
+	return nil
+}
+
+// newDefaultConfig returns a config with all options set to their default values.
+func newDefaultConfig() *Config {
+	return &Config{
+		PrettyPrint: true,
+		// If the user does not provide an include list, we give an empty package prefix to catch
+		// all packages.
+		includePkgs:  []pkgPattern{{prefix: ""}},
+		OutputFormat: OutputFormatText,
+		SortOrder:    SortPosition,
+		skipVendor:   true,
+		testFileMode: TestFileModeAnalyze,
+
+		SuppressionReasonDelimiter: DefaultSuppressionReasonDelimiter,
+		SuppressionReasonMinLength: DefaultSuppressionReasonMinLength,
+		DumpSitesFile:              DefaultDumpSitesFile,
+
+		stats: &packageStats{},
+	}
+}
+
+const (
+	// _listFilePrefix is the prefix of the entries of the lists given to the flags (see splitList)
+	// that refer to the files containing the entries.
+	_listFilePrefix = "@"
+	// _docStringListFilePrefix is the counterpart of _listFilePrefix for the docstrings, which may
+	// legitimately start with "@" (e.g., "@generated") and hence need an unambiguous prefix.
+	_docStringListFilePrefix = "@@"
+)
+
+// splitList splits the comma-separated list given to a flag, where the entries of the form
+// "<filePrefix><file>" are replaced by the lines of the file, such that long lists (e.g.,
+// generated by build tools) do not exceed the command-line length limits. The blank lines and the
+// lines starting with "#" (i.e., comments) in the file are ignored. The other entries are kept as
+// is regardless of whether a file with the same name exists.
+func splitList(value string, filePrefix string) ([]string, error) {
+	var entries []string
+	for _, e := range strings.Split(value, ",") {
+		path, ok := strings.CutPrefix(e, filePrefix)
+		if !ok {
+			entries = append(entries, e)
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read list file: %w", err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// flagValue returns the value of the flag if it has been set explicitly (even if to its default
+// value), or nil otherwise.
+// nilable(result 0)
+func flagValue(pass *analysis.Pass, name string) any {
+	f := pass.Analyzer.Flags.Lookup(name)
+	if v, ok := f.Value.(*trackedValue); !ok || !v.set {
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
 }
@@ -0,0 +1,714 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".nilaway.yaml")
+	content := `
+pretty-print: false
+quiet: true
+include-pkgs:
+  - go.uber.org/foo
+  - go.uber.org/bar
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.False(t, conf.PrettyPrint)
+	require.True(t, conf.Quiet)
+	require.Equal(t, []pkgPattern{{prefix: "go.uber.org/foo"}, {prefix: "go.uber.org/bar"}}, conf.includePkgs)
+	// Absent keys should keep their default values.
+	require.Empty(t, conf.excludePkgs)
+	require.Empty(t, conf.excludeFileDocStrings)
+}
+
+func TestLoadConfigFile_JSON(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.json")
+	content := `{"exclude-pkgs": ["go.uber.org/foo/mock"]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.True(t, conf.PrettyPrint)
+	require.False(t, conf.Quiet)
+	require.Equal(t, []pkgPattern{{prefix: ""}}, conf.includePkgs)
+	require.Equal(t, []pkgPattern{{prefix: "go.uber.org/foo/mock"}}, conf.excludePkgs)
+}
+
+func TestLoadConfigFile_Missing(t *testing.T) {
+	t.Parallel()
+
+	conf, err := LoadConfigFile(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, newDefaultConfig(), conf)
+}
+
+func TestLoadConfigFile_RelativePaths(t *testing.T) { //nolint:paralleltest
+	// This test is not parallel since it changes the working directory.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	path := filepath.Join(dir, ".nilaway.yaml")
+	content := `
+stubs: stubs.yaml
+output-format: sarif
+output-file: out/nilaway.sarif
+baseline: nilaway-baseline.json
+summary-file: summary.json
+report-html: report.html
+cache-dir: ` + cacheDir + `
+path-base: src
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stubs.yaml"), []byte("{}\n"), 0o600))
+
+	// The relative paths in the file are resolved against its directory (while the absolute ones
+	// are kept as is), no matter where the file is loaded from.
+	require.NoError(t, os.Chdir(t.TempDir()))
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "out", "nilaway.sarif"), conf.OutputFile)
+	require.Equal(t, filepath.Join(dir, "nilaway-baseline.json"), conf.Baseline)
+	require.Equal(t, filepath.Join(dir, "summary.json"), conf.SummaryFile)
+	require.Equal(t, filepath.Join(dir, "report.html"), conf.ReportHTML)
+	require.Equal(t, cacheDir, conf.CacheDir)
+	require.Equal(t, filepath.Join(dir, "src"), conf.PathBase)
+
+	// A relative path of the file itself is relative to the working directory, and so are the
+	// paths resolved against it.
+	require.NoError(t, os.Chdir(filepath.Dir(dir)))
+	conf, err = LoadConfigFile(filepath.Join(filepath.Base(dir), ".nilaway.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(filepath.Base(dir), "summary.json"), conf.SummaryFile)
+
+	// The special values are not paths.
+	require.NoError(t, os.WriteFile(path, []byte("output-format: jsonl\noutput-file: \"-\"\npath-base: module\n"), 0o600))
+	conf, err = LoadConfigFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "-", conf.OutputFile)
+	require.Equal(t, PathBaseModule, conf.PathBase)
+}
+
+func TestFlagsOverrideConfigFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("pretty-print: false\nquiet: true\n"), 0o600))
+
+	// A fresh flag set is used for the analyzer such that the test does not interfere with others.
+	flags := newFlagSet()
+	pass := &analysis.Pass{
+		Analyzer: &analysis.Analyzer{Name: Analyzer.Name, Flags: flags},
+		Pkg:      types.NewPackage("go.uber.org/foo", "foo"),
+		Fset:     token.NewFileSet(),
+	}
+	require.NoError(t, flags.Set(ConfigFileFlag, path))
+
+	// The flags that are not set do not override the configuration file.
+	result, err := run(pass)
+	require.NoError(t, err)
+	require.False(t, result.(*Config).PrettyPrint)
+	require.True(t, result.(*Config).Quiet)
+
+	// The flags explicitly set to their default values still override the configuration file.
+	require.NoError(t, flags.Lookup(PrettyPrintFlag).Value.Set("true"))
+	result, err = run(pass)
+	require.NoError(t, err)
+	require.True(t, result.(*Config).PrettyPrint)
+	require.True(t, result.(*Config).Quiet)
+}
+
+func TestLoadConfigFile_Cached(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("quiet: true\n"), 0o600))
+
+	first, err := loadConfigFile(path)
+	require.NoError(t, err)
+	require.True(t, first.Quiet)
+
+	// Each call returns a copy of the cached config, such that overriding it for one package
+	// does not affect the others.
+	first.Quiet = false
+	second, err := loadConfigFile(path)
+	require.NoError(t, err)
+	require.NotSame(t, first, second)
+	require.True(t, second.Quiet)
+
+	// The file is not read again once it is cached.
+	require.NoError(t, os.Remove(path))
+	third, err := loadConfigFile(path)
+	require.NoError(t, err)
+	require.Equal(t, second, third)
+}
+
+func TestLoadConfigFile_Malformed(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	content := "pretty-print: true\nexclude-pkgs: foo: bar\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	_, err := LoadConfigFile(path)
+	require.ErrorContains(t, err, "line 2")
+
+	// Unknown keys should also be rejected to catch typos.
+	require.NoError(t, os.WriteFile(path, []byte("include-pkg: [foo]\n"), 0o600))
+	_, err = LoadConfigFile(path)
+	require.ErrorContains(t, err, "include-pkg")
+}
+
+func TestIsPkgInScope_Regexp(t *testing.T) {
+	t.Parallel()
+
+	include, err := parsePkgPatterns([]string{"github.com/acme"})
+	require.NoError(t, err)
+	exclude, err := parsePkgPatterns([]string{`re:_mock$`, "github.com/acme/legacy"})
+	require.NoError(t, err)
+	conf := &Config{includePkgs: include, excludePkgs: exclude}
+
+	tests := map[string]bool{
+		"github.com/acme/foo":             true,
+		"github.com/acme/foo_mock":        false,
+		"github.com/acme/foo_mock/nested": true,
+		"github.com/acme/legacy/bar":      false,
+		"github.com/other/foo":            false,
+	}
+	for path, expected := range tests {
+		require.Equal(t, expected, conf.IsPkgInScope(types.NewPackage(path, "p")), path)
+	}
+
+	// Regular expressions can also be used in the include list.
+	include, err = parsePkgPatterns([]string{`re:^github\.com/acme/(foo|bar)$`})
+	require.NoError(t, err)
+	conf = &Config{includePkgs: include}
+	require.True(t, conf.IsPkgInScope(types.NewPackage("github.com/acme/bar", "bar")))
+	require.False(t, conf.IsPkgInScope(types.NewPackage("github.com/acme/baz", "baz")))
+}
+
+func TestIsPkgInScope_Rules(t *testing.T) {
+	t.Parallel()
+
+	include, err := parsePkgPatterns([]string{"github.com/acme"})
+	require.NoError(t, err)
+	exclude, err := parsePkgPatterns([]string{`re:_mock$`})
+	require.NoError(t, err)
+	rules, err := parsePkgRules([]string{
+		"-github.com/acme/internal",
+		"+github.com/acme/internal/public",
+		"+re:^github\\.com/acme/keep_mock$",
+	})
+	require.NoError(t, err)
+	conf := &Config{includePkgs: include, excludePkgs: exclude, pkgRules: rules}
+
+	tests := map[string]bool{
+		"github.com/acme/foo":                    true,
+		"github.com/acme/foo_mock":               false,
+		"github.com/acme/keep_mock":              true,
+		"github.com/acme/internal/bar":           false,
+		"github.com/acme/internal/public":        true,
+		"github.com/acme/internal/public/nested": true,
+		"github.com/other/internal/public":       false,
+	}
+	for path, expected := range tests {
+		require.Equal(t, expected, conf.IsPkgInScope(types.NewPackage(path, "p")), path)
+	}
+
+	for _, invalid := range []string{"github.com/acme", "", "+re:("} {
+		_, err := parsePkgRules([]string{invalid})
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestNew_Setters(t *testing.T) {
+	t.Parallel()
+
+	conf := New()
+	require.True(t, conf.IsPkgPathInScope("github.com/other/foo"))
+
+	require.NoError(t, conf.SetIncludePkgs("github.com/acme"))
+	require.NoError(t, conf.SetExcludePkgs("re:_mock$"))
+	require.NoError(t, conf.SetPkgRules("+github.com/acme/keep_mock"))
+	require.False(t, conf.IsPkgPathInScope("github.com/other/foo"))
+	require.False(t, conf.IsPkgPathInScope("github.com/acme/foo_mock"))
+	require.True(t, conf.IsPkgPathInScope("github.com/acme/keep_mock"))
+	require.NoError(t, conf.SetIncludePkgs())
+	require.True(t, conf.IsPkgPathInScope("github.com/other/foo"))
+
+	conf.SetWarnCategories("long_chain")
+	require.True(t, conf.IsWarning("long_chain"))
+	conf.SetChangedPkgs("github.com/acme/foo")
+	require.True(t, conf.ShouldReanalyze(types.NewPackage("github.com/acme/foo", "foo")))
+	require.False(t, conf.ShouldReanalyze(types.NewPackage("github.com/acme/bar", "bar")))
+	conf.SetExternalReturnsNilable(true)
+	require.True(t, conf.ExternalReturnsNilable())
+
+	require.Error(t, conf.SetIncludePkgs("re:("))
+	require.Error(t, conf.SetPackageDefaults("github.com/acme"))
+	require.Error(t, conf.SetTestFileMode("unknown"))
+	require.Error(t, conf.SetOutput("unknown", ""))
+	require.NoError(t, conf.SetOutput(OutputFormatSARIF, ""))
+	require.Equal(t, "nilaway.sarif", conf.OutputFile)
+
+	conf.MaxChainDepth = -1
+	require.ErrorContains(t, conf.validate(), MaxChainDepthFlag)
+}
+
+func TestIsPkgInScope_SkipVendor(t *testing.T) {
+	t.Parallel()
+
+	conf := newDefaultConfig()
+	tests := map[string]bool{
+		"github.com/acme/foo":                true,
+		"github.com/acme/vendor/foo":         false,
+		"vendor/golang.org/x/net/http2":      false,
+		"github.com/acme/vendored":           true,
+		"github.com/acme/vendored/foo":       true,
+		"github.com/acme/foo/vendor":         true,
+		"github.com/acme/myvendor/foo":       true,
+		"github.com/acme/vendor.example/foo": true,
+	}
+	for path, expected := range tests {
+		require.Equal(t, expected, conf.IsPkgInScope(types.NewPackage(path, "p")), path)
+	}
+
+	// Once disabled, the vendored packages follow the include and exclude lists.
+	conf.skipVendor = false
+	require.True(t, conf.IsPkgInScope(types.NewPackage("github.com/acme/vendor/foo", "foo")))
+}
+
+func TestRelativePath(t *testing.T) {
+	t.Parallel()
+
+	conf := newDefaultConfig()
+	require.Equal(t, "/src/repo/foo/foo.go", conf.RelativePath("/src/repo/foo/foo.go"))
+
+	conf.pathBaseDir = "/src/repo"
+	require.Equal(t, filepath.Join("foo", "foo.go"), conf.RelativePath("/src/repo/foo/foo.go"))
+	require.Equal(t, "foo.go", conf.RelativePath("/src/repo/foo.go"))
+	// Paths outside of the path base fall back to absolute paths instead of ".." chains.
+	require.Equal(t, "/src/other/foo.go", conf.RelativePath("/src/other/foo.go"))
+	require.Equal(t, "/src/repo2/foo.go", conf.RelativePath("/src/repo2/foo.go"))
+	// Relative paths are resolved against the current working directory first.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(wd, "foo.go"), conf.RelativePath("foo.go"))
+}
+
+func TestParsePkgPatterns_InvalidRegexp(t *testing.T) {
+	t.Parallel()
+
+	_, err := parsePkgPatterns([]string{"github.com/acme", "re:foo("})
+	require.ErrorContains(t, err, `"foo("`)
+}
+
+func TestSplitList(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pkgs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# generated\ngithub.com/acme/a\n\n  github.com/acme/b  \n"), 0o644))
+
+	entries, err := splitList("github.com/foo,@"+path+",re:_mock$", _listFilePrefix)
+	require.NoError(t, err)
+	require.Equal(t, []string{"github.com/foo", "github.com/acme/a", "github.com/acme/b", "re:_mock$"}, entries)
+
+	_, err = splitList("@"+filepath.Join(t.TempDir(), "missing.txt"), _listFilePrefix)
+	require.ErrorContains(t, err, "missing.txt")
+}
+
+func TestSplitList_DocStrings(t *testing.T) { //nolint:paralleltest
+	// This test is not parallel since it changes the working directory.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	// The docstrings starting with a single "@" are kept as is even if a file or a directory with
+	// the same name exists in the working directory.
+	path := filepath.Join(t.TempDir(), "docstrings.txt")
+	require.NoError(t, os.WriteFile(path, []byte("DO NOT EDIT\n"), 0o644))
+	for _, create := range []func(name string) error{
+		func(name string) error { return os.Mkdir(name, 0o755) },
+		func(name string) error { return os.WriteFile(name, []byte("not a docstring\n"), 0o644) },
+	} {
+		require.NoError(t, os.Chdir(t.TempDir()))
+		require.NoError(t, create("generated"))
+		entries, err := splitList("@generated,Code generated by,@@"+path, _docStringListFilePrefix)
+		require.NoError(t, err)
+		require.Equal(t, []string{"@generated", "Code generated by", "DO NOT EDIT"}, entries)
+	}
+}
+
+func TestLoadConfigFile_Output(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("output-format: sarif\n"), 0o600))
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.Equal(t, OutputFormatSARIF, conf.OutputFormat)
+	require.Equal(t, "nilaway.sarif", conf.OutputFile)
+
+	require.NoError(t, os.WriteFile(path, []byte("output-format: xml\n"), 0o600))
+	_, err = LoadConfigFile(path)
+	require.ErrorContains(t, err, `unknown output format "xml"`)
+}
+
+func TestIsFileInScope_BuildTags(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"package foo\n": true,
+		"//go:build " + runtime.GOOS + "\n\npackage foo\n":               true,
+		"//go:build !" + runtime.GOOS + "\n\npackage foo\n":              false,
+		"//go:build nilaway_unknown_tag\n\npackage foo\n":                false,
+		"// +build nilaway_unknown_tag\n\npackage foo\n":                 false,
+		"//go:build !nilaway_unknown_tag\n\npackage foo\n":               true,
+		"// Code generated by foo.\n\n//go:build go1.1\n\npackage foo\n": true,
+	}
+	for src, expected := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), "foo.go", src, parser.ParseComments)
+		require.NoError(t, err)
+		require.Equal(t, expected, (&Config{respectBuildTags: true}).IsFileInScope(file), src)
+		// Build constraints are ignored unless the option is set.
+		require.True(t, (&Config{}).IsFileInScope(file), src)
+	}
+
+	// The docstring exclusion continues to work independently.
+	file, err := parser.ParseFile(token.NewFileSet(), "foo.go", "// Code generated by foo.\n\npackage foo\n", parser.ParseComments)
+	require.NoError(t, err)
+	conf := &Config{respectBuildTags: true, excludeFileDocStrings: []string{"Code generated by"}}
+	require.False(t, conf.IsFileInScope(file))
+}
+
+func TestIsFileInScope_TestFileMode(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", "package foo\n", 0)
+	require.NoError(t, err)
+	testFile, err := parser.ParseFile(fset, "foo_test.go", "package foo\n", 0)
+	require.NoError(t, err)
+
+	for _, mode := range []string{TestFileModeAnalyze, TestFileModeSkip, TestFileModeRelaxed} {
+		conf := &Config{testFileMode: mode, fset: fset}
+		require.True(t, conf.IsFileInScope(file), mode)
+		require.False(t, conf.IsRelaxedTestFile(file), mode)
+		require.Equal(t, mode != TestFileModeSkip, conf.IsFileInScope(testFile), mode)
+		require.Equal(t, mode == TestFileModeRelaxed, conf.IsRelaxedTestFile(testFile), mode)
+	}
+
+	_, err = parseTestFileMode("lenient")
+	require.ErrorContains(t, err, `invalid test file mode "lenient"`)
+}
+
+func TestIsFileInScope_DocStringModes(t *testing.T) {
+	t.Parallel()
+
+	parse := func(doc string) *ast.File {
+		file, err := parser.ParseFile(token.NewFileSet(), "foo.go", doc+"\npackage foo\n", parser.ParseComments)
+		require.NoError(t, err)
+		return file
+	}
+	regenerated := parse("// This file is regenerated manually.\n")
+	upper := parse("// GENERATED by a tool.\n")
+	tagged := parse("// @generated\n")
+	suffixed := parse("// @generated_by tool\n")
+
+	// The default substring matching is kept for backward compatibility.
+	conf := &Config{excludeFileDocStrings: []string{"generated", "@generated"}}
+	require.False(t, conf.IsFileInScope(regenerated))
+	require.True(t, conf.IsFileInScope(upper))
+	require.False(t, conf.IsFileInScope(suffixed))
+
+	conf.docStringWholeWord = true
+	require.True(t, conf.IsFileInScope(regenerated))
+	require.True(t, conf.IsFileInScope(upper))
+	require.False(t, conf.IsFileInScope(tagged))
+	require.True(t, conf.IsFileInScope(suffixed))
+
+	conf.docStringIgnoreCase = true
+	require.True(t, conf.IsFileInScope(regenerated))
+	require.False(t, conf.IsFileInScope(upper))
+
+	// A later standalone occurrence matches even if an earlier one does not.
+	require.True(t, matchDocString("regenerated, then generated", "generated", false, true))
+	require.False(t, matchDocString("x@generated", "@generated", false, true))
+}
+
+func TestPackageDefaultNilable(t *testing.T) {
+	t.Parallel()
+
+	defaults, err := parsePackageDefaults([]string{"github.com/acme/legacy:nilable", "github.com/acme/legacy/new:nonnil"})
+	require.NoError(t, err)
+	conf := &Config{packageDefaults: defaults}
+
+	tests := map[string]struct{ nilable, ok bool }{
+		"github.com/acme/legacy/foo":     {nilable: true, ok: true},
+		"github.com/acme/legacy/new/foo": {nilable: false, ok: true},
+		"github.com/acme/other":          {nilable: false, ok: false},
+	}
+	for path, expected := range tests {
+		nilable, ok := conf.PackageDefaultNilable(types.NewPackage(path, "p"))
+		require.Equal(t, expected.nilable, nilable, path)
+		require.Equal(t, expected.ok, ok, path)
+	}
+
+	_, err = parsePackageDefaults([]string{"github.com/acme/legacy"})
+	require.ErrorContains(t, err, "invalid package default")
+	_, err = parsePackageDefaults([]string{"github.com/acme/legacy:maybe"})
+	require.ErrorContains(t, err, `invalid nilability "maybe"`)
+}
+
+func TestLoadConfigFile_ExternalReturns(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("include-pkgs: [github.com/acme]\n"), 0o600))
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.False(t, conf.ExternalReturnsNilable())
+
+	require.NoError(t, os.WriteFile(path, []byte("external-returns: nilable\n"), 0o600))
+	conf, err = LoadConfigFile(path)
+	require.NoError(t, err)
+	require.True(t, conf.ExternalReturnsNilable())
+
+	require.NoError(t, os.WriteFile(path, []byte("external-returns: maybe\n"), 0o600))
+	_, err = LoadConfigFile(path)
+	require.ErrorContains(t, err, `invalid external returns "maybe"`)
+}
+
+func TestLoadConfigFile_SuppressionReason(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".nilaway.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("require-suppression-reason: true\n"), 0o600))
+	conf, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	require.True(t, conf.RequireSuppressionReason)
+	// Absent keys should keep their default values.
+	require.Equal(t, DefaultSuppressionReasonDelimiter, conf.SuppressionReasonDelimiter)
+	require.Equal(t, DefaultSuppressionReasonMinLength, conf.SuppressionReasonMinLength)
+
+	content := "suppression-reason-delimiter: \"reason:\"\nsuppression-reason-min-length: 10\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	conf, err = LoadConfigFile(path)
+	require.NoError(t, err)
+	require.False(t, conf.RequireSuppressionReason)
+	require.Equal(t, "reason:", conf.SuppressionReasonDelimiter)
+	require.Equal(t, 10, conf.SuppressionReasonMinLength)
+}
+
+func TestIsFuncInScope(t *testing.T) {
+	t.Parallel()
+
+	src := `package foo
+
+func Func() {}
+
+type T struct{}
+
+func (*T) Ptr() {}
+
+func (T) Value() {}
+
+type G[V any] struct{}
+
+func (*G[V]) Generic() {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("github.com/acme/foo", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+
+	lookup := func(typeName, name string) *types.Func {
+		if typeName == "" {
+			return pkg.Scope().Lookup(name).(*types.Func)
+		}
+		obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(pkg.Scope().Lookup(typeName).Type()), true, pkg, name)
+		return obj.(*types.Func)
+	}
+	funcs := map[string]*types.Func{
+		"github.com/acme/foo.Func":         lookup("", "Func"),
+		"github.com/acme/foo.(*T).Ptr":     lookup("T", "Ptr"),
+		"github.com/acme/foo.(T).Value":    lookup("T", "Value"),
+		"github.com/acme/foo.(*G).Generic": lookup("G", "Generic"),
+	}
+	conf := &Config{excludeFuncs: parseNameSet([]string{"github.com/acme/foo.Func", " github.com/acme/foo.(*T).Ptr"})}
+	for name, fn := range funcs {
+		require.Equal(t, name, FuncName(fn))
+	}
+	require.False(t, conf.IsFuncInScope(funcs["github.com/acme/foo.Func"]))
+	require.False(t, conf.IsFuncInScope(funcs["github.com/acme/foo.(*T).Ptr"]))
+	require.True(t, conf.IsFuncInScope(funcs["github.com/acme/foo.(T).Value"]))
+	require.True(t, conf.IsFuncInScope(funcs["github.com/acme/foo.(*G).Generic"]))
+	require.True(t, (&Config{}).IsFuncInScope(funcs["github.com/acme/foo.Func"]))
+}
+
+func TestShouldReanalyze(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, (&Config{}).ShouldReanalyze(types.NewPackage("github.com/acme/foo", "foo")))
+
+	path := filepath.Join(t.TempDir(), "changed.txt")
+	require.NoError(t, os.WriteFile(path, []byte("github.com/acme/foo\ngithub.com/acme/bar, github.com/acme/baz\n"), 0o600))
+	for _, value := range []string{"github.com/acme/foo,github.com/acme/bar,github.com/acme/baz", "@" + path} {
+		pkgs, err := parseChangedPkgs(value)
+		require.NoError(t, err)
+		conf := &Config{changedPkgs: pkgs}
+		require.True(t, conf.HasChangedPkgs())
+		require.True(t, conf.ShouldReanalyze(types.NewPackage("github.com/acme/bar", "bar")))
+		require.False(t, conf.ShouldReanalyze(types.NewPackage("github.com/acme/foo/nested", "nested")))
+	}
+
+	_, err := parseChangedPkgs("@" + filepath.Join(t.TempDir(), "missing.txt"))
+	require.ErrorContains(t, err, "read changed package list")
+}
+
+func TestIsWarning(t *testing.T) {
+	t.Parallel()
+
+	conf := &Config{warnCategories: parseNameSet([]string{"mapread", " fieldaccess"})}
+	require.True(t, conf.IsWarning("mapread"))
+	require.True(t, conf.IsWarning("fieldaccess"))
+	require.False(t, conf.IsWarning("funcret"))
+	// Uncategorized diagnostics are always errors.
+	require.False(t, conf.IsWarning(""))
+	require.False(t, (&Config{}).IsWarning("mapread"))
+}
+
+func TestFormatMessage(t *testing.T) {
+	t.Parallel()
+
+	fields := MessageFields{Position: "a.go:1:2", Category: "mapread", RootSite: "b.go:3:4", Message: "msg"}
+	conf := New()
+	msg, err := conf.FormatMessage(fields)
+	require.NoError(t, err)
+	require.Equal(t, "msg", msg)
+
+	conf.messageTemplate, err = parseMessageTemplate("{{.Position}}: [{{.Category}}] {{.Message}} (from {{.RootSite}})")
+	require.NoError(t, err)
+	msg, err = conf.FormatMessage(fields)
+	require.NoError(t, err)
+	require.Equal(t, "a.go:1:2: [mapread] msg (from b.go:3:4)", msg)
+
+	_, err = parseMessageTemplate("{{.Message")
+	require.Error(t, err)
+	_, err = parseMessageTemplate("{{.Line}}")
+	require.ErrorContains(t, err, "Line")
+}
+
+func TestProfiled(t *testing.T) {
+	t.Parallel()
+
+	pass := &analysis.Pass{
+		Analyzer: &analysis.Analyzer{Name: "sub_analyzer"},
+		Pkg:      types.NewPackage("go.uber.org/profiled", "profiled"),
+		ResultOf: map[*analysis.Analyzer]any{Analyzer: &Config{ProfilePackages: true}},
+	}
+	run := Profiled(func(*analysis.Pass) (any, error) {
+		time.Sleep(10 * time.Millisecond)
+		return nil, nil
+	})
+	_, err := run(pass)
+	require.NoError(t, err)
+	pass.ResultOf[Analyzer].(*Config).RecordInferredSites(pass, 42)
+
+	var profile *PackageProfile
+	for _, p := range PackageProfiles() {
+		if p.Path == "go.uber.org/profiled" {
+			p := p
+			profile = &p
+		}
+	}
+	require.NotNil(t, profile)
+	require.Equal(t, 42, profile.Sites)
+	require.GreaterOrEqual(t, profile.Durations["sub_analyzer"], 10*time.Millisecond)
+
+	// Nothing is recorded if profiling is disabled.
+	pass.Pkg = types.NewPackage("go.uber.org/unprofiled", "unprofiled")
+	pass.ResultOf[Analyzer] = &Config{}
+	_, err = run(pass)
+	require.NoError(t, err)
+	for _, p := range PackageProfiles() {
+		require.NotEqual(t, "go.uber.org/unprofiled", p.Path)
+	}
+
+	// The stats of the package itself are recorded regardless.
+	conf := newDefaultConfig()
+	pass.ResultOf[Analyzer] = conf
+	_, err = run(pass)
+	require.NoError(t, err)
+	conf.RecordInferredSites(pass, 42)
+	require.GreaterOrEqual(t, conf.AnalysisTime(), 10*time.Millisecond)
+	require.Equal(t, 42, conf.InferredSites())
+	for _, p := range PackageProfiles() {
+		require.NotEqual(t, "go.uber.org/unprofiled", p.Path)
+	}
+}
+
+func TestWriteProfileSummary(t *testing.T) {
+	t.Parallel()
+
+	profiles := []PackageProfile{
+		{Path: "go.uber.org/small", Durations: map[string]time.Duration{"a": time.Second}, Sites: 10},
+		{Path: "go.uber.org/large", Durations: map[string]time.Duration{"a": time.Second, "b": 2 * time.Second, "c": time.Microsecond}, Sites: 100},
+	}
+	SortPackageProfiles(profiles)
+	require.Equal(t, "go.uber.org/large", profiles[0].Path)
+	require.Equal(t, 3*time.Second+time.Microsecond, profiles[0].Total())
+
+	var buf strings.Builder
+	require.NoError(t, WriteProfileSummary(&buf, profiles))
+	require.Equal(t, `PACKAGE            TIME  SITES  ANALYZERS
+go.uber.org/large  3s    100    b=2s a=1s
+go.uber.org/small  1s    10     a=1s
+`, buf.String())
+}
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
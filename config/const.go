@@ -30,6 +30,11 @@ const ErrorOnNilableMapRead = false
 // NilAway from inferring the annotations for that package - this is useful for unit tests
 const NilAwayNoInferString = "<nilaway no inference>"
 
+// NilAwayNilablePackageString is the marker that may be inserted into the docstring of any file of a
+// package to make the unannotated sites of the package nilable by default, e.g., for packages whose
+// APIs commonly return or accept nil. Explicit annotations of the sites still take precedence.
+const NilAwayNilablePackageString = "nilaway:nilable-package"
+
 const uberPkgPathPrefix = "go.uber.org"
 
 // NilAwayPkgPathPrefix is the package prefix for NilAway.
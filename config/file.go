@@ -0,0 +1,332 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"gopkg.in/yaml.v3"
+)
+
+// _configFileNames is the list of configuration file names that we look for in the module root,
+// in order of preference.
+var _configFileNames = [...]string{".nilaway.yaml", ".nilaway.yml", ".nilaway.json"}
+
+// fileConfig is the on-disk representation of the configuration file. The keys are identical to
+// the flag names. Since JSON is (for our purposes) a subset of YAML, the same struct is used for
+// decoding both formats. Absent keys are left as nil such that the defaults still apply.
+type fileConfig struct {
+	PrettyPrint                *bool    `yaml:"pretty-print"`
+	Quiet                      *bool    `yaml:"quiet"`
+	CollapseDuplicates         *bool    `yaml:"collapse-duplicates"`
+	MessageTemplate            string   `yaml:"message-template"`
+	IncludePkgs                []string `yaml:"include-pkgs"`
+	ExcludePkgs                []string `yaml:"exclude-pkgs"`
+	PkgRules                   []string `yaml:"pkg-rules"`
+	DistrustPkgs               []string `yaml:"distrust-pkgs"`
+	ExcludeFileDocStrings      []string `yaml:"exclude-file-docstrings"`
+	DocStringsIgnoreCase       *bool    `yaml:"exclude-file-docstrings-ignore-case"`
+	DocStringsWholeWord        *bool    `yaml:"exclude-file-docstrings-whole-word"`
+	ExcludeFuncs               []string `yaml:"exclude-funcs"`
+	ChangedPkgs                []string `yaml:"changed-pkgs"`
+	WarnCategories             []string `yaml:"warn-categories"`
+	Stubs                      string   `yaml:"stubs"`
+	PackageDefaults            []string `yaml:"package-defaults"`
+	RespectBuildTags           *bool    `yaml:"respect-build-tags"`
+	SkipVendor                 *bool    `yaml:"skip-vendor"`
+	OutputFormat               string   `yaml:"output-format"`
+	OutputFile                 string   `yaml:"output-file"`
+	Sort                       string   `yaml:"sort"`
+	MaxErrorsPerPackage        int      `yaml:"max-errors-per-package"`
+	Baseline                   string   `yaml:"baseline"`
+	SummaryFile                string   `yaml:"summary-file"`
+	ReportHTML                 string   `yaml:"report-html"`
+	CacheDir                   string   `yaml:"cache-dir"`
+	ReportUndetermined         *bool    `yaml:"report-undetermined"`
+	StrictExported             *bool    `yaml:"strict-exported"`
+	BoundaryOnly               *bool    `yaml:"boundary-only"`
+	Explain                    *bool    `yaml:"explain"`
+	GroupErrors                *bool    `yaml:"group-errors"`
+	UnmarshalNilable           *bool    `yaml:"unmarshal-nilable"`
+	ReflectNilable             *bool    `yaml:"reflect-nilable"`
+	ConstructorFields          *bool    `yaml:"constructor-fields"`
+	ExportInScopeOnly          *bool    `yaml:"export-in-scope-only"`
+	MaxSites                   int      `yaml:"max-sites"`
+	MaxChainDepth              int      `yaml:"max-chain-depth"`
+	ExternalReturns            string   `yaml:"external-returns"`
+	TestFileMode               string   `yaml:"test-file-mode"`
+	PathBase                   string   `yaml:"path-base"`
+	RequireSuppressionReason   *bool    `yaml:"require-suppression-reason"`
+	SuppressionReasonDelimiter string   `yaml:"suppression-reason-delimiter"`
+	SuppressionReasonMinLength *int     `yaml:"suppression-reason-min-length"`
+	ReportRedundantChecks      *bool    `yaml:"report-redundant-checks"`
+}
+
+// LoadConfigFile reads the YAML or JSON configuration file at the given path and returns the
+// config populated from it, where options absent from the file keep their default values. A
+// missing file is not an error: the default config is returned instead. Relative paths in the
+// file are resolved against the directory of the file (see resolvePath).
+func LoadConfigFile(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newDefaultConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file %q: %w", path, err)
+	}
+
+	var fc fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// The yaml decoder reports the line number of any syntax error in its error messages, so
+	// here we simply wrap it with the file path.
+	if err := decoder.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config file %q: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	conf := newDefaultConfig()
+	if fc.PrettyPrint != nil {
+		conf.PrettyPrint = *fc.PrettyPrint
+	}
+	if fc.Quiet != nil {
+		conf.Quiet = *fc.Quiet
+	}
+	if fc.CollapseDuplicates != nil {
+		conf.CollapseDuplicates = *fc.CollapseDuplicates
+	}
+	conf.MessageTemplate = fc.MessageTemplate
+	if len(fc.IncludePkgs) != 0 {
+		if conf.includePkgs, err = parsePkgPatterns(fc.IncludePkgs); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if len(fc.ExcludePkgs) != 0 {
+		if conf.excludePkgs, err = parsePkgPatterns(fc.ExcludePkgs); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if len(fc.PkgRules) != 0 {
+		if conf.pkgRules, err = parsePkgRules(fc.PkgRules); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if len(fc.DistrustPkgs) != 0 {
+		if conf.distrustPkgs, err = parsePkgPatterns(fc.DistrustPkgs); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if len(fc.ExcludeFileDocStrings) != 0 {
+		conf.excludeFileDocStrings = fc.ExcludeFileDocStrings
+	}
+	if fc.DocStringsIgnoreCase != nil {
+		conf.docStringIgnoreCase = *fc.DocStringsIgnoreCase
+	}
+	if fc.DocStringsWholeWord != nil {
+		conf.docStringWholeWord = *fc.DocStringsWholeWord
+	}
+	if len(fc.ExcludeFuncs) != 0 {
+		conf.excludeFuncs = parseNameSet(fc.ExcludeFuncs)
+	}
+	if len(fc.ChangedPkgs) != 0 {
+		conf.changedPkgs = make(map[string]bool, len(fc.ChangedPkgs))
+		for _, p := range fc.ChangedPkgs {
+			conf.changedPkgs[p] = true
+		}
+	}
+	if len(fc.WarnCategories) != 0 {
+		conf.warnCategories = parseNameSet(fc.WarnCategories)
+	}
+	if fc.Stubs != "" {
+		if conf.Stubs, err = loadStubs(resolvePath(dir, fc.Stubs)); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if len(fc.PackageDefaults) != 0 {
+		if conf.packageDefaults, err = parsePackageDefaults(fc.PackageDefaults); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if fc.RespectBuildTags != nil {
+		conf.respectBuildTags = *fc.RespectBuildTags
+	}
+	if fc.SkipVendor != nil {
+		conf.skipVendor = *fc.SkipVendor
+	}
+	if fc.Sort != "" {
+		conf.SortOrder = fc.Sort
+	}
+	if fc.MaxErrorsPerPackage != 0 {
+		conf.MaxErrorsPerPackage = fc.MaxErrorsPerPackage
+	}
+	if fc.OutputFormat != "" {
+		if err := conf.setOutput(fc.OutputFormat, resolvePath(dir, fc.OutputFile)); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	} else {
+		conf.OutputFile = resolvePath(dir, fc.OutputFile)
+	}
+	if fc.ExternalReturns != "" {
+		if conf.externalReturnsNilable, err = parseExternalReturns(fc.ExternalReturns); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	if fc.TestFileMode != "" {
+		if conf.testFileMode, err = parseTestFileMode(fc.TestFileMode); err != nil {
+			return nil, fmt.Errorf("parse config file %q: %w", path, err)
+		}
+	}
+	conf.Baseline = resolvePath(dir, fc.Baseline)
+	conf.SummaryFile = resolvePath(dir, fc.SummaryFile)
+	conf.ReportHTML = resolvePath(dir, fc.ReportHTML)
+	conf.CacheDir = resolvePath(dir, fc.CacheDir)
+	conf.PathBase = fc.PathBase
+	if fc.PathBase != PathBaseModule {
+		conf.PathBase = resolvePath(dir, fc.PathBase)
+	}
+	if fc.ReportUndetermined != nil {
+		conf.ReportUndetermined = *fc.ReportUndetermined
+	}
+	if fc.StrictExported != nil {
+		conf.StrictExported = *fc.StrictExported
+	}
+	if fc.BoundaryOnly != nil {
+		conf.BoundaryOnly = *fc.BoundaryOnly
+	}
+	if fc.Explain != nil {
+		conf.Explain = *fc.Explain
+	}
+	if fc.GroupErrors != nil {
+		conf.GroupErrors = *fc.GroupErrors
+	}
+	if fc.UnmarshalNilable != nil {
+		conf.UnmarshalNilable = *fc.UnmarshalNilable
+	}
+	if fc.ReflectNilable != nil {
+		conf.ReflectNilable = *fc.ReflectNilable
+	}
+	if fc.ConstructorFields != nil {
+		conf.ConstructorFields = *fc.ConstructorFields
+	}
+	if fc.ExportInScopeOnly != nil {
+		conf.ExportInScopeOnly = *fc.ExportInScopeOnly
+	}
+	conf.MaxSites = fc.MaxSites
+	conf.MaxChainDepth = fc.MaxChainDepth
+	if fc.ReportRedundantChecks != nil {
+		conf.ReportRedundantChecks = *fc.ReportRedundantChecks
+	}
+	if fc.RequireSuppressionReason != nil {
+		conf.RequireSuppressionReason = *fc.RequireSuppressionReason
+	}
+	if fc.SuppressionReasonDelimiter != "" {
+		conf.SuppressionReasonDelimiter = fc.SuppressionReasonDelimiter
+	}
+	if fc.SuppressionReasonMinLength != nil {
+		conf.SuppressionReasonMinLength = *fc.SuppressionReasonMinLength
+	}
+	return conf, nil
+}
+
+// resolvePath resolves the path given in the configuration file against the directory of the
+// file, such that the file (e.g., the one found at the module root) behaves the same regardless of
+// the working directory. Empty and absolute paths, as well as "-" for stdout, are kept as is.
+func resolvePath(dir, path string) string {
+	if path == "" || path == "-" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// _configFiles caches the config loaded from each configuration file (see loadConfigFile), since
+// the same file applies to all packages of the module.
+var _configFiles sync.Map
+
+// configFileResult is the cached result of loading a configuration file.
+type configFileResult struct {
+	conf *Config
+	err  error
+}
+
+// loadConfigFile is LoadConfigFile cached per path, such that the file is read and parsed only
+// once. It returns a copy of the cached config such that the caller can override its values
+// (e.g., by the flags) for each package.
+func loadConfigFile(path string) (*Config, error) {
+	r, ok := _configFiles.Load(path)
+	if !ok {
+		conf, err := LoadConfigFile(path)
+		r, _ = _configFiles.LoadOrStore(path, configFileResult{conf: conf, err: err})
+	}
+	if err := r.(configFileResult).err; err != nil {
+		return nil, err
+	}
+	conf := *r.(configFileResult).conf
+	return &conf, nil
+}
+
+// _configFilePaths caches the configuration file path found for each directory, since the config
+// analyzer is run once for every package and the file system walk is otherwise repeated.
+var _configFilePaths sync.Map
+
+// discoverConfigFile walks up from the directory of the package being analyzed to the module
+// root (i.e., the directory containing "go.mod") and returns the path to the configuration file
+// there, or "" if no such file exists.
+func discoverConfigFile(pass *analysis.Pass) string {
+	if len(pass.Files) == 0 {
+		return ""
+	}
+	file := pass.Fset.File(pass.Files[0].Pos())
+	if file == nil {
+		return ""
+	}
+	dir := filepath.Dir(file.Name())
+	if path, ok := _configFilePaths.Load(dir); ok {
+		return path.(string)
+	}
+
+	path := ""
+	if root, ok := findModuleRoot(dir); ok {
+		for _, name := range _configFileNames {
+			if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+				path = filepath.Join(root, name)
+				break
+			}
+		}
+	}
+
+	_configFilePaths.Store(dir, path)
+	return path
+}
+
+// findModuleRoot walks up from the directory to the module root (i.e., the directory containing
+// "go.mod") and returns it, or false if the directory is not within a module.
+func findModuleRoot(dir string) (string, bool) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, true
+		}
+		if filepath.Dir(d) == d {
+			return "", false
+		}
+	}
+}
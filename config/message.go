@@ -0,0 +1,63 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// MessageFields are the fields available to the message template (see MessageTemplate).
+type MessageFields struct {
+	// Position is the position of the diagnostic in the form of "file:line:column", where the file
+	// is printed relative to the path base (see PathBase).
+	Position string
+	// Category is the category of the diagnostic (e.g., "mapread"), which is empty if the
+	// diagnostic fits none of the categories.
+	Category string
+	// RootSite is the position of the nil source of the diagnostic (i.e., the first node of its
+	// nil flow) in the same form as Position, or empty if it cannot be recovered.
+	RootSite string
+	// Message is the message of the diagnostic, which is pretty printed if PrettyPrint is set.
+	Message string
+}
+
+// parseMessageTemplate parses the message template and executes it once on empty fields, such that
+// references to unknown fields are rejected before the analysis instead of for each diagnostic.
+func parseMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New(MessageTemplateFlag).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, MessageFields{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// FormatMessage formats the fields of a diagnostic with the message template, or returns the
+// message as-is if no template is set.
+func (c *Config) FormatMessage(fields MessageFields) (string, error) {
+	if c.messageTemplate == nil {
+		return fields.Message, nil
+	}
+	var b strings.Builder
+	if err := c.messageTemplate.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("execute %s: %w", MessageTemplateFlag, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,147 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// This file contains the setters of the options that are not exported as fields of Config, since
+// they are stored in parsed forms. Together with New, they allow the drivers embedding NilAway
+// (see nilaway.Analyze) to build the config programmatically instead of through the flags. Each
+// setter accepts the same values as the corresponding flag (after splitting the lists).
+
+// New returns a config with all options set to their default values, which is the same config
+// the flags and the configuration file start from.
+func New() *Config {
+	return newDefaultConfig()
+}
+
+// SetIncludePkgs sets the list of packages to analyze (see IncludePkgsFlag), where no patterns
+// means all packages.
+func (c *Config) SetIncludePkgs(patterns ...string) error {
+	if len(patterns) == 0 {
+		c.includePkgs = []pkgPattern{{prefix: ""}}
+		return nil
+	}
+	parsed, err := parsePkgPatterns(patterns)
+	if err != nil {
+		return err
+	}
+	c.includePkgs = parsed
+	return nil
+}
+
+// SetExcludePkgs sets the list of packages to exclude from analysis (see ExcludePkgsFlag).
+func (c *Config) SetExcludePkgs(patterns ...string) error {
+	parsed, err := parsePkgPatterns(patterns)
+	if err != nil {
+		return err
+	}
+	c.excludePkgs = parsed
+	return nil
+}
+
+// SetPkgRules sets the ordered list of package rules refining the include and exclude lists (see
+// PkgRulesFlag).
+func (c *Config) SetPkgRules(rules ...string) error {
+	parsed, err := parsePkgRules(rules)
+	if err != nil {
+		return err
+	}
+	c.pkgRules = parsed
+	return nil
+}
+
+// SetDistrustPkgs sets the list of packages whose sites are ignored in the facts imported from the
+// upstream packages (see DistrustPkgsFlag).
+func (c *Config) SetDistrustPkgs(patterns ...string) error {
+	parsed, err := parsePkgPatterns(patterns)
+	if err != nil {
+		return err
+	}
+	c.distrustPkgs = parsed
+	return nil
+}
+
+// SetExcludeFileDocStrings sets the list of file doc strings that exclude the files from analysis
+// (see ExcludeFileDocStringsFlag), along with how they are matched.
+func (c *Config) SetExcludeFileDocStrings(ignoreCase, wholeWord bool, docStrings ...string) {
+	c.excludeFileDocStrings = docStrings
+	c.docStringIgnoreCase, c.docStringWholeWord = ignoreCase, wholeWord
+}
+
+// SetExcludeFuncs sets the qualified names of the functions whose diagnostics are not reported
+// (see ExcludeFuncsFlag).
+func (c *Config) SetExcludeFuncs(names ...string) {
+	c.excludeFuncs = parseNameSet(names)
+}
+
+// SetChangedPkgs sets the paths of the packages changed since the last run (see
+// ChangedPkgsFlag). Calling it without paths means no package has changed, in contrast to the
+// default of reporting all packages.
+func (c *Config) SetChangedPkgs(paths ...string) {
+	c.changedPkgs = make(map[string]bool, len(paths))
+	for _, p := range paths {
+		c.changedPkgs[p] = true
+	}
+}
+
+// SetPackageDefaults sets the rules of the form "<package prefix>:nilable|nonnil" for the
+// default nilability of the unannotated sites (see PackageDefaultsFlag).
+func (c *Config) SetPackageDefaults(rules ...string) error {
+	parsed, err := parsePackageDefaults(rules)
+	if err != nil {
+		return err
+	}
+	c.packageDefaults = parsed
+	return nil
+}
+
+// SetWarnCategories sets the diagnostic categories reported as warnings (see WarnCategoriesFlag).
+func (c *Config) SetWarnCategories(categories ...string) {
+	c.warnCategories = parseNameSet(categories)
+}
+
+// SetRespectBuildTags sets whether the files whose build constraints are not satisfied are
+// excluded from analysis (see RespectBuildTagsFlag).
+func (c *Config) SetRespectBuildTags(respect bool) {
+	c.respectBuildTags = respect
+}
+
+// SetSkipVendor sets whether the vendored packages are out of scope (see SkipVendorFlag).
+func (c *Config) SetSkipVendor(skip bool) {
+	c.skipVendor = skip
+}
+
+// SetExternalReturnsNilable sets whether the pointer results of the functions in the packages out
+// of scope are treated as nilable (see ExternalReturnsFlag).
+func (c *Config) SetExternalReturnsNilable(nilable bool) {
+	c.externalReturnsNilable = nilable
+}
+
+// SetTestFileMode sets the mode of analyzing the test files, which must be one of the
+// TestFileMode* constants (see TestFileModeFlag).
+func (c *Config) SetTestFileMode(mode string) error {
+	parsed, err := parseTestFileMode(mode)
+	if err != nil {
+		return err
+	}
+	c.testFileMode = parsed
+	return nil
+}
+
+// SetOutput sets the format of the additional output of the diagnostics (see the OutputFormat*
+// constants) and the file it is written to, where an empty file falls back to the default file of
+// the format.
+func (c *Config) SetOutput(format, file string) error {
+	return c.setOutput(format, file)
+}
@@ -0,0 +1,231 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// PackageProfile is the wall-clock time spent in each sub-analyzer of NilAway for a single
+// package, recorded if ProfilePackages is set.
+type PackageProfile struct {
+	// Path is the path of the package.
+	Path string `json:"path"`
+	// Durations maps the names of the sub-analyzers to the time spent in them for the package.
+	Durations map[string]time.Duration `json:"durations"`
+	// Sites is the number of sites in the inferred map of the package, or 0 if the package is not
+	// inferred (e.g., when it is out of scope).
+	Sites int `json:"sites"`
+}
+
+// Total returns the time spent in all sub-analyzers for the package.
+func (p PackageProfile) Total() time.Duration {
+	var total time.Duration
+	for _, d := range p.Durations {
+		total += d
+	}
+	return total
+}
+
+// profileRecorder accumulates the profiles of all packages analyzed in the process, which is
+// shared by the sub-analyzers since they are run separately (and concurrently) for each package.
+type profileRecorder struct {
+	mu       sync.Mutex
+	profiles map[string]*PackageProfile
+}
+
+// _profiles is the profile recorder of the process.
+var _profiles = profileRecorder{profiles: make(map[string]*PackageProfile)}
+
+// get returns the profile of the package, creating it if needed. The caller must hold the lock.
+func (r *profileRecorder) get(path string) *PackageProfile {
+	p, ok := r.profiles[path]
+	if !ok {
+		p = &PackageProfile{Path: path, Durations: make(map[string]time.Duration)}
+		r.profiles[path] = p
+	}
+	return p
+}
+
+// packageStats is the time spent in the sub-analyzers, the number of inferred sites and the errors
+// dropped by MaxErrorsPerPackage of a single package, which are recorded on the configuration of the package regardless of ProfilePackages
+// (e.g., for the metrics exported to the drivers). Since the sub-analyzers of a package may run
+// concurrently, the fields are guarded by the mutex.
+type packageStats struct {
+	mu       sync.Mutex
+	duration time.Duration
+	sites    int
+	// truncated is the positions of the errors dropped by MaxErrorsPerPackage.
+	truncated []token.Pos
+}
+
+// Profiled wraps the run function of a sub-analyzer (which must require Analyzer) such that the
+// wall-clock time spent in it is recorded for the package (see AnalysisTime), and for the summary
+// of all packages if ProfilePackages is set. The name of the analyzer is read from the pass, such
+// that the wrapper can be used in the declaration of the analyzer itself.
+func Profiled(run func(*analysis.Pass) (any, error)) func(*analysis.Pass) (any, error) {
+	return func(pass *analysis.Pass) (any, error) {
+		conf, ok := pass.ResultOf[Analyzer].(*Config)
+		if !ok {
+			return run(pass)
+		}
+
+		start := time.Now()
+		defer func() {
+			d := time.Since(start)
+			if conf.stats != nil {
+				conf.stats.mu.Lock()
+				conf.stats.duration += d
+				conf.stats.mu.Unlock()
+			}
+			if !conf.ProfilePackages {
+				return
+			}
+			_profiles.mu.Lock()
+			defer _profiles.mu.Unlock()
+			_profiles.get(pass.Pkg.Path()).Durations[pass.Analyzer.Name] += d
+		}()
+		return run(pass)
+	}
+}
+
+// AnalysisTime returns the wall-clock time spent so far in the sub-analyzers (see Profiled) for
+// the package being analyzed.
+func (c *Config) AnalysisTime() time.Duration {
+	if c.stats == nil {
+		return 0
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	return c.stats.duration
+}
+
+// InferredSites returns the number of sites in the inferred map of the package being analyzed, or
+// 0 if the package is not inferred (e.g., when it is out of scope).
+func (c *Config) InferredSites() int {
+	if c.stats == nil {
+		return 0
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	return c.stats.sites
+}
+
+// RecordInferredSites records the number of sites in the inferred map of the package (see
+// InferredSites), and for the summary of all packages if ProfilePackages is set, such that the
+// size of the package can be correlated with the time spent.
+func (c *Config) RecordInferredSites(pass *analysis.Pass, sites int) {
+	if c.stats != nil {
+		c.stats.mu.Lock()
+		c.stats.sites = sites
+		c.stats.mu.Unlock()
+	}
+	if !c.ProfilePackages {
+		return
+	}
+	_profiles.mu.Lock()
+	defer _profiles.mu.Unlock()
+	_profiles.get(pass.Pkg.Path()).Sites = sites
+}
+
+// TruncatedErrors returns the positions of the errors of the package being analyzed that are
+// dropped by MaxErrorsPerPackage, such that the drivers counting the reported errors (after their
+// own filtering by position) can count the true total.
+func (c *Config) TruncatedErrors() []token.Pos {
+	if c.stats == nil {
+		return nil
+	}
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	return c.stats.truncated
+}
+
+// RecordTruncatedErrors records the positions of the errors of the package dropped by
+// MaxErrorsPerPackage (see TruncatedErrors).
+func (c *Config) RecordTruncatedErrors(errors []token.Pos) {
+	if c.stats != nil {
+		c.stats.mu.Lock()
+		c.stats.truncated = errors
+		c.stats.mu.Unlock()
+	}
+}
+
+// PackageProfiles returns the profiles of all packages recorded so far in the process, sorted by
+// their total time in descending order (see SortPackageProfiles).
+func PackageProfiles() []PackageProfile {
+	_profiles.mu.Lock()
+	defer _profiles.mu.Unlock()
+
+	profiles := make([]PackageProfile, 0, len(_profiles.profiles))
+	for _, p := range _profiles.profiles {
+		durations := make(map[string]time.Duration, len(p.Durations))
+		for name, d := range p.Durations {
+			durations[name] = d
+		}
+		profiles = append(profiles, PackageProfile{Path: p.Path, Durations: durations, Sites: p.Sites})
+	}
+	SortPackageProfiles(profiles)
+	return profiles
+}
+
+// SortPackageProfiles sorts the profiles by their total time in descending order, where the ties
+// are broken by the package paths.
+func SortPackageProfiles(profiles []PackageProfile) {
+	sort.Slice(profiles, func(i, j int) bool {
+		if ti, tj := profiles[i].Total(), profiles[j].Total(); ti != tj {
+			return ti > tj
+		}
+		return profiles[i].Path < profiles[j].Path
+	})
+}
+
+// WriteProfileSummary writes the summary of the (sorted) profiles as a table to w, with one row
+// per package listing the total time, the number of inferred sites and the time spent in each
+// sub-analyzer.
+func WriteProfileSummary(w io.Writer, profiles []PackageProfile) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tTIME\tSITES\tANALYZERS")
+	for _, p := range profiles {
+		names := make([]string, 0, len(p.Durations))
+		for name := range p.Durations {
+			names = append(names, name)
+		}
+		// List the most expensive sub-analyzers first.
+		sort.Slice(names, func(i, j int) bool {
+			if di, dj := p.Durations[names[i]], p.Durations[names[j]]; di != dj {
+				return di > dj
+			}
+			return names[i] < names[j]
+		})
+		var analyzers []string
+		for _, name := range names {
+			// Omit the negligible ones to keep the summary readable.
+			if d := p.Durations[name].Round(time.Millisecond); d > 0 {
+				analyzers = append(analyzers, fmt.Sprintf("%s=%s", name, d))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", p.Path, p.Total().Round(time.Millisecond), p.Sites, strings.Join(analyzers, " "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,191 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The kinds of the sites of the stubs (see Stub.Site).
+const (
+	// StubSiteValue is the site of a field or a global variable itself.
+	StubSiteValue = ""
+	// StubSiteRecv is the site of the receiver of a method.
+	StubSiteRecv = "recv"
+	// StubSiteParam is the site of a parameter of a function.
+	StubSiteParam = "param"
+	// StubSiteResult is the site of a result of a function.
+	StubSiteResult = "result"
+)
+
+// Stub is the nilability override of a single site loaded from the stub file, which maps the
+// qualified identifiers of the functions, fields and global variables to their nilability. The
+// stub file is a YAML (or JSON) mapping from the identifiers to either a nilability (for fields
+// and global variables) or a mapping from the sites of the functions ("recv", "param N" and
+// "result N") to their nilability, for example:
+//
+//	os.LookupEnv:
+//	  result 0: nonnil
+//	github.com/acme/foo.(*Client).Get:
+//	  param 0: nilable
+//	  result 0: nilable,deepnonnil
+//	github.com/acme/foo.Config.Logger: nilable
+//
+// A nilability is a comma-separated list of "nilable" or "nonnil" for the shallow nilability and
+// "deepnilable" or "deepnonnil" for the deep nilability, where the unspecified ones are left to
+// the analysis.
+type Stub struct {
+	// Name is the qualified identifier of the function (see FuncName), the field (of the form
+	// "pkgpath.Type.Field") or the global variable (of the form "pkgpath.Var").
+	Name string
+	// Site is the kind of the site (see the StubSite* constants).
+	Site string
+	// Index is the index of the parameter or the result, and 0 for the other kinds of sites.
+	Index int
+	// Nilable and DeepNilable are the shallow and deep nilability of the site, nil if unspecified.
+	Nilable, DeepNilable *bool
+	// Position is the position of the stub in the stub file.
+	Position token.Position
+}
+
+// String returns the string representation of the stub, which is used in the fingerprint of the
+// config.
+func (s Stub) String() string {
+	val := func(b *bool) string {
+		if b == nil {
+			return "-"
+		}
+		return strconv.FormatBool(*b)
+	}
+	return fmt.Sprintf("%s:%s:%d:%s:%s", s.Name, s.Site, s.Index, val(s.Nilable), val(s.DeepNilable))
+}
+
+// _stubs caches the stubs loaded for each stub file, since the config analyzer is run once for
+// every package.
+var _stubs sync.Map
+
+// stubsResult is the cached result of loading a stub file.
+type stubsResult struct {
+	stubs []Stub
+	err   error
+}
+
+// loadStubs loads the stubs from the stub file at the given path, see Stub for the format.
+func loadStubs(path string) ([]Stub, error) {
+	if r, ok := _stubs.Load(path); ok {
+		return r.(stubsResult).stubs, r.(stubsResult).err
+	}
+	stubs, err := parseStubs(path)
+	_stubs.Store(path, stubsResult{stubs: stubs, err: err})
+	return stubs, err
+}
+
+// parseStubs reads and parses the stub file at the given path.
+func parseStubs(path string) ([]Stub, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read stub file %q: %w", path, err)
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse stub file %q: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	position := func(n *yaml.Node) token.Position {
+		return token.Position{Filename: path, Line: n.Line, Column: n.Column}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse stub file %q: line %d: expected a mapping from identifiers to nilability", path, root.Line)
+	}
+	var stubs []Stub
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, value := root.Content[i], root.Content[i+1]
+		if value.Kind == yaml.ScalarNode {
+			stub := Stub{Name: name.Value, Site: StubSiteValue, Position: position(name)}
+			if err := parseStubVal(value.Value, &stub); err != nil {
+				return nil, fmt.Errorf("parse stub file %q: line %d: %w", path, value.Line, err)
+			}
+			stubs = append(stubs, stub)
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("parse stub file %q: line %d: expected a nilability or a mapping from sites to nilability", path, value.Line)
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			site, val := value.Content[j], value.Content[j+1]
+			stub := Stub{Name: name.Value, Position: position(site)}
+			if err := parseStubSite(site.Value, &stub); err != nil {
+				return nil, fmt.Errorf("parse stub file %q: line %d: %w", path, site.Line, err)
+			}
+			if err := parseStubVal(val.Value, &stub); err != nil {
+				return nil, fmt.Errorf("parse stub file %q: line %d: %w", path, val.Line, err)
+			}
+			stubs = append(stubs, stub)
+		}
+	}
+	return stubs, nil
+}
+
+// parseStubSite parses the site of a function stub ("recv", "param N" or "result N") into the stub.
+func parseStubSite(site string, stub *Stub) error {
+	if site == StubSiteRecv {
+		stub.Site = StubSiteRecv
+		return nil
+	}
+	kind, index, ok := strings.Cut(site, " ")
+	if !ok || (kind != StubSiteParam && kind != StubSiteResult) {
+		return fmt.Errorf("invalid site %q, expected %q, %q or %q", site, StubSiteRecv, "param N", "result N")
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid index in site %q", site)
+	}
+	stub.Site, stub.Index = kind, n
+	return nil
+}
+
+// parseStubVal parses the nilability (e.g., "nilable,deepnonnil") into the stub.
+func parseStubVal(val string, stub *Stub) error {
+	for _, token := range strings.Split(val, ",") {
+		b := false
+		switch strings.TrimSpace(token) {
+		case "nilable":
+			b = true
+			stub.Nilable = &b
+		case "nonnil":
+			stub.Nilable = &b
+		case "deepnilable":
+			b = true
+			stub.DeepNilable = &b
+		case "deepnonnil":
+			stub.DeepNilable = &b
+		default:
+			return fmt.Errorf("invalid nilability %q, expected \"nilable\", \"nonnil\", \"deepnilable\" or \"deepnonnil\"", token)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import "go.uber.org/nilaway/annotation"
+
+// The categories of the diagnostics (see analysis.Diagnostic.Category), which are decided by the
+// kind of the expression whose value is consumed at the point of the potential nil panic.
+// Diagnostics that fit none of the categories are uncategorized (i.e., with an empty category).
+const (
+	// CategoryMapRead is the category of the diagnostics where the value read from a map is
+	// consumed, e.g., `*m[k]`.
+	CategoryMapRead = "mapread"
+	// CategoryFuncReturn is the category of the diagnostics where the result of a function (or
+	// method) call is consumed, e.g., `*f()`.
+	CategoryFuncReturn = "funcret"
+	// CategoryFieldAccess is the category of the diagnostics where the value read from a field is
+	// consumed, e.g., `*s.f`.
+	CategoryFieldAccess = "fieldaccess"
+	// CategoryRedundantCheck is the category of the cleanup hints on the nil checks that can never
+	// be true (or never be false), see RedundantNilCheckDiagnostics.
+	CategoryRedundantCheck = "redundantcheck"
+	// CategoryLongChain is the category of the diagnostics whose nil flows are longer than the
+	// configured maximum (see config.Config.MaxChainDepth), which replaces their original categories.
+	CategoryLongChain = "longchain"
+)
+
+// categoryOf returns the category of the diagnostic whose consumed value is produced as described
+// by the producer prestring, or "" if it fits none of the categories.
+func categoryOf(producer annotation.Prestring) string {
+	if l, ok := producer.(annotation.LocatedPrestring); ok {
+		producer = l.Contained
+	}
+	// The deep reads of the map-typed variables (e.g., `m[k]` for a map parameter `m`) are
+	// wrapped in GuardMissing if they are not guarded by the `v, ok := m[k]` form. Besides them,
+	// only the results of the error-returning functions, channel receives, type assertions,
+	// library functions with `(value, ok)` semantics and the targets of `errors.As` can be wrapped.
+	if g, ok := producer.(annotation.GuardMissingPrestring); ok {
+		switch g.OldPrestring.(type) {
+		case annotation.ChanRecvPrestring, annotation.TypeAssertionPrestring:
+			return ""
+		case annotation.OkFuncReturnPrestring, annotation.ErrorsAsTargetPrestring:
+			return CategoryFuncReturn
+		}
+		if c := categoryOf(g.OldPrestring); c != "" {
+			return c
+		}
+		return CategoryMapRead
+	}
+	switch producer.(type) {
+	case annotation.MapReadPrestring:
+		return CategoryMapRead
+	case annotation.FuncReturnPrestring, annotation.MethodReturnPrestring, annotation.ContextValuePrestring,
+		annotation.ReflectInterfacePrestring:
+		return CategoryFuncReturn
+	case annotation.FldReadPrestring, annotation.ParamFldReadPrestring:
+		return CategoryFieldAccess
+	default:
+		return ""
+	}
+}
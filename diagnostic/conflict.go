@@ -10,6 +10,8 @@ type conflict struct {
 	pos              token.Pos   // stores position where the error should be reported (note that this field is used only within the current, and should NOT be exported)
 	flow             nilFlow     // stores nil flow from source to dereference point
 	similarConflicts []*conflict // stores other conflicts that are similar to this one
+	category         string      // stores the category of the conflict (see categoryOf)
+	boundary         bool        // stores whether the conflict concerns an exported site (see config.Config.BoundaryOnly)
 }
 
 func (c *conflict) String() string {
@@ -41,11 +43,30 @@ func (c *conflict) addSimilarConflict(conflict conflict) {
 
 // groupConflicts groups conflicts with the same nil path together and update conflicts list.
 func groupConflicts(allConflicts []conflict) []conflict {
-	conflictsMap := make(map[string]int)  // key: nil path string, value: index in `allConflicts`
+	return groupConflictsBy(allConflicts, func(c conflict) string {
+		return pathString(c.flow.nilPath)
+	})
+}
+
+// groupConflictsByRoot groups conflicts with the same root nil source (i.e., the first node of
+// the nil path, which is the site determined to be nilable at the end of the chain of implications
+// leading to the conflict) together, even if the nil flows from the source differ.
+func groupConflictsByRoot(allConflicts []conflict) []conflict {
+	return groupConflictsBy(allConflicts, func(c conflict) string {
+		if len(c.flow.nilPath) == 0 {
+			return ""
+		}
+		return c.flow.nilPath[0].String()
+	})
+}
+
+// groupConflictsBy groups conflicts with the same key together and update conflicts list.
+func groupConflictsBy(allConflicts []conflict, keyOf func(conflict) string) []conflict {
+	conflictsMap := make(map[string]int)  // key: grouping key, value: index in `allConflicts`
 	indicesToIgnore := make(map[int]bool) // indices of conflicts to be ignored from `allConflicts`, since they are grouped with other conflicts
 
 	for i, c := range allConflicts {
-		key := pathString(c.flow.nilPath)
+		key := keyOf(c)
 
 		// Handle the case of single assertion conflict separately
 		if len(c.flow.nilPath) == 0 && len(c.flow.nonnilPath) == 1 {
@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 
 	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/inference"
 	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
@@ -44,6 +45,23 @@ type Engine struct {
 	// for faster lookup when converting correct upstream position back to local token.Pos for
 	// reporting purposes.
 	files map[string]fileInfo
+	// truncatedFiles maps the truncated file names (see util.TruncatePosition) to the file names in
+	// files, for recovering the complete positions of the nodes in the nil flows. It is lazily
+	// initialized since it is only needed when there are conflicts.
+	truncatedFiles map[string][]string
+	// relativePath rewrites the file names of the positions printed in the nil flows if a path
+	// base is configured (see config.Config.PathBase), and is nil otherwise.
+	relativePath func(filename string) string
+	// maxChainDepth is the maximum number of nodes in the nil flows of the conflicts, beyond which
+	// the conflicts are categorized as CategoryLongChain (see config.Config.MaxChainDepth). Zero
+	// means no limit.
+	maxChainDepth int
+	// boundaryOnly indicates whether only the conflicts concerning the exported sites are reported
+	// (see config.Config.BoundaryOnly).
+	boundaryOnly bool
+	// explain indicates whether a verbose explanation is appended to each diagnostic (see
+	// config.Config.Explain).
+	explain bool
 }
 
 // NewEngine creates a new diagnostic engine.
@@ -92,44 +110,293 @@ func NewEngine(pass *analysis.Pass) *Engine {
 		return true
 	})
 
-	return &Engine{pass: pass, files: files}
+	var relativePath func(string) string
+	maxChainDepth, boundaryOnly, explain := 0, false, false
+	if conf, ok := pass.ResultOf[config.Analyzer].(*config.Config); ok {
+		if conf.PathBase != "" {
+			relativePath = conf.RelativePath
+		}
+		maxChainDepth, boundaryOnly, explain = conf.MaxChainDepth, conf.BoundaryOnly, conf.Explain
+	}
+
+	return &Engine{
+		pass:          pass,
+		files:         files,
+		relativePath:  relativePath,
+		maxChainDepth: maxChainDepth,
+		boundaryOnly:  boundaryOnly,
+		explain:       explain,
+	}
 }
 
 // Diagnostics generates diagnostics from the internally-stored conflicts. The grouping parameter
 // controls whether the conflicts with the same nil flow -- the part in the complete nil flow going
 // from a nilable source point to the conflict point -- are grouped together for concise reporting.
 func (e *Engine) Diagnostics(grouping bool) []analysis.Diagnostic {
-	conflicts := e.conflicts
+	conflicts := e.categorizeLongChains(e.boundaryConflicts(e.unsuppressedConflicts()))
 	if grouping {
 		// group conflicts with the same nil path together for concise reporting
-		conflicts = groupConflicts(e.conflicts)
+		conflicts = groupConflicts(conflicts)
 	}
 
 	// build diagnostics from conflicts
+	return e.diagnosticsOf(conflicts)
+}
+
+// DiagnosticsGroupedByRoot is similar to Diagnostics with grouping, except that the conflicts are
+// grouped by their root nil sources only, regardless of the nil flows from the sources to the
+// conflict points. A single diagnostic is generated for each root nil source, where the other
+// conflict points caused by the same source are attached as related information.
+func (e *Engine) DiagnosticsGroupedByRoot() []analysis.Diagnostic {
+	return e.diagnosticsOf(groupConflictsByRoot(e.categorizeLongChains(e.boundaryConflicts(e.unsuppressedConflicts()))))
+}
+
+// SameSourceMessage is the message of the related information attached to a diagnostic for each of
+// the other conflict points grouped with it (i.e., caused by the same nil source).
+const SameSourceMessage = "potential nil panic caused by the same nil source"
+
+// diagnosticsOf builds a diagnostic for each of the (possibly grouped) conflicts, where the nodes
+// of the nil flow and the other conflict points grouped with the conflict are attached as related
+// information. The verbose explanation of the nil flow is appended to the message if configured
+// (see config.Config.Explain).
+func (e *Engine) diagnosticsOf(conflicts []conflict) []analysis.Diagnostic {
 	diagnostics := make([]analysis.Diagnostic, 0, len(conflicts))
 	for _, c := range conflicts {
+		related := e.relatedInformation(c.flow)
+		for _, s := range c.similarConflicts {
+			related = append(related, analysis.RelatedInformation{
+				Pos:     s.pos,
+				Message: SameSourceMessage,
+			})
+		}
+		relocated := e.relocate(&c)
+		message := relocated.String()
+		if e.explain {
+			message += relocated.flow.explanation()
+		}
+		diagnostics = append(diagnostics, analysis.Diagnostic{
+			Pos:      c.pos,
+			Category: c.category,
+			Message:  message,
+			Related:  related,
+		})
+	}
+	return diagnostics
+}
+
+// unsuppressedConflicts filters out the conflicts suppressed by nolint directives or reported
+// within the excluded functions. This must be done before grouping such that the suppressed
+// conflicts do not hide the other conflicts grouped with them. Note that conflicts reported
+// elsewhere are kept even if their nil flows go through the excluded functions.
+func (e *Engine) unsuppressedConflicts() []conflict {
+	suppressed, excluded := suppressedLines(e.pass), excludedFuncRanges(e.pass)
+	if len(suppressed) == 0 && len(excluded) == 0 {
+		return e.conflicts
+	}
+	conflicts := make([]conflict, 0, len(e.conflicts))
+	for _, c := range e.conflicts {
+		if !isSuppressed(suppressed, e.pass.Fset.Position(c.pos)) && !isExcluded(excluded, c.pos) {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// boundaryConflicts filters out the conflicts not concerning any exported sites if only the
+// boundary of the package is reported (see config.Config.BoundaryOnly). Similar to
+// unsuppressedConflicts, this must be done before grouping.
+func (e *Engine) boundaryConflicts(conflicts []conflict) []conflict {
+	if !e.boundaryOnly {
+		return conflicts
+	}
+	filtered := make([]conflict, 0, len(conflicts))
+	for _, c := range conflicts {
+		if c.boundary {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// categorizeLongChains returns a copy of the conflicts where the ones whose nil flows, from the
+// nil sources to the dereference points, have more nodes (i.e., hops in the implication graph)
+// than the configured maximum are categorized as CategoryLongChain. This must be done before
+// grouping such that the long chains are not hidden behind (or hiding) the other conflicts.
+func (e *Engine) categorizeLongChains(conflicts []conflict) []conflict {
+	if e.maxChainDepth <= 0 {
+		return conflicts
+	}
+	categorized := make([]conflict, len(conflicts))
+	for i, c := range conflicts {
+		if len(c.flow.nilPath)+len(c.flow.nonnilPath) > e.maxChainDepth {
+			c.category = CategoryLongChain
+		}
+		categorized[i] = c
+	}
+	return categorized
+}
+
+// UndeterminedSiteDiagnostics returns a diagnostic for each of the sites whose nilability cannot be
+// inferred, reported at the declarations of the sites.
+func (e *Engine) UndeterminedSiteDiagnostics(sites []inference.UndeterminedSite) []analysis.Diagnostic {
+	diagnostics := make([]analysis.Diagnostic, 0, len(sites))
+	for _, s := range sites {
+		deepStr := ""
+		if s.IsDeep {
+			deepStr = "deep "
+		}
+		diagnostics = append(diagnostics, analysis.Diagnostic{
+			Pos: e.toPos(s.Position),
+			Message: fmt.Sprintf("Undetermined %snilability of exported %s site `%s`, consider annotating it "+
+				"as nilable or nonnil", deepStr, s.Kind, s.Repr),
+		})
+	}
+	return diagnostics
+}
+
+// _strictSiteKinds maps the kinds of the sites checked in strict mode (see
+// StrictExportedDiagnostics) to their descriptions in the diagnostics.
+var _strictSiteKinds = map[inference.SiteKind]string{
+	inference.SiteKindParam:  "param",
+	inference.SiteKindReturn: "return",
+	inference.SiteKindRecv:   "receiver",
+	inference.SiteKindField:  "field",
+}
+
+// StrictExportedDiagnostics returns a diagnostic for each of the exported params, results,
+// receivers and fields whose nilability is not determined after inference, reported at the
+// declarations of the sites as errors of the strict mode (see config.Config.StrictExported).
+// The other kinds of sites (e.g., global variables) are skipped.
+func (e *Engine) StrictExportedDiagnostics(sites []inference.UndeterminedSite) []analysis.Diagnostic {
+	var diagnostics []analysis.Diagnostic
+	for _, s := range sites {
+		kind, ok := _strictSiteKinds[s.Kind]
+		if !ok {
+			continue
+		}
+		deepStr := ""
+		if s.IsDeep {
+			deepStr = "deep "
+		}
 		diagnostics = append(diagnostics, analysis.Diagnostic{
-			Pos:     c.pos,
-			Message: c.String(),
+			Pos: e.toPos(s.Position),
+			Message: fmt.Sprintf("Missing explicit %snilability of exported %s `%s` (strict mode), annotate it "+
+				"as nilable or nonnil", deepStr, kind, s.Repr),
 		})
 	}
 	return diagnostics
 }
 
+// relatedInformation returns the nodes of the nil flow, from the nil source to the dereference
+// point, as related information of the diagnostic such that the flow can be followed by tools
+// consuming structured output (e.g., SARIF). Nodes whose positions cannot be recovered are omitted.
+func (e *Engine) relatedInformation(flow nilFlow) []analysis.RelatedInformation {
+	var related []analysis.RelatedInformation
+	for _, nodes := range [...][]node{flow.nilPath, flow.nonnilPath} {
+		for _, n := range nodes {
+			if !n.consumerPosition.IsValid() {
+				continue
+			}
+			position, ok := e.untruncate(n.consumerPosition)
+			if !ok {
+				continue
+			}
+			related = append(related, analysis.RelatedInformation{
+				Pos:     e.toPos(position),
+				Message: n.reason(),
+			})
+		}
+	}
+	return related
+}
+
+// untruncate recovers the complete file name of a position truncated by util.TruncatePosition
+// from the files known to the engine. It returns false if the file name is unknown or ambiguous.
+func (e *Engine) untruncate(position token.Position) (token.Position, bool) {
+	if e.truncatedFiles == nil {
+		e.truncatedFiles = make(map[string][]string, len(e.files))
+		for name, info := range e.files {
+			// The original file name is truncated instead of the (possibly relative) name, such
+			// that the directory of the files at the working directory is kept (see
+			// util.TruncatePosition).
+			truncated := util.TruncatePosition(token.Position{Filename: info.file.Name()}).Filename
+			e.truncatedFiles[truncated] = append(e.truncatedFiles[truncated], name)
+		}
+	}
+
+	names := e.truncatedFiles[position.Filename]
+	if len(names) != 1 {
+		return token.Position{}, false
+	}
+	position.Filename = names[0]
+	return position, true
+}
+
+// relocate returns a copy of the conflict whose printed positions are relative to the path base
+// (see config.Config.PathBase). The positions whose complete file names cannot be recovered are
+// kept truncated.
+func (e *Engine) relocate(original *conflict) *conflict {
+	if e.relativePath == nil {
+		return original
+	}
+	c := *original
+	for _, nodes := range [...]*[]node{&c.flow.nilPath, &c.flow.nonnilPath} {
+		relocated := make([]node, len(*nodes))
+		for i, n := range *nodes {
+			n.producerPosition = e.relocatePosition(n.producerPosition)
+			n.consumerPosition = e.relocatePosition(n.consumerPosition)
+			relocated[i] = n
+		}
+		*nodes = relocated
+	}
+	similarConflicts := make([]*conflict, len(c.similarConflicts))
+	for i, s := range c.similarConflicts {
+		similarConflicts[i] = e.relocate(s)
+	}
+	c.similarConflicts = similarConflicts
+	return &c
+}
+
+// relocatePosition recovers the complete file name of the truncated position and makes it
+// relative to the path base, see relocate.
+func (e *Engine) relocatePosition(position token.Position) token.Position {
+	if !position.IsValid() {
+		return position
+	}
+	complete, ok := e.untruncate(position)
+	if !ok {
+		return position
+	}
+	complete.Filename = e.relativePath(complete.Filename)
+	return complete
+}
+
 // AddSingleAssertionConflict adds a new single assertion conflict to the engine.
 func (e *Engine) AddSingleAssertionConflict(trigger annotation.FullTrigger) {
 	producer, consumer := trigger.Prestrings(e.pass)
 	flow := nilFlow{}
 	flow.addNonNilPathNode(producer, consumer)
 
+	// Without inference, the conflict concerns an exported site if either side of the trigger
+	// depends on one (e.g., an annotated exported param or result).
+	boundary := false
+	for _, site := range [...]annotation.Key{trigger.Producer.Annotation.UnderlyingSite(), trigger.Consumer.Annotation.UnderlyingSite()} {
+		if site != nil && site.Object().Exported() {
+			boundary = true
+		}
+	}
+
 	e.conflicts = append(e.conflicts, conflict{
-		pos:  trigger.Consumer.Expr.Pos(),
-		flow: flow,
+		pos:      trigger.Consumer.ReportPos(),
+		flow:     flow,
+		category: categoryOf(producer),
+		boundary: boundary,
 	})
 }
 
-// AddOverconstraintConflict adds a new overconstraint conflict to the engine.
-func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.ExplainedBool) {
+// AddOverconstraintConflict adds a new overconstraint conflict to the engine, where exported
+// indicates whether the overconstrained site is exported.
+func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.ExplainedBool, exported bool) {
 	flow := nilFlow{}
 
 	// Build nil path by traversing the inference graph from `nilReason` part of the overconstraint failure.
@@ -157,6 +424,7 @@ func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.Exp
 	// i.e., the point of dereference where the nil panic would occur. In NilAway's context this is the last node
 	// in the non-nil path. Therefore, we keep updating `c.pos` until we reach the end of the non-nil path.
 	var reportPosition token.Position
+	var category string
 	for r := nonnilReason; r != nil; r = r.DeeperReason() {
 		producer, consumer := r.TriggerReprs()
 		position := r.Position()
@@ -166,18 +434,22 @@ func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.Exp
 		if producer != nil && consumer != nil {
 			flow.addNonNilPathNode(producer, consumer)
 			reportPosition = position
+			category = categoryOf(producer)
 		} else {
 			flow.addNonNilPathNode(annotation.LocatedPrestring{
 				Contained: r,
 				Location:  util.TruncatePosition(r.Position()),
 			}, nil)
 			reportPosition = position
+			category = ""
 		}
 	}
 
 	e.conflicts = append(e.conflicts, conflict{
-		pos:  e.toPos(reportPosition),
-		flow: flow,
+		pos:      e.toPos(reportPosition),
+		flow:     flow,
+		category: category,
+		boundary: exported,
 	})
 }
 
@@ -0,0 +1,66 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// posRange is a range [start, end) of positions in the local file set.
+type posRange struct {
+	start, end token.Pos
+}
+
+// excludedFuncRanges returns the ranges of the declarations of the functions in the package that
+// are excluded from reporting by the config (see config.Config.IsFuncInScope). Since the ranges
+// cover the entire declarations, the anonymous functions declared within the excluded functions
+// are excluded as well.
+func excludedFuncRanges(pass *analysis.Pass) []posRange {
+	conf, ok := pass.ResultOf[config.Analyzer].(*config.Config)
+	if !ok {
+		return nil
+	}
+
+	var ranges []posRange
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+			if !ok || conf.IsFuncInScope(fn) {
+				continue
+			}
+			ranges = append(ranges, posRange{start: funcDecl.Pos(), end: funcDecl.End()})
+		}
+	}
+	return ranges
+}
+
+// isExcluded returns true iff the position falls in any of the excluded ranges.
+func isExcluded(ranges []posRange, pos token.Pos) bool {
+	for _, r := range ranges {
+		if r.start <= pos && pos < r.end {
+			return true
+		}
+	}
+	return false
+}
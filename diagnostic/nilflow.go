@@ -75,13 +75,79 @@ func newNode(p annotation.Prestring, c annotation.Prestring) node {
 	return nodeObj
 }
 
-func (n *node) String() string {
-	posStr := "<no pos info>"
-	reasonStr := ""
+// explanation returns a verbose explanation of the nil flow (see config.Config.Explain), which
+// walks through the nil source, the assertion behind each step of the flow and the dereference
+// point. It is indented and delimited such that it can be appended to the concise diagnostic.
+func (n *nilFlow) explanation() string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, "\t| "+fmt.Sprintf(format, args...))
+	}
+
+	var allNodes []node
+	allNodes = append(allNodes, n.nilPath...)
+	allNodes = append(allNodes, n.nonnilPath...)
+	if len(allNodes) == 0 {
+		return ""
+	}
+
+	// The nodes describing the annotations (or stubs) have no consumers, and their producers
+	// already explain why the sites are NILABLE (or NONNIL).
+	if source := allNodes[0]; source.consumerRepr == "" {
+		add("nil source (%s): the site is %s", source.posString(), source.producerRepr)
+	} else {
+		add("nil source (%s): %s", source.posString(), source.producerRepr)
+	}
+
+	step := 0
+	explainSteps := func(nodes []node, effect string) {
+		for _, nodeObj := range nodes {
+			step++
+			add("step %d (%s): %s", step, nodeObj.posString(), nodeObj.reason())
+			if nodeObj.consumerRepr != "" {
+				add("    %s", effect)
+			}
+		}
+	}
+	explainSteps(n.nilPath, "the value may be nil here, hence the site it flows into is NILABLE")
+	if len(n.nilPath) > 0 && len(n.nonnilPath) > 0 {
+		add("conflict: the site reached above is NILABLE, but the steps below require it to be NONNIL")
+	}
+	nonnilEffect := "the value must be nonnil here, hence the site it flows from must be NONNIL"
+	if len(n.nilPath) == 0 && len(n.nonnilPath) == 1 {
+		// A single assertion conflicts by itself without involving any sites.
+		nonnilEffect = "the value is nil but must be nonnil here"
+	}
+	explainSteps(n.nonnilPath, nonnilEffect)
+
+	if deref := allNodes[len(allNodes)-1]; deref.consumerRepr == "" {
+		add("dereference (%s): the site is %s", deref.posString(), deref.producerRepr)
+	} else {
+		add("dereference (%s): %s", deref.posString(), deref.consumerRepr)
+	}
+
+	return "\t--- explanation ---\n" + strings.Join(lines, "\n") + "\n\t--- end of explanation ---\n"
+}
+
+// posString returns the position of the node, which is the consumer position if available, or
+// the producer position otherwise.
+func (n *node) posString() string {
 	if n.consumerPosition.IsValid() {
-		posStr = n.consumerPosition.String()
+		return n.consumerPosition.String()
+	} else if n.producerPosition.IsValid() {
+		// Nodes describing the annotations (or stubs) only have producer positions.
+		return n.producerPosition.String()
 	}
+	return "<no pos info>"
+}
+
+func (n *node) String() string {
+	return fmt.Sprintf("\t-> %s: %s", n.posString(), n.reason())
+}
 
+// reason returns the description of the node, i.e., the producer and consumer representations.
+func (n *node) reason() string {
+	reasonStr := ""
 	if len(n.producerRepr) > 0 {
 		reasonStr += n.producerRepr
 	}
@@ -91,8 +157,7 @@ func (n *node) String() string {
 		}
 		reasonStr += n.consumerRepr
 	}
-
-	return fmt.Sprintf("\t-> %s: %s", posStr, reasonStr)
+	return reasonStr
 }
 
 func pathString(nodes []node) string {
@@ -0,0 +1,207 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _nolintDirective is the comment directive for suppressing diagnostics, which follows the
+// convention of golangci-lint: "//nolint" suppresses all linters, and "//nolint:a,b" suppresses
+// only the listed linters. Anything after the linter list (e.g., "// explanation") is ignored.
+const _nolintDirective = "nolint"
+
+// _nolintName is the linter name that the nolint directives refer to for NilAway.
+const _nolintName = "nilaway"
+
+// lineRange is an inclusive range of lines in a file.
+type lineRange struct {
+	start, end int
+}
+
+// suppressedLines returns the ranges of lines in each file (keyed by file name) where NilAway
+// diagnostics are suppressed by "//nolint:nilaway" (or "//nolint") directives. The exact matching
+// rules are the following:
+//
+//   - A directive trailing code on the same line suppresses diagnostics reported on that line.
+//   - A directive on its own line suppresses diagnostics within the outermost syntax node (e.g., a
+//     statement, a block, or a function declaration) starting on the line right after the comment
+//     group containing the directive, or only diagnostics on that line if no node starts there.
+func suppressedLines(pass *analysis.Pass) map[string][]lineRange {
+	ranges := make(map[string][]lineRange)
+	for _, file := range pass.Files {
+		var directives []*ast.Comment
+		var groups []*ast.CommentGroup
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if isNilawayNolint(c.Text) {
+					directives = append(directives, c)
+					groups = append(groups, group)
+				}
+			}
+		}
+		if len(directives) == 0 {
+			continue
+		}
+
+		// Collect the positions of all nodes in the file, such that we can determine whether a
+		// directive trails code on the same line, and which node starts on a given line.
+		tf := pass.Fset.File(file.Pos())
+		if tf == nil {
+			continue
+		}
+		// codeBefore maps each line to the smallest column where any code appears on that line.
+		codeBefore := make(map[int]int)
+		// nodeEnd maps each line to the largest end line of the nodes starting on that line.
+		nodeEnd := make(map[int]int)
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				return false
+			}
+			if _, ok := n.(*ast.File); ok {
+				return true
+			}
+			if _, ok := n.(*ast.CommentGroup); ok {
+				return false
+			}
+			if _, ok := n.(*ast.Comment); ok {
+				return false
+			}
+			start, end := tf.Position(n.Pos()), tf.Position(n.End())
+			for _, p := range [...]token.Position{start, end} {
+				if col, ok := codeBefore[p.Line]; !ok || p.Column < col {
+					codeBefore[p.Line] = p.Column
+				}
+			}
+			if end.Line > nodeEnd[start.Line] {
+				nodeEnd[start.Line] = end.Line
+			}
+			return true
+		})
+
+		name := tf.Name()
+		for i, c := range directives {
+			position := tf.Position(c.Pos())
+			if col, ok := codeBefore[position.Line]; ok && col < position.Column {
+				// The directive trails code on the same line.
+				ranges[name] = append(ranges[name], lineRange{start: position.Line, end: position.Line})
+				continue
+			}
+
+			next := tf.Position(groups[i].End()).Line + 1
+			end := next
+			if e, ok := nodeEnd[next]; ok {
+				end = e
+			}
+			ranges[name] = append(ranges[name], lineRange{start: next, end: end})
+		}
+	}
+	return ranges
+}
+
+// isNilawayNolint returns true iff the comment text is a nolint directive that applies to NilAway.
+func isNilawayNolint(text string) bool {
+	_, ok := parseNilawayNolint(text)
+	return ok
+}
+
+// parseNilawayNolint parses the comment text as a nolint directive that applies to NilAway, and
+// returns the rest of the comment after the directive (e.g., " // explanation" for
+// "//nolint:nilaway // explanation"), or false if it is not such a directive.
+func parseNilawayNolint(text string) (string, bool) {
+	text, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		return "", false
+	}
+	text, ok = strings.CutPrefix(strings.TrimSpace(text), _nolintDirective)
+	if !ok {
+		return "", false
+	}
+	// A bare "//nolint" (possibly followed by an explanation) suppresses all linters.
+	if text == "" || text[0] == ' ' || text[0] == '\t' {
+		return text, true
+	}
+	linters, ok := strings.CutPrefix(text, ":")
+	if !ok {
+		return "", false
+	}
+	rest := ""
+	if i := strings.IndexAny(linters, " \t"); i >= 0 {
+		linters, rest = linters[:i], linters[i:]
+	}
+	for _, l := range strings.Split(linters, ",") {
+		if l == _nolintName || l == "all" {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// suppressionReason returns the reason following the delimiter in the rest of a nolint directive
+// (see parseNilawayNolint), or false if there is no delimiter.
+func suppressionReason(rest, delimiter string) (string, bool) {
+	_, reason, ok := strings.Cut(rest, delimiter)
+	return strings.TrimSpace(reason), ok
+}
+
+// UnjustifiedSuppressionDiagnostics returns a diagnostic for each nolint directive suppressing
+// NilAway diagnostics in the files in scope that is not justified by a reason, i.e., the
+// delimiter followed by a reason of at least the minimum length (see
+// config.Config.RequireSuppressionReason). The diagnostics are reported at the directives.
+func UnjustifiedSuppressionDiagnostics(pass *analysis.Pass, conf *config.Config) []analysis.Diagnostic {
+	var diagnostics []analysis.Diagnostic
+	for _, file := range pass.Files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				rest, ok := parseNilawayNolint(c.Text)
+				if !ok {
+					continue
+				}
+				reason, ok := suppressionReason(rest, conf.SuppressionReasonDelimiter)
+				if ok && utf8.RuneCountInString(reason) >= conf.SuppressionReasonMinLength {
+					continue
+				}
+				msg := fmt.Sprintf("nolint directive suppressing NilAway diagnostics lacks a reason, "+
+					"expected %q followed by a reason after the directive", conf.SuppressionReasonDelimiter)
+				if reason != "" {
+					msg = fmt.Sprintf("nolint directive suppressing NilAway diagnostics has a reason "+
+						"shorter than %d characters", conf.SuppressionReasonMinLength)
+				}
+				diagnostics = append(diagnostics, analysis.Diagnostic{Pos: c.Pos(), End: c.End(), Message: msg})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// isSuppressed returns true iff the position falls in any of the suppressed line ranges.
+func isSuppressed(ranges map[string][]lineRange, position token.Position) bool {
+	for _, r := range ranges[position.Filename] {
+		if r.start <= position.Line && position.Line <= r.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/inference"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// RedundantNilCheckDiagnostics returns a cleanup hint (of category CategoryRedundantCheck) for
+// each nil check (i.e., `x == nil` or `x != nil`) in the files in scope that can never be true (or
+// never be false), since the site of the checked value is determined to be nonnil in the inferred
+// map (see inference.InferredMap.IsDeterminedNonnil). To avoid false cleanup suggestions, only
+// the parameters, receivers, global variables and fields are checked, and the ones that are
+// assigned (or whose addresses are taken) in the enclosing function are skipped, since their
+// values at the checks may differ from the values of their sites. The hints can be suppressed by
+// nolint directives like any other diagnostics.
+func RedundantNilCheckDiagnostics(pass *analysis.Pass, conf *config.Config, inferredMap *inference.InferredMap) []analysis.Diagnostic {
+	var diagnostics []analysis.Diagnostic
+	suppressed := suppressedLines(pass)
+	for _, file := range pass.Files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+			if !ok || !conf.IsFuncInScope(fn) {
+				continue
+			}
+			assigned := assignedObjects(pass, funcDecl.Body)
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				binary, ok := n.(*ast.BinaryExpr)
+				if !ok || (binary.Op != token.EQL && binary.Op != token.NEQ) {
+					return true
+				}
+				isNil := func(expr ast.Expr) bool { return pass.TypesInfo.Types[expr].IsNil() }
+				checked := astutil.Unparen(binary.X)
+				if !isNil(binary.Y) {
+					if !isNil(binary.X) {
+						return true
+					}
+					checked = astutil.Unparen(binary.Y)
+				}
+				key, obj := checkedSite(pass, fn, checked)
+				if key == nil || assigned[obj] || !inferredMap.IsDeterminedNonnil(key) ||
+					isSuppressed(suppressed, pass.Fset.Position(binary.Pos())) {
+					return true
+				}
+				always := "true"
+				if binary.Op == token.EQL {
+					always = "false"
+				}
+				diagnostics = append(diagnostics, analysis.Diagnostic{
+					Pos:      binary.Pos(),
+					End:      binary.End(),
+					Category: CategoryRedundantCheck,
+					Message: fmt.Sprintf("Redundant nil check: `%s` is determined to be nonnil, so the "+
+						"check is always %s", types.ExprString(checked), always),
+				})
+				return true
+			})
+		}
+	}
+	return diagnostics
+}
+
+// checkedSite returns the annotation key of the site of the checked expression in the function,
+// along with the object of the site, or nil if the expression is not one of the supported sites.
+func checkedSite(pass *analysis.Pass, fn *types.Func, expr ast.Expr) (annotation.Key, types.Object) {
+	var obj *types.Var
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		obj, _ = pass.TypesInfo.Uses[expr].(*types.Var)
+	case *ast.SelectorExpr:
+		if sel, ok := pass.TypesInfo.Selections[expr]; ok {
+			if sel.Kind() != types.FieldVal {
+				return nil, nil
+			}
+			return annotation.FieldAnnotationKey{FieldDecl: sel.Obj().(*types.Var)}, sel.Obj()
+		}
+		// This is a qualified identifier, e.g., `pkg.Var`.
+		obj, _ = pass.TypesInfo.Uses[expr.Sel].(*types.Var)
+	}
+	if obj == nil || util.TypeBarsNilness(obj.Type()) {
+		return nil, nil
+	}
+	if annotation.VarIsGlobal(obj) {
+		return annotation.GlobalVarAnnotationKey{VarDecl: obj}, obj
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() == obj {
+		return annotation.RecvAnnotationKey{FuncDecl: fn}, obj
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if sig.Params().At(i) == obj {
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				// The site of a variadic parameter is for its elements.
+				return nil, nil
+			}
+			return annotation.ParamKeyFromArgNum(fn, i), obj
+		}
+	}
+	return nil, nil
+}
+
+// assignedObjects returns the set of objects (i.e., variables and fields) that are assigned or
+// whose addresses are taken in the node.
+func assignedObjects(pass *analysis.Pass, node ast.Node) map[types.Object]bool {
+	assigned := make(map[types.Object]bool)
+	mark := func(expr ast.Expr) {
+		switch expr := astutil.Unparen(expr).(type) {
+		case *ast.Ident:
+			if obj := pass.TypesInfo.ObjectOf(expr); obj != nil {
+				assigned[obj] = true
+			}
+		case *ast.SelectorExpr:
+			if obj := pass.TypesInfo.ObjectOf(expr.Sel); obj != nil {
+				assigned[obj] = true
+			}
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				mark(lhs)
+			}
+		case *ast.RangeStmt:
+			if n.Key != nil {
+				mark(n.Key)
+			}
+			if n.Value != nil {
+				mark(n.Value)
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				mark(n.X)
+			}
+		}
+		return true
+	})
+	return assigned
+}
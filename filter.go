@@ -0,0 +1,188 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Diagnostic is a potential nil panic (or any other finding) of NilAway as seen by the filters
+// (see RegisterFilter) and in the results of Analyze.
+type Diagnostic struct {
+	// Diagnostic is the diagnostic to be reported, with its category (see the diagnostic.Category*
+	// constants) already assigned.
+	analysis.Diagnostic
+	// Position is the resolved position of the diagnostic.
+	Position token.Position
+	// PkgPath is the path of the package being analyzed.
+	PkgPath string
+}
+
+// Filter decides whether a diagnostic should be reported, where returning false suppresses it.
+type Filter func(diag Diagnostic) bool
+
+// _filters are the filters registered via RegisterFilter, in the order of registration.
+var _filters struct {
+	sync.RWMutex
+	list []*Filter
+}
+
+// RegisterFilter registers a filter that is invoked for each diagnostic of every package before it
+// is emitted, such that drivers can implement custom suppression logic (e.g., based on ownership
+// or ticket tracking) that is awkward to express via flags. The filters run in the order of
+// registration and a diagnostic is suppressed as soon as one of them returns false. They run
+// after the diagnostics suppressed by `//nolint` comments are removed (i.e., such diagnostics are
+// never passed to the filters) and before the baseline (see config.Config.Baseline) and the
+// output (e.g., SARIF) are processed. Since packages are analyzed concurrently, the filters must
+// be safe for concurrent use, and they should be registered before the analysis starts. The
+// returned function unregisters the filter.
+func RegisterFilter(f Filter) (unregister func()) {
+	_filters.Lock()
+	defer _filters.Unlock()
+
+	entry := &f
+	_filters.list = append(_filters.list, entry)
+	return func() {
+		_filters.Lock()
+		defer _filters.Unlock()
+
+		for i, e := range _filters.list {
+			if e == entry {
+				_filters.list = append(_filters.list[:i:i], _filters.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// applyFilters returns the diagnostics that pass all registered filters (see RegisterFilter).
+func applyFilters(pass *analysis.Pass, diagnostics []analysis.Diagnostic) []analysis.Diagnostic {
+	_filters.RLock()
+	defer _filters.RUnlock()
+
+	if len(_filters.list) == 0 {
+		return diagnostics
+	}
+	kept := make([]analysis.Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		diag := Diagnostic{Diagnostic: d, Position: pass.Fset.Position(d.Pos), PkgPath: pass.Pkg.Path()}
+		keep := true
+		for _, f := range _filters.list {
+			if !(*f)(diag) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// dropCategory returns the diagnostics that are not of the given category.
+func dropCategory(diagnostics []analysis.Diagnostic, category string) []analysis.Diagnostic {
+	kept := make([]analysis.Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		if d.Category != category {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// collapseDuplicates returns the diagnostics where the ones with the same category and message in
+// the same file are collapsed into the first occurrence, whose message is extended with the number
+// of occurrences and the lines of the others. The order of the diagnostics is otherwise kept.
+func collapseDuplicates(pass *analysis.Pass, diagnostics []analysis.Diagnostic) []analysis.Diagnostic {
+	type key struct {
+		filename, category, message string
+	}
+	groups := make(map[key][]int)
+	var keys []key
+	for i, d := range diagnostics {
+		k := key{filename: pass.Fset.Position(d.Pos).Filename, category: d.Category, message: d.Message}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+	if len(keys) == len(diagnostics) {
+		return diagnostics
+	}
+
+	collapsed := make([]analysis.Diagnostic, 0, len(keys))
+	for _, k := range keys {
+		indices := groups[k]
+		sort.Slice(indices, func(i, j int) bool { return diagnostics[indices[i]].Pos < diagnostics[indices[j]].Pos })
+		first := diagnostics[indices[0]]
+		if len(indices) > 1 {
+			lines := make([]string, len(indices)-1)
+			for i, index := range indices[1:] {
+				lines[i] = strconv.Itoa(pass.Fset.Position(diagnostics[index].Pos).Line)
+			}
+			first.Message = fmt.Sprintf("%s\n(Reported %d times in this file, also at line(s) %s.)",
+				strings.TrimSuffix(first.Message, "\n"), len(indices), strings.Join(lines, ", "))
+		}
+		collapsed = append(collapsed, first)
+	}
+	return collapsed
+}
+
+// truncateDiagnostics returns at most limit of the diagnostics, where the errors are kept in
+// preference to the warnings (as judged by isWarning) and the order of the kept diagnostics is
+// otherwise unchanged. The message of the last kept diagnostic is extended with the number of the
+// dropped ones, and the positions of the dropped errors are returned as well.
+func truncateDiagnostics(diagnostics []analysis.Diagnostic, limit int, isWarning func(category string) bool) ([]analysis.Diagnostic, []token.Pos) {
+	if len(diagnostics) <= limit {
+		return diagnostics, nil
+	}
+
+	errors := 0
+	for _, d := range diagnostics {
+		if !isWarning(d.Category) {
+			errors++
+		}
+	}
+	// The warnings are only kept if there is still room after all errors.
+	warnings := limit - errors
+	kept := make([]analysis.Diagnostic, 0, limit)
+	var droppedErrors []token.Pos
+	for _, d := range diagnostics {
+		switch {
+		case isWarning(d.Category) && warnings > 0 && len(kept) < limit:
+			warnings--
+		case isWarning(d.Category):
+			continue
+		case len(kept) == limit:
+			droppedErrors = append(droppedErrors, d.Pos)
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	last := &kept[len(kept)-1]
+	last.Message = fmt.Sprintf("%s\n(... and %d more in this package, see -%s.)",
+		strings.TrimSuffix(last.Message, "\n"), len(diagnostics)-len(kept), config.MaxErrorsPerPackageFlag)
+	return kept, droppedErrors
+}
@@ -0,0 +1,262 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _htmlSnippetContext is the number of source lines shown before and after the line of each
+// location in the HTML report.
+const _htmlSnippetContext = 2
+
+type (
+	// htmlReport is the data rendered by _htmlTemplate.
+	htmlReport struct {
+		Total    int
+		Packages []htmlPackage
+	}
+	// htmlPackage is the diagnostics of a single package in the HTML report.
+	htmlPackage struct {
+		Path        string
+		Diagnostics []htmlDiagnostic
+	}
+	// htmlDiagnostic is a single diagnostic in the HTML report, along with the nodes of its nil
+	// flow (i.e., the related information of the diagnostic).
+	htmlDiagnostic struct {
+		Location htmlLocation
+		Level    string
+		Category string
+		Summary  string
+		Message  string
+		Flow     []htmlLocation
+	}
+	// htmlLocation is a location in the source code along with a snippet of the surrounding lines.
+	htmlLocation struct {
+		Position string
+		Message  string
+		Snippet  []htmlLine
+		// file, line and column are only used for sorting.
+		file         string
+		line, column int
+	}
+	// htmlLine is a line of a source snippet, where Highlighted marks the line of the location.
+	htmlLine struct {
+		Number      int
+		Text        string
+		Highlighted bool
+	}
+)
+
+// htmlWriter accumulates the diagnostics of all packages analyzed in the process and writes them
+// to a single HTML report. Similar to sarifWriter, the complete file is rewritten whenever new
+// diagnostics are added, since the analysis drivers do not offer a hook at the end of the analysis.
+type htmlWriter struct {
+	mu   sync.Mutex
+	path string
+	// packages maps the package paths to their diagnostics, keyed by their positions and
+	// messages, which also de-duplicates the diagnostics for the test variants of the packages.
+	packages map[string]map[string]htmlDiagnostic
+	// sources caches the lines of the source files read for the snippets.
+	sources map[string][]string
+	// written indicates whether the file has been written at least once.
+	written bool
+}
+
+// _htmlWriters stores the htmlWriter for each report file.
+var _htmlWriters sync.Map
+
+// writeHTMLReport adds the diagnostics of the pass to the HTML report at the given path.
+func writeHTMLReport(path string, pass *analysis.Pass, diagnostics []analysis.Diagnostic, conf *config.Config) error {
+	v, _ := _htmlWriters.LoadOrStore(path, &htmlWriter{
+		path:     path,
+		packages: make(map[string]map[string]htmlDiagnostic),
+		sources:  make(map[string][]string),
+	})
+	return v.(*htmlWriter).add(pass, diagnostics, conf)
+}
+
+// add converts the diagnostics to the entries of the report and rewrites the file if there are
+// new ones.
+func (w *htmlWriter) add(pass *analysis.Pass, diagnostics []analysis.Diagnostic, conf *config.Config) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := !w.written
+	pkg := pass.Pkg.Path()
+	for _, d := range diagnostics {
+		diag := htmlDiagnostic{
+			Location: w.location(pass.Fset.Position(d.Pos), "", conf),
+			Level:    "error",
+			Category: d.Category,
+			Message:  d.Message,
+		}
+		summary, _, _ := strings.Cut(d.Message, "\n")
+		diag.Summary = strings.TrimSpace(summary)
+		if conf.IsWarning(d.Category) {
+			diag.Level = "warning"
+		}
+		for _, r := range d.Related {
+			diag.Flow = append(diag.Flow, w.location(pass.Fset.Position(r.Pos), r.Message, conf))
+		}
+
+		key := diag.Location.Position + "\n" + d.Message
+		if _, ok := w.packages[pkg][key]; ok {
+			continue
+		}
+		if w.packages[pkg] == nil {
+			w.packages[pkg] = make(map[string]htmlDiagnostic)
+		}
+		w.packages[pkg][key] = diag
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := _htmlTemplate.Execute(&buf, w.report()); err != nil {
+		return fmt.Errorf("render HTML report: %w", err)
+	}
+	if err := writeFileAtomically(w.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("write HTML report: %w", err)
+	}
+	w.written = true
+	return nil
+}
+
+// report returns the data of the report, where the packages and their diagnostics are sorted for
+// deterministic output since packages are analyzed in parallel. The caller must hold the lock.
+func (w *htmlWriter) report() htmlReport {
+	var report htmlReport
+	for path, diagnostics := range w.packages {
+		pkg := htmlPackage{Path: path, Diagnostics: make([]htmlDiagnostic, 0, len(diagnostics))}
+		for _, d := range diagnostics {
+			pkg.Diagnostics = append(pkg.Diagnostics, d)
+		}
+		sort.Slice(pkg.Diagnostics, func(i, j int) bool {
+			a, b := pkg.Diagnostics[i], pkg.Diagnostics[j]
+			if a.Location.file != b.Location.file {
+				return a.Location.file < b.Location.file
+			}
+			if a.Location.line != b.Location.line {
+				return a.Location.line < b.Location.line
+			}
+			if a.Location.column != b.Location.column {
+				return a.Location.column < b.Location.column
+			}
+			return a.Message < b.Message
+		})
+		report.Total += len(pkg.Diagnostics)
+		report.Packages = append(report.Packages, pkg)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool { return report.Packages[i].Path < report.Packages[j].Path })
+	return report
+}
+
+// location converts the position to a location of the report, along with the snippet of the
+// surrounding lines if the source file can be read. The caller must hold the lock.
+func (w *htmlWriter) location(position token.Position, message string, conf *config.Config) htmlLocation {
+	filename := conf.RelativePath(position.Filename)
+	loc := htmlLocation{
+		Position: fmt.Sprintf("%s:%d:%d", filename, position.Line, position.Column),
+		Message:  message,
+		file:     filename,
+		line:     position.Line,
+		column:   position.Column,
+	}
+
+	lines, ok := w.sources[position.Filename]
+	if !ok {
+		// A missing file simply results in no snippets, hence the error is ignored.
+		if content, err := os.ReadFile(position.Filename); err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		}
+		w.sources[position.Filename] = lines
+	}
+	if position.Line < 1 || position.Line > len(lines) {
+		return loc
+	}
+	first, last := position.Line-_htmlSnippetContext, position.Line+_htmlSnippetContext
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	for n := first; n <= last; n++ {
+		loc.Snippet = append(loc.Snippet, htmlLine{Number: n, Text: lines[n-1], Highlighted: n == position.Line})
+	}
+	return loc
+}
+
+// _htmlTemplate is the template of the HTML report, which is self-contained (i.e., with inline
+// CSS and no external assets) such that it can be archived as is.
+var _htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>NilAway report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { font-size: 1.1em; border-bottom: 1px solid #ccc; padding-bottom: 0.2em; }
+details { margin: 0.5em 0; border: 1px solid #ddd; border-radius: 4px; padding: 0.4em 0.8em; }
+summary { cursor: pointer; }
+.error { color: #b00020; font-weight: bold; }
+.warning { color: #a06000; font-weight: bold; }
+.category { color: #666; font-size: 0.9em; }
+.position { font-family: monospace; }
+ol.flow > li { margin: 0.6em 0; }
+pre { background: #f6f8fa; padding: 0.4em; overflow-x: auto; margin: 0.3em 0; }
+pre .highlighted { background: #fff3b0; display: block; }
+pre .number { color: #999; user-select: none; }
+</style>
+</head>
+<body>
+<h1>NilAway report</h1>
+<p>{{.Total}} diagnostic(s) in {{len .Packages}} package(s).</p>
+{{- define "snippet"}}{{if .}}<pre>{{range .}}<span{{if .Highlighted}} class="highlighted"{{end}}><span class="number">{{printf "%5d" .Number}}</span>  {{.Text}}</span>
+{{end}}</pre>{{end}}{{end}}
+{{- range .Packages}}
+<h2>{{.Path}} ({{len .Diagnostics}})</h2>
+{{- range .Diagnostics}}
+<details>
+<summary><span class="{{.Level}}">{{.Level}}</span> <span class="position">{{.Location.Position}}</span>: {{.Summary}}{{if .Category}} <span class="category">[{{.Category}}]</span>{{end}}</summary>
+{{template "snippet" .Location.Snippet}}
+{{- if .Flow}}
+<ol class="flow">
+{{- range .Flow}}
+<li><span class="position">{{.Position}}</span>: {{.Message}}{{template "snippet" .Snippet}}</li>
+{{- end}}
+</ol>
+{{- else}}
+<pre>{{.Message}}</pre>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,181 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ObserveConstructors determines the shallow sites of the pointer fields of the structs declared in
+// the current package from their constructors (see constructorLits), e.g.,
+//
+//	func NewT(p *int) *T { return &T{p: p} } // T.p is nonnil, T.q is nilable
+//
+// A field set (to anything but a literal nil) by every composite literal returned by the
+// constructors is determined to be nonnil, and a field left unset (or set to nil) by any of them is
+// determined to be nilable. Sites that are already determined (e.g., by annotations or stubs) are
+// left intact. This must be called before ObservePackage such that the nilability is propagated
+// along the assertions of the package.
+func (e *Engine) ObserveConstructors(files []*ast.File) {
+	type fieldNilability struct {
+		nilable bool
+		pos     token.Position
+		fn      string
+	}
+	var fields []*types.Var
+	nilabilities := make(map[*types.Var]*fieldNilability)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			st, lits := e.constructorLits(funcDecl)
+			for _, lit := range lits {
+				values := fieldValues(e.pass.TypesInfo, st, lit)
+				for i := 0; i < st.NumFields(); i++ {
+					f := st.Field(i)
+					if !util.TypeIsDeeplyPtr(f.Type()) {
+						continue
+					}
+					value, ok := values[f]
+					nilable, pos := !ok, lit.Pos()
+					if ok && e.pass.TypesInfo.Types[value].IsNil() {
+						nilable, pos = true, value.Pos()
+					}
+					n, ok := nilabilities[f]
+					if !ok {
+						fields = append(fields, f)
+						n = &fieldNilability{}
+						nilabilities[f] = n
+					}
+					// The nilable fields are explained by the first constructor leaving them nil,
+					// and the nonnil ones by the first constructor setting them.
+					if !ok || nilable && !n.nilable {
+						n.nilable = nilable
+						n.pos = e.pass.Fset.Position(pos)
+						n.fn = funcDecl.Name.Name
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range fields {
+		site := e.primitive.site(annotation.FieldAnnotationKey{FieldDecl: f}, false)
+		if v, ok := e.inferredMap.Load(site); ok {
+			if _, ok := v.(*DeterminedVal); ok {
+				continue
+			}
+		}
+		n := nilabilities[f]
+		if n.nilable {
+			e.recordSiteExplanation(site, TrueBecauseConstructor{ConstructorPos: n.pos, FuncName: n.fn})
+		} else {
+			e.recordSiteExplanation(site, FalseBecauseConstructor{ConstructorPos: n.pos, FuncName: n.fn})
+		}
+	}
+}
+
+// constructorLits returns the struct built by the function if it is a constructor, along with the
+// composite literals of the struct returned by it. A constructor is a function without receiver
+// named "New..." (or "new...") with a single (pointer to) struct result declared in the current
+// package, optionally followed by an error result, where every return statement returns a
+// composite literal of the struct (or its address). For the error-returning constructors, the
+// return statements returning nil structs are skipped since they are guarded by the error result.
+// Nil and no literals are returned for any other function.
+func (e *Engine) constructorLits(decl *ast.FuncDecl) (*types.Struct, []*ast.CompositeLit) {
+	if decl.Recv != nil || decl.Body == nil ||
+		!strings.HasPrefix(decl.Name.Name, "New") && !strings.HasPrefix(decl.Name.Name, "new") {
+		return nil, nil
+	}
+	funcObj, ok := e.pass.TypesInfo.ObjectOf(decl.Name).(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	results := funcObj.Type().(*types.Signature).Results()
+	errReturning := util.FuncIsErrReturning(funcObj)
+	if results.Len() != 1 && !(results.Len() == 2 && errReturning) {
+		return nil, nil
+	}
+	named, ok := util.UnwrapPtr(results.At(0).Type()).(*types.Named)
+	// The fields of the instantiated generic structs are distinct from the declared ones, hence
+	// the generic structs are not recognized.
+	if !ok || named.Obj().Pkg() != e.pass.Pkg || named.TypeArgs().Len() > 0 {
+		return nil, nil
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+
+	var lits []*ast.CompositeLit
+	recognized := true
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			// The return statements of the function literals do not return from the constructor.
+			return false
+		case *ast.ReturnStmt:
+			if len(node.Results) != results.Len() {
+				// Naked returns of named results are not recognized.
+				recognized = false
+				return false
+			}
+			expr := astutil.Unparen(node.Results[0])
+			if errReturning && e.pass.TypesInfo.Types[expr].IsNil() {
+				return false
+			}
+			if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+				expr = astutil.Unparen(unary.X)
+			}
+			lit, ok := expr.(*ast.CompositeLit)
+			if !ok || !types.Identical(e.pass.TypesInfo.TypeOf(lit), named) {
+				recognized = false
+				return false
+			}
+			lits = append(lits, lit)
+		}
+		return recognized
+	})
+	if !recognized || len(lits) == 0 {
+		return nil, nil
+	}
+	return st, lits
+}
+
+// fieldValues returns the values of the fields of the struct set by the composite literal.
+func fieldValues(info *types.Info, st *types.Struct, lit *ast.CompositeLit) map[*types.Var]ast.Expr {
+	values := make(map[*types.Var]ast.Expr, len(lit.Elts))
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if ident, ok := kv.Key.(*ast.Ident); ok {
+				if field, ok := info.ObjectOf(ident).(*types.Var); ok {
+					values[field] = astutil.Unparen(kv.Value)
+				}
+			}
+		} else if i < st.NumFields() {
+			values[st.Field(i)] = astutil.Unparen(elt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,48 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/assertion/function/functioncontracts"
+)
+
+// ObserveImplicationContracts translates the implication contracts of the package (see
+// functioncontracts.ImplicationContract) into implication edges in the inferred map: for every
+// param i and result j that are both nonnil in a contract, "param i nonnil implies result j
+// nonnil" is observed as its contrapositive "result j nilable implies param i nilable", i.e., an
+// implication from the result site to the param site. If either site is already determined, the
+// other site is determined accordingly (see observeImplication).
+func (e *Engine) ObserveImplicationContracts(contracts []*functioncontracts.ImplicationContract) {
+	for _, c := range contracts {
+		for i, in := range c.Contract.Ins {
+			if in != functioncontracts.NonNil {
+				continue
+			}
+			param := e.primitive.site(annotation.ParamKeyFromArgNum(c.Func, i), false /* isDeep */)
+			for j, out := range c.Contract.Outs {
+				if out != functioncontracts.NonNil {
+					continue
+				}
+				result := e.primitive.site(annotation.RetKeyFromRetNum(c.Func, j), false /* isDeep */)
+				e.recordImplication(result, param, primitiveFullTrigger{
+					Position:     e.primitive.toPosition(c.Pos),
+					ProducerRepr: annotation.FuncReturnPrestring{RetNum: j, FuncName: c.Func.Name()},
+					ConsumerRepr: annotation.ContractParamPrestring{ParamNum: i, FuncName: c.Func.Name()},
+				})
+			}
+		}
+	}
+}
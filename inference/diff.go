@@ -0,0 +1,118 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+// DiffKind indicates how the inferred value of a site differs between two InferredMaps.
+type DiffKind int
+
+const (
+	// DiffAdded indicates that the site is only present in the new map.
+	DiffAdded DiffKind = iota
+	// DiffRemoved indicates that the site is only present in the old map.
+	DiffRemoved
+	// DiffChanged indicates that the site is present in both maps, but with different values.
+	DiffChanged
+)
+
+// String returns the string representation of the DiffKind.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// SiteDiff describes the change of the inferred value of a single site between two InferredMaps.
+type SiteDiff struct {
+	// Kind indicates which side(s) the site is present in.
+	Kind DiffKind
+	// Old is the value in the old map, or nil if the site is only present in the new map.
+	Old InferredVal
+	// New is the value in the new map, or nil if the site is only present in the old map.
+	New InferredVal
+}
+
+// FlippedToNilable returns true if the site was determined to be nonnil in the old map, and is
+// determined to be nilable in the new map.
+func (d SiteDiff) FlippedToNilable() bool {
+	oldVal, oldOk := d.Old.(*DeterminedVal)
+	newVal, newOk := d.New.(*DeterminedVal)
+	return oldOk && newOk && !oldVal.Bool.Val() && newVal.Bool.Val()
+}
+
+// Diff returns the sites whose inferred values differ between the old and the new InferredMaps.
+// Sites whose values are the same in both maps are omitted.
+func Diff(oldMap, newMap *InferredMap) map[primitiveSite]SiteDiff {
+	// The sites of the old map are copied first such that the two locks are never held at the same
+	// time, otherwise concurrent diffs in both directions could deadlock (see Merge).
+	oldMap.mu.RLock()
+	oldVals := make(map[primitiveSite]InferredVal, len(oldMap.mapping.Pairs))
+	for _, p := range oldMap.mapping.Pairs {
+		oldVals[p.Key] = p.Value.copy()
+	}
+	oldMap.mu.RUnlock()
+
+	newMap.mu.RLock()
+	defer newMap.mu.RUnlock()
+
+	diffs := make(map[primitiveSite]SiteDiff)
+	for _, p := range newMap.mapping.Pairs {
+		site, newVal := p.Key, p.Value
+		oldVal, ok := oldVals[site]
+		if !ok {
+			diffs[site] = SiteDiff{Kind: DiffAdded, New: newVal}
+			continue
+		}
+		if inferredValChanged(oldVal, newVal) {
+			diffs[site] = SiteDiff{Kind: DiffChanged, Old: oldVal, New: newVal}
+		}
+	}
+	for site, oldVal := range oldVals {
+		if _, ok := newMap.mapping.Load(site); !ok {
+			diffs[site] = SiteDiff{Kind: DiffRemoved, Old: oldVal}
+		}
+	}
+	return diffs
+}
+
+// inferredValChanged returns true if the two values carry different information. It reuses
+// inferredValDiff for the cases it supports (i.e., where one value supersedes the other), and
+// handles the cases that inferredValDiff considers to be programming errors separately.
+func inferredValChanged(oldVal, newVal InferredVal) bool {
+	switch o := oldVal.(type) {
+	case *DeterminedVal:
+		switch n := newVal.(type) {
+		case *DeterminedVal:
+			return o.Bool.Val() != n.Bool.Val()
+		case *UndeterminedVal:
+			return true
+		}
+	case *UndeterminedVal:
+		if _, ok := newVal.(*DeterminedVal); ok {
+			return true
+		}
+		// Edges could be both added and removed, so we check the difference in both directions.
+		_, added := inferredValDiff(newVal, oldVal)
+		_, removed := inferredValDiff(oldVal, newVal)
+		return added || removed
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// _dotNilableColor is the fill color for nodes determined to be nilable in the DOT output.
+	_dotNilableColor = "lightcoral"
+	// _dotNonnilColor is the fill color for nodes determined to be nonnil in the DOT output.
+	_dotNonnilColor = "palegreen"
+)
+
+// WriteDOT renders the inferred map as a directed graph in the Graphviz DOT language for
+// debugging purposes, e.g., `dot -Tsvg`. Each annotation site becomes a node labeled with its
+// string representation, where determined sites are colored by their nilability. Each
+// implication edge is labeled with a short form of the assertion that created it.
+func (i *InferredMap) WriteDOT(w io.Writer) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph InferredMap {\n")
+	buf.WriteString("\tnode [shape=box];\n")
+
+	// Assign stable node IDs in insertion order of the sites.
+	ids := make(map[primitiveSite]int, len(i.mapping.Pairs))
+	nodeID := func(site primitiveSite) int {
+		if id, ok := ids[site]; ok {
+			return id
+		}
+		id := len(ids)
+		ids[site] = id
+		// Sites that only appear as edge targets (e.g., in exported incremental maps) have no
+		// value in the map, so we render them as plain nodes here.
+		if _, ok := i.mapping.Load(site); !ok {
+			fmt.Fprintf(&buf, "\tn%d [%s, style=dashed];\n", id, dotLabel(site))
+		}
+		return id
+	}
+
+	for _, p := range i.mapping.Pairs {
+		id := nodeID(p.Key)
+		switch v := p.Value.(type) {
+		case *DeterminedVal:
+			color := _dotNonnilColor
+			if v.Bool.Val() {
+				color = _dotNilableColor
+			}
+			fmt.Fprintf(&buf, "\tn%d [%s, style=filled, fillcolor=%s];\n", id, dotLabel(p.Key), color)
+		case *UndeterminedVal:
+			fmt.Fprintf(&buf, "\tn%d [%s];\n", id, dotLabel(p.Key))
+		}
+	}
+
+	// The implication edges are stored on both ends (Implicates of the producer and Implicants of
+	// the consumer), so we de-duplicate them here.
+	type edge struct{ from, to primitiveSite }
+	drawn := make(map[edge]bool)
+	drawEdge := func(from, to primitiveSite, assertion primitiveFullTrigger) {
+		if drawn[edge{from, to}] {
+			return
+		}
+		drawn[edge{from, to}] = true
+		fmt.Fprintf(&buf, "\tn%d -> n%d [label=%s];\n", nodeID(from), nodeID(to), dotQuote(assertion.shortString()))
+	}
+	for _, p := range i.mapping.Pairs {
+		v, ok := p.Value.(*UndeterminedVal)
+		if !ok {
+			continue
+		}
+		for _, implicate := range v.Implicates.Pairs {
+			drawEdge(p.Key, implicate.Key, implicate.Value)
+		}
+		for _, implicant := range v.Implicants.Pairs {
+			drawEdge(implicant.Key, p.Key, implicant.Value)
+		}
+	}
+
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// dotLabel returns the label attribute of the node for the site, as well as a tooltip attribute
+// with the location of the site if it is known.
+func dotLabel(site primitiveSite) string {
+	label := "label=" + dotQuote(site.String())
+	if loc := site.Location(); loc != "" {
+		label += ", tooltip=" + dotQuote(loc)
+	}
+	return label
+}
+
+// dotQuote returns the string as a quoted DOT string literal.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
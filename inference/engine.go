@@ -19,10 +19,13 @@ package inference
 import (
 	"encoding/gob"
 	"fmt"
+	"go/token"
+	"go/types"
 	"strings"
 
 	"go.uber.org/nilaway/annotation"
 	"go.uber.org/nilaway/assertion/function/assertiontree"
+	"go.uber.org/nilaway/util"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"golang.org/x/tools/go/analysis"
@@ -32,7 +35,9 @@ import (
 // This makes the inference engine independent of the diagnostic generation logic.
 type conflictHandler interface {
 	AddSingleAssertionConflict(trigger annotation.FullTrigger)
-	AddOverconstraintConflict(nilExplanation, nonnilExplanation ExplainedBool)
+	// AddOverconstraintConflict handles a site determined to be both nilable and nonnil, where
+	// exported indicates whether the site is exported.
+	AddOverconstraintConflict(nilExplanation, nonnilExplanation ExplainedBool, exported bool)
 }
 
 // Engine is the structure responsible for running the inference: it contains methods to run
@@ -54,6 +59,19 @@ type Engine struct {
 	// controls any triggers. This field is for internal use in the struct only and should not be
 	// accessed elsewhere.
 	controlledTriggersBySite map[primitiveSite]map[annotation.FullTrigger]bool
+	// maxSites is the maximum number of sites in the inferred map beyond which no new undetermined
+	// sites are added (see LimitSites), zero means no limit.
+	maxSites int
+	// truncated indicates whether any implication has been dropped due to maxSites.
+	truncated bool
+	// distrusted returns true for the packages whose sites are ignored in the upstream maps (see
+	// DistrustPkgs).
+	distrusted func(pkgPath string) bool
+	// session records the observations made by the engine if it is non-nil (see Record).
+	session *Session
+	// controlledEventsBySite is the counterpart of controlledTriggersBySite when replaying a
+	// session, where the controlled triggers are only available in their primitive forms.
+	controlledEventsBySite map[primitiveSite][]sessionEvent
 }
 
 // NewEngine constructs an inference engine that is ready to run inference.
@@ -73,6 +91,29 @@ func (e *Engine) InferredMap() *InferredMap {
 	return e.inferredMap
 }
 
+// LimitSites limits the number of sites in the inferred map to n (zero means no limit): once the
+// limit is reached, the implications that would add new undetermined sites to the map are dropped
+// and the engine is marked as truncated (see Truncated). To make the truncation deterministic,
+// the assertions of the package are then observed in a fixed order (see truncationOrder), where
+// the implications between purely local (i.e., non-exported) sites go last and are hence dropped
+// first. This must be called before ObservePackage.
+func (e *Engine) LimitSites(n int) {
+	e.maxSites = n
+}
+
+// Truncated returns true iff any implication has been dropped due to the limit on the number of
+// sites (see LimitSites), i.e., the inference of the package is incomplete.
+func (e *Engine) Truncated() bool {
+	return e.truncated
+}
+
+// DistrustPkgs makes the engine ignore the sites of the packages for which distrusted returns true
+// in the upstream maps, along with the implications from or to them, such that the sites are
+// treated like those of the packages never analyzed. This must be called before ObserveUpstream.
+func (e *Engine) DistrustPkgs(distrusted func(pkgPath string) bool) {
+	e.distrusted = distrusted
+}
+
 // ObserveUpstream imports all information from upstream dependencies. Specifically, it iterates
 // over the direct imports of the passed pass's package, using the Facts mechanism to observe any
 // InferredMap's that were computed by multi-package inference for that imported package.
@@ -98,32 +139,41 @@ func (e *Engine) ObserveUpstream() {
 	})
 
 	for _, f := range facts {
-		f.Fact.(*InferredMap).OrderedRange(func(site primitiveSite, val InferredVal) bool {
-			switch v := val.(type) {
-			case *DeterminedVal:
-				// Fix as an Explained site any sites that `otherMap` knows are explained
-				// This can yield an overconstrainedConflict if the current map disagrees on the
-				// value of the site.
-				e.observeSiteExplanation(site, v.Bool)
-			case *UndeterminedVal:
-				// Observe all forward implications from this site.
-				for _, p := range v.Implicates.Pairs {
-					implicantSite, assertion := p.Key, p.Value
-					e.observeImplication(site, implicantSite, assertion)
-				}
-				// Observe all backward implications from this site.
-				for _, p := range v.Implicants.Pairs {
-					implicantSite, assertion := p.Key, p.Value
-					e.observeImplication(implicantSite, site, assertion)
-				}
-			}
-			return true
-		})
+		upstream := f.Fact.(*InferredMap)
+		if e.distrusted != nil {
+			upstream = upstream.withoutPkgs(e.distrusted)
+		}
+		if e.session != nil {
+			e.session.Upstream = append(e.session.Upstream, upstream)
+		}
+		e.observeUpstreamMap(upstream)
 	}
 
 	// copy imported maps into upstreamMapping field
-	e.inferredMap.OrderedRange(func(site primitiveSite, val InferredVal) bool {
-		e.inferredMap.upstreamMapping[site] = val.copy()
+	e.inferredMap.recordUpstream()
+}
+
+// observeUpstreamMap observes all sites and implications of an upstream map.
+func (e *Engine) observeUpstreamMap(upstream *InferredMap) {
+	upstream.OrderedRange(func(site primitiveSite, val InferredVal) bool {
+		switch v := val.(type) {
+		case *DeterminedVal:
+			// Fix as an Explained site any sites that `otherMap` knows are explained
+			// This can yield an overconstrainedConflict if the current map disagrees on the
+			// value of the site.
+			e.observeSiteExplanation(site, v.Bool)
+		case *UndeterminedVal:
+			// Observe all forward implications from this site.
+			for _, p := range v.Implicates.Pairs {
+				implicantSite, assertion := p.Key, p.Value
+				e.observeImplication(site, implicantSite, assertion)
+			}
+			// Observe all backward implications from this site.
+			for _, p := range v.Implicants.Pairs {
+				implicantSite, assertion := p.Key, p.Value
+				e.observeImplication(implicantSite, site, assertion)
+			}
+		}
 		return true
 	})
 }
@@ -139,13 +189,55 @@ func (e *Engine) ObserveAnnotations(pkgAnnotations *annotation.ObservedMap, mode
 	pkgAnnotations.Range(func(key annotation.Key, isDeep bool, val bool) {
 		site := e.primitive.site(key, isDeep)
 		if val {
-			e.observeSiteExplanation(site, TrueBecauseAnnotation{AnnotationPos: site.Position})
+			e.recordSiteExplanation(site, TrueBecauseAnnotation{AnnotationPos: site.Position})
 		} else {
-			e.observeSiteExplanation(site, FalseBecauseAnnotation{AnnotationPos: site.Position})
+			e.recordSiteExplanation(site, FalseBecauseAnnotation{AnnotationPos: site.Position})
 		}
 	}, mode != NoInfer)
 }
 
+// ObserveExternalReturns determines the shallow sites of the pointer results of the functions in
+// packages that are out of scope (as judged by inScope) to be nilable for the passed full triggers
+// producing such results. Since the out-of-scope packages are never analyzed, their sites are
+// otherwise left undetermined and eventually treated as nonnil. The results of error-returning
+// functions are skipped, since they are guarded by the error result (i.e., they are nonnil if the
+// error is nil) following the error contract. In NoInfer mode, the deep sites of the results are
+// determined by their defaults as well, since annotation lookups require both sites to be
+// determined. Sites that are already determined (e.g., by stubs) are left intact. This must be
+// called before ObservePackage such that the nilability is propagated along the assertions of the
+// package.
+func (e *Engine) ObserveExternalReturns(pkgFullTriggers []annotation.FullTrigger, inScope func(*types.Package) bool, mode ModeOfInference) {
+	for _, trigger := range pkgFullTriggers {
+		if trigger.Producer.Annotation.Kind() != annotation.Conditional {
+			continue
+		}
+		key, ok := trigger.Producer.Annotation.UnderlyingSite().(annotation.RetAnnotationKey)
+		if !ok || key.FuncDecl.Pkg() == nil || inScope(key.FuncDecl.Pkg()) || util.FuncIsErrReturning(key.FuncDecl) {
+			continue
+		}
+		results := key.FuncDecl.Type().(*types.Signature).Results()
+		if key.RetNum >= results.Len() || !util.TypeIsDeeplyPtr(results.At(key.RetNum).Type()) {
+			continue
+		}
+		site := e.primitive.site(key, false)
+		if v, ok := e.inferredMap.Load(site); ok {
+			if _, ok := v.(*DeterminedVal); ok {
+				continue
+			}
+		}
+		e.recordSiteExplanation(site, TrueBecauseExternalReturn{ReturnPos: site.Position})
+
+		if mode == NoInfer {
+			deepSite := e.primitive.site(key, true)
+			if annotation.TypeIsDeepDefaultNilable(results.At(key.RetNum).Type()) {
+				e.recordSiteExplanation(deepSite, TrueBecauseAnnotation{AnnotationPos: deepSite.Position})
+			} else {
+				e.recordSiteExplanation(deepSite, FalseBecauseAnnotation{AnnotationPos: deepSite.Position})
+			}
+		}
+	}
+}
+
 // ObservePackage observes all the annotations and assertions computed locally about the current
 // package. The assertions are sorted based on whether they are already known to trigger without
 // reliance on annotation sites, such as `x` in `x = nil; x.f`, which will generate
@@ -225,6 +317,10 @@ func (e *Engine) ObservePackage(pkgFullTriggers []annotation.FullTrigger) {
 }
 
 func (e *Engine) buildPkgInferenceMap(triggers []annotation.FullTrigger) {
+	// The controlled triggers of each call are only activated by the observations of the call
+	// itself, hence a new phase starts in the recorded session.
+	e.record(sessionEvent{Kind: eventNewPhase})
+
 	// Map each site to all the triggers controlled by the site
 	controlledTgsBySite := map[primitiveSite]map[annotation.FullTrigger]bool{}
 	for _, trigger := range triggers {
@@ -240,10 +336,16 @@ func (e *Engine) buildPkgInferenceMap(triggers []annotation.FullTrigger) {
 			ts = map[annotation.FullTrigger]bool{}
 			controlledTgsBySite[site] = ts
 		}
+		if !ts[trigger] {
+			e.recordControlled(site, trigger)
+		}
 		ts[trigger] = true
 	}
 	e.controlledTriggersBySite = controlledTgsBySite
 
+	if e.maxSites > 0 {
+		triggers = e.truncationOrder(triggers)
+	}
 	for _, trigger := range triggers {
 		// As the initial status, the controlled triggers are skipped and NilAway just pretends not
 		// to see them. Those controlled triggers will be activated and encoded into the inference
@@ -251,11 +353,68 @@ func (e *Engine) buildPkgInferenceMap(triggers []annotation.FullTrigger) {
 		if trigger.Controlled() {
 			continue
 		}
-		e.buildFromSingleFullTrigger(trigger)
+		if ev, ok := e.triggerEvent(trigger); ok {
+			e.record(ev)
+			e.applyEvent(ev)
+		}
+	}
+}
+
+// truncationOrder returns a copy of the triggers sorted in the order they are observed when the
+// number of sites is limited (see LimitSites): the triggers that may determine sites go first,
+// followed by the implications between exported sites and then the remaining implications, where
+// each group is ordered by the positions of the triggers.
+func (e *Engine) truncationOrder(triggers []annotation.FullTrigger) []annotation.FullTrigger {
+	type rankedTrigger struct {
+		trigger annotation.FullTrigger
+		rank    int
+		pos     token.Position
+	}
+	ranked := make([]rankedTrigger, len(triggers))
+	for i, t := range triggers {
+		ranked[i] = rankedTrigger{trigger: t, pos: e.primitive.fullTrigger(t).Position}
+		pKind, cKind := t.Producer.Annotation.Kind(), t.Consumer.Annotation.Kind()
+		if (pKind != annotation.Conditional && pKind != annotation.DeepConditional) ||
+			(cKind != annotation.Conditional && cKind != annotation.DeepConditional) {
+			continue
+		}
+		producer := e.primitive.site(t.Producer.Annotation.UnderlyingSite(), pKind == annotation.DeepConditional)
+		consumer := e.primitive.site(t.Consumer.Annotation.UnderlyingSite(), cKind == annotation.DeepConditional)
+		if producer.Exported && consumer.Exported {
+			ranked[i].rank = 1
+		} else {
+			ranked[i].rank = 2
+		}
 	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedTrigger) int {
+		if a.rank != b.rank {
+			return a.rank - b.rank
+		}
+		if a.pos.Filename != b.pos.Filename {
+			return strings.Compare(a.pos.Filename, b.pos.Filename)
+		}
+		if a.pos.Line != b.pos.Line {
+			return a.pos.Line - b.pos.Line
+		}
+		return a.pos.Column - b.pos.Column
+	})
+	sorted := make([]annotation.FullTrigger, len(ranked))
+	for i, r := range ranked {
+		sorted[i] = r.trigger
+	}
+	return sorted
 }
 
 func (e *Engine) buildFromSingleFullTrigger(trigger annotation.FullTrigger) {
+	if ev, ok := e.triggerEvent(trigger); ok {
+		e.applyEvent(ev)
+	}
+}
+
+// triggerEvent returns the primitive observation of the full trigger (see applyEvent), or false
+// if the trigger yields no information (e.g., its producer never produces nil).
+func (e *Engine) triggerEvent(trigger annotation.FullTrigger) (sessionEvent, bool) {
 	pKind, cKind := trigger.Producer.Annotation.Kind(), trigger.Consumer.Annotation.Kind()
 	pSite, cSite := trigger.Producer.Annotation.UnderlyingSite(), trigger.Consumer.Annotation.UnderlyingSite()
 	// NilAway does not know that (kind == Conditional || DeepConditional) => (site != nil),
@@ -265,7 +424,11 @@ func (e *Engine) buildFromSingleFullTrigger(trigger annotation.FullTrigger) {
 	case pKind == annotation.Always && cKind == annotation.Always:
 		// Producer always produces nilable value -> consumer always consumes nonnil value.
 		// We simply generate a failure for this case.
-		e.diagnosticEngine.AddSingleAssertionConflict(trigger)
+		return sessionEvent{
+			Kind:      eventConflict,
+			Assertion: e.primitive.fullTrigger(trigger),
+			trigger:   &trigger,
+		}, true
 
 	case pKind == annotation.Always && (cKind == annotation.Conditional || cKind == annotation.DeepConditional):
 		// Producer always produces nilable value -> consumer unknown.
@@ -273,10 +436,13 @@ func (e *Engine) buildFromSingleFullTrigger(trigger annotation.FullTrigger) {
 		if cSite == nil {
 			panic("trigger is conditional but the underlying site is nil")
 		}
-		site := e.primitive.site(cSite, cKind == annotation.DeepConditional)
-		e.observeSiteExplanation(site, TrueBecauseShallowConstraint{
-			ExternalAssertion: e.primitive.fullTrigger(trigger),
-		})
+		return sessionEvent{
+			Kind: eventExplanation,
+			Site: e.primitive.site(cSite, cKind == annotation.DeepConditional),
+			Explanation: TrueBecauseShallowConstraint{
+				ExternalAssertion: e.primitive.fullTrigger(trigger),
+			},
+		}, true
 
 	case (pKind == annotation.Conditional || pKind == annotation.DeepConditional) && (cKind == annotation.Always):
 		// Producer unknown -> consumer always consumes nonnil value.
@@ -284,10 +450,13 @@ func (e *Engine) buildFromSingleFullTrigger(trigger annotation.FullTrigger) {
 		if pSite == nil {
 			panic("trigger is conditional but the underlying site is nil")
 		}
-		site := e.primitive.site(pSite, pKind == annotation.DeepConditional)
-		e.observeSiteExplanation(site, FalseBecauseShallowConstraint{
-			ExternalAssertion: e.primitive.fullTrigger(trigger),
-		})
+		return sessionEvent{
+			Kind: eventExplanation,
+			Site: e.primitive.site(pSite, pKind == annotation.DeepConditional),
+			Explanation: FalseBecauseShallowConstraint{
+				ExternalAssertion: e.primitive.fullTrigger(trigger),
+			},
+		}, true
 
 	case (pKind == annotation.Conditional || pKind == annotation.DeepConditional) &&
 		(cKind == annotation.Conditional || cKind == annotation.DeepConditional):
@@ -296,10 +465,31 @@ func (e *Engine) buildFromSingleFullTrigger(trigger annotation.FullTrigger) {
 		if pSite == nil || cSite == nil {
 			panic("trigger is conditional but the underlying site is nil")
 		}
-		producer := e.primitive.site(pSite, pKind == annotation.DeepConditional)
-		consumer := e.primitive.site(cSite, cKind == annotation.DeepConditional)
+		return sessionEvent{
+			Kind:      eventImplication,
+			Producer:  e.primitive.site(pSite, pKind == annotation.DeepConditional),
+			Consumer:  e.primitive.site(cSite, cKind == annotation.DeepConditional),
+			Assertion: e.primitive.fullTrigger(trigger),
+		}, true
+	}
+	return sessionEvent{}, false
+}
 
-		e.observeImplication(producer, consumer, e.primitive.fullTrigger(trigger))
+// applyEvent applies the primitive observation to the inferred map, which is either made from a
+// full trigger of the package (see triggerEvent) or replayed from a recorded session.
+func (e *Engine) applyEvent(ev sessionEvent) {
+	switch ev.Kind {
+	case eventConflict:
+		if ev.trigger != nil {
+			e.diagnosticEngine.AddSingleAssertionConflict(*ev.trigger)
+		} else if h, ok := e.diagnosticEngine.(primitiveConflictHandler); ok {
+			// The full triggers are not available when replaying a session.
+			h.addPrimitiveConflict(ev.Assertion)
+		}
+	case eventExplanation:
+		e.observeSiteExplanation(ev.Site, ev.Explanation)
+	case eventImplication:
+		e.observeImplication(ev.Producer, ev.Consumer, ev.Assertion)
 	}
 }
 
@@ -348,7 +538,7 @@ func (e *Engine) observeSiteExplanation(site primitiveSite, siteExplained Explai
 		if !v.Bool.Val() {
 			trueExplanation, falseExplanation = falseExplanation, trueExplanation
 		}
-		e.diagnosticEngine.AddOverconstraintConflict(trueExplanation, falseExplanation)
+		e.diagnosticEngine.AddOverconstraintConflict(trueExplanation, falseExplanation, site.Exported)
 
 		// Even though we have a conflict, we still need to make sure to activate any controlled
 		// triggers that are waiting on this site, so that we would not miss processing any
@@ -397,6 +587,11 @@ func (e *Engine) activateControlledTriggers(site primitiveSite, siteExplained Ex
 			e.buildFromSingleFullTrigger(tg)
 		}
 	}
+	if controlledEvents, ok := e.controlledEventsBySite[site]; ok && siteExplained.Val() {
+		for _, ev := range controlledEvents {
+			e.applyEvent(ev)
+		}
+	}
 }
 
 // observeImplication augments the inferred map with a new implication discovered as
@@ -464,10 +659,123 @@ func (e *Engine) observeImplication(
 	}
 
 	// If we reach here, it means that the existing values for the producer and consumer are
-	// undetermined (or non-existent), so we can simply add an implication edge in the graph.
+	// undetermined (or non-existent), so we can simply add an implication edge in the graph,
+	// unless the edge would add new sites beyond the limit (see LimitSites).
+	if e.maxSites > 0 {
+		newSites := 0
+		for _, site := range [...]primitiveSite{producerSite, consumerSite} {
+			if _, ok := e.inferredMap.Load(site); !ok {
+				newSites++
+			}
+		}
+		if newSites > 0 && e.inferredMap.Len()+newSites > e.maxSites {
+			e.truncated = true
+			return
+		}
+	}
 	e.inferredMap.StoreImplication(producerSite, consumerSite, assertion)
 }
 
+// _factTypes is the list of concrete types that may appear behind the interfaces (InferredVal,
+// ExplainedBool and annotation.Prestring) in an InferredMap. They must be registered for gob
+// encoding (see GobRegister), and are also used to resolve type names in the JSON encoding.
+// Note that the gob encoding relies on the order of this list, so new types must be appended.
+var _factTypes = [...]any{
+	&DeterminedVal{},
+	&UndeterminedVal{},
+	FalseBecauseShallowConstraint{},
+	FalseBecauseDeepConstraint{},
+	FalseBecauseAnnotation{},
+	TrueBecauseShallowConstraint{},
+	TrueBecauseDeepConstraint{},
+	TrueBecauseAnnotation{},
+
+	annotation.PtrLoadPrestring{},
+	annotation.MapAccessPrestring{},
+	annotation.MapWrittenToPrestring{},
+	annotation.SliceAccessPrestring{},
+	annotation.ChanAccessPrestring{},
+	annotation.FldAccessPrestring{},
+	annotation.UseAsErrorResultPrestring{},
+	annotation.FldAssignPrestring{},
+	annotation.GlobalVarAssignPrestring{},
+	annotation.ArgPassPrestring{},
+	annotation.InterfaceResultFromImplementationPrestring{},
+	annotation.MethodParamFromInterfacePrestring{},
+	annotation.UseAsReturnPrestring{},
+	annotation.SliceAssignPrestring{},
+	annotation.ArrayAssignPrestring{},
+	annotation.PtrAssignPrestring{},
+	annotation.MapAssignPrestring{},
+	annotation.DeepAssignPrimitivePrestring{},
+	annotation.ParamAssignDeepPrestring{},
+	annotation.FuncRetAssignDeepPrestring{},
+	annotation.VariadicParamAssignDeepPrestring{},
+	annotation.FieldAssignDeepPrestring{},
+	annotation.GlobalVarAssignDeepPrestring{},
+	annotation.LocalVarAssignDeepPrestring{},
+	annotation.ChanSendPrestring{},
+
+	annotation.TriggerIfNilablePrestring{},
+	annotation.TriggerIfDeepNilablePrestring{},
+	annotation.ProduceTriggerTautologyPrestring{},
+	annotation.ProduceTriggerNeverPrestring{},
+	annotation.PositiveNilCheckPrestring{},
+	annotation.NegativeNilCheckPrestring{},
+	annotation.ConstNilPrestring{},
+	annotation.NoVarAssignPrestring{},
+	annotation.FuncParamPrestring{},
+	annotation.VariadicFuncParamPrestring{},
+	annotation.TrustedFuncNilablePrestring{},
+	annotation.TrustedFuncNonnilPrestring{},
+	annotation.FldReadPrestring{},
+	annotation.FuncReturnPrestring{},
+	annotation.MethodReturnPrestring{},
+	annotation.MethodResultReachesInterfacePrestring{},
+	annotation.InterfaceParamReachesImplementationPrestring{},
+	annotation.GlobalVarReadPrestring{},
+	annotation.MapReadPrestring{},
+	annotation.SliceReadPrestring{},
+	annotation.ArrayReadPrestring{},
+	annotation.PtrReadPrestring{},
+	annotation.ChanRecvPrestring{},
+	annotation.FuncParamDeepPrestring{},
+	annotation.VariadicFuncParamDeepPrestring{},
+	annotation.FuncReturnDeepPrestring{},
+	annotation.FldReadDeepPrestring{},
+	annotation.LocalVarReadDeepPrestring{},
+	annotation.GlobalVarReadDeepPrestring{},
+	annotation.GuardMissingPrestring{},
+	annotation.UseAsFldOfReturnPrestring{},
+	annotation.ArgFldPassPrestring{},
+	annotation.ParamFldReadPrestring{},
+	annotation.UnassignedFldPrestring{},
+	annotation.FldEscapePrestring{},
+	annotation.LocatedPrestring{},
+	annotation.UseAsErrorRetWithNilabilityUnknownPrestring{},
+	annotation.UseAsNonErrorRetDependentOnErrorRetNilabilityPrestring{},
+	annotation.MethodRecvPrestring{},
+	annotation.RecvPassPrestring{},
+	annotation.MethodRecvDeepPrestring{},
+	annotation.FldReturnPrestring{},
+	TrueBecauseExternalReturn{},
+	annotation.ContractParamPrestring{},
+	annotation.ElemReadDeepPrestring{},
+	annotation.ElemAssignDeepPrestring{},
+	annotation.FuncValueCallPrestring{},
+	annotation.TypeAssertionPrestring{},
+	annotation.OkFuncReturnPrestring{},
+	annotation.UnmarshalledFieldPrestring{},
+	annotation.UnassignedArrayElemPrestring{},
+	annotation.ErrorsAsTargetPrestring{},
+	annotation.ContextValuePrestring{},
+	annotation.TypeAssertOperandPrestring{},
+	annotation.NilStoredThroughParamPrestring{},
+	annotation.ReflectInterfacePrestring{},
+	TrueBecauseConstructor{},
+	FalseBecauseConstructor{},
+}
+
 // GobRegister must be called in an `init` function before attempting to run any procedure that can
 // deal with InferredAnnotationMaps as Facts. If not, gob encoding/decoding will be unable to handle
 // the data structures.
@@ -484,81 +792,7 @@ func GobRegister() {
 		return out
 	}
 
-	gob.RegisterName(nextStr(), &DeterminedVal{})
-	gob.RegisterName(nextStr(), &UndeterminedVal{})
-	gob.RegisterName(nextStr(), FalseBecauseShallowConstraint{})
-	gob.RegisterName(nextStr(), FalseBecauseDeepConstraint{})
-	gob.RegisterName(nextStr(), FalseBecauseAnnotation{})
-	gob.RegisterName(nextStr(), TrueBecauseShallowConstraint{})
-	gob.RegisterName(nextStr(), TrueBecauseDeepConstraint{})
-	gob.RegisterName(nextStr(), TrueBecauseAnnotation{})
-
-	gob.RegisterName(nextStr(), annotation.PtrLoadPrestring{})
-	gob.RegisterName(nextStr(), annotation.MapAccessPrestring{})
-	gob.RegisterName(nextStr(), annotation.MapWrittenToPrestring{})
-	gob.RegisterName(nextStr(), annotation.SliceAccessPrestring{})
-	gob.RegisterName(nextStr(), annotation.ChanAccessPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldAccessPrestring{})
-	gob.RegisterName(nextStr(), annotation.UseAsErrorResultPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.GlobalVarAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.ArgPassPrestring{})
-	gob.RegisterName(nextStr(), annotation.InterfaceResultFromImplementationPrestring{})
-	gob.RegisterName(nextStr(), annotation.MethodParamFromInterfacePrestring{})
-	gob.RegisterName(nextStr(), annotation.UseAsReturnPrestring{})
-	gob.RegisterName(nextStr(), annotation.SliceAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.ArrayAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.PtrAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.MapAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.DeepAssignPrimitivePrestring{})
-	gob.RegisterName(nextStr(), annotation.ParamAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.FuncRetAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.VariadicParamAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.FieldAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.GlobalVarAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.LocalVarAssignDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.ChanSendPrestring{})
-
-	gob.RegisterName(nextStr(), annotation.TriggerIfNilablePrestring{})
-	gob.RegisterName(nextStr(), annotation.TriggerIfDeepNilablePrestring{})
-	gob.RegisterName(nextStr(), annotation.ProduceTriggerTautologyPrestring{})
-	gob.RegisterName(nextStr(), annotation.ProduceTriggerNeverPrestring{})
-	gob.RegisterName(nextStr(), annotation.PositiveNilCheckPrestring{})
-	gob.RegisterName(nextStr(), annotation.NegativeNilCheckPrestring{})
-	gob.RegisterName(nextStr(), annotation.ConstNilPrestring{})
-	gob.RegisterName(nextStr(), annotation.NoVarAssignPrestring{})
-	gob.RegisterName(nextStr(), annotation.FuncParamPrestring{})
-	gob.RegisterName(nextStr(), annotation.VariadicFuncParamPrestring{})
-	gob.RegisterName(nextStr(), annotation.TrustedFuncNilablePrestring{})
-	gob.RegisterName(nextStr(), annotation.TrustedFuncNonnilPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.FuncReturnPrestring{})
-	gob.RegisterName(nextStr(), annotation.MethodReturnPrestring{})
-	gob.RegisterName(nextStr(), annotation.MethodResultReachesInterfacePrestring{})
-	gob.RegisterName(nextStr(), annotation.InterfaceParamReachesImplementationPrestring{})
-	gob.RegisterName(nextStr(), annotation.GlobalVarReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.MapReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.SliceReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.ArrayReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.PtrReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.ChanRecvPrestring{})
-	gob.RegisterName(nextStr(), annotation.FuncParamDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.VariadicFuncParamDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.FuncReturnDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldReadDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.LocalVarReadDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.GlobalVarReadDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.GuardMissingPrestring{})
-	gob.RegisterName(nextStr(), annotation.UseAsFldOfReturnPrestring{})
-	gob.RegisterName(nextStr(), annotation.ArgFldPassPrestring{})
-	gob.RegisterName(nextStr(), annotation.ParamFldReadPrestring{})
-	gob.RegisterName(nextStr(), annotation.UnassignedFldPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldEscapePrestring{})
-	gob.RegisterName(nextStr(), annotation.LocatedPrestring{})
-	gob.RegisterName(nextStr(), annotation.UseAsErrorRetWithNilabilityUnknownPrestring{})
-	gob.RegisterName(nextStr(), annotation.UseAsNonErrorRetDependentOnErrorRetNilabilityPrestring{})
-	gob.RegisterName(nextStr(), annotation.MethodRecvPrestring{})
-	gob.RegisterName(nextStr(), annotation.RecvPassPrestring{})
-	gob.RegisterName(nextStr(), annotation.MethodRecvDeepPrestring{})
-	gob.RegisterName(nextStr(), annotation.FldReturnPrestring{})
+	for _, t := range _factTypes {
+		gob.RegisterName(nextStr(), t)
+	}
 }
@@ -235,3 +235,93 @@ func (FalseBecauseAnnotation) TriggerReprs() (fmt.Stringer, fmt.Stringer) {
 func (f FalseBecauseAnnotation) DeeperReason() ExplainedBool {
 	return nil
 }
+
+// TrueBecauseExternalReturn is used as the label for a site X describing a pointer result of a
+// function in a package that is not analyzed, when such results are configured to be nilable.
+type TrueBecauseExternalReturn struct {
+	ExplainedTrue
+	ReturnPos token.Position
+}
+
+func (TrueBecauseExternalReturn) String() string {
+	return "NILABLE because it is returned from a package that is not analyzed"
+}
+
+// Position is the position of underlying site.
+func (t TrueBecauseExternalReturn) Position() token.Position {
+	return t.ReturnPos
+}
+
+// TriggerReprs simply returns nil, nil since this constraint is the result of the configuration.
+func (TrueBecauseExternalReturn) TriggerReprs() (fmt.Stringer, fmt.Stringer) {
+	return nil, nil
+}
+
+// DeeperReason returns another ExplainedBool that marks the deeper reason of this constraint.
+// It is only nonnil for deep constraints.
+func (TrueBecauseExternalReturn) DeeperReason() ExplainedBool {
+	return nil
+}
+
+// TrueBecauseConstructor is used as the label for a site X describing a pointer field of a struct
+// that is left unset (or set to nil) by one of the constructors of the struct, when the nilability of such fields
+// is configured to be seeded from the constructors.
+type TrueBecauseConstructor struct {
+	ExplainedTrue
+	// ConstructorPos is the position of the nil value of the field in the composite literal, or
+	// that of the composite literal itself if the field is left unset.
+	ConstructorPos token.Position
+	// FuncName is the name of the constructor.
+	FuncName string
+}
+
+func (t TrueBecauseConstructor) String() string {
+	return fmt.Sprintf("NILABLE because it is left nil by the constructor `%s()`", t.FuncName)
+}
+
+// Position is the position of the composite literal (or the nil value in it) leaving the field nil.
+func (t TrueBecauseConstructor) Position() token.Position {
+	return t.ConstructorPos
+}
+
+// TriggerReprs simply returns nil, nil since this constraint is the result of the configuration.
+func (TrueBecauseConstructor) TriggerReprs() (fmt.Stringer, fmt.Stringer) {
+	return nil, nil
+}
+
+// DeeperReason returns another ExplainedBool that marks the deeper reason of this constraint.
+// It is only nonnil for deep constraints.
+func (TrueBecauseConstructor) DeeperReason() ExplainedBool {
+	return nil
+}
+
+// FalseBecauseConstructor is the counterpart of TrueBecauseConstructor for a pointer field set by
+// every constructor of the struct.
+type FalseBecauseConstructor struct {
+	ExplainedFalse
+	// ConstructorPos is the position of the composite literal setting the field in the first
+	// constructor.
+	ConstructorPos token.Position
+	// FuncName is the name of the first constructor.
+	FuncName string
+}
+
+func (f FalseBecauseConstructor) String() string {
+	return fmt.Sprintf("NONNIL because it is set by every constructor (e.g., `%s()`)", f.FuncName)
+}
+
+// Position is the position of the composite literal setting the field in the first constructor.
+func (f FalseBecauseConstructor) Position() token.Position {
+	return f.ConstructorPos
+}
+
+// TriggerReprs simply returns nil, nil since this constraint is the result of the configuration.
+func (FalseBecauseConstructor) TriggerReprs() (fmt.Stringer, fmt.Stringer) {
+	return nil, nil
+}
+
+// DeeperReason returns another ExplainedBool that marks the deeper reason of this constraint.
+// It is only nonnil for deep constraints.
+func (FalseBecauseConstructor) DeeperReason() ExplainedBool {
+	return nil
+}
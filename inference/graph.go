@@ -0,0 +1,210 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import "golang.org/x/exp/slices"
+
+// ExplainPath returns the shortest sequence of sites (including both ends) connecting the site
+// `from` to the site `to` by following the Implicates edges of the undetermined sites, e.g., from
+// a source of nil to the site being reported. The assertion of each hop can be retrieved via
+// ImplicationAssertion. If no such path exists, false is returned. Note that the edges of a site
+// are dropped once it is determined, so the path can only go through undetermined sites (except
+// for the site `to` itself).
+func (i *InferredMap) ExplainPath(from, to primitiveSite) ([]primitiveSite, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if from == to {
+		if _, ok := i.mapping.Load(from); !ok {
+			return nil, false
+		}
+		return []primitiveSite{from}, true
+	}
+
+	// Perform a breadth-first search, where parents records the previous site on the shortest
+	// path to each visited site.
+	parents := map[primitiveSite]primitiveSite{from: from}
+	queue := []primitiveSite{from}
+	for len(queue) > 0 {
+		site := queue[0]
+		queue = queue[1:]
+
+		val, _ := i.mapping.Load(site)
+		v, ok := val.(*UndeterminedVal)
+		if !ok {
+			continue
+		}
+		for _, p := range v.Implicates.Pairs {
+			next := p.Key
+			if _, visited := parents[next]; visited {
+				continue
+			}
+			parents[next] = site
+			if next != to {
+				queue = append(queue, next)
+				continue
+			}
+
+			// Reconstruct the path by walking the parents backwards.
+			path := []primitiveSite{to}
+			for s := to; s != from; {
+				s = parents[s]
+				path = append(path, s)
+			}
+			for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+				path[l], path[r] = path[r], path[l]
+			}
+			return path, true
+		}
+	}
+	return nil, false
+}
+
+// ImplicationAssertion returns the assertion stored on the implication edge from the site `from`
+// to the site `to`, or false if no such edge exists.
+func (i *InferredMap) ImplicationAssertion(from, to primitiveSite) (primitiveFullTrigger, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if val, ok := i.mapping.Load(from); ok {
+		if v, ok := val.(*UndeterminedVal); ok {
+			if assertion, ok := v.Implicates.Load(to); ok {
+				return assertion, true
+			}
+		}
+	}
+	// The edge might only be stored on the implicant side in incrementally-exported maps.
+	if val, ok := i.mapping.Load(to); ok {
+		if v, ok := val.(*UndeterminedVal); ok {
+			if assertion, ok := v.Implicants.Load(from); ok {
+				return assertion, true
+			}
+		}
+	}
+	return primitiveFullTrigger{}, false
+}
+
+// Cycles returns the strongly connected components with more than one site in the implication
+// graph formed by the undetermined sites, i.e., the groups of sites that imply each other and hence
+// must eventually share the same nilability. The sites in each component, as well as the
+// components themselves, are returned in the insertion order of the map. The components are found
+// by Tarjan's algorithm in time linear to the size of the graph.
+func (i *InferredMap) Cycles() [][]primitiveSite {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// Build the adjacency lists of the undetermined sites, where the sites are identified by their
+	// insertion order. An edge is stored on both of its ends, but only one of them may be present
+	// in incrementally-exported maps, so we collect the edges from both sides.
+	order := make(map[primitiveSite]int)
+	for _, p := range i.mapping.Pairs {
+		if _, ok := p.Value.(*UndeterminedVal); ok {
+			order[p.Key] = len(order)
+		}
+	}
+	sites := make([]primitiveSite, len(order))
+	adjacency := make([][]int, len(order))
+	type edge struct{ from, to int }
+	seen := make(map[edge]bool)
+	addEdge := func(from, to primitiveSite) {
+		f, fok := order[from]
+		t, tok := order[to]
+		if !fok || !tok || seen[edge{f, t}] {
+			return
+		}
+		seen[edge{f, t}] = true
+		adjacency[f] = append(adjacency[f], t)
+	}
+	for _, p := range i.mapping.Pairs {
+		v, ok := p.Value.(*UndeterminedVal)
+		if !ok {
+			continue
+		}
+		sites[order[p.Key]] = p.Key
+		for _, e := range v.Implicates.Pairs {
+			addEdge(p.Key, e.Key)
+		}
+		for _, e := range v.Implicants.Pairs {
+			addEdge(e.Key, p.Key)
+		}
+	}
+
+	// Run Tarjan's algorithm, where index records the discovery order of each site (-1 if not yet
+	// visited) and lowLink records the smallest index reachable from it within the DFS tree.
+	index, lowLink := make([]int, len(sites)), make([]int, len(sites))
+	for n := range index {
+		index[n] = -1
+	}
+	onStack := make([]bool, len(sites))
+	var stack []int
+	var components [][]int
+	next := 0
+
+	var visit func(n int)
+	visit = func(n int) {
+		index[n], lowLink[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range adjacency[n] {
+			if index[m] == -1 {
+				visit(m)
+				if lowLink[m] < lowLink[n] {
+					lowLink[n] = lowLink[m]
+				}
+			} else if onStack[m] && index[m] < lowLink[n] {
+				lowLink[n] = index[m]
+			}
+		}
+
+		if lowLink[n] != index[n] {
+			return
+		}
+		// n is the root of a strongly connected component, pop the component from the stack.
+		var component []int
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			component = append(component, m)
+			if m == n {
+				break
+			}
+		}
+		if len(component) > 1 {
+			components = append(components, component)
+		}
+	}
+	for n := range sites {
+		if index[n] == -1 {
+			visit(n)
+		}
+	}
+
+	cycles := make([][]primitiveSite, 0, len(components))
+	for _, component := range components {
+		slices.Sort(component)
+		cycle := make([]primitiveSite, len(component))
+		for k, n := range component {
+			cycle[k] = sites[n]
+		}
+		cycles = append(cycles, cycle)
+	}
+	slices.SortFunc(cycles, func(a, b []primitiveSite) int {
+		return order[a[0]] - order[b[0]]
+	})
+	return cycles
+}
@@ -16,13 +16,21 @@ package inference
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"go/types"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/klauspost/compress/s2"
 	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/util"
 	"go.uber.org/nilaway/util/orderedmap"
+	"golang.org/x/exp/slices"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -39,7 +47,11 @@ import (
 // add information only to Mapping. On export, iterations combined with calls to
 // inferredValDiff on shared keys is used to ensure that only
 // information present in `Mapping` but not `UpstreamMapping` is exported.
+//
+// All methods of InferredMap are safe for concurrent use.
 type InferredMap struct {
+	// mu guards mapping and upstreamMapping.
+	mu              sync.RWMutex
 	primitive       *primitivizer
 	upstreamMapping map[primitiveSite]InferredVal
 	mapping         *orderedmap.OrderedMap[primitiveSite, InferredVal]
@@ -60,17 +72,26 @@ func (*InferredMap) AFact() {}
 // Load returns the value stored in the map for an annotation site, or nil if no value is present.
 // The ok result indicates whether value was found in the map.
 func (i *InferredMap) Load(site primitiveSite) (value InferredVal, ok bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	return i.mapping.Load(site)
 }
 
 // StoreDetermined sets the inferred value for an annotation site.
 func (i *InferredMap) StoreDetermined(site primitiveSite, value ExplainedBool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.mapping.Store(site, &DeterminedVal{Bool: value})
 }
 
 // StoreImplication stores an implication edge between the `from` and `to` annotation sites in the
 // graph with the assertion for error reporting.
 func (i *InferredMap) StoreImplication(from primitiveSite, to primitiveSite, assertion primitiveFullTrigger) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	// First create UndeterminedVal in the map if it does not exist yet.
 	for _, site := range [...]primitiveSite{from, to} {
 		if _, ok := i.mapping.Load(site); !ok {
@@ -87,12 +108,19 @@ func (i *InferredMap) StoreImplication(from primitiveSite, to primitiveSite, ass
 
 // Len returns the number of annotation sites currently stored in the map.
 func (i *InferredMap) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	return len(i.mapping.Pairs)
 }
 
 // OrderedRange calls f sequentially for each annotation site and inferred value present in the map
-// in insertion order. If f returns false, range stops the iteration.
+// in insertion order. If f returns false, range stops the iteration. Note that f must not modify
+// the map.
 func (i *InferredMap) OrderedRange(f func(primitiveSite, InferredVal) bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	for _, p := range i.mapping.Pairs {
 		if !f(p.Key, p.Value) {
 			return
@@ -100,11 +128,467 @@ func (i *InferredMap) OrderedRange(f func(primitiveSite, InferredVal) bool) {
 	}
 }
 
+// MaxConfidence is the confidence score (see InferredMap.Confidence) of the sites that are
+// determined directly, e.g., by annotations or by a single assertion with a definite nil
+// production or nonnil consumption.
+const MaxConfidence = 10
+
+// Confidence returns a score in [0, MaxConfidence] indicating how directly the nilability of the
+// site is determined. Directly determined sites score MaxConfidence, and each deeper step in the
+// chain of implications that determined the site lowers the score by one (down to a minimum of 1).
+// Sites that are not determined (or not present in the map) score 0.
+func (i *InferredMap) Confidence(site primitiveSite) int {
+	val, ok := i.Load(site)
+	if !ok {
+		return 0
+	}
+	determined, ok := val.(*DeterminedVal)
+	if !ok {
+		return 0
+	}
+
+	score := MaxConfidence
+	for r := determined.Bool.DeeperReason(); r != nil && score > 1; r = r.DeeperReason() {
+		score--
+	}
+	return score
+}
+
+// SortedRange calls f sequentially for each annotation site and inferred value present in the map
+// in a deterministic order sorted by the sites (see primitiveSite.compare), which is independent of
+// the insertion order. If f returns false, range stops the iteration. Note that f must not modify
+// the map.
+func (i *InferredMap) SortedRange(f func(primitiveSite, InferredVal) bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	pairs := slices.Clone(i.mapping.Pairs)
+	slices.SortFunc(pairs, func(a, b *orderedmap.Pair[primitiveSite, InferredVal]) int {
+		return a.Key.compare(&b.Key)
+	})
+	for _, p := range pairs {
+		if !f(p.Key, p.Value) {
+			return
+		}
+	}
+}
+
+// DeterminedSites returns the sites whose nilability is determined (i.e., mapped to a
+// DeterminedVal), sorted by the sites (see primitiveSite.compare) for stable output. If
+// exportedOnly is true, only the exported sites are returned. Together with Len, this gives the
+// ratio of the sites NilAway has decided on. The sites are returned as copies, so modifying them
+// does not affect the map.
+func (i *InferredMap) DeterminedSites(exportedOnly bool) []primitiveSite {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var sites []primitiveSite
+	for _, p := range i.mapping.Pairs {
+		if _, ok := p.Value.(*DeterminedVal); !ok || (exportedOnly && !p.Key.Exported) {
+			continue
+		}
+		sites = append(sites, p.Key)
+	}
+	slices.SortFunc(sites, func(a, b primitiveSite) int { return a.compare(&b) })
+	return sites
+}
+
+// MapStats summarizes the contents of an InferredMap.
+type MapStats struct {
+	// Nilable is the number of sites determined to be nilable.
+	Nilable int
+	// Nonnil is the number of sites determined to be nonnil.
+	Nonnil int
+	// Undetermined is the number of sites whose nilability is not determined.
+	Undetermined int
+	// Edges is the number of distinct implication edges between undetermined sites.
+	Edges int
+	// Exported is the number of sites that are exported (in the go sense).
+	Exported int
+	// Unexported is the number of sites that are not exported (in the go sense).
+	Unexported int
+}
+
+// String returns a one-line summary of the stats.
+func (s MapStats) String() string {
+	return fmt.Sprintf("sites: %d (nilable: %d, nonnil: %d, undetermined: %d), edges: %d, exported: %d, unexported: %d",
+		s.Nilable+s.Nonnil+s.Undetermined, s.Nilable, s.Nonnil, s.Undetermined, s.Edges, s.Exported, s.Unexported)
+}
+
+// Stats returns the statistics of the sites and edges stored in the map.
+func (i *InferredMap) Stats() MapStats {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	type edge struct{ from, to primitiveSite }
+	edges := make(map[edge]bool)
+
+	var stats MapStats
+	for _, p := range i.mapping.Pairs {
+		if p.Key.Exported {
+			stats.Exported++
+		} else {
+			stats.Unexported++
+		}
+
+		switch v := p.Value.(type) {
+		case *DeterminedVal:
+			if v.Bool.Val() {
+				stats.Nilable++
+			} else {
+				stats.Nonnil++
+			}
+		case *UndeterminedVal:
+			stats.Undetermined++
+			// An edge is stored on both of its ends, but only one of them may be present in
+			// incrementally-exported maps, so we de-duplicate the edges from both sides.
+			for _, e := range v.Implicates.Pairs {
+				edges[edge{from: p.Key, to: e.Key}] = true
+			}
+			for _, e := range v.Implicants.Pairs {
+				edges[edge{from: e.Key, to: p.Key}] = true
+			}
+		}
+	}
+	stats.Edges = len(edges)
+	return stats
+}
+
+// Edge is an implication edge between two undetermined sites in an InferredMap, i.e., From being
+// nilable implies To being nilable, due to the Assertion.
+type Edge struct {
+	From, To  primitiveSite
+	Assertion primitiveFullTrigger
+}
+
+// Edges returns the implication edges stored in the map as a read-only adjacency list for
+// external tooling (e.g., visualizers), sorted by the From and then the To sites (see
+// primitiveSite.compare) with each edge appearing once. The edges are returned as copies, so
+// modifying them does not affect the map.
+func (i *InferredMap) Edges() []Edge {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	type edge struct{ from, to primitiveSite }
+	seen := make(map[edge]bool)
+	var edges []Edge
+	add := func(from, to primitiveSite, assertion primitiveFullTrigger) {
+		if seen[edge{from: from, to: to}] {
+			return
+		}
+		seen[edge{from: from, to: to}] = true
+		edges = append(edges, Edge{From: from, To: to, Assertion: assertion})
+	}
+	for _, p := range i.mapping.Pairs {
+		v, ok := p.Value.(*UndeterminedVal)
+		if !ok {
+			continue
+		}
+		// Similar to Stats, an edge may only be present on one of its ends in incrementally-exported
+		// maps, so we collect the edges from both sides.
+		for _, e := range v.Implicates.Pairs {
+			add(p.Key, e.Key, e.Value)
+		}
+		for _, e := range v.Implicants.Pairs {
+			add(e.Key, p.Key, e.Value)
+		}
+	}
+	slices.SortFunc(edges, func(a, b Edge) int {
+		if c := a.From.compare(&b.From); c != 0 {
+			return c
+		}
+		return a.To.compare(&b.To)
+	})
+	return edges
+}
+
+// _maxEdgesInString is the maximum number of implicants (or implicates) rendered for each site by
+// InferredMap.String, such that dumping large maps stays manageable.
+const _maxEdgesInString = 8
+
+// String returns a string representation of the map for debugging purposes _only_, with one site
+// per line in insertion order. Undetermined sites list their implicants and implicates along with
+// the (compact) assertions that created the edges, e.g.,
+//
+//	Global Variable "g": undetermined implicants=[Field f {"assigned into global variable `g` @ foo.go:10"}] implicates=[]
+//
+// where at most _maxEdgesInString edges are rendered in each list, followed by "..." and the
+// number of omitted edges.
+func (i *InferredMap) String() string {
+	edgesStr := func(edges *orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger]) string {
+		strs := make([]string, 0, len(edges.Pairs))
+		for _, e := range edges.Pairs {
+			if len(strs) == _maxEdgesInString {
+				strs = append(strs, fmt.Sprintf("... (%d more)", len(edges.Pairs)-_maxEdgesInString))
+				break
+			}
+			strs = append(strs, fmt.Sprintf("%s {%q}", e.Key.String(), e.Value.shortString()))
+		}
+		return "[" + strings.Join(strs, ", ") + "]"
+	}
+	valStr := func(val InferredVal) string {
+		switch val := val.(type) {
+		case *DeterminedVal:
+			if val.Bool.Val() {
+				return "nilable"
+			}
+			return "nonnil"
+		case *UndeterminedVal:
+			return fmt.Sprintf("undetermined implicants=%s implicates=%s", edgesStr(val.Implicants), edgesStr(val.Implicates))
+		default:
+			return fmt.Sprintf("unknown %T", val)
+		}
+	}
+
+	var b strings.Builder
+	i.OrderedRange(func(site primitiveSite, val InferredVal) bool {
+		fmt.Fprintf(&b, "%s: %s\n", site.String(), valStr(val))
+		return true
+	})
+	return b.String()
+}
+
+// WriteText writes the sites that would be exported (see chooseSitesToExport) to w in a stable,
+// line-oriented text format, such that the exported facts can be committed as golden files and
+// diffed across changes to the analysis. Unlike String, the sites are sorted (see
+// primitiveSite.compare) and each undetermined site lists the (sorted) exported sites it
+// implicates without the assertions, e.g.,
+//
+//	Result 0 of Function Foo: determined nilable
+//	Field F: undetermined implicates=[Global Variable "G"]
+func (i *InferredMap) WriteText(w io.Writer) {
+	i.WriteMatchingText(w, nil)
+}
+
+// WriteMatchingText is similar to WriteText, but only writes the sites whose full string
+// representations (e.g., `Field F`) match the regular expression (unless it is nil), which is
+// useful for inspecting the facts of a specific cross-package issue. Note that the implicates of
+// the written sites are still listed regardless of the regular expression.
+func (i *InferredMap) WriteMatchingText(w io.Writer, re *regexp.Regexp) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	sitesToExport := i.chooseSitesToExport()
+	pairs := make([]*orderedmap.Pair[primitiveSite, InferredVal], 0, len(sitesToExport))
+	for _, p := range i.mapping.Pairs {
+		if sitesToExport[p.Key] && (re == nil || re.MatchString(p.Key.String())) {
+			pairs = append(pairs, p)
+		}
+	}
+	slices.SortFunc(pairs, func(a, b *orderedmap.Pair[primitiveSite, InferredVal]) int {
+		return a.Key.compare(&b.Key)
+	})
+
+	for _, p := range pairs {
+		switch val := p.Value.(type) {
+		case *DeterminedVal:
+			nilability := "nonnil"
+			if val.Bool.Val() {
+				nilability = "nilable"
+			}
+			fmt.Fprintf(w, "%s: determined %s\n", p.Key.String(), nilability)
+		case *UndeterminedVal:
+			var targets []primitiveSite
+			for _, e := range val.Implicates.Pairs {
+				if sitesToExport[e.Key] {
+					targets = append(targets, e.Key)
+				}
+			}
+			slices.SortFunc(targets, func(a, b primitiveSite) int { return a.compare(&b) })
+			strs := make([]string, len(targets))
+			for j := range targets {
+				strs[j] = targets[j].String()
+			}
+			fmt.Fprintf(w, "%s: undetermined implicates=[%s]\n", p.Key.String(), strings.Join(strs, ", "))
+		}
+	}
+}
+
+// Merge unions the sites of the other map into this map. For sites present in both maps, a
+// DeterminedVal takes precedence over an UndeterminedVal, and the implicant and implicate edges of
+// two UndeterminedVals are merged. Note that Merge does not propagate the determined values along
+// the merged implication edges. If the same site is determined to be nilable in one map and
+// nonnil in the other, the site is left untouched and an error describing all such conflicting
+// sites is returned after the remaining sites have been merged.
+func (i *InferredMap) Merge(other *InferredMap) error {
+	if i == other {
+		return nil
+	}
+	// The sites of the other map are copied first such that the two locks are never held at the
+	// same time, otherwise concurrent merges in both directions could deadlock.
+	other.mu.RLock()
+	pairs := make([]orderedmap.Pair[primitiveSite, InferredVal], len(other.mapping.Pairs))
+	for j, p := range other.mapping.Pairs {
+		pairs[j] = orderedmap.Pair[primitiveSite, InferredVal]{Key: p.Key, Value: p.Value.copy()}
+	}
+	other.mu.RUnlock()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var conflicts []error
+	for _, p := range pairs {
+		site, otherVal := p.Key, p.Value
+		val, ok := i.mapping.Load(site)
+		if !ok {
+			i.mapping.Store(site, otherVal)
+			continue
+		}
+
+		switch v := val.(type) {
+		case *DeterminedVal:
+			if o, ok := otherVal.(*DeterminedVal); ok && o.Bool.Val() != v.Bool.Val() {
+				conflicts = append(conflicts, fmt.Errorf(
+					"site %q is inferred as nilable=%t in one map but nilable=%t in the other",
+					site.String(), v.Bool.Val(), o.Bool.Val()))
+			}
+		case *UndeterminedVal:
+			switch o := otherVal.(type) {
+			case *DeterminedVal:
+				i.mapping.Store(site, o)
+			case *UndeterminedVal:
+				for _, e := range o.Implicants.Pairs {
+					v.Implicants.Store(e.Key, e.Value)
+				}
+				for _, e := range o.Implicates.Pairs {
+					v.Implicates.Store(e.Key, e.Value)
+				}
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("merge inferred maps: %d conflicting site(s): %w", len(conflicts), errors.Join(conflicts...))
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the map, where both mapping and upstreamMapping are copied along
+// with the implication edges of the UndeterminedVals, such that modifying the clone (e.g., via
+// StoreImplication) has no visible effect on this map. The primitivizer is shared since it is
+// read-only after creation.
+func (i *InferredMap) Clone() *InferredMap {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	clone := newInferredMap(i.primitive)
+	for site, val := range i.upstreamMapping {
+		clone.upstreamMapping[site] = val.copy()
+	}
+	for _, p := range i.mapping.Pairs {
+		clone.mapping.Store(p.Key, p.Value.copy())
+	}
+	return clone
+}
+
+// withoutPkgs returns a copy of the map without the sites of the packages for which drop returns
+// true, along with the implications from or to such sites (see Engine.DistrustPkgs). The map
+// itself is returned if no site is dropped.
+func (i *InferredMap) withoutPkgs(drop func(pkgPath string) bool) *InferredMap {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// Most maps do not refer to any dropped package, hence we check that first to avoid copying.
+	dropsAny := func(s *orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger]) bool {
+		return slices.ContainsFunc(s.Pairs, func(p *orderedmap.Pair[primitiveSite, primitiveFullTrigger]) bool {
+			return drop(p.Key.PkgPath)
+		})
+	}
+	if !slices.ContainsFunc(i.mapping.Pairs, func(p *orderedmap.Pair[primitiveSite, InferredVal]) bool {
+		v, ok := p.Value.(*UndeterminedVal)
+		return drop(p.Key.PkgPath) || ok && (dropsAny(v.Implicants) || dropsAny(v.Implicates))
+	}) {
+		return i
+	}
+
+	keepEdges := func(s *orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger]) *orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger] {
+		out := orderedmap.New[primitiveSite, primitiveFullTrigger]()
+		for _, p := range s.Pairs {
+			if !drop(p.Key.PkgPath) {
+				out.Store(p.Key, p.Value)
+			}
+		}
+		return out
+	}
+	filtered := newInferredMap(i.primitive)
+	for _, p := range i.mapping.Pairs {
+		if drop(p.Key.PkgPath) {
+			continue
+		}
+		if v, ok := p.Value.(*UndeterminedVal); ok {
+			filtered.mapping.Store(p.Key, &UndeterminedVal{
+				Implicants: keepEdges(v.Implicants),
+				Implicates: keepEdges(v.Implicates),
+			})
+			continue
+		}
+		filtered.mapping.Store(p.Key, p.Value.copy())
+	}
+	return filtered
+}
+
+// ResetToUpstream discards all information added to the map since the upstream information was
+// recorded (e.g., via StoreDetermined and StoreImplication), restoring mapping to a deep copy of
+// upstreamMapping. The upstream sites keep their original order, and Export produces nothing until
+// new information is added. This is cheaper than keeping a Clone around if the speculative
+// observations only need to be rolled back to the imported baseline.
+func (i *InferredMap) ResetToUpstream() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// orderedmap does not support deletion, so we rebuild it with the upstream sites in the same
+	// order. All upstream sites are present in mapping since it only grows after recordUpstream.
+	mapping := orderedmap.New[primitiveSite, InferredVal]()
+	for _, p := range i.mapping.Pairs {
+		if val, ok := i.upstreamMapping[p.Key]; ok {
+			mapping.Store(p.Key, val.copy())
+		}
+	}
+	i.mapping = mapping
+}
+
+// Prune removes the sites that would never be exported (see chooseSitesToExport) from the map to
+// reduce memory usage, i.e., the undetermined sites that are not both reachable from and reaching
+// an exported site, as well as the determined non-exported sites. The exported sites are always
+// kept such that the results of checkAnnotationKey for exported APIs do not change, and the
+// implication edges of the kept sites are left intact (the same as the exported facts). Since the
+// pruned sites can no longer take part in inference, Prune should only be called once the analysis
+// of the current package is complete, and Export produces the same facts before and after Prune.
+func (i *InferredMap) Prune() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	sitesToKeep := i.chooseSitesToExport()
+	if len(sitesToKeep) == len(i.mapping.Pairs) {
+		return
+	}
+
+	// orderedmap does not support deletion, so we rebuild it with the kept sites in the same order.
+	mapping := orderedmap.New[primitiveSite, InferredVal]()
+	for _, p := range i.mapping.Pairs {
+		if sitesToKeep[p.Key] {
+			mapping.Store(p.Key, p.Value)
+		}
+	}
+	i.mapping = mapping
+	for site := range i.upstreamMapping {
+		if !sitesToKeep[site] {
+			delete(i.upstreamMapping, site)
+		}
+	}
+}
+
 // Export only encodes new information not already present in the upstream maps, and it does not
 // encode all (in the go sense; i.e. capitalized) annotation sites (See chooseSitesToExport).
 // This ensures that only _incremental_ information is exported by this package and plays a _vital_
 // role in minimizing build output.
-func (i *InferredMap) Export(pass *analysis.Pass) {
+//
+// If inScope is not nil, the leaf sites (see dropOutOfScopeLeaves) of the packages other than the
+// current one for which inScope returns false are not exported either.
+func (i *InferredMap) Export(pass *analysis.Pass, inScope func(pkgPath string) bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	if len(i.mapping.Pairs) == 0 {
 		return
 	}
@@ -113,6 +597,11 @@ func (i *InferredMap) Export(pass *analysis.Pass) {
 	// like to export.
 	exported := orderedmap.New[primitiveSite, InferredVal]()
 	sitesToExport := i.chooseSitesToExport()
+	if inScope != nil {
+		i.dropOutOfScopeLeaves(sitesToExport, func(pkgPath string) bool {
+			return pkgPath == pass.Pkg.Path() || inScope(pkgPath)
+		})
+	}
 	for _, p := range i.mapping.Pairs {
 		site, val := p.Key, p.Value
 		if !sitesToExport[site] {
@@ -138,9 +627,33 @@ func (i *InferredMap) Export(pass *analysis.Pass) {
 	}
 }
 
-// GobEncode encodes the inferred map via gob encoding.
+// _factMagic is the magic prefix of the encoded inferred maps (see GobEncode), which is followed by
+// the version of the encoding as a big-endian uint32.
+var _factMagic = [...]byte{'N', 'L', 'W', 'F'}
+
+const (
+	// _factVersion is the version of the encoding of the inferred maps, which must be bumped
+	// whenever the encoded structure changes, such that the facts (or cache entries) produced by a
+	// different version of NilAway are rejected with a descriptive error (or translated, see
+	// GobDecode) instead of being silently mis-decoded.
+	_factVersion uint32 = 1
+	// _legacyFactVersion is the version of the encodings without the header (i.e., the ones
+	// produced before the versioning was introduced), which share the structure of version 1.
+	_legacyFactVersion uint32 = 0
+)
+
+// GobEncode encodes the inferred map via gob encoding, prefixed by a header consisting of
+// _factMagic and _factVersion.
 func (i *InferredMap) GobEncode() (b []byte, err error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
 	var buf bytes.Buffer
+	buf.Write(_factMagic[:])
+	if err := binary.Write(&buf, binary.BigEndian, _factVersion); err != nil {
+		return nil, err
+	}
+
 	writer := s2.NewWriter(&buf)
 	defer func() {
 		if cerr := writer.Close(); cerr != nil {
@@ -159,13 +672,44 @@ func (i *InferredMap) GobEncode() (b []byte, err error) {
 	return buf.Bytes(), nil
 }
 
-// GobDecode decodes the InferredMap from buffer.
+// GobDecode decodes the InferredMap from buffer. It supports the current version of the encoding
+// and the immediately previous one, and returns a descriptive error for any other version.
 func (i *InferredMap) GobDecode(input []byte) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.mapping = orderedmap.New[primitiveSite, InferredVal]()
 	i.upstreamMapping = make(map[primitiveSite]InferredVal)
 
-	buf := bytes.NewBuffer(input)
-	return gob.NewDecoder(s2.NewReader(buf)).Decode(&i.mapping)
+	version, payload := _legacyFactVersion, input
+	if bytes.HasPrefix(input, _factMagic[:]) {
+		header := len(_factMagic) + 4
+		if len(input) < header {
+			return fmt.Errorf("truncated fact header of %d bytes", len(input))
+		}
+		version, payload = binary.BigEndian.Uint32(input[len(_factMagic):header]), input[header:]
+	}
+
+	switch version {
+	case _factVersion, _legacyFactVersion:
+		// The legacy encoding only lacks the header, hence no translation is needed. Translations
+		// of the previous structures into the current one should be added here when the encoded
+		// structure changes.
+		return gob.NewDecoder(s2.NewReader(bytes.NewReader(payload))).Decode(&i.mapping)
+	default:
+		return fmt.Errorf("fact version %d not supported by this NilAway (supports %d)", version, _factVersion)
+	}
+}
+
+// recordUpstream copies the current contents of the map into upstreamMapping, such that only the
+// information added afterwards is exported (see Export).
+func (i *InferredMap) recordUpstream() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, p := range i.mapping.Pairs {
+		i.upstreamMapping[p.Key] = p.Value.copy()
+	}
 }
 
 // chooseSitesToExport returns the set of AnnotationSites mapped by this InferredMap that are both
@@ -232,6 +776,38 @@ func (i *InferredMap) chooseSitesToExport() map[primitiveSite]bool {
 	return toExport
 }
 
+// dropOutOfScopeLeaves removes the leaf sites of the out-of-scope packages from the set of sites to
+// export, where a leaf site is either determined or has no implicants or implicates in the set.
+// Dropping such sites never breaks an implication chain between the remaining sites, hence the
+// exported set stays convex (see chooseSitesToExport). Note that the leaves are decided on the
+// original set only, i.e., the sites becoming leaves after the removal are still exported.
+func (i *InferredMap) dropOutOfScopeLeaves(sitesToExport map[primitiveSite]bool, inScope func(pkgPath string) bool) {
+	isLeaf := func(site primitiveSite) bool {
+		v, ok := i.mapping.Value(site).(*UndeterminedVal)
+		if !ok {
+			return true
+		}
+		for _, edges := range [...]*orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger]{v.Implicants, v.Implicates} {
+			for _, p := range edges.Pairs {
+				if p.Key != site && sitesToExport[p.Key] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	var leaves []primitiveSite
+	for site := range sitesToExport {
+		if !inScope(site.PkgPath) && isLeaf(site) {
+			leaves = append(leaves, site)
+		}
+	}
+	for _, site := range leaves {
+		delete(sitesToExport, site)
+	}
+}
+
 // The following method implementations make InferredMap satisfy the annotation.Map
 // interface, so that triggers can be checked against it.
 
@@ -255,9 +831,10 @@ func (i *InferredMap) CheckFuncRecvAnn(fdecl *types.Func) (annotation.Val, bool)
 	return i.checkAnnotationKey(annotation.RecvAnnotationKey{FuncDecl: fdecl})
 }
 
-// CheckDeepTypeAnn checks this InferredMap for a concrete mapping of the type name key provideed
+// CheckDeepTypeAnn checks this InferredMap for a concrete mapping of the type name key provideed,
+// where aliases are resolved to the named types they denote (see util.UnaliasTypeName)
 func (i *InferredMap) CheckDeepTypeAnn(name *types.TypeName) (annotation.Val, bool) {
-	return i.checkAnnotationKey(annotation.TypeNameAnnotationKey{TypeDecl: name})
+	return i.checkAnnotationKey(annotation.TypeNameAnnotationKey{TypeDecl: util.UnaliasTypeName(name)})
 }
 
 // CheckGlobalVarAnn checks this InferredMap for a concrete mapping of the global variable key provided
@@ -277,10 +854,143 @@ func (i *InferredMap) CheckFuncCallSiteRetAnn(key annotation.CallSiteRetAnnotati
 	return i.checkAnnotationKey(key)
 }
 
+// CheckElemAnn checks this InferredMap for a concrete mapping of the element key provided, where
+// the shallow nilability of the key is resolved to the deep nilability of its container site (see
+// primitivizer.site).
+func (i *InferredMap) CheckElemAnn(key annotation.ElemAnnotationKey) (annotation.Val, bool) {
+	return i.checkAnnotationKey(key)
+}
+
+// NilabilityOf returns the nilability of the object in this InferredMap, dispatching on the kind
+// of the object: fields and global variables are checked directly, the parameters, receivers and
+// named results of package-level functions and methods are checked via their enclosing functions,
+// and functions themselves are checked via their first results (i.e., the values of the call
+// expressions). Type names are checked for their deep nilabilities. It returns false if the object
+// is of any other kind (e.g., local variables), or if its nilability has not been determined. This
+// is useful for tools (e.g., IDE hover providers) that query the nilability of arbitrary objects.
+func (i *InferredMap) NilabilityOf(obj types.Object) (annotation.Val, bool) {
+	switch obj := obj.(type) {
+	case *types.Var:
+		if obj.IsField() {
+			return i.CheckFieldAnn(obj)
+		}
+		if obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+			return i.CheckGlobalVarAnn(obj)
+		}
+		fn := enclosingFunc(obj)
+		if fn == nil {
+			return annotation.EmptyVal, false
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Recv() == obj {
+			return i.CheckFuncRecvAnn(fn)
+		}
+		for n := 0; n < sig.Params().Len(); n++ {
+			if sig.Params().At(n) == obj {
+				return i.CheckFuncParamAnn(fn, n)
+			}
+		}
+		for n := 0; n < sig.Results().Len(); n++ {
+			if sig.Results().At(n) == obj {
+				return i.CheckFuncRetAnn(fn, n)
+			}
+		}
+	case *types.Func:
+		if sig, ok := obj.Type().(*types.Signature); ok && sig.Results().Len() > 0 {
+			return i.CheckFuncRetAnn(obj, 0)
+		}
+	case *types.TypeName:
+		return i.CheckDeepTypeAnn(obj)
+	}
+	return annotation.EmptyVal, false
+}
+
+// enclosingFunc returns the package-level function or method whose signature declares the
+// variable (i.e., as its receiver, parameter or result), or nil if there is no such function.
+func enclosingFunc(v *types.Var) *types.Func {
+	if v.Pkg() == nil || v.Parent() == nil {
+		return nil
+	}
+	scope := v.Pkg().Scope()
+	for _, name := range scope.Names() {
+		var candidates []*types.Func
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			candidates = append(candidates, obj)
+		case *types.TypeName:
+			if named, ok := obj.Type().(*types.Named); ok {
+				for m := 0; m < named.NumMethods(); m++ {
+					candidates = append(candidates, named.Method(m))
+				}
+			}
+		}
+		for _, fn := range candidates {
+			if fn.Scope() == v.Parent() {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// IsDeterminedNonnil returns true iff the (shallow) site of the key is determined to be nonnil,
+// i.e., it is mapped to a DeterminedVal that is false. Undetermined (or absent) sites are never
+// considered nonnil, even though they may be treated as such at the end of the inference.
+func (i *InferredMap) IsDeterminedNonnil(key annotation.Key) bool {
+	site := i.primitive.site(key, false)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	val, ok := i.mapping.Load(site)
+	if !ok {
+		return false
+	}
+	determined, ok := val.(*DeterminedVal)
+	return ok && !determined.Bool.Val()
+}
+
 func (i *InferredMap) checkAnnotationKey(key annotation.Key) (annotation.Val, bool) {
 	shallowKey := i.primitive.site(key, false)
 	deepKey := i.primitive.site(key, true)
 
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.checkSites(shallowKey, deepKey)
+}
+
+// KeyResult is the result of checking a single annotation key in CheckAll, where Ok is false if
+// the nilability of the key has not been determined (see checkAnnotationKey).
+type KeyResult struct {
+	Val annotation.Val
+	Ok  bool
+}
+
+// CheckAll checks this InferredMap for the concrete mappings of all the keys provided, and returns
+// the results aligned with the keys by index. This is equivalent to checking each key separately,
+// but the map is only locked once, which is useful for bulk consumers (e.g., report generators)
+// querying the nilability of many objects.
+func (i *InferredMap) CheckAll(keys []annotation.Key) []KeyResult {
+	// The sites are computed before locking the map, since that involves encoding the object paths.
+	sites := make([][2]primitiveSite, len(keys))
+	for n, key := range keys {
+		sites[n] = [2]primitiveSite{i.primitive.site(key, false), i.primitive.site(key, true)}
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	results := make([]KeyResult, len(keys))
+	for n := range sites {
+		results[n].Val, results[n].Ok = i.checkSites(sites[n][0], sites[n][1])
+	}
+	return results
+}
+
+// checkSites returns the nilability of the shallow and deep sites of a key, or false if either of
+// them has not been determined. The caller must hold the read lock.
+func (i *InferredMap) checkSites(shallowKey, deepKey primitiveSite) (annotation.Val, bool) {
 	shallowVal, shallowOk := i.mapping.Load(shallowKey)
 	deepVal, deepOk := i.mapping.Load(deepKey)
 	if !shallowOk || !deepOk {
@@ -2,13 +2,25 @@ package inference
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
 	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"go.uber.org/nilaway/annotation"
+	"golang.org/x/exp/slices"
+	"golang.org/x/tools/go/analysis"
 )
 
 // BenchmarkGobEncoding benchmarks the gob encoding of an inferred map to test the overhead.
@@ -90,6 +102,832 @@ func TestDecoding(t *testing.T) {
 	require.Equal(t, value, v.(*DeterminedVal).Bool)
 }
 
+func TestDecoding_Versions(t *testing.T) {
+	t.Parallel()
+
+	m := newInferredMap(nil /* primitive */)
+	site := primitiveSite{Repr: "Result 0 of Function Foo"}
+	m.StoreDetermined(site, TrueBecauseAnnotation{})
+	encoded, err := m.GobEncode()
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(encoded, _factMagic[:]))
+
+	header := len(_factMagic) + 4
+	withVersion := func(version uint32) []byte {
+		b := slices.Clone(encoded)
+		binary.BigEndian.PutUint32(b[len(_factMagic):header], version)
+		return b
+	}
+
+	// The current version and the legacy encoding without the header are both decoded.
+	for _, input := range [][]byte{encoded, encoded[header:]} {
+		decoded := new(InferredMap)
+		require.NoError(t, decoded.GobDecode(input))
+		v, ok := decoded.Load(site)
+		require.True(t, ok)
+		require.Equal(t, TrueBecauseAnnotation{}, v.(*DeterminedVal).Bool)
+	}
+
+	// Other versions are rejected with a descriptive error.
+	err = new(InferredMap).GobDecode(withVersion(_factVersion + 1))
+	require.EqualError(t, err, fmt.Sprintf("fact version %d not supported by this NilAway (supports %d)", _factVersion+1, _factVersion))
+	require.ErrorContains(t, new(InferredMap).GobDecode(encoded[:header-1]), "truncated fact header")
+}
+
+func TestSiteLocation(t *testing.T) {
+	t.Parallel()
+
+	upstream := primitiveSite{
+		Position: token.Position{Filename: "upstream/foo.go", Line: 3, Column: 6},
+		PkgPath:  "go.uber.org/upstream",
+		Repr:     "Result 0 of Function Foo",
+	}
+	// Sites for synthesized objects have no source positions.
+	synthesized := primitiveSite{PkgPath: "go.uber.org/upstream", Repr: "Result 0 of Function error"}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreDetermined(upstream, TrueBecauseAnnotation{})
+	m.StoreDetermined(synthesized, FalseBecauseAnnotation{})
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(m))
+	var decodedMap InferredMap
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decodedMap))
+
+	var locations []string
+	decodedMap.OrderedRange(func(site primitiveSite, _ InferredVal) bool {
+		locations = append(locations, site.Location())
+		return true
+	})
+	require.Equal(t, []string{"upstream/foo.go:3:6", "go.uber.org/upstream"}, locations)
+	require.Empty(t, (&primitiveSite{Repr: "unknown"}).Location())
+}
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+
+	m := newInferredMap(nil /* primitive */)
+	nilable := primitiveSite{Repr: "Result 0 of Function foo"}
+	nonnil := primitiveSite{Repr: "Param 0 of Function bar"}
+	from := primitiveSite{Repr: "Field f"}
+	to := primitiveSite{Repr: "Global Variable \"g\""}
+	m.StoreDetermined(nilable, TrueBecauseAnnotation{})
+	m.StoreDetermined(nonnil, FalseBecauseAnnotation{})
+	m.StoreImplication(from, to, primitiveFullTrigger{
+		Position:     token.Position{Filename: "/tmp/foo.go", Line: 10},
+		ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteDOT(&buf))
+	require.Equal(t, `digraph InferredMap {
+	node [shape=box];
+	n0 [label="Result 0 of Function foo", style=filled, fillcolor=lightcoral];
+	n1 [label="Param 0 of Function bar", style=filled, fillcolor=palegreen];
+	n2 [label="Field f"];
+	n3 [label="Global Variable \"g\""];
+	n2 -> n3 [label="assigned into global variable `+"`g`"+` @ foo.go:10"];
+}
+`, buf.String())
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreDetermined(primitiveSite{Repr: "Result 0 of Function foo"}, TrueBecauseAnnotation{})
+	from := primitiveSite{Repr: "Field f"}
+	to := primitiveSite{Repr: "Global Variable \"g\""}
+	m.StoreImplication(from, to, primitiveFullTrigger{
+		Position:     token.Position{Filename: "/tmp/foo.go", Line: 10},
+		ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"},
+	})
+	require.Equal(t, `Result 0 of Function foo: nilable
+Field f: undetermined implicants=[] implicates=[Global Variable "g" {"assigned into global variable `+"`g`"+` @ foo.go:10"}]
+Global Variable "g": undetermined implicants=[Field f {"assigned into global variable `+"`g`"+` @ foo.go:10"}] implicates=[]
+`, m.String())
+
+	// The edges of each site are capped.
+	m = newInferredMap(nil /* primitive */)
+	for i := 0; i < _maxEdgesInString+3; i++ {
+		m.StoreImplication(from, primitiveSite{Repr: "Param " + strconv.Itoa(i) + " of Function bar"}, primitiveFullTrigger{})
+	}
+	first, _, _ := strings.Cut(m.String(), "\n")
+	require.True(t, strings.HasSuffix(first, ", ... (3 more)]"), first)
+	require.Equal(t, _maxEdgesInString, strings.Count(first, "Param "))
+}
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+
+	site := func(repr string, line int, exported bool) primitiveSite {
+		return primitiveSite{Repr: repr, Position: token.Position{Filename: "foo.go", Line: line}, Exported: exported}
+	}
+	ret := site("Result 0 of Function Foo", 1, true)
+	field := site("Field F", 2, true)
+	global := site("Global Variable \"G\"", 3, true)
+	local := site("Param 0 of Function bar", 4, false)
+	unexported := site("Result 0 of Function baz", 5, false)
+
+	// The output is independent of the insertion order.
+	var outputs []string
+	for _, reversed := range []bool{false, true} {
+		m := newInferredMap(nil /* primitive */)
+		store := []func(){
+			func() { m.StoreDetermined(ret, TrueBecauseAnnotation{}) },
+			func() { m.StoreDetermined(unexported, FalseBecauseAnnotation{}) },
+			func() { m.StoreImplication(field, global, primitiveFullTrigger{}) },
+			// The local site is not exported since it does not reach any exported site.
+			func() { m.StoreImplication(field, local, primitiveFullTrigger{}) },
+		}
+		if reversed {
+			slices.Reverse(store)
+		}
+		for _, f := range store {
+			f()
+		}
+		var b strings.Builder
+		m.WriteText(&b)
+		outputs = append(outputs, b.String())
+	}
+	require.Equal(t, `Result 0 of Function Foo: determined nilable
+Field F: undetermined implicates=[Global Variable "G"]
+Global Variable "G": undetermined implicates=[]
+`, outputs[0])
+	require.Equal(t, outputs[0], outputs[1])
+}
+
+func TestWriteMatchingText(t *testing.T) {
+	t.Parallel()
+
+	site := func(repr string, line int) primitiveSite {
+		return primitiveSite{Repr: repr, Position: token.Position{Filename: "foo.go", Line: line}, Exported: true}
+	}
+	m := newInferredMap(nil /* primitive */)
+	m.StoreDetermined(site("Result 0 of Function Foo", 1), TrueBecauseAnnotation{})
+	m.StoreImplication(site("Field F", 2), site("Global Variable \"G\"", 3), primitiveFullTrigger{})
+
+	// The implicates of the matching sites are listed even if they do not match.
+	var b strings.Builder
+	m.WriteMatchingText(&b, regexp.MustCompile(`^Field `))
+	require.Equal(t, "Field F: undetermined implicates=[Global Variable \"G\"]\n", b.String())
+
+	// A regular expression without matching sites writes nothing.
+	b.Reset()
+	m.WriteMatchingText(&b, regexp.MustCompile(`Param`))
+	require.Empty(t, b.String())
+}
+
+func TestDeterminedSites(t *testing.T) {
+	t.Parallel()
+
+	site := func(repr string, line int, exported bool) primitiveSite {
+		return primitiveSite{Repr: repr, Position: token.Position{Filename: "foo.go", Line: line}, Exported: exported}
+	}
+	ret := site("Result 0 of Function Foo", 1, true)
+	field := site("Field F", 2, true)
+	param := site("Param 0 of Function bar", 3, false)
+	global := site("Global Variable \"G\"", 4, true)
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreDetermined(param, FalseBecauseAnnotation{})
+	m.StoreImplication(field, global, primitiveFullTrigger{})
+	m.StoreDetermined(ret, TrueBecauseAnnotation{})
+
+	require.Equal(t, []primitiveSite{ret, param}, m.DeterminedSites(false /* exportedOnly */))
+	require.Equal(t, []primitiveSite{ret}, m.DeterminedSites(true /* exportedOnly */))
+	require.Equal(t, 4, m.Len())
+
+	// Modifying the returned sites does not affect the map.
+	sites := m.DeterminedSites(false /* exportedOnly */)
+	sites[0].Repr = "modified"
+	require.Equal(t, []primitiveSite{ret, param}, m.DeterminedSites(false /* exportedOnly */))
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	m := newBigInferredMap()
+	// Add a site with nested explanations and prestrings to exercise the type-tagged encoding.
+	m.StoreDetermined(primitiveSite{Repr: "nested"}, TrueBecauseDeepConstraint{
+		InternalAssertion: primitiveFullTrigger{
+			ProducerRepr: annotation.GuardMissingPrestring{OldPrestring: annotation.FldReadPrestring{FieldName: "f"}},
+			ConsumerRepr: annotation.LocatedPrestring{
+				Contained: annotation.PtrLoadPrestring{},
+				Location:  token.Position{Filename: "foo.go", Line: 3},
+			},
+		},
+		DeeperExplanation: TrueBecauseAnnotation{AnnotationPos: token.Position{Filename: "bar.go", Line: 4}},
+	})
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+
+	newMap := newInferredMap(nil /* primitive */)
+	require.NoError(t, json.Unmarshal(b, newMap))
+	require.Equal(t, m.mapping, newMap.mapping)
+
+	// Encoding is stable.
+	b2, err := json.Marshal(newMap)
+	require.NoError(t, err)
+	require.Equal(t, b, b2)
+}
+
+func TestJSON_UnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	m := newInferredMap(nil /* primitive */)
+	err := json.Unmarshal([]byte(`{"version": 42, "sites": []}`), m)
+	require.ErrorContains(t, err, "unsupported InferredMap JSON version 42")
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	a, b, c, d := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"}, primitiveSite{Repr: "d"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m1 := newInferredMap(nil /* primitive */)
+	m1.StoreImplication(a, b, assertion)
+	m1.StoreDetermined(d, TrueBecauseAnnotation{})
+	m2 := newInferredMap(nil /* primitive */)
+	m2.StoreImplication(a, c, assertion)
+	m2.StoreDetermined(d, TrueBecauseAnnotation{})
+
+	require.NoError(t, m1.Merge(m2))
+	require.Equal(t, 4, m1.Len())
+	v, ok := m1.Load(a)
+	require.True(t, ok)
+	implicates := v.(*UndeterminedVal).Implicates
+	require.Len(t, implicates.Pairs, 2)
+	require.Equal(t, b, implicates.Pairs[0].Key)
+	require.Equal(t, c, implicates.Pairs[1].Key)
+
+	// Determined values take precedence over undetermined ones.
+	m3 := newInferredMap(nil /* primitive */)
+	m3.StoreDetermined(b, FalseBecauseAnnotation{})
+	require.NoError(t, m1.Merge(m3))
+	v, ok = m1.Load(b)
+	require.True(t, ok)
+	require.IsType(t, &DeterminedVal{}, v)
+
+	// Conflicting determined values are reported.
+	m4 := newInferredMap(nil /* primitive */)
+	m4.StoreDetermined(d, FalseBecauseAnnotation{})
+	require.ErrorContains(t, m1.Merge(m4), "1 conflicting site(s)")
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(a, b, assertion)
+	m.recordUpstream()
+	clone := m.Clone()
+	require.Equal(t, m.mapping, clone.mapping)
+	require.Equal(t, m.upstreamMapping, clone.upstreamMapping)
+
+	// Modifying the clone has no visible effect on the original map.
+	clone.StoreImplication(a, c, assertion)
+	clone.StoreDetermined(b, TrueBecauseAnnotation{})
+	require.Equal(t, 2, m.Len())
+	v, ok := m.Load(a)
+	require.True(t, ok)
+	require.Len(t, v.(*UndeterminedVal).Implicates.Pairs, 1)
+	v, ok = m.Load(b)
+	require.True(t, ok)
+	require.IsType(t, &UndeterminedVal{}, v)
+	require.Len(t, m.upstreamMapping[a].(*UndeterminedVal).Implicates.Pairs, 1)
+}
+
+func TestResetToUpstream(t *testing.T) {
+	t.Parallel()
+
+	a, b, c, d := primitiveSite{Repr: "a", Exported: true}, primitiveSite{Repr: "b", Exported: true},
+		primitiveSite{Repr: "c", Exported: true}, primitiveSite{Repr: "d", Exported: true}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(a, b, assertion)
+	m.StoreDetermined(c, TrueBecauseAnnotation{})
+	m.recordUpstream()
+	upstream := m.Clone().mapping
+
+	// Speculative observations on both the upstream and the new sites are discarded.
+	m.StoreImplication(a, d, assertion)
+	m.StoreDetermined(b, FalseBecauseAnnotation{})
+	m.ResetToUpstream()
+	require.Equal(t, upstream, m.mapping)
+
+	// Nothing is exported after the reset, and the reset map is detached from upstreamMapping.
+	exported := 0
+	pass := &analysis.Pass{ExportPackageFact: func(analysis.Fact) { exported++ }}
+	m.Export(pass, nil /* inScope */)
+	require.Zero(t, exported)
+	m.StoreImplication(b, d, assertion)
+	require.Len(t, m.upstreamMapping[b].(*UndeterminedVal).Implicates.Pairs, 0)
+	m.Export(pass, nil /* inScope */)
+	require.Equal(t, 1, exported)
+}
+
+func TestExport_InScopeOnly(t *testing.T) {
+	t.Parallel()
+
+	local := primitiveSite{PkgPath: "local", Repr: "local", Exported: true}
+	// external sites: determined, implied by the local site, and a leaf implication chain.
+	determined := primitiveSite{PkgPath: "ext", Repr: "determined", Exported: true}
+	implied := primitiveSite{PkgPath: "ext", Repr: "implied", Exported: true}
+	leaf := primitiveSite{PkgPath: "ext", Repr: "leaf", Exported: true}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(local, implied, assertion)
+	m.StoreImplication(leaf, leaf, assertion)
+	m.StoreDetermined(determined, TrueBecauseAnnotation{})
+
+	exportedSites := func(inScope func(string) bool) []string {
+		var sites []string
+		pass := &analysis.Pass{
+			Pkg: types.NewPackage("local", "local"),
+			ExportPackageFact: func(fact analysis.Fact) {
+				for _, p := range fact.(*InferredMap).mapping.Pairs {
+					sites = append(sites, p.Key.Repr)
+				}
+			},
+		}
+		m.Export(pass, inScope)
+		return sites
+	}
+
+	require.Equal(t, []string{"local", "implied", "leaf", "determined"}, exportedSites(nil))
+	// Only the "local" package is in scope, and the current package is always kept.
+	require.Equal(t, []string{"local", "implied"}, exportedSites(func(string) bool { return false }))
+	require.Equal(t, []string{"local", "implied", "leaf", "determined"}, exportedSites(func(p string) bool { return p == "ext" }))
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	e1, e2, e3 := primitiveSite{Repr: "e1", Exported: true}, primitiveSite{Repr: "e2", Exported: true},
+		primitiveSite{Repr: "e3", Exported: true}
+	a, b, c, d, x, y := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"},
+		primitiveSite{Repr: "d"}, primitiveSite{Repr: "x"}, primitiveSite{Repr: "y"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	// b is both reachable from and reaching exported sites, so it is kept, while a only reaches an
+	// exported site and c is only reachable from one.
+	m.StoreImplication(e1, b, assertion)
+	m.StoreImplication(b, e2, assertion)
+	m.StoreImplication(a, e1, assertion)
+	m.StoreImplication(e2, c, assertion)
+	// x and y are not connected to any exported site.
+	m.StoreImplication(x, y, assertion)
+	m.StoreDetermined(d, TrueBecauseAnnotation{})
+	m.StoreDetermined(e3, FalseBecauseAnnotation{})
+	m.recordUpstream()
+
+	m.Prune()
+	var sites []primitiveSite
+	m.OrderedRange(func(site primitiveSite, _ InferredVal) bool {
+		sites = append(sites, site)
+		return true
+	})
+	require.Equal(t, []primitiveSite{e1, b, e2, e3}, sites)
+	require.Len(t, m.upstreamMapping, 4)
+
+	// The edges of the kept sites are left intact.
+	v, ok := m.Load(e2)
+	require.True(t, ok)
+	require.Len(t, v.(*UndeterminedVal).Implicates.Pairs, 1)
+	require.Len(t, v.(*UndeterminedVal).Implicants.Pairs, 1)
+}
+
+func TestUndeterminedExportedSites(t *testing.T) {
+	t.Parallel()
+
+	param := primitiveSite{PkgPath: "foo", Repr: "Param 0: 'x' of Function Foo", Exported: true}
+	ret := primitiveSite{PkgPath: "foo", Repr: "Result 0 of Function Foo", Exported: true, IsDeep: true}
+	field := primitiveSite{PkgPath: "foo", Repr: "Field F", Exported: true}
+	recv := primitiveSite{PkgPath: "foo", Repr: "Receiver of Method M", Exported: true}
+	unexported := primitiveSite{PkgPath: "foo", Repr: "Result 0 of Function bar"}
+	determined := primitiveSite{PkgPath: "foo", Repr: "Global Variable G", Exported: true}
+	upstream := primitiveSite{PkgPath: "bar", Repr: "Result 0 of Function Bar", Exported: true}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(param, ret, assertion)
+	m.StoreImplication(field, unexported, assertion)
+	m.StoreImplication(recv, upstream, assertion)
+	m.StoreDetermined(determined, TrueBecauseAnnotation{})
+
+	var kinds []SiteKind
+	for _, s := range m.UndeterminedExportedSites("foo") {
+		kinds = append(kinds, s.Kind)
+	}
+	require.Equal(t, []SiteKind{SiteKindParam, SiteKindReturn, SiteKindField, SiteKindRecv}, kinds)
+	require.True(t, m.UndeterminedExportedSites("foo")[1].IsDeep)
+	require.Empty(t, m.UndeterminedExportedSites("baz"))
+}
+
+func TestNilabilityOf(t *testing.T) {
+	t.Parallel()
+
+	src := `package foo
+
+var G *int
+
+type T struct{ F *int }
+
+type A = T
+
+func (t *T) Method(x *int) (r *int) { return nil }
+
+func Func() *int { return nil }
+
+func local() { var l *int; _ = l }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	require.NoError(t, err)
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	pkg, err := (&types.Config{}).Check("foo", fset, []*ast.File{file}, info)
+	require.NoError(t, err)
+	pass := &analysis.Pass{Fset: fset, Pkg: pkg, AllPackageFacts: func() []analysis.PackageFact { return nil }}
+	m := newInferredMap(newPrimitivizer(pass))
+
+	typeName := pkg.Scope().Lookup("T").(*types.TypeName)
+	field := typeName.Type().Underlying().(*types.Struct).Field(0)
+	method := typeName.Type().(*types.Named).Method(0)
+	sig := method.Type().(*types.Signature)
+	fn := pkg.Scope().Lookup("Func").(*types.Func)
+	global := pkg.Scope().Lookup("G").(*types.Var)
+	alias := pkg.Scope().Lookup("A").(*types.TypeName)
+
+	// Only the shallow sites are nilable, and the deep sites are nonnil.
+	for _, key := range []annotation.Key{
+		annotation.GlobalVarAnnotationKey{VarDecl: global},
+		annotation.FieldAnnotationKey{FieldDecl: field},
+		annotation.RecvAnnotationKey{FuncDecl: method},
+		annotation.ParamKeyFromArgNum(method, 0),
+		annotation.RetKeyFromRetNum(method, 0),
+		annotation.RetKeyFromRetNum(fn, 0),
+		annotation.TypeNameAnnotationKey{TypeDecl: typeName},
+	} {
+		m.StoreDetermined(m.primitive.site(key, false), TrueBecauseAnnotation{})
+		m.StoreDetermined(m.primitive.site(key, true), FalseBecauseAnnotation{})
+	}
+
+	expected := annotation.Val{IsNilable: true, IsNilableSet: true, IsDeepNilableSet: true}
+	for _, obj := range []types.Object{global, field, sig.Recv(), sig.Params().At(0), sig.Results().At(0), fn, method, typeName, alias} {
+		val, ok := m.NilabilityOf(obj)
+		require.True(t, ok, obj.String())
+		require.Equal(t, expected, val, obj.String())
+	}
+
+	// Local variables are not supported, and undetermined sites are not reported.
+	for ident, obj := range info.Defs {
+		if ident.Name == "l" || ident.Name == "local" {
+			_, ok := m.NilabilityOf(obj)
+			require.False(t, ok, obj.String())
+		}
+	}
+}
+
+func TestInferredMapForTesting(t *testing.T) {
+	t.Parallel()
+
+	src := `package foo
+
+var G *int
+
+func Func(x *int) *int { return x }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("foo", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+	pass := &analysis.Pass{Fset: fset, Pkg: pkg, AllPackageFacts: func() []analysis.PackageFact { return nil }}
+
+	global := annotation.GlobalVarAnnotationKey{VarDecl: pkg.Scope().Lookup("G").(*types.Var)}
+	fn := pkg.Scope().Lookup("Func").(*types.Func)
+	var annMap annotation.Map = NewInferredMapForTesting(pass).
+		WithDetermined(SiteForTesting{Key: global}, true).
+		WithDetermined(SiteForTesting{Key: global, IsDeep: true}, false).
+		WithImplication(SiteForTesting{Key: annotation.ParamKeyFromArgNum(fn, 0)}, SiteForTesting{Key: annotation.RetKeyFromRetNum(fn, 0)}, file.Pos())
+
+	val, ok := annMap.CheckGlobalVarAnn(global.VarDecl)
+	require.True(t, ok)
+	require.Equal(t, annotation.Val{IsNilable: true, IsNilableSet: true, IsDeepNilableSet: true}, val)
+	// The sites connected by the implication remain undetermined.
+	_, ok = annMap.CheckFuncParamAnn(fn, 0)
+	require.False(t, ok)
+	require.Equal(t, MapStats{Nilable: 1, Nonnil: 1, Undetermined: 2, Edges: 1, Exported: 4}, annMap.(*InferredMap).Stats())
+}
+
+func TestCheckAll(t *testing.T) {
+	t.Parallel()
+
+	src := `package foo
+
+var G *int
+
+func Func(x *int) *int { return x }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("foo", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+	pass := &analysis.Pass{Fset: fset, Pkg: pkg, AllPackageFacts: func() []analysis.PackageFact { return nil }}
+
+	global := annotation.GlobalVarAnnotationKey{VarDecl: pkg.Scope().Lookup("G").(*types.Var)}
+	fn := pkg.Scope().Lookup("Func").(*types.Func)
+	param, ret := annotation.ParamKeyFromArgNum(fn, 0), annotation.RetKeyFromRetNum(fn, 0)
+	m := NewInferredMapForTesting(pass).
+		WithDetermined(SiteForTesting{Key: global}, true).
+		WithDetermined(SiteForTesting{Key: global, IsDeep: true}, false).
+		WithDetermined(SiteForTesting{Key: ret}, false).
+		WithDetermined(SiteForTesting{Key: ret, IsDeep: true}, false)
+
+	keys := []annotation.Key{ret, param, global, ret}
+	results := m.CheckAll(keys)
+	require.Len(t, results, len(keys))
+	// The results are aligned with the keys, and identical to checking the keys separately.
+	for n, key := range keys {
+		val, ok := m.checkAnnotationKey(key)
+		require.Equal(t, KeyResult{Val: val, Ok: ok}, results[n])
+	}
+	require.Equal(t, KeyResult{Val: annotation.Val{IsNilable: true, IsNilableSet: true, IsDeepNilableSet: true}, Ok: true}, results[2])
+	require.False(t, results[1].Ok)
+	require.Empty(t, m.CheckAll(nil))
+}
+
+func TestExplainPath(t *testing.T) {
+	t.Parallel()
+
+	a, b, c, d, e := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"},
+		primitiveSite{Repr: "d"}, primitiveSite{Repr: "e"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+	other := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "h"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(a, d, assertion)
+	m.StoreImplication(d, e, assertion)
+	m.StoreImplication(e, c, assertion)
+	m.StoreImplication(a, b, other)
+	m.StoreImplication(b, c, assertion)
+
+	path, ok := m.ExplainPath(a, c)
+	require.True(t, ok)
+	require.Equal(t, []primitiveSite{a, b, c}, path)
+	assertionOnHop, ok := m.ImplicationAssertion(path[0], path[1])
+	require.True(t, ok)
+	require.Equal(t, other, assertionOnHop)
+
+	path, ok = m.ExplainPath(a, a)
+	require.True(t, ok)
+	require.Equal(t, []primitiveSite{a}, path)
+
+	// The edges are directed.
+	_, ok = m.ExplainPath(c, a)
+	require.False(t, ok)
+	_, ok = m.ImplicationAssertion(c, a)
+	require.False(t, ok)
+}
+
+func TestCycles(t *testing.T) {
+	t.Parallel()
+
+	a, b, c, d, e, f := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"},
+		primitiveSite{Repr: "d"}, primitiveSite{Repr: "e"}, primitiveSite{Repr: "f"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	m := newInferredMap(nil /* primitive */)
+	m.StoreImplication(a, b, assertion)
+	m.StoreImplication(b, c, assertion)
+	m.StoreImplication(c, a, assertion)
+	m.StoreImplication(c, d, assertion)
+	m.StoreImplication(e, d, assertion)
+	m.StoreImplication(d, e, assertion)
+	m.StoreImplication(e, f, assertion)
+	require.Equal(t, [][]primitiveSite{{a, b, c}, {d, e}}, m.Cycles())
+
+	// A large cycle is detected as a whole.
+	m = newInferredMap(nil /* primitive */)
+	for i := 0; i < 20_000; i++ {
+		m.StoreImplication(primitiveSite{Repr: strconv.Itoa(i)}, primitiveSite{Repr: strconv.Itoa((i + 1) % 20_000)}, assertion)
+	}
+	cycles := m.Cycles()
+	require.Len(t, cycles, 1)
+	require.Len(t, cycles[0], 20_000)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	a, b, c, d := primitiveSite{Repr: "a"}, primitiveSite{Repr: "b"}, primitiveSite{Repr: "c"}, primitiveSite{Repr: "d"}
+	assertion := primitiveFullTrigger{ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "g"}}
+
+	oldMap := newInferredMap(nil /* primitive */)
+	oldMap.StoreDetermined(a, FalseBecauseAnnotation{})
+	oldMap.StoreDetermined(b, TrueBecauseAnnotation{})
+	oldMap.StoreDetermined(c, TrueBecauseAnnotation{})
+	newMap := newInferredMap(nil /* primitive */)
+	newMap.StoreDetermined(a, TrueBecauseAnnotation{})
+	newMap.StoreDetermined(b, TrueBecauseAnnotation{})
+	newMap.StoreImplication(d, c, assertion)
+
+	diffs := Diff(oldMap, newMap)
+	require.Len(t, diffs, 3)
+	require.Equal(t, DiffChanged, diffs[a].Kind)
+	require.True(t, diffs[a].FlippedToNilable())
+	require.Equal(t, DiffChanged, diffs[c].Kind)
+	require.False(t, diffs[c].FlippedToNilable())
+	require.Equal(t, DiffAdded, diffs[d].Kind)
+	require.Nil(t, diffs[d].Old)
+
+	diffs = Diff(newMap, oldMap)
+	require.Equal(t, DiffRemoved, diffs[d].Kind)
+	require.Nil(t, diffs[d].New)
+}
+
+func TestSortedRange(t *testing.T) {
+	t.Parallel()
+
+	pos := token.Position{Filename: "foo.go", Line: 1}
+	sites := []primitiveSite{
+		{Position: pos, Repr: "a"},
+		{Position: pos, Repr: "a", Exported: true},
+		{Position: pos, Repr: "b"},
+		{Position: token.Position{Filename: "foo.go", Line: 2}, Repr: "a"},
+	}
+	m := newInferredMap(nil /* primitive */)
+	for i := len(sites) - 1; i >= 0; i-- {
+		m.StoreDetermined(sites[i], TrueBecauseAnnotation{})
+	}
+
+	var visited []primitiveSite
+	m.SortedRange(func(site primitiveSite, _ InferredVal) bool {
+		visited = append(visited, site)
+		return true
+	})
+	require.Equal(t, sites, visited)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	m := newInferredMap(nil /* primitive */)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.StoreDetermined(primitiveSite{Position: token.Position{Line: i}}, TrueBecauseAnnotation{})
+			m.StoreImplication(
+				primitiveSite{Position: token.Position{Line: i + 100}},
+				primitiveSite{Position: token.Position{Line: i + 200}},
+				primitiveFullTrigger{},
+			)
+		}()
+		go func() {
+			defer wg.Done()
+			m.Load(primitiveSite{Position: token.Position{Line: i}})
+			m.OrderedRange(func(primitiveSite, InferredVal) bool { return true })
+			_ = m.Len()
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 30, m.Len())
+
+	// Merging and diffing two maps in both directions concurrently (along with the other writers)
+	// must not deadlock.
+	a, b := newInferredMap(nil /* primitive */), newInferredMap(nil /* primitive */)
+	a.StoreImplication(primitiveSite{Repr: "a1"}, primitiveSite{Repr: "a2"}, primitiveFullTrigger{})
+	b.StoreImplication(primitiveSite{Repr: "b1"}, primitiveSite{Repr: "b2"}, primitiveFullTrigger{})
+	for i := 0; i < 100; i++ {
+		i := i
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, a.Merge(b))
+		}()
+		go func() {
+			defer wg.Done()
+			Diff(a, b)
+			Diff(b, a)
+		}()
+		go func() {
+			defer wg.Done()
+			require.NoError(t, b.Merge(a))
+		}()
+		go func() {
+			defer wg.Done()
+			a.StoreImplication(primitiveSite{Position: token.Position{Line: i}}, primitiveSite{Repr: "a1"}, primitiveFullTrigger{})
+			b.StoreImplication(primitiveSite{Position: token.Position{Line: i}}, primitiveSite{Repr: "b1"}, primitiveFullTrigger{})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, a.Merge(b))
+	require.NoError(t, b.Merge(a))
+	require.Equal(t, 104, a.Len())
+	require.Equal(t, a.Len(), b.Len())
+
+	// Checking the annotations concurrently must also be safe, since computing the sites of the
+	// keys encodes the object paths with a shared encoder.
+	src := `package foo
+
+type T struct{ F *int }
+
+func (t *T) Method(x *int) *int { return x }
+
+func Func(x *int) *int { return x }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("foo", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+	pass := &analysis.Pass{Fset: fset, Pkg: pkg, AllPackageFacts: func() []analysis.PackageFact { return nil }}
+	fn := pkg.Scope().Lookup("Func").(*types.Func)
+	method, _, _ := types.LookupFieldOrMethod(pkg.Scope().Lookup("T").Type(), true, pkg, "Method")
+	// The field comes first since its object path is searched in the (memoized) package scope.
+	keys := []annotation.Key{
+		annotation.FieldAnnotationKey{FieldDecl: pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct).Field(0)},
+		annotation.ParamKeyFromArgNum(fn, 0),
+		annotation.RetKeyFromRetNum(fn, 0),
+		annotation.ParamKeyFromArgNum(method.(*types.Func), 0),
+		annotation.RetKeyFromRetNum(method.(*types.Func), 0),
+	}
+	// The map is empty such that the goroutines are the first to use the encoder.
+	c := NewInferredMapForTesting(pass)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for _, key := range keys {
+				c.checkAnnotationKey(key)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.CheckAll(keys)
+		}()
+	}
+	wg.Wait()
+	for _, result := range c.CheckAll(keys) {
+		require.False(t, result.Ok)
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	t.Parallel()
+
+	direct, deep, undetermined, other := primitiveSite{Repr: "direct"}, primitiveSite{Repr: "deep"}, primitiveSite{Repr: "undetermined"}, primitiveSite{Repr: "other"}
+	m := newInferredMap(nil /* primitive */)
+	m.StoreDetermined(direct, TrueBecauseShallowConstraint{})
+	m.StoreDetermined(deep, TrueBecauseDeepConstraint{
+		DeeperExplanation: TrueBecauseDeepConstraint{DeeperExplanation: TrueBecauseAnnotation{}},
+	})
+	m.StoreImplication(undetermined, other, primitiveFullTrigger{})
+
+	require.Equal(t, MaxConfidence, m.Confidence(direct))
+	require.Equal(t, MaxConfidence-2, m.Confidence(deep))
+	require.Zero(t, m.Confidence(undetermined))
+	require.Zero(t, m.Confidence(primitiveSite{Repr: "absent"}))
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	stats := newBigInferredMap().Stats()
+	require.Equal(t, MapStats{Nilable: 1000, Undetermined: 2000, Edges: 1000, Unexported: 3000}, stats)
+	require.Equal(t, "sites: 3000 (nilable: 1000, nonnil: 0, undetermined: 2000), edges: 1000, exported: 0, unexported: 3000", stats.String())
+}
+
+func TestEdges(t *testing.T) {
+	t.Parallel()
+
+	edges := newBigInferredMap().Edges()
+	require.Len(t, edges, 1000)
+	for i, e := range edges {
+		require.Equal(t, 1000+i, e.From.Position.Line)
+		require.Equal(t, 2000+i, e.To.Position.Line)
+		require.Equal(t, annotation.GlobalVarAssignPrestring{VarName: "foo"}, e.Assertion.ConsumerRepr)
+	}
+
+	// The returned edges are copies.
+	m := newBigInferredMap()
+	m.Edges()[0].From.Repr = "modified"
+	require.NotEqual(t, "modified", m.Edges()[0].From.Repr)
+}
+
 // newBigInferredMap creates an inferred map with 3000 sites, where the first 1000 are determined,
 // and the next 2000 with implications between them for stress testing.
 func newBigInferredMap() *InferredMap {
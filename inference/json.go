@@ -0,0 +1,275 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/nilaway/util/orderedmap"
+)
+
+// _jsonVersion is the version of the JSON encoding of InferredMap. It must be bumped whenever the
+// layout of the document changes in an incompatible way.
+const _jsonVersion = 1
+
+// jsonInferredMap is the top-level JSON document of an InferredMap.
+type jsonInferredMap struct {
+	Version int            `json:"version"`
+	Sites   []jsonSiteInfo `json:"sites"`
+}
+
+// jsonSiteInfo is the JSON representation of a single site in the InferredMap and its inferred
+// value. Exactly one of Determined and (Implicants, Implicates) is populated, depending on whether
+// the value is a DeterminedVal or an UndeterminedVal.
+type jsonSiteInfo struct {
+	Site       primitiveSite   `json:"site"`
+	Determined json.RawMessage `json:"determined,omitempty"`
+	Implicants []jsonEdge      `json:"implicants,omitempty"`
+	Implicates []jsonEdge      `json:"implicates,omitempty"`
+}
+
+// jsonEdge is the JSON representation of an implication edge to another site, along with the
+// assertion that created it.
+type jsonEdge struct {
+	Site      primitiveSite   `json:"site"`
+	Assertion json.RawMessage `json:"assertion"`
+}
+
+// jsonTypedValue is the JSON representation of a value stored behind an interface (e.g.,
+// ExplainedBool or annotation.Prestring), where Type records the name of the concrete type.
+type jsonTypedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// _jsonTypes maps the names used in the JSON encoding to the concrete types in _factTypes.
+var _jsonTypes = func() map[string]reflect.Type {
+	types := make(map[string]reflect.Type, len(_factTypes))
+	for _, t := range _factTypes {
+		typ := reflect.TypeOf(t)
+		types[jsonTypeName(typ)] = typ
+	}
+	return types
+}()
+
+// jsonTypeName returns the stable name of the given type used in the JSON encoding, e.g.,
+// "annotation.FldReadPrestring".
+func jsonTypeName(typ reflect.Type) string {
+	return strings.TrimPrefix(typ.String(), "*")
+}
+
+// MarshalJSON encodes the inferred map as a versioned JSON document. Unlike the gob encoding, the
+// JSON encoding is human-readable and stable across runs, which makes it suitable for inspecting
+// and diffing inference results.
+func (i *InferredMap) MarshalJSON() ([]byte, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	doc := jsonInferredMap{Version: _jsonVersion, Sites: make([]jsonSiteInfo, 0, len(i.mapping.Pairs))}
+	for _, p := range i.mapping.Pairs {
+		info := jsonSiteInfo{Site: p.Key}
+		switch v := p.Value.(type) {
+		case *DeterminedVal:
+			b, err := marshalJSONValue(reflect.ValueOf(&v.Bool).Elem())
+			if err != nil {
+				return nil, fmt.Errorf("encode site %q: %w", p.Key.String(), err)
+			}
+			info.Determined = b
+		case *UndeterminedVal:
+			var err error
+			if info.Implicants, err = marshalJSONEdges(v.Implicants); err != nil {
+				return nil, fmt.Errorf("encode implicants of site %q: %w", p.Key.String(), err)
+			}
+			if info.Implicates, err = marshalJSONEdges(v.Implicates); err != nil {
+				return nil, fmt.Errorf("encode implicates of site %q: %w", p.Key.String(), err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown inferred value type %T for site %q", p.Value, p.Key.String())
+		}
+		doc.Sites = append(doc.Sites, info)
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes the InferredMap from a JSON document produced by MarshalJSON. Documents
+// with an unsupported version are rejected with an error.
+func (i *InferredMap) UnmarshalJSON(input []byte) error {
+	var doc jsonInferredMap
+	if err := json.Unmarshal(input, &doc); err != nil {
+		return err
+	}
+	if doc.Version != _jsonVersion {
+		return fmt.Errorf("unsupported InferredMap JSON version %d (expected %d)", doc.Version, _jsonVersion)
+	}
+
+	mapping := orderedmap.New[primitiveSite, InferredVal]()
+	for _, info := range doc.Sites {
+		if info.Determined != nil {
+			var b ExplainedBool
+			if err := unmarshalJSONValue(info.Determined, reflect.ValueOf(&b).Elem()); err != nil {
+				return fmt.Errorf("decode site %q: %w", info.Site.String(), err)
+			}
+			mapping.Store(info.Site, &DeterminedVal{Bool: b})
+			continue
+		}
+
+		implicants, err := unmarshalJSONEdges(info.Implicants)
+		if err != nil {
+			return fmt.Errorf("decode implicants of site %q: %w", info.Site.String(), err)
+		}
+		implicates, err := unmarshalJSONEdges(info.Implicates)
+		if err != nil {
+			return fmt.Errorf("decode implicates of site %q: %w", info.Site.String(), err)
+		}
+		mapping.Store(info.Site, &UndeterminedVal{Implicants: implicants, Implicates: implicates})
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.mapping = mapping
+	i.upstreamMapping = make(map[primitiveSite]InferredVal)
+	return nil
+}
+
+func marshalJSONEdges(edges *orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger]) ([]jsonEdge, error) {
+	result := make([]jsonEdge, 0, len(edges.Pairs))
+	for _, p := range edges.Pairs {
+		b, err := marshalJSONValue(reflect.ValueOf(p.Value))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, jsonEdge{Site: p.Key, Assertion: b})
+	}
+	return result, nil
+}
+
+func unmarshalJSONEdges(edges []jsonEdge) (*orderedmap.OrderedMap[primitiveSite, primitiveFullTrigger], error) {
+	result := orderedmap.New[primitiveSite, primitiveFullTrigger]()
+	for _, e := range edges {
+		var trigger primitiveFullTrigger
+		if err := unmarshalJSONValue(e.Assertion, reflect.ValueOf(&trigger).Elem()); err != nil {
+			return nil, err
+		}
+		result.Store(e.Site, trigger)
+	}
+	return result, nil
+}
+
+// marshalJSONValue encodes the given value, recursively wrapping every value stored behind an
+// interface in a jsonTypedValue such that the concrete type can be recovered upon decoding.
+// Only the kinds of values that are stored in InferredMap (structs of basic types and interfaces)
+// are supported.
+func marshalJSONValue(v reflect.Value) (json.RawMessage, error) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return json.RawMessage("null"), nil
+		}
+		name := jsonTypeName(v.Elem().Type())
+		if _, ok := _jsonTypes[name]; !ok {
+			return nil, fmt.Errorf("type %q is not registered for JSON encoding", name)
+		}
+		inner, err := marshalJSONValue(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(jsonTypedValue{Type: name, Value: inner})
+	case reflect.Pointer:
+		if v.IsNil() {
+			return json.RawMessage("null"), nil
+		}
+		return marshalJSONValue(v.Elem())
+	case reflect.Struct:
+		fields := make(map[string]json.RawMessage, v.NumField())
+		for j := 0; j < v.NumField(); j++ {
+			if !v.Type().Field(j).IsExported() {
+				continue
+			}
+			b, err := marshalJSONValue(v.Field(j))
+			if err != nil {
+				return nil, err
+			}
+			fields[v.Type().Field(j).Name] = b
+		}
+		return json.Marshal(fields)
+	default:
+		return json.Marshal(v.Interface())
+	}
+}
+
+// unmarshalJSONValue is the inverse of marshalJSONValue, decoding the input into the given
+// settable value.
+func unmarshalJSONValue(input json.RawMessage, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if string(input) == "null" {
+			return nil
+		}
+		var typed jsonTypedValue
+		if err := json.Unmarshal(input, &typed); err != nil {
+			return err
+		}
+		typ, ok := _jsonTypes[typed.Type]
+		if !ok {
+			return fmt.Errorf("unknown type %q", typed.Type)
+		}
+		if !typ.Implements(v.Type()) {
+			return fmt.Errorf("type %q does not implement %s", typed.Type, v.Type())
+		}
+		concrete := reflect.New(typ).Elem()
+		if err := unmarshalJSONValue(typed.Value, concrete); err != nil {
+			return err
+		}
+		v.Set(concrete)
+		return nil
+	case reflect.Pointer:
+		if string(input) == "null" {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := unmarshalJSONValue(input, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(input, &fields); err != nil {
+			return err
+		}
+		for j := 0; j < v.NumField(); j++ {
+			field := v.Type().Field(j)
+			if !field.IsExported() {
+				continue
+			}
+			b, ok := fields[field.Name]
+			if !ok {
+				continue
+			}
+			if err := unmarshalJSONValue(b, v.Field(j)); err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+		return nil
+	default:
+		if err := json.Unmarshal(input, v.Addr().Interface()); err != nil {
+			return fmt.Errorf("decode %s: %w", v.Type(), err)
+		}
+		return nil
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"go.uber.org/nilaway/annotation"
 	"golang.org/x/tools/go/analysis"
@@ -42,6 +44,21 @@ type primitiveFullTrigger struct {
 	ConsumerRepr annotation.Prestring
 }
 
+// shortString returns a compact string representation of the full trigger, i.e., the consumer
+// representation (without its location) followed by the file name and line of the trigger, for
+// debugging purposes _only_.
+func (t *primitiveFullTrigger) shortString() string {
+	pos := fmt.Sprintf("%s:%d", filepath.Base(t.Position.Filename), t.Position.Line)
+	consumer := t.ConsumerRepr
+	if l, ok := consumer.(annotation.LocatedPrestring); ok {
+		consumer = l.Contained
+	}
+	if consumer == nil {
+		return pos
+	}
+	return consumer.String() + " @ " + pos
+}
+
 // A primitiveSite represents an atomic choice that may be made about annotations. It is
 // more specific than an annotation.Key only in factoring out information such as depth (deep
 // annotation or not that would make the choice anything other than a boolean).
@@ -93,6 +110,60 @@ func (s *primitiveSite) String() string {
 	return deepStr + s.Repr
 }
 
+// Location returns the location of the site for reporting, i.e., the file name, line and column
+// of its declaration. Since the position is recorded in the site itself, this works for sites of
+// upstream packages as well. Sites for synthesized objects (e.g., those without source positions)
+// degrade to the path of their package, and "" is returned if that is unknown as well.
+func (s *primitiveSite) Location() string {
+	if s.Position.IsValid() {
+		return s.Position.String()
+	}
+	return s.PkgPath
+}
+
+// compare returns an integer comparing two primitive sites, which is -1 if s < other, 0 if
+// s == other, and +1 if s > other. The sites are ordered by their positions first, and ties are
+// broken by the remaining fields such that the order is total.
+func (s *primitiveSite) compare(other *primitiveSite) int {
+	compareInt := func(a, b int) int {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	}
+	compareBool := func(a, b bool) int {
+		switch {
+		case !a && b:
+			return -1
+		case a && !b:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	for _, c := range [...]int{
+		strings.Compare(s.Position.Filename, other.Position.Filename),
+		compareInt(s.Position.Line, other.Position.Line),
+		compareInt(s.Position.Column, other.Position.Column),
+		compareInt(s.Position.Offset, other.Position.Offset),
+		strings.Compare(s.PkgPath, other.PkgPath),
+		strings.Compare(s.Repr, other.Repr),
+		compareBool(s.IsDeep, other.IsDeep),
+		compareBool(s.Exported, other.Exported),
+		strings.Compare(string(s.ObjectPath), string(other.ObjectPath)),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
 // primitivizer is able to convert full triggers and annotation sites to their primitive forms. It
 // is useful for getting the correct primitive sites and positions for upstream objects due to the
 // lack of complete position information in downstream analysis in incremental build systems (e.g.,
@@ -128,6 +199,9 @@ type primitivizer struct {
 	// objPathEncoder is used to encode object paths, which amortizes the cost of encoding the
 	// paths of multiple objects.
 	objPathEncoder *objectpath.Encoder
+	// encoderMu guards objPathEncoder, which caches the scopes internally and hence is not safe for
+	// concurrent use (e.g., concurrent queries on the InferredMap).
+	encoderMu sync.Mutex
 }
 
 // newPrimitivizer returns a new and properly-initialized primitivizer.
@@ -189,7 +263,7 @@ func (p *primitivizer) fullTrigger(trigger annotation.FullTrigger) primitiveFull
 
 	producer, consumer := trigger.Prestrings(p.pass)
 	return primitiveFullTrigger{
-		Position:     p.toPosition(trigger.Consumer.Expr.Pos()),
+		Position:     p.toPosition(trigger.Consumer.ReportPos()),
 		ProducerRepr: producer,
 		ConsumerRepr: consumer,
 	}
@@ -197,7 +271,15 @@ func (p *primitivizer) fullTrigger(trigger annotation.FullTrigger) primitiveFull
 
 // site returns the primitive version of the annotation site.
 func (p *primitivizer) site(key annotation.Key, isDeep bool) primitiveSite {
+	// The shallow nilability of the elements of a container is the deep nilability of the container
+	// itself, so they share the same site, see annotation.ElemAnnotationKey.
+	if elem, ok := key.(annotation.ElemAnnotationKey); ok && !isDeep {
+		return p.site(elem.Container, true /* isDeep */)
+	}
+
+	p.encoderMu.Lock()
 	objPath, err := p.objPathEncoder.For(key.Object())
+	p.encoderMu.Unlock()
 	if err != nil {
 		// An error will occur when trying to get object path for unexported objects, in which case
 		// we simply assign an empty object path.
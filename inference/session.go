@@ -0,0 +1,196 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"fmt"
+	"go/token"
+
+	"go.uber.org/nilaway/annotation"
+)
+
+// A Session is the complete input of the inference of a package in primitive forms, recorded by
+// an Engine (see Engine.Record) such that the inference can be replayed offline without the
+// source (see Replay), e.g., for reproducing bug reports. Unlike the exported facts, it contains
+// the complete (instead of the incremental) maps of the upstream packages, as well as every
+// observation made by the engine for the package itself. Sessions can be gob encoded.
+//
+// Note that the assertions of the packages in NoInfer mode are checked directly against the
+// annotations instead of by inference (see ModeOfInference), hence replaying their sessions only
+// reproduces their inferred maps but not their conflicts.
+type Session struct {
+	// PkgPath is the path of the recorded package.
+	PkgPath string
+	// Upstream is the list of the maps imported from the upstream packages, in the order they
+	// are observed.
+	Upstream []*InferredMap
+	// Events is the list of the observations made for the package, in the order they are made.
+	Events []sessionEvent
+	// MaxSites is the limit on the number of sites (see Engine.LimitSites).
+	MaxSites int
+}
+
+// eventKind is the kind of a sessionEvent.
+type eventKind uint8
+
+const (
+	// eventExplanation determines the nilability of a site (see observeSiteExplanation).
+	eventExplanation eventKind = iota
+	// eventImplication adds an implication between two sites (see observeImplication).
+	eventImplication
+	// eventConflict is an assertion that always fails regardless of the sites.
+	eventConflict
+	// eventNewPhase discards the controlled events of the previous phase, see
+	// Engine.buildPkgInferenceMap.
+	eventNewPhase
+)
+
+// A sessionEvent is a single primitive observation of the engine. Only the fields relevant to the
+// kind of the event are set.
+type sessionEvent struct {
+	Kind        eventKind
+	Site        primitiveSite
+	Explanation ExplainedBool
+	Producer    primitiveSite
+	Consumer    primitiveSite
+	Assertion   primitiveFullTrigger
+	// Controller is the site controlling the event (see annotation.FullTrigger.Controller), such
+	// that the event is only applied once the site is determined to be nilable.
+	Controller *primitiveSite
+
+	// trigger is the full trigger the event is made from, which is only available in the live
+	// analysis (i.e., not when replaying) for reporting the conflicts.
+	trigger *annotation.FullTrigger
+}
+
+// Record makes the engine record all its observations into the returned session. It must be
+// called after LimitSites and before any other observations are made.
+func (e *Engine) Record() *Session {
+	e.session = &Session{PkgPath: e.pass.Pkg.Path(), MaxSites: e.maxSites}
+	return e.session
+}
+
+// record appends the event to the recorded session, if any.
+func (e *Engine) record(ev sessionEvent) {
+	if e.session != nil {
+		e.session.Events = append(e.session.Events, ev)
+	}
+}
+
+// recordControlled records the full trigger controlled by the site, if a session is recorded.
+func (e *Engine) recordControlled(controller primitiveSite, trigger annotation.FullTrigger) {
+	if e.session == nil {
+		return
+	}
+	if ev, ok := e.triggerEvent(trigger); ok {
+		ev.Controller = &controller
+		e.record(ev)
+	}
+}
+
+// recordSiteExplanation records and observes the explanation of a site made directly (i.e., not as
+// the consequence of other observations).
+func (e *Engine) recordSiteExplanation(site primitiveSite, siteExplained ExplainedBool) {
+	e.record(sessionEvent{Kind: eventExplanation, Site: site, Explanation: siteExplained})
+	e.observeSiteExplanation(site, siteExplained)
+}
+
+// recordImplication records and observes an implication made directly (i.e., not as the
+// consequence of other observations).
+func (e *Engine) recordImplication(producerSite, consumerSite primitiveSite, assertion primitiveFullTrigger) {
+	e.record(sessionEvent{Kind: eventImplication, Producer: producerSite, Consumer: consumerSite, Assertion: assertion})
+	e.observeImplication(producerSite, consumerSite, assertion)
+}
+
+// primitiveConflictHandler is implemented by the conflict handlers that receive the conflicts of
+// the replayed sessions, where the full triggers are not available.
+type primitiveConflictHandler interface {
+	addPrimitiveConflict(assertion primitiveFullTrigger)
+}
+
+// ReplayedConflict is a conflict found when replaying a session.
+type ReplayedConflict struct {
+	// Position is the position of the dereference point of the conflict.
+	Position token.Position
+	// Message describes the conflict.
+	Message string
+}
+
+// ReplayResult is the result of replaying a session (see Replay).
+type ReplayResult struct {
+	// InferredMap is the inferred map of the package after the replay.
+	InferredMap *InferredMap
+	// Conflicts are the conflicts found during the replay, in the order they are found.
+	Conflicts []ReplayedConflict
+	// Truncated indicates whether any implication was dropped due to the limit on the number of
+	// sites (see Engine.Truncated).
+	Truncated bool
+}
+
+// AddSingleAssertionConflict is never called when replaying a session since the full triggers are
+// not recorded, see addPrimitiveConflict.
+func (r *ReplayResult) AddSingleAssertionConflict(annotation.FullTrigger) {}
+
+// AddOverconstraintConflict records a site determined to be both nilable and nonnil, which is
+// reported at the dereference point of the nonnil explanation.
+func (r *ReplayResult) AddOverconstraintConflict(nilExplanation, nonnilExplanation ExplainedBool, _ bool) {
+	deref := nonnilExplanation
+	for deref.DeeperReason() != nil {
+		deref = deref.DeeperReason()
+	}
+	r.Conflicts = append(r.Conflicts, ReplayedConflict{
+		Position: deref.Position(),
+		Message:  fmt.Sprintf("%s; but also %s", nilExplanation, nonnilExplanation),
+	})
+}
+
+func (r *ReplayResult) addPrimitiveConflict(assertion primitiveFullTrigger) {
+	r.Conflicts = append(r.Conflicts, ReplayedConflict{
+		Position: assertion.Position,
+		Message:  fmt.Sprintf("%s %s", assertion.ProducerRepr, assertion.ConsumerRepr),
+	})
+}
+
+// Replay re-runs the inference of a recorded session (see Engine.Record) without the source, and
+// returns the resulting inferred map along with the conflicts found. The observations are applied
+// in the recorded order, hence the result is the same as the one of the recorded analysis (modulo
+// the grouping and formatting of the diagnostics).
+func Replay(session *Session) *ReplayResult {
+	result := &ReplayResult{}
+	e := &Engine{
+		inferredMap:      newInferredMap(nil /* primitive */),
+		diagnosticEngine: result,
+		maxSites:         session.MaxSites,
+	}
+	for _, upstream := range session.Upstream {
+		e.observeUpstreamMap(upstream)
+	}
+	e.inferredMap.recordUpstream()
+
+	for _, ev := range session.Events {
+		switch {
+		case ev.Kind == eventNewPhase:
+			e.controlledEventsBySite = make(map[primitiveSite][]sessionEvent)
+		case ev.Controller != nil:
+			e.controlledEventsBySite[*ev.Controller] = append(e.controlledEventsBySite[*ev.Controller], ev)
+		default:
+			e.applyEvent(ev)
+		}
+	}
+
+	result.InferredMap = e.inferredMap
+	result.Truncated = e.truncated
+	return result
+}
@@ -0,0 +1,167 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
+)
+
+// ObserveStubs determines the sites overridden by the stubs (see config.Stub) as if they were
+// annotated in the stub file. The identifiers of the stubs are resolved against the current
+// package and its (transitive) imports, where the stubs of the packages that are not imported are
+// irrelevant and skipped, and an error is returned if an identifier does not resolve in an
+// imported package. Sites that are already determined (e.g., by annotations or by the analysis of
+// the upstream packages) are left intact, hence the stubs are mostly useful for the packages that
+// are not analyzed. In NoInfer mode, the unspecified shallow or deep nilability of the overridden
+// sites is determined by its default as well, since annotation lookups require both sites to be
+// determined. This must be called before ObservePackage such that the nilability is propagated
+// along the assertions of the package.
+func (e *Engine) ObserveStubs(stubs []config.Stub, mode ModeOfInference) error {
+	if len(stubs) == 0 {
+		return nil
+	}
+	pkgs := make(map[string]*types.Package)
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		if _, ok := pkgs[pkg.Path()]; ok {
+			return
+		}
+		pkgs[pkg.Path()] = pkg
+		for _, imported := range pkg.Imports() {
+			visit(imported)
+		}
+	}
+	visit(e.pass.Pkg)
+
+	for _, stub := range stubs {
+		key, typ, err := resolveStub(stub, pkgs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stub.Position, err)
+		}
+		if key == nil {
+			continue
+		}
+
+		for _, s := range [...]struct {
+			isDeep bool
+			val    *bool
+		}{{false, stub.Nilable}, {true, stub.DeepNilable}} {
+			site := e.primitive.site(key, s.isDeep)
+			if v, ok := e.inferredMap.Load(site); ok {
+				if _, ok := v.(*DeterminedVal); ok {
+					continue
+				}
+			}
+			val, explanation := s.val, stub.Position
+			if val == nil {
+				if mode != NoInfer {
+					continue
+				}
+				nilable := s.isDeep && annotation.TypeIsDeepDefaultNilable(typ)
+				val, explanation = &nilable, site.Position
+			}
+			if *val {
+				e.recordSiteExplanation(site, TrueBecauseAnnotation{AnnotationPos: explanation})
+			} else {
+				e.recordSiteExplanation(site, FalseBecauseAnnotation{AnnotationPos: explanation})
+			}
+		}
+	}
+	return nil
+}
+
+// resolveStub resolves the identifier of the stub to the annotation key of the overridden site and
+// the type of the site. It returns a nil key if the package of the identifier is not visible.
+func resolveStub(stub config.Stub, pkgs map[string]*types.Package) (annotation.Key, types.Type, error) {
+	// The package path ends at the first dot after the last slash, e.g., "github.com/acme/foo" for
+	// "github.com/acme/foo.(*T).Method".
+	slash := strings.LastIndex(stub.Name, "/")
+	dot := strings.Index(stub.Name[slash+1:], ".")
+	if dot < 0 {
+		return nil, nil, fmt.Errorf("invalid identifier %q", stub.Name)
+	}
+	pkgPath, name := stub.Name[:slash+1+dot], stub.Name[slash+1+dot+1:]
+	pkg, ok := pkgs[pkgPath]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	if stub.Site == config.StubSiteValue {
+		if typeName, field, ok := strings.Cut(name, "."); ok {
+			if obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName); ok {
+				if s, ok := obj.Type().Underlying().(*types.Struct); ok {
+					for i := 0; i < s.NumFields(); i++ {
+						if s.Field(i).Name() == field {
+							return annotation.FieldAnnotationKey{FieldDecl: s.Field(i)}, s.Field(i).Type(), nil
+						}
+					}
+				}
+			}
+			return nil, nil, fmt.Errorf("field %q not found", stub.Name)
+		}
+		if v, ok := pkg.Scope().Lookup(name).(*types.Var); ok {
+			return annotation.GlobalVarAnnotationKey{VarDecl: v}, v.Type(), nil
+		}
+		return nil, nil, fmt.Errorf("global variable %q not found", stub.Name)
+	}
+
+	fn := lookupFunc(pkg, stub.Name)
+	if fn == nil {
+		return nil, nil, fmt.Errorf("function %q not found", stub.Name)
+	}
+	sig := fn.Type().(*types.Signature)
+	switch {
+	case stub.Site == config.StubSiteRecv && sig.Recv() != nil:
+		return annotation.RecvAnnotationKey{FuncDecl: fn}, sig.Recv().Type(), nil
+	case stub.Site == config.StubSiteParam && stub.Index < sig.Params().Len():
+		return annotation.ParamKeyFromArgNum(fn, stub.Index), sig.Params().At(stub.Index).Type(), nil
+	case stub.Site == config.StubSiteResult && stub.Index < sig.Results().Len():
+		return annotation.RetKeyFromRetNum(fn, stub.Index), sig.Results().At(stub.Index).Type(), nil
+	}
+	if stub.Site == config.StubSiteRecv {
+		return nil, nil, fmt.Errorf("function %q has no receiver", stub.Name)
+	}
+	return nil, nil, fmt.Errorf("function %q has no %s %d", stub.Name, stub.Site, stub.Index)
+}
+
+// lookupFunc returns the function or method in the package with the qualified name (see
+// config.FuncName), or nil if there is no such function.
+func lookupFunc(pkg *types.Package, qualifiedName string) *types.Func {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.Func:
+			if config.FuncName(obj) == qualifiedName {
+				return obj
+			}
+		case *types.TypeName:
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				if config.FuncName(named.Method(i)) == qualifiedName {
+					return named.Method(i)
+				}
+			}
+		}
+	}
+	return nil
+}
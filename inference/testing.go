@@ -0,0 +1,65 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"go/token"
+
+	"go.uber.org/nilaway/annotation"
+	"golang.org/x/tools/go/analysis"
+)
+
+// This file contains the helpers for building InferredMap fixtures in the tests of other packages,
+// e.g., the ones exercising code against the annotation.Map interface without running the full
+// analyzer. They are meant for testing _only_ and must not be used in production code.
+
+// SiteForTesting identifies an annotation site in an InferredMap fixture, i.e., the annotation key
+// and whether the site is for its deep nilability.
+type SiteForTesting struct {
+	Key    annotation.Key
+	IsDeep bool
+}
+
+// NewInferredMapForTesting returns a new, empty InferredMap for the package of the pass, which
+// can be populated via WithDetermined and WithImplication. The pass only needs to have the Fset,
+// Pkg and AllPackageFacts fields set. For testing _only_.
+func NewInferredMapForTesting(pass *analysis.Pass) *InferredMap {
+	return newInferredMap(newPrimitivizer(pass))
+}
+
+// WithDetermined stores the site as determined to be nilable (or nonnil) as if it were annotated,
+// and returns the map for chaining. Note that the value is stored as-is, i.e., it is not
+// propagated along the implications of the site. For testing _only_.
+func (i *InferredMap) WithDetermined(site SiteForTesting, nilable bool) *InferredMap {
+	primitive := i.primitive.site(site.Key, site.IsDeep)
+	explanation := ExplainedBool(FalseBecauseAnnotation{AnnotationPos: primitive.Position})
+	if nilable {
+		explanation = TrueBecauseAnnotation{AnnotationPos: primitive.Position}
+	}
+	i.StoreDetermined(primitive, explanation)
+	return i
+}
+
+// WithImplication stores an implication from the `from` site to the `to` site (i.e., `from`
+// being nilable implies `to` being nilable), created by an assertion at the position, and returns
+// the map for chaining. For testing _only_.
+func (i *InferredMap) WithImplication(from, to SiteForTesting, pos token.Pos) *InferredMap {
+	i.StoreImplication(
+		i.primitive.site(from.Key, from.IsDeep),
+		i.primitive.site(to.Key, to.IsDeep),
+		primitiveFullTrigger{Position: i.primitive.toPosition(pos)},
+	)
+	return i
+}
@@ -0,0 +1,103 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"go/token"
+	"strings"
+)
+
+// SiteKind is the kind of the declaration that an annotation site belongs to.
+type SiteKind string
+
+const (
+	// SiteKindParam is the kind of function parameter sites.
+	SiteKindParam SiteKind = "param"
+	// SiteKindReturn is the kind of function result sites.
+	SiteKindReturn SiteKind = "return"
+	// SiteKindField is the kind of struct field sites.
+	SiteKindField SiteKind = "field"
+	// SiteKindRecv is the kind of method receiver sites.
+	SiteKindRecv SiteKind = "recv"
+	// SiteKindGlobal is the kind of global variable sites.
+	SiteKindGlobal SiteKind = "global"
+	// SiteKindType is the kind of named type sites (for deep nilability).
+	SiteKindType SiteKind = "type"
+	// SiteKindOther is the kind of any other sites.
+	SiteKindOther SiteKind = "other"
+)
+
+// _siteKindPrefixes maps the prefixes of the representations of the sites (see the String methods
+// of the annotation keys) to their kinds. Note that the fields of params and results (e.g.,
+// "Field f of Result 0 of Function foo") are considered fields.
+var _siteKindPrefixes = [...]struct {
+	prefix string
+	kind   SiteKind
+}{
+	{"Param ", SiteKindParam},
+	{"Result ", SiteKindReturn},
+	{"Field ", SiteKindField},
+	{"escaped Field ", SiteKindField},
+	{"Receiver ", SiteKindRecv},
+	{"Global Variable ", SiteKindGlobal},
+	{"Type ", SiteKindType},
+}
+
+// kind returns the kind of the site.
+func (s *primitiveSite) kind() SiteKind {
+	for _, p := range _siteKindPrefixes {
+		if strings.HasPrefix(s.Repr, p.prefix) {
+			return p.kind
+		}
+	}
+	return SiteKindOther
+}
+
+// UndeterminedSite describes an annotation site whose nilability is not determined by inference.
+type UndeterminedSite struct {
+	// Kind is the kind of the declaration that the site belongs to.
+	Kind SiteKind
+	// Repr is the string representation of the site, e.g., "Result 0 of Function foo".
+	Repr string
+	// IsDeep indicates whether the site is about the deep nilability.
+	IsDeep bool
+	// Position is the position of the declaration of the site.
+	Position token.Position
+}
+
+// UndeterminedExportedSites returns the exported (in the Go sense) sites in the package with the
+// given path that remain undetermined, i.e., the public API surface whose nilability the
+// inference could not pin down from the current package. These sites will be treated as nonnil
+// unless downstream packages determine them otherwise, hence they are good candidates for manual
+// annotations. The sites are returned in the insertion order of the map.
+func (i *InferredMap) UndeterminedExportedSites(pkgPath string) []UndeterminedSite {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var sites []UndeterminedSite
+	for _, p := range i.mapping.Pairs {
+		site := p.Key
+		if _, ok := p.Value.(*UndeterminedVal); !ok || !site.Exported || site.PkgPath != pkgPath {
+			continue
+		}
+		sites = append(sites, UndeterminedSite{
+			Kind:     site.kind(),
+			Repr:     site.Repr,
+			IsDeep:   site.IsDeep,
+			Position: site.Position,
+		})
+	}
+	return sites
+}
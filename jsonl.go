@@ -0,0 +1,127 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// jsonlRecord is a diagnostic written as a single line of the JSON Lines output, which is
+// self-contained such that the downstream tools can process the lines incrementally.
+type jsonlRecord struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Level    string `json:"level"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// jsonlWriter streams the diagnostics of all packages analyzed in the process to a single output,
+// one JSON object per line. Unlike sarifWriter, the diagnostics are appended as soon as the
+// analysis of each package finishes instead of rewriting the complete file, so the lines appear in
+// the order the packages are analyzed.
+type jsonlWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	err error
+	// seen stores the lines written so far, which de-duplicates the diagnostics for files that
+	// belong to multiple packages (e.g., "foo" and "foo.test").
+	seen map[string]bool
+}
+
+// _jsonlWriters stores the jsonlWriter for each output file.
+var _jsonlWriters sync.Map
+
+// writeJSONL appends the diagnostics of the pass to the JSON Lines output at the given path, or to
+// stdout if the path is "-". The output file is truncated when it is first written in the process.
+func writeJSONL(path string, pass *analysis.Pass, diagnostics []analysis.Diagnostic, conf *config.Config) error {
+	v, loaded := _jsonlWriters.LoadOrStore(path, &jsonlWriter{seen: make(map[string]bool)})
+	w := v.(*jsonlWriter)
+	if !loaded {
+		w.open(path)
+	}
+
+	records := make([]jsonlRecord, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		position := pass.Fset.Position(d.Pos)
+		level := "error"
+		if conf.IsWarning(d.Category) {
+			level = "warning"
+		}
+		records = append(records, jsonlRecord{
+			File:     conf.RelativePath(position.Filename),
+			Line:     position.Line,
+			Column:   position.Column,
+			Level:    level,
+			Category: d.Category,
+			Message:  d.Message,
+		})
+	}
+	return w.add(records)
+}
+
+// open opens the output at the given path, where the error (if any) is recorded and returned by
+// all subsequent writes.
+func (w *jsonlWriter) open(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if path == "-" {
+		w.out = os.Stdout
+		return
+	}
+	// The file is intentionally kept open until the process exits, since the drivers do not
+	// offer a hook at the end of the analysis.
+	f, err := os.Create(path)
+	if err != nil {
+		w.err = fmt.Errorf("create JSON Lines file: %w", err)
+		return
+	}
+	w.out = f
+}
+
+// add writes the records that have not been written yet, each on its own line.
+func (w *jsonlWriter) add(records []jsonlRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encode JSON Lines record: %w", err)
+		}
+		if w.seen[string(line)] {
+			continue
+		}
+		w.seen[string(line)] = true
+		// Each line is written in a single call such that the readers never observe a partial line
+		// between the analysis of two packages.
+		if _, err := w.out.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("write JSON Lines output: %w", err)
+		}
+	}
+	return nil
+}
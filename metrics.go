@@ -0,0 +1,147 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// PackageMetrics are the metrics of the analysis of a single package, which are passed to the
+// metrics recorders (see RegisterMetrics).
+type PackageMetrics struct {
+	// PkgPath is the path of the package.
+	PkgPath string
+	// Sites is the number of sites in the inferred map of the package, or 0 if the package is not
+	// inferred (e.g., when it is out of scope).
+	Sites int
+	// Diagnostics maps the categories (see the diagnostic.Category* constants) to the number of
+	// diagnostics emitted for the package, i.e., after the filters and the baseline are applied.
+	Diagnostics map[string]int
+	// Duration is the wall-clock time spent in the sub-analyzers of NilAway for the package.
+	Duration time.Duration
+}
+
+// MetricsRecorder records the metrics of the analyzed packages, e.g., for exporting them to a
+// monitoring system (such as a Prometheus collector) when NilAway is run in a long-running
+// service. It is implemented by the host and registered via RegisterMetrics.
+type MetricsRecorder interface {
+	// RecordPackage is invoked once for every analyzed package after its diagnostics are
+	// determined. Since packages are analyzed concurrently, it must be safe for concurrent use.
+	RecordPackage(m PackageMetrics)
+}
+
+// _metricsRecorders are the metrics recorders registered via RegisterMetrics.
+var _metricsRecorders struct {
+	sync.RWMutex
+	list []*MetricsRecorder
+}
+
+// RegisterMetrics registers a metrics recorder that is invoked for every package analyzed by
+// NilAway (see MetricsRecorder). Similar to the filters (see RegisterFilter), the recorders should
+// be registered before the analysis starts. The returned function unregisters the recorder.
+func RegisterMetrics(r MetricsRecorder) (unregister func()) {
+	_metricsRecorders.Lock()
+	defer _metricsRecorders.Unlock()
+
+	entry := &r
+	_metricsRecorders.list = append(_metricsRecorders.list, entry)
+	return func() {
+		_metricsRecorders.Lock()
+		defer _metricsRecorders.Unlock()
+
+		for i, e := range _metricsRecorders.list {
+			if e == entry {
+				_metricsRecorders.list = append(_metricsRecorders.list[:i:i], _metricsRecorders.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// recordMetrics passes the metrics of the package to the registered metrics recorders (see
+// RegisterMetrics), if any.
+func recordMetrics(pass *analysis.Pass, sites int, duration time.Duration, diagnostics []analysis.Diagnostic) {
+	_metricsRecorders.RLock()
+	defer _metricsRecorders.RUnlock()
+
+	if len(_metricsRecorders.list) == 0 {
+		return
+	}
+	categories := make(map[string]int)
+	for _, d := range diagnostics {
+		categories[d.Category]++
+	}
+	for _, r := range _metricsRecorders.list {
+		// Each recorder gets its own copy of the map, such that it may keep it.
+		m := PackageMetrics{PkgPath: pass.Pkg.Path(), Sites: sites, Duration: duration, Diagnostics: make(map[string]int, len(categories))}
+		for c, n := range categories {
+			m.Diagnostics[c] = n
+		}
+		(*r).RecordPackage(m)
+	}
+}
+
+// Stats is a snapshot of the metrics aggregated over all packages by a StatsCollector.
+type Stats struct {
+	// Packages is the number of analyzed packages.
+	Packages int
+	// Sites is the total number of sites in the inferred maps of the packages.
+	Sites int
+	// Diagnostics maps the categories (see the diagnostic.Category* constants) to the total number
+	// of diagnostics emitted.
+	Diagnostics map[string]int
+	// Duration is the total wall-clock time spent in the sub-analyzers of NilAway.
+	Duration time.Duration
+}
+
+// StatsCollector is a MetricsRecorder that simply aggregates the metrics of all packages, such
+// that the host can periodically scrape a snapshot via Stats instead of implementing its own
+// recorder. The zero value is ready to use.
+type StatsCollector struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// RecordPackage adds the metrics of the package to the aggregated ones.
+func (c *StatsCollector) RecordPackage(m PackageMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Packages++
+	c.stats.Sites += m.Sites
+	c.stats.Duration += m.Duration
+	if c.stats.Diagnostics == nil {
+		c.stats.Diagnostics = make(map[string]int)
+	}
+	for category, n := range m.Diagnostics {
+		c.stats.Diagnostics[category] += n
+	}
+}
+
+// Stats returns a snapshot of the metrics aggregated so far.
+func (c *StatsCollector) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Diagnostics = make(map[string]int, len(c.stats.Diagnostics))
+	for category, n := range c.stats.Diagnostics {
+		stats.Diagnostics[category] = n
+	}
+	return stats
+}
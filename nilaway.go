@@ -17,8 +17,13 @@
 package nilaway
 
 import (
+	"fmt"
+	"go/token"
+	"sort"
+
 	"go.uber.org/nilaway/accumulation"
 	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/diagnostic"
 	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
 )
@@ -31,7 +36,7 @@ const _doc = "Run NilAway on this package to report any possible flows of nil va
 var Analyzer = &analysis.Analyzer{
 	Name:      "nilaway",
 	Doc:       _doc,
-	Run:       run,
+	Run:       config.Profiled(run),
 	FactTypes: []analysis.Fact{},
 	Requires:  []*analysis.Analyzer{config.Analyzer, accumulation.Analyzer},
 }
@@ -40,12 +45,122 @@ var Analyzer = &analysis.Analyzer{
 func run(pass *analysis.Pass) (interface{}, error) {
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
 	deferredErrors := pass.ResultOf[accumulation.Analyzer].([]analysis.Diagnostic)
+	// The custom filters registered by the driver (if any) are applied first, such that the
+	// suppressed diagnostics are neither written to the baseline nor to the outputs.
+	deferredErrors = applyFilters(pass, deferredErrors)
+	// The diagnostics of long nil flows (see config.Config.MaxChainDepth) are only reported if
+	// they are demoted to warnings.
+	if !conf.IsWarning(diagnostic.CategoryLongChain) {
+		deferredErrors = dropCategory(deferredErrors, diagnostic.CategoryLongChain)
+	}
+	if conf.Baseline != "" {
+		if conf.WriteBaseline {
+			if err := writeBaseline(conf.Baseline, pass, deferredErrors); err != nil {
+				return nil, err
+			}
+		} else {
+			filtered, err := filterBaseline(conf.Baseline, pass, deferredErrors)
+			if err != nil {
+				return nil, err
+			}
+			deferredErrors = filtered
+		}
+	}
+	sortDiagnostics(pass, conf, deferredErrors)
+	switch conf.OutputFormat {
+	case config.OutputFormatSARIF:
+		if err := writeSARIF(conf.OutputFile, pass, deferredErrors, conf.IsWarning); err != nil {
+			return nil, err
+		}
+	case config.OutputFormatJSONL:
+		if err := writeJSONL(conf.OutputFile, pass, deferredErrors, conf); err != nil {
+			return nil, err
+		}
+	}
+	if conf.ReportHTML != "" {
+		if err := writeHTMLReport(conf.ReportHTML, pass, deferredErrors, conf); err != nil {
+			return nil, err
+		}
+	}
+	// The time spent in this analyzer itself is negligible, hence not included in the metrics.
+	recordMetrics(pass, conf.InferredSites(), conf.AnalysisTime(), deferredErrors)
+	if conf.CollapseDuplicates {
+		deferredErrors = collapseDuplicates(pass, deferredErrors)
+	}
+	// The dropped errors are recorded such that the drivers can count the true total.
+	if conf.MaxErrorsPerPackage > 0 {
+		var truncated []token.Pos
+		deferredErrors, truncated = truncateDiagnostics(deferredErrors, conf.MaxErrorsPerPackage, conf.IsWarning)
+		conf.RecordTruncatedErrors(truncated)
+	}
 	for _, e := range deferredErrors {
 		if conf.PrettyPrint {
 			e.Message = util.PrettyPrintErrorMessage(e.Message)
 		}
+		if conf.MessageTemplate != "" {
+			msg, err := conf.FormatMessage(messageFields(pass, conf, e))
+			if err != nil {
+				return nil, err
+			}
+			e.Message = msg
+		}
 		pass.Report(e)
 	}
 
 	return nil, nil
 }
+
+// messageFields returns the fields of the diagnostic for formatting its message with the message
+// template (see config.Config.MessageTemplate).
+func messageFields(pass *analysis.Pass, conf *config.Config, d analysis.Diagnostic) config.MessageFields {
+	format := func(pos token.Pos) string {
+		position := pass.Fset.Position(pos)
+		return fmt.Sprintf("%s:%d:%d", conf.RelativePath(position.Filename), position.Line, position.Column)
+	}
+	fields := config.MessageFields{
+		Position: format(d.Pos),
+		Category: d.Category,
+		Message:  d.Message,
+	}
+	// The nodes of the nil flow are attached as the leading related information, starting from the
+	// nil source (see diagnostic.SameSourceMessage for the others).
+	if len(d.Related) > 0 && d.Related[0].Message != diagnostic.SameSourceMessage {
+		fields.RootSite = format(d.Related[0].Pos)
+	}
+	return fields
+}
+
+// sortDiagnostics sorts the diagnostics in place in the configured order (see
+// config.Config.SortOrder), where the ties are broken by position (i.e., file path, line and
+// column) and then message, such that the order is deterministic.
+func sortDiagnostics(pass *analysis.Pass, conf *config.Config, diagnostics []analysis.Diagnostic) {
+	// rank returns the primary key of the diagnostic in the configured order, the diagnostics are
+	// ordered by position only if all ranks are equal.
+	rank := func(d analysis.Diagnostic) string {
+		switch conf.SortOrder {
+		case config.SortCategory:
+			return d.Category
+		case config.SortSeverity:
+			if conf.IsWarning(d.Category) {
+				return "1"
+			}
+			return "0"
+		}
+		return ""
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if ri, rj := rank(diagnostics[i]), rank(diagnostics[j]); ri != rj {
+			return ri < rj
+		}
+		pi, pj := pass.Fset.Position(diagnostics[i].Pos), pass.Fset.Position(diagnostics[j].Pos)
+		switch {
+		case pi.Filename != pj.Filename:
+			return pi.Filename < pj.Filename
+		case pi.Line != pj.Line:
+			return pi.Line < pj.Line
+		case pi.Column != pj.Column:
+			return pi.Column < pj.Column
+		}
+		return diagnostics[i].Message < diagnostics[j].Message
+	})
+}
@@ -15,13 +15,23 @@
 package nilaway
 
 import (
+	"encoding/json"
 	"fmt"
+	"go/token"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"go.uber.org/nilaway/accumulation"
 	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/diagnostic"
+	"go.uber.org/nilaway/inference"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
@@ -42,6 +52,13 @@ func TestContracts(t *testing.T) {
 	analysistest.Run(t, testdata, Analyzer, "go.uber.org/contracts")
 }
 
+func TestImplicationContracts(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "implicationcontracts")
+}
+
 func TestTesting(t *testing.T) {
 	t.Parallel()
 
@@ -111,6 +128,69 @@ func TestDeepNil(t *testing.T) {
 	analysistest.Run(t, testdata, Analyzer, "go.uber.org/deepnil")
 }
 
+func TestNestedDeep(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/nesteddeep")
+}
+
+func TestFuncValues(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/funcvalues")
+}
+
+func TestTypeAssertions(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/typeassertions")
+}
+
+func TestOkFuncs(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/okfuncs")
+}
+
+func TestTypeAlias(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/typealias")
+}
+
+func TestErrorsAs(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/errorsas")
+}
+
+func TestContextValue(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/contextvalue")
+}
+
+func TestNilStore(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "nilstore")
+}
+
+func TestStructTags(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "structtags")
+}
+
 func TestNilableTypes(t *testing.T) {
 	t.Parallel()
 
@@ -180,7 +260,21 @@ func TestNamedReturn(t *testing.T) {
 	t.Parallel()
 
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, Analyzer, "go.uber.org/namedreturn")
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/namedreturn", "go.uber.org/namedreturn/inference")
+}
+
+func TestVariadic(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/variadic")
+}
+
+func TestUnsafePointer(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/unsafepointer")
 }
 
 func TestIgnoreGenerated(t *testing.T) {
@@ -215,7 +309,7 @@ func TestGenerics(t *testing.T) {
 	t.Parallel()
 
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, Analyzer, "go.uber.org/generics")
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/generics", "go.uber.org/generics/inference")
 }
 
 func TestFunctionContracts(t *testing.T) {
@@ -232,6 +326,78 @@ func TestConstants(t *testing.T) {
 	analysistest.Run(t, testdata, Analyzer, "go.uber.org/consts")
 }
 
+func TestEmbedding(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/embedding", "go.uber.org/embedding/inference")
+}
+
+func TestNolint(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/nolint")
+}
+
+func TestNilablePackage(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/nilablepackage")
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	// The filter only concerns the test package, such that the other tests running in parallel
+	// are not affected.
+	var categories []string
+	var mu sync.Mutex
+	unregister := RegisterFilter(func(diag Diagnostic) bool {
+		if diag.PkgPath != "filter" {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		categories = append(categories, diag.Category)
+		return diag.Category != diagnostic.CategoryFieldAccess
+	})
+	defer unregister()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "filter")
+	require.ElementsMatch(t, []string{diagnostic.CategoryFieldAccess, diagnostic.CategoryFuncReturn}, categories)
+}
+
+// metricsRecorderFunc is a MetricsRecorder backed by a function.
+type metricsRecorderFunc func(m PackageMetrics)
+
+func (f metricsRecorderFunc) RecordPackage(m PackageMetrics) { f(m) }
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	// Only the metrics of the test package are collected, such that the other tests running in
+	// parallel do not affect the stats.
+	var collector StatsCollector
+	unregister := RegisterMetrics(metricsRecorderFunc(func(m PackageMetrics) {
+		if m.PkgPath == "metrics" {
+			collector.RecordPackage(m)
+		}
+	}))
+	defer unregister()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "metrics")
+
+	stats := collector.Stats()
+	require.Equal(t, 1, stats.Packages)
+	require.Positive(t, stats.Sites)
+	require.Positive(t, stats.Duration)
+	require.Equal(t, map[string]int{diagnostic.CategoryFieldAccess: 1, diagnostic.CategoryFuncReturn: 1}, stats.Diagnostics)
+}
+
 func TestPrettyPrint(t *testing.T) { //nolint:paralleltest
 	// We specifically do not set this test to be parallel such that this test is run separately
 	// from the parallel tests. This makes it possible to set the pretty-print flag to true for
@@ -247,6 +413,842 @@ func TestPrettyPrint(t *testing.T) { //nolint:paralleltest
 	analysistest.Run(t, testdata, Analyzer, "prettyprint")
 }
 
+func TestSARIF(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the output flags.
+	path := filepath.Join(t.TempDir(), "nilaway.sarif")
+	require.NoError(t, config.Analyzer.Flags.Set(config.OutputFormatFlag, config.OutputFormatSARIF))
+	require.NoError(t, config.Analyzer.Flags.Set(config.OutputFileFlag, path))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.OutputFormatFlag, config.OutputFormatText))
+		require.NoError(t, config.Analyzer.Flags.Set(config.OutputFileFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "sarif")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(content, &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 1)
+
+	result := log.Runs[0].Results[0]
+	require.Equal(t, "nilaway", result.RuleID)
+	require.Contains(t, result.Message.Text, "Potential nil panic")
+	require.True(t, strings.HasSuffix(result.Locations[0].PhysicalLocation.ArtifactLocation.URI, "sarif/main.go"))
+	require.Equal(t, 10, result.Locations[0].PhysicalLocation.Region.StartLine)
+
+	// The nil flow goes from the nil return to the dereference.
+	require.Len(t, result.CodeFlows, 1)
+	var lines []int
+	for _, l := range result.CodeFlows[0].ThreadFlows[0].Locations {
+		lines = append(lines, l.Location.PhysicalLocation.Region.StartLine)
+	}
+	require.Equal(t, []int{5, 10}, lines)
+}
+
+func TestJSONL(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the output flags.
+	path := filepath.Join(t.TempDir(), "nilaway.jsonl")
+	require.NoError(t, config.Analyzer.Flags.Set(config.OutputFormatFlag, config.OutputFormatJSONL))
+	require.NoError(t, config.Analyzer.Flags.Set(config.OutputFileFlag, path))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.OutputFormatFlag, config.OutputFormatText))
+		require.NoError(t, config.Analyzer.Flags.Set(config.OutputFileFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "sarif")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 1)
+
+	var record jsonlRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.True(t, strings.HasSuffix(record.File, "sarif/main.go"))
+	require.Equal(t, 10, record.Line)
+	require.Positive(t, record.Column)
+	require.Equal(t, "error", record.Level)
+	require.NotEmpty(t, record.Category)
+	require.Contains(t, record.Message, "Potential nil panic")
+}
+
+func TestReportHTML(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the report-html flag.
+	path := filepath.Join(t.TempDir(), "nilaway.html")
+	require.NoError(t, config.Analyzer.Flags.Set(config.ReportHTMLFlag, path))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ReportHTMLFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "sarif")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := string(content)
+	require.Contains(t, report, "1 diagnostic(s) in 1 package(s).")
+	require.Contains(t, report, "<h2>sarif (1)</h2>")
+	require.Contains(t, report, "sarif/main.go:10:")
+	require.Contains(t, report, "Potential nil panic")
+	// The nodes of the nil flow are listed with the source snippets, where the special
+	// characters are escaped.
+	require.Contains(t, report, `<ol class="flow">`)
+	require.Contains(t, report, `class="highlighted"`)
+	require.Contains(t, report, "&#34;Potential nil panic&#34;")
+	// The report is self-contained.
+	require.NotContains(t, report, "<link")
+	require.NotContains(t, report, "<script")
+}
+
+func TestDumpSites(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the dump flags.
+	dir := t.TempDir()
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFlag, ""))
+		require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFileFlag, config.DefaultDumpSitesFile))
+	}()
+	testdata := analysistest.TestData()
+
+	path := filepath.Join(dir, "matching.txt")
+	require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFlag, `Function (Nilable|FromGlobal)$`))
+	require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFileFlag, path))
+	analysistest.Run(t, testdata, Analyzer, "dumpsites")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `# dumpsites
+Result 0 of Function Nilable: determined nilable
+Result 0 of Function FromGlobal: determined nilable
+`, string(content))
+
+	// A regular expression without matching sites produces an empty dump.
+	path = filepath.Join(dir, "empty.txt")
+	require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFlag, `NoSuchSite`))
+	require.NoError(t, config.Analyzer.Flags.Set(config.DumpSitesFileFlag, path))
+	analysistest.Run(t, testdata, Analyzer, "dumpsites")
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, content)
+}
+
+func TestRecordReplay(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the record flag.
+	path := filepath.Join(t.TempDir(), "nilaway-sessions.gob")
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.RecordFlag, ""))
+	}()
+	testdata := analysistest.TestData()
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.RecordFlag, path))
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "go.uber.org/errorreturn/inference")
+	require.Len(t, results, 1)
+	reported := make(map[string]bool)
+	for _, d := range results[0].Diagnostics {
+		pos := results[0].Pass.Fset.Position(d.Pos)
+		reported[fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)] = true
+	}
+	require.NotEmpty(t, reported)
+
+	sessions, err := accumulation.ReadSessions(path)
+	require.NoError(t, err)
+	// The upstream packages are recorded as well, before their downstream package.
+	require.Greater(t, len(sessions), 1)
+	session := sessions[len(sessions)-1]
+	require.Equal(t, "go.uber.org/errorreturn/inference", session.PkgPath)
+	require.NotEmpty(t, session.Upstream)
+
+	// Replaying the session finds the same conflicts without the source (modulo grouping).
+	result := inference.Replay(session)
+	require.False(t, result.Truncated)
+	replayed := make(map[string]bool)
+	for _, c := range result.Conflicts {
+		replayed[fmt.Sprintf("%s:%d", filepath.Base(c.Position.Filename), c.Position.Line)] = true
+	}
+	require.Equal(t, reported, replayed)
+}
+
+func TestBaseline(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the baseline flags.
+	path := filepath.Join(t.TempDir(), "nilaway-baseline.json")
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.BaselineFlag, ""))
+		require.NoError(t, config.Analyzer.Flags.Set(config.WriteBaselineFlag, "false"))
+	}()
+	testdata := analysistest.TestData()
+
+	// Write the baseline, where the diagnostics are still reported.
+	require.NoError(t, config.Analyzer.Flags.Set(config.BaselineFlag, path))
+	require.NoError(t, config.Analyzer.Flags.Set(config.WriteBaselineFlag, "true"))
+	analysistest.Run(t, testdata, Analyzer, "sarif")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written baselineFile
+	require.NoError(t, json.Unmarshal(content, &written))
+	require.Equal(t, 1, written.Version)
+	require.Len(t, written.Entries, 1)
+	entry := written.Entries[0]
+	require.True(t, strings.HasSuffix(entry.File, "sarif/main.go"))
+	require.Equal(t, 10, entry.Line)
+	require.Equal(t, `print(*x) //want "Potential nil panic"`, entry.Anchor)
+	require.Contains(t, entry.Message, "sarif/main.go: result 0 of `retNil()`")
+
+	// Suppress the diagnostics recorded in a baseline, even if the line has drifted.
+	file, err := filepath.Abs(filepath.Join(testdata, "src", "baseline", "main.go"))
+	require.NoError(t, err)
+	rel, err := filepath.Rel(filepath.Dir(path), file)
+	require.NoError(t, err)
+	content, err = json.Marshal(baselineFile{Version: 1, Entries: []baselineEntry{{
+		File:    filepath.ToSlash(rel),
+		Line:    5,
+		Anchor:  "print(*x)",
+		Message: "Potential nil panic detected. Observed nil flow from source to dereference point: \n\t-> baseline/main.go: unassigned variable `x` dereferenced\n",
+	}}})
+	require.NoError(t, err)
+	path = filepath.Join(filepath.Dir(path), "existing-baseline.json")
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+	require.NoError(t, config.Analyzer.Flags.Set(config.BaselineFlag, path))
+	require.NoError(t, config.Analyzer.Flags.Set(config.WriteBaselineFlag, "false"))
+	analysistest.Run(t, testdata, Analyzer, "baseline")
+}
+
+func TestExternalReturns(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the external returns flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.ExternalReturnsFlag, config.ExternalReturnsNilable))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ExternalReturnsFlag, config.ExternalReturnsNonnil))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "externalreturns", "externalreturns/noinfer")
+}
+
+func TestExcludeFuncs(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the exclude funcs flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.ExcludeFuncsFlag, "excludefuncs.excluded,excludefuncs.(*T).Method"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ExcludeFuncsFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "excludefuncs")
+}
+
+func TestReportUndetermined(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the report flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.ReportUndeterminedFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ReportUndeterminedFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "undetermined")
+}
+
+func TestStrictExported(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the strict flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.StrictExportedFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.StrictExportedFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "strictexported")
+}
+
+func TestBoundaryOnly(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the boundary flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.BoundaryOnlyFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.BoundaryOnlyFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "boundaryonly")
+}
+
+func TestUnmarshalNilable(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the unmarshal flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.UnmarshalNilableFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.UnmarshalNilableFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "unmarshal")
+}
+
+func TestReflectNilable(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the reflect flag.
+	testdata := analysistest.TestData()
+
+	// Without the flag, the pointers asserted from reflection are not considered nilable.
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "reflectnilable")
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Diagnostics)
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.ReflectNilableFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ReflectNilableFlag, "false"))
+	}()
+	analysistest.Run(t, testdata, Analyzer, "reflectnilable")
+}
+
+func TestPathBase(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the path base flag.
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.PathBaseFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	require.NoError(t, config.Analyzer.Flags.Set(config.PathBaseFlag, config.PathBaseModule))
+	analysistest.Run(t, testdata, Analyzer, "pathbase")
+
+	// The files outside of the path base are printed as absolute paths.
+	require.NoError(t, config.Analyzer.Flags.Set(config.PathBaseFlag, t.TempDir()))
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "pathbase")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	require.Contains(t, results[0].Diagnostics[0].Message, "-> "+filepath.Join(testdata, "src", "pathbase", "pathbase.go")+":5:9")
+}
+
+func TestMessageTemplate(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the message template flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.MessageTemplateFlag, "{{.Position}} [{{.Category}}] ({{.RootSite}}) {{.Message}}"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.MessageTemplateFlag, ""))
+	}()
+
+	testdata := analysistest.TestData()
+	file := filepath.Join(testdata, "src", "pathbase", "pathbase.go")
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "pathbase")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	require.True(t, strings.HasPrefix(results[0].Diagnostics[0].Message,
+		file+":9:9 [funcret] ("+file+":5:9) Potential nil panic detected."), results[0].Diagnostics[0].Message)
+
+	// Invalid templates are rejected before the analysis.
+	require.NoError(t, config.Analyzer.Flags.Set(config.MessageTemplateFlag, "{{.Line}}"))
+	results = analysistest.Run(ignoreWants{}, testdata, config.Analyzer, "pathbase")
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Err, config.MessageTemplateFlag)
+}
+
+func TestRequireSuppressionReason(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the suppression flags.
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.RequireSuppressionReasonFlag, "false"))
+		require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonDelimiterFlag, config.DefaultSuppressionReasonDelimiter))
+		require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonMinLengthFlag, "1"))
+	}()
+	require.NoError(t, config.Analyzer.Flags.Set(config.RequireSuppressionReasonFlag, "true"))
+	require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonDelimiterFlag, "reason:"))
+	require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonMinLengthFlag, "5"))
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "suppressionreason")
+	require.Len(t, results, 1)
+	// The nil panics are all suppressed, and only the unjustified directives are reported at
+	// their exact positions.
+	var reported []string
+	for _, d := range results[0].Diagnostics {
+		reported = append(reported, fmt.Sprintf("%s: %s", results[0].Pass.Fset.Position(d.Pos), d.Message))
+	}
+	file := filepath.Join(testdata, "src", "suppressionreason", "suppressionreason.go")
+	require.Equal(t, []string{
+		file + ":11:12: nolint directive suppressing NilAway diagnostics lacks a reason, expected \"reason:\" followed by a reason after the directive",
+		file + ":16:2: nolint directive suppressing NilAway diagnostics lacks a reason, expected \"reason:\" followed by a reason after the directive",
+		file + ":22:12: nolint directive suppressing NilAway diagnostics has a reason shorter than 5 characters",
+	}, reported)
+}
+
+func TestCollapseDuplicates(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the collapse and the
+	// suppression flags.
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.CollapseDuplicatesFlag, "false"))
+		require.NoError(t, config.Analyzer.Flags.Set(config.RequireSuppressionReasonFlag, "false"))
+		require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonDelimiterFlag, config.DefaultSuppressionReasonDelimiter))
+		require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonMinLengthFlag, "1"))
+	}()
+	require.NoError(t, config.Analyzer.Flags.Set(config.CollapseDuplicatesFlag, "true"))
+	require.NoError(t, config.Analyzer.Flags.Set(config.RequireSuppressionReasonFlag, "true"))
+	require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonDelimiterFlag, "reason:"))
+	require.NoError(t, config.Analyzer.Flags.Set(config.SuppressionReasonMinLengthFlag, "5"))
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "suppressionreason")
+	require.Len(t, results, 1)
+	// The two directives lacking a reason share the same message, while the third one differs.
+	var reported []string
+	for _, d := range results[0].Diagnostics {
+		reported = append(reported, fmt.Sprintf("%d: %s", results[0].Pass.Fset.Position(d.Pos).Line, d.Message))
+	}
+	require.Equal(t, []string{
+		"11: nolint directive suppressing NilAway diagnostics lacks a reason, expected \"reason:\" followed by a reason after the directive\n" +
+			"(Reported 2 times in this file, also at line(s) 16.)",
+		"22: nolint directive suppressing NilAway diagnostics has a reason shorter than 5 characters",
+	}, reported)
+}
+
+func TestCollapseDuplicates_Categories(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("a.go", -1, 100)
+	file.SetLines([]int{0, 10, 20, 30})
+	pass := &analysis.Pass{Fset: fset}
+	diagnostics := []analysis.Diagnostic{
+		{Pos: file.Pos(25), Message: "m"},
+		{Pos: file.Pos(5), Message: "m", Category: "mapread"},
+		{Pos: file.Pos(15), Message: "m"},
+		{Pos: file.Pos(5), Message: "other"},
+	}
+	collapsed := collapseDuplicates(pass, diagnostics)
+	require.Equal(t, []analysis.Diagnostic{
+		{Pos: file.Pos(15), Message: "m\n(Reported 2 times in this file, also at line(s) 3.)"},
+		{Pos: file.Pos(5), Message: "m", Category: "mapread"},
+		{Pos: file.Pos(5), Message: "other"},
+	}, collapsed)
+}
+
+func TestReportRedundantChecks(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the redundant checks flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.ReportRedundantChecksFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ReportRedundantChecksFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "redundantchecks")
+	require.Len(t, results, 1)
+	for _, d := range results[0].Diagnostics {
+		require.Equal(t, diagnostic.CategoryRedundantCheck, d.Category)
+	}
+}
+
+func TestRelocateBaseline(t *testing.T) {
+	t.Parallel()
+
+	// The baseline is written in a checkout at "base", and relocated for the checkout at "current".
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "base", "sub", "baseline.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(src), 0o755))
+	outside := filepath.ToSlash(filepath.Join(tmp, "outside.go"))
+	content, err := json.Marshal(baselineFile{Version: 1, Entries: []baselineEntry{
+		{File: "foo/foo.go", Line: 1, Anchor: "a", Message: "m"},
+		{File: outside, Line: 2, Anchor: "b", Message: "n"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(src, content, 0o644))
+
+	dst := filepath.Join(tmp, "relocated.json")
+	require.NoError(t, RelocateBaseline(src, dst, filepath.Join(tmp, "current", "sub")))
+	content, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	var relocated baselineFile
+	require.NoError(t, json.Unmarshal(content, &relocated))
+	require.Equal(t, []baselineEntry{
+		{File: "current/sub/foo/foo.go", Line: 1, Anchor: "a", Message: "m"},
+		{File: outside, Line: 2, Anchor: "b", Message: "n"},
+	}, relocated.Entries)
+
+	// Unsupported versions are rejected.
+	require.NoError(t, os.WriteFile(src, []byte(`{"version": 42}`), 0o644))
+	require.ErrorContains(t, RelocateBaseline(src, dst, tmp), "unsupported baseline file version")
+}
+
+func TestFocus(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the focus flags.
+	testdata := analysistest.TestData()
+
+	// Focusing on a file reports the diagnostics in the file only.
+	require.NoError(t, config.Analyzer.Flags.Set(config.FocusFlag, filepath.Join(testdata, "src", "focus", "focused.go")))
+	analysistest.Run(t, testdata, Analyzer, "focus")
+	require.NoError(t, config.Analyzer.Flags.Set(config.FocusFlag, ""))
+
+	// Focusing on a function reports the diagnostics in the function only.
+	require.NoError(t, config.Analyzer.Flags.Set(config.FocusFuncFlag, "focus.Focused"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.FocusFuncFlag, ""))
+	}()
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "focus")
+	require.Len(t, results, 1)
+	var lines []int
+	for _, d := range results[0].Diagnostics {
+		lines = append(lines, results[0].Pass.Fset.Position(d.Pos).Line)
+	}
+	require.Equal(t, []int{7}, lines)
+}
+
+func TestMaxChainDepth(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the max-chain-depth flag.
+	testdata := analysistest.TestData()
+	lines := func(result *analysistest.Result) map[int]string {
+		categories := make(map[int]string)
+		for _, d := range result.Diagnostics {
+			categories[result.Pass.Fset.Position(d.Pos).Line] = d.Category
+		}
+		return categories
+	}
+
+	// Without the limit, the nil flow through the chain of functions is reported.
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "longchains")
+	require.Len(t, results, 1)
+	require.Equal(t, map[int]string{18: diagnostic.CategoryFuncReturn, 23: ""}, lines(results[0]))
+
+	// With the limit, the long nil flow is suppressed.
+	require.NoError(t, config.Analyzer.Flags.Set(config.MaxChainDepthFlag, "3"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.MaxChainDepthFlag, "0"))
+	}()
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "longchains")
+	require.Len(t, results, 1)
+	require.Equal(t, map[int]string{23: ""}, lines(results[0]))
+
+	// The long nil flow is reported if its category is demoted to warnings.
+	require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, diagnostic.CategoryLongChain))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, ""))
+	}()
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "longchains")
+	require.Len(t, results, 1)
+	require.Equal(t, map[int]string{18: diagnostic.CategoryLongChain, 23: ""}, lines(results[0]))
+}
+
+func TestExplain(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the explain flag.
+	testdata := analysistest.TestData()
+	messages := func(result *analysistest.Result) map[int]string {
+		msgs := make(map[int]string)
+		for _, d := range result.Diagnostics {
+			msgs[result.Pass.Fset.Position(d.Pos).Line] = d.Message
+		}
+		return msgs
+	}
+
+	// Without the flag, the diagnostics stay concise.
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "longchains")
+	require.Len(t, results, 1)
+	for _, msg := range messages(results[0]) {
+		require.NotContains(t, msg, "--- explanation ---")
+	}
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.ExplainFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ExplainFlag, "false"))
+	}()
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "longchains")
+	require.Len(t, results, 1)
+	msgs := messages(results[0])
+	require.Len(t, msgs, 2)
+
+	// The explanation walks through the nil source, every step of the nil flow and the
+	// dereference point, after the concise nil flow.
+	long := msgs[18]
+	_, explanation, found := strings.Cut(long, "\t--- explanation ---\n")
+	require.True(t, found, long)
+	require.True(t, strings.HasSuffix(explanation, "\t--- end of explanation ---\n"), explanation)
+	require.Contains(t, explanation, "\t| nil source (")
+	require.Contains(t, explanation, "literal `nil`")
+	require.Contains(t, explanation, "\t| step 4 (")
+	require.Contains(t, explanation, "\t| conflict: ")
+	require.Contains(t, explanation, "\t| dereference (")
+	require.NotContains(t, explanation, "\t| step 5 (")
+
+	// Local nil flows are explained similarly.
+	require.Contains(t, msgs[23], "\t| dereference (")
+}
+
+func TestGroupErrors(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the grouping flag.
+	require.NoError(t, config.Analyzer.Flags.Set(config.GroupErrorsFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.GroupErrorsFlag, "false"))
+	}()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "grouperrors")
+	require.Len(t, results, 1)
+	// The dereferences through different nil flows from the same source are attached to the
+	// primary diagnostic as related locations.
+	var grouped []int
+	for _, r := range results[0].Diagnostics[0].Related {
+		if r.Message == diagnostic.SameSourceMessage {
+			grouped = append(grouped, results[0].Pass.Fset.Position(r.Pos).Line)
+		}
+	}
+	require.Equal(t, []int{17, 23}, grouped)
+}
+
+func TestMaxSites(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the max-sites flag.
+	testdata := analysistest.TestData()
+
+	// Without the limit, the nil flow through the chain of functions is reported.
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "maxsites")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	require.NotContains(t, results[0].Diagnostics[0].Message, "analysis truncated")
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.MaxSitesFlag, "4"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.MaxSitesFlag, "0"))
+	}()
+	analysistest.Run(t, testdata, Analyzer, "maxsites")
+}
+
+func TestTestFileMode(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the test-file-mode flag.
+	testdata := analysistest.TestData()
+
+	// diagnosedFiles returns the sorted names of the files with diagnostics across all variants
+	// of the package (i.e., with and without the test files).
+	diagnosedFiles := func() []string {
+		files := make(map[string]bool)
+		for _, r := range analysistest.Run(ignoreWants{}, testdata, Analyzer, "testfilemode") {
+			for _, d := range r.Diagnostics {
+				files[filepath.Base(r.Pass.Fset.Position(d.Pos).Filename)] = true
+			}
+		}
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	require.Equal(t, []string{"main.go", "main_test.go"}, diagnosedFiles())
+
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.TestFileModeFlag, config.TestFileModeAnalyze))
+	}()
+	for _, mode := range []string{config.TestFileModeSkip, config.TestFileModeRelaxed} {
+		require.NoError(t, config.Analyzer.Flags.Set(config.TestFileModeFlag, mode))
+		require.Equal(t, []string{"main.go"}, diagnosedFiles(), mode)
+	}
+}
+
+func TestDerefColumns(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "derefcolumns")
+	require.Len(t, results, 1)
+	var columns []int
+	for _, d := range results[0].Diagnostics {
+		columns = append(columns, results[0].Pass.Fset.Position(d.Pos).Column)
+	}
+	// The diagnostics point at the failing selector `c`, the `[` of the index expression, and the
+	// `*` of the dereference respectively.
+	require.Equal(t, []int{12, 9, 12}, columns)
+}
+
+// ignoreWants implements analysistest.Testing by discarding the errors, such that the diagnostics
+// can be checked directly instead of via "want" comments.
+type ignoreWants struct{}
+
+func (ignoreWants) Errorf(string, ...any) {}
+
+func TestCache(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the cache flag.
+	cacheDir := t.TempDir()
+	require.NoError(t, config.Analyzer.Flags.Set(config.CacheDirFlag, cacheDir))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.CacheDirFlag, ""))
+	}()
+
+	// Copy the test packages to a temporary directory such that the upstream package can be modified.
+	testdata := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(testdata, "src", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	writeFile("upstream/upstream.go", "package upstream\n\nfunc Get() *int {\n\treturn nil\n}\n")
+	writeFile("downstream/downstream.go", "package downstream\n\nimport \"upstream\"\n\nfunc f() {\n\tprint(*upstream.Get())\n}\n")
+
+	run := func() []string {
+		results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "downstream")
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+		messages := make([]string, 0, len(results[0].Diagnostics))
+		for _, d := range results[0].Diagnostics {
+			messages = append(messages, d.Message)
+		}
+		return messages
+	}
+	entries := func() []string {
+		paths, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+		require.NoError(t, err)
+		return paths
+	}
+
+	messages := run()
+	require.Len(t, messages, 1)
+	require.Contains(t, messages[0], "result 0 of `Get()`")
+	cached := entries()
+	require.NotEmpty(t, cached)
+
+	// Tamper with the cached diagnostics to check that the results are loaded from the cache.
+	for _, path := range cached {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		content = []byte(strings.ReplaceAll(string(content), "Potential nil panic", "Cached nil panic"))
+		require.NoError(t, os.WriteFile(path, content, 0o644))
+	}
+	messages = run()
+	require.Len(t, messages, 1)
+	require.True(t, strings.HasPrefix(messages[0], "Cached nil panic"), messages[0])
+	require.ElementsMatch(t, cached, entries())
+
+	// Changing the upstream package invalidates the cached results of the downstream package.
+	writeFile("upstream/upstream.go", "package upstream\n\nfunc Get() *int {\n\treturn new(int)\n}\n")
+	require.Empty(t, run())
+}
+
+func TestChangedPkgs(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the flags.
+	cacheDir := t.TempDir()
+	setFlags := func(changed, cache string) {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ChangedPkgsFlag, changed))
+		require.NoError(t, config.Analyzer.Flags.Set(config.CacheDirFlag, cache))
+	}
+	defer setFlags("", "")
+
+	testdata := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(testdata, "src", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	const upstream = "package upstream\n\nfunc Get() *int {\n\treturn nil\n}\n\nfunc f() {\n\tvar p *int\n\tprint(*p)\n}\n"
+	writeFile("upstream/upstream.go", upstream)
+	writeFile("downstream/downstream.go", "package downstream\n\nimport \"upstream\"\n\nfunc f() {\n\tprint(*upstream.Get())\n}\n")
+	writeFile("other/other.go", "package other\n\nfunc f() {\n\tvar p *int\n\tprint(*p)\n}\n")
+
+	// run returns the packages with diagnostics.
+	run := func() []string {
+		results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "upstream", "downstream", "other")
+		var reported []string
+		for _, r := range results {
+			require.NoError(t, r.Err)
+			if len(r.Diagnostics) > 0 {
+				reported = append(reported, r.Pass.Pkg.Path())
+			}
+		}
+		return reported
+	}
+	require.Equal(t, []string{"upstream", "downstream", "other"}, run())
+
+	// Without the cache, the exports of the changed packages are assumed to be changed, so the
+	// dependents are reported as well.
+	setFlags("upstream", "")
+	require.Equal(t, []string{"upstream", "downstream"}, run())
+
+	// With the cache, the exports are compared with the ones recorded in the last run.
+	setFlags("upstream", cacheDir)
+	require.Equal(t, []string{"upstream", "downstream"}, run())
+	require.Equal(t, []string{"upstream"}, run())
+
+	// Changes that do not alter the exports do not affect the dependents.
+	writeFile("upstream/upstream.go", upstream+"\nfunc g() {}\n")
+	require.Equal(t, []string{"upstream"}, run())
+
+	// Changes that alter the exports affect the dependents.
+	writeFile("upstream/upstream.go", "package upstream\n\nfunc Other() *int {\n\treturn nil\n}\n\n"+upstream[len("package upstream\n\n"):])
+	require.Equal(t, []string{"upstream", "downstream"}, run())
+
+	// The list can also be read from a file.
+	list := filepath.Join(t.TempDir(), "changed.txt")
+	require.NoError(t, os.WriteFile(list, []byte("other\n"), 0o644))
+	setFlags("@"+list, "")
+	require.Equal(t, []string{"other"}, run())
+}
+
+func TestCategories(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	categories := make(map[int]string)
+	for _, d := range results[0].Diagnostics {
+		categories[results[0].Pass.Fset.Position(d.Pos).Line] = d.Category
+	}
+	require.Equal(t, map[int]string{
+		17: diagnostic.CategoryMapRead,
+		21: diagnostic.CategoryFuncReturn,
+		25: diagnostic.CategoryFuncReturn,
+		33: diagnostic.CategoryFieldAccess,
+		38: "",
+	}, categories)
+}
+
+func TestSortOrder(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the sort flag.
+	testdata := analysistest.TestData()
+	lines := func(result *analysistest.Result) []int {
+		lines := make([]int, len(result.Diagnostics))
+		for i, d := range result.Diagnostics {
+			lines[i] = result.Pass.Fset.Position(d.Pos).Line
+		}
+		return lines
+	}
+
+	// By default, the diagnostics are sorted by position.
+	results := analysistest.Run(t, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{17, 21, 25, 33, 38}, lines(results[0]))
+
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.SortFlag, config.SortPosition))
+		require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, ""))
+	}()
+
+	// The diagnostics without categories come first, and the ties are broken by position.
+	require.NoError(t, config.Analyzer.Flags.Set(config.SortFlag, config.SortCategory))
+	results = analysistest.Run(t, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{38, 33, 21, 25, 17}, lines(results[0]))
+
+	// The errors come before the warnings.
+	require.NoError(t, config.Analyzer.Flags.Set(config.SortFlag, config.SortSeverity))
+	require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, diagnostic.CategoryFuncReturn))
+	results = analysistest.Run(t, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{17, 33, 38, 21, 25}, lines(results[0]))
+
+	// Unknown orders are rejected.
+	require.NoError(t, config.Analyzer.Flags.Set(config.SortFlag, "unknown"))
+	results = analysistest.Run(ignoreWants{}, testdata, config.Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Err, config.SortFlag)
+}
+
+func TestStubs(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the stubs flag.
+	testdata := analysistest.TestData()
+	require.NoError(t, config.Analyzer.Flags.Set(config.StubsFlag, filepath.Join(testdata, "stubs.yaml")))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.StubsFlag, ""))
+	}()
+	analysistest.Run(t, testdata, Analyzer, "stubs")
+
+	// Identifiers that do not resolve in the imported packages are rejected.
+	path := filepath.Join(t.TempDir(), "stubs.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ignoredpkg1.Missing:\n  result 0: nilable\n"), 0o644))
+	require.NoError(t, config.Analyzer.Flags.Set(config.StubsFlag, path))
+	results := analysistest.Run(ignoreWants{}, testdata, accumulation.Analyzer, "stubs")
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Err, `function "ignoredpkg1.Missing" not found`)
+}
+
 func TestMain(m *testing.M) {
 	flags := map[string]string{
 		// Pretty print should be turned off for easier error message matching in test files.
@@ -262,3 +1264,108 @@ func TestMain(m *testing.M) {
 	}
 	goleak.VerifyTestMain(m)
 }
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.New()
+	cfg.PrettyPrint = false
+	dir := filepath.Join("testdata", "src", "go.uber.org", "unsafepointer")
+	result, err := Analyze(cfg, []string{"./" + filepath.ToSlash(dir)})
+	require.NoError(t, err)
+	require.Len(t, result.Packages, 1)
+
+	pkg := result.Packages[0]
+	require.Equal(t, "go.uber.org/nilaway/testdata/src/go.uber.org/unsafepointer", pkg.Path)
+	require.Len(t, pkg.Diagnostics, 4)
+	for _, d := range pkg.Diagnostics {
+		require.Equal(t, "unsafepointer.go", filepath.Base(d.Position.Filename))
+		require.True(t, d.Position.IsValid())
+		require.Contains(t, d.Message, "Potential nil panic detected")
+	}
+
+	// The invalid options set programmatically are rejected before the analysis.
+	cfg.MaxSites = -1
+	_, err = Analyze(cfg, []string{"./" + filepath.ToSlash(dir)})
+	require.ErrorContains(t, err, config.MaxSitesFlag)
+}
+
+func TestDistrustPkgs(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the distrust flag.
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "distrust")
+
+	// The facts of the distrusted package are ignored, hence its results fall back to the default
+	// of the packages that are not analyzed.
+	require.NoError(t, config.Analyzer.Flags.Set(config.DistrustPkgsFlag, "distrust/upstream"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.DistrustPkgsFlag, ""))
+	}()
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "distrust")
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Diagnostics)
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.ExternalReturnsFlag, config.ExternalReturnsNilable))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ExternalReturnsFlag, config.ExternalReturnsNonnil))
+	}()
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "distrust")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	require.Contains(t, results[0].Diagnostics[0].Message, "not analyzed")
+}
+
+func TestConstructorFields(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the constructor flag.
+	testdata := analysistest.TestData()
+
+	// Without the flag, the fields are not seeded from the constructors.
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "constructorfields")
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Diagnostics)
+
+	require.NoError(t, config.Analyzer.Flags.Set(config.ConstructorFieldsFlag, "true"))
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.ConstructorFieldsFlag, "false"))
+	}()
+	analysistest.Run(t, testdata, Analyzer, "constructorfields")
+}
+
+func TestMaxErrorsPerPackage(t *testing.T) { //nolint:paralleltest
+	// Similar to TestPrettyPrint, this test is not parallel since it sets the max errors flag.
+	testdata := analysistest.TestData()
+	lines := func(result *analysistest.Result) []int {
+		lines := make([]int, len(result.Diagnostics))
+		for i, d := range result.Diagnostics {
+			lines[i] = result.Pass.Fset.Position(d.Pos).Line
+		}
+		return lines
+	}
+	defer func() {
+		require.NoError(t, config.Analyzer.Flags.Set(config.MaxErrorsPerPackageFlag, "0"))
+		require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, ""))
+	}()
+
+	// The first diagnostics are kept, where the last one notes the number of the dropped ones.
+	require.NoError(t, config.Analyzer.Flags.Set(config.MaxErrorsPerPackageFlag, "2"))
+	results := analysistest.Run(ignoreWants{}, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{17, 21}, lines(results[0]))
+	require.Contains(t, results[0].Diagnostics[1].Message, "... and 3 more in this package")
+
+	// The errors are kept in preference to the warnings.
+	require.NoError(t, config.Analyzer.Flags.Set(config.WarnCategoriesFlag, diagnostic.CategoryFuncReturn))
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{17, 33}, lines(results[0]))
+	require.NoError(t, config.Analyzer.Flags.Set(config.MaxErrorsPerPackageFlag, "4"))
+	results = analysistest.Run(ignoreWants{}, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Equal(t, []int{17, 21, 33, 38}, lines(results[0]))
+
+	// Negative limits are rejected.
+	require.NoError(t, config.Analyzer.Flags.Set(config.MaxErrorsPerPackageFlag, "-1"))
+	results = analysistest.Run(ignoreWants{}, testdata, config.Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.ErrorContains(t, results[0].Err, config.MaxErrorsPerPackageFlag)
+}
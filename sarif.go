@@ -0,0 +1,238 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nilaway
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	_sarifVersion = "2.1.0"
+	_sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	_sarifRuleID  = "nilaway"
+	// _sarifToolName is the name of the tool, which is identical to the name of the top-level
+	// analyzer (we cannot refer to Analyzer.Name here due to initialization cycles).
+	_sarifToolName = "nilaway"
+)
+
+// The following types model the subset of the SARIF 2.1.0 format that we produce. See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html for the specification.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+		CodeFlows []sarifCodeFlow `json:"codeFlows,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+		Message          *sarifMessage         `json:"message,omitempty"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+	sarifCodeFlow struct {
+		ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+	}
+	sarifThreadFlow struct {
+		Locations []sarifThreadFlowLocation `json:"locations"`
+	}
+	sarifThreadFlowLocation struct {
+		Location sarifLocation `json:"location"`
+	}
+)
+
+// sarifWriter accumulates the SARIF results of all packages analyzed in the process and writes
+// them to a single file. Since the analysis drivers do not offer a hook at the end of the
+// analysis, the complete file is rewritten whenever new results are added.
+type sarifWriter struct {
+	mu   sync.Mutex
+	path string
+	// results stores the results keyed by their JSON encoding, which also de-duplicates the
+	// results for files that belong to multiple packages (e.g., "foo" and "foo.test").
+	results map[string]sarifResult
+	// written indicates whether the file has been written at least once.
+	written bool
+}
+
+// _sarifWriters stores the sarifWriter for each output file.
+var _sarifWriters sync.Map
+
+// writeSARIF adds the diagnostics of the pass to the SARIF file at the given path, where the
+// diagnostics whose categories are warnings (as decided by isWarning) have the "warning" level.
+func writeSARIF(path string, pass *analysis.Pass, diagnostics []analysis.Diagnostic, isWarning func(category string) bool) error {
+	w, _ := _sarifWriters.LoadOrStore(path, &sarifWriter{path: path, results: make(map[string]sarifResult)})
+	return w.(*sarifWriter).add(pass.Fset, diagnostics, isWarning)
+}
+
+// add converts the diagnostics to SARIF results and rewrites the file if there are new results.
+func (w *sarifWriter) add(fset *token.FileSet, diagnostics []analysis.Diagnostic, isWarning func(category string) bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := !w.written
+	for _, d := range diagnostics {
+		result := newSARIFResult(fset, d)
+		if isWarning(d.Category) {
+			result.Level = "warning"
+		}
+		key, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("encode SARIF result: %w", err)
+		}
+		if _, ok := w.results[string(key)]; !ok {
+			w.results[string(key)] = result
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	// Sort the results for deterministic output since packages are analyzed in parallel.
+	keys := make([]string, 0, len(w.results))
+	for k := range w.results {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := w.results[keys[i]].Locations[0].PhysicalLocation, w.results[keys[j]].Locations[0].PhysicalLocation
+		if a.ArtifactLocation.URI != b.ArtifactLocation.URI {
+			return a.ArtifactLocation.URI < b.ArtifactLocation.URI
+		}
+		if a.Region.StartLine != b.Region.StartLine {
+			return a.Region.StartLine < b.Region.StartLine
+		}
+		if a.Region.StartColumn != b.Region.StartColumn {
+			return a.Region.StartColumn < b.Region.StartColumn
+		}
+		return keys[i] < keys[j]
+	})
+	results := make([]sarifResult, 0, len(keys))
+	for _, k := range keys {
+		results = append(results, w.results[k])
+	}
+
+	log := sarifLog{
+		Version: _sarifVersion,
+		Schema:  _sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           _sarifToolName,
+				InformationURI: "https://github.com/uber-go/nilaway",
+				Rules:          []sarifRule{{ID: _sarifRuleID, ShortDescription: sarifMessage{Text: _doc}}},
+			}},
+			Results: results,
+		}},
+	}
+	content, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode SARIF log: %w", err)
+	}
+
+	if err := writeFileAtomically(w.path, content); err != nil {
+		return fmt.Errorf("write SARIF file: %w", err)
+	}
+	w.written = true
+	return nil
+}
+
+// writeFileAtomically writes to a temporary file first and then renames it to the given path,
+// such that readers never observe a partially-written file.
+func writeFileAtomically(path string, content []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newSARIFResult converts a diagnostic to a SARIF result, where the related information of the
+// diagnostic (i.e., the nodes of the nil flow) is converted to a code flow.
+func newSARIFResult(fset *token.FileSet, d analysis.Diagnostic) sarifResult {
+	result := sarifResult{
+		RuleID:    _sarifRuleID,
+		Level:     "error",
+		Message:   sarifMessage{Text: d.Message},
+		Locations: []sarifLocation{{PhysicalLocation: newSARIFPhysicalLocation(fset.Position(d.Pos))}},
+	}
+	if len(d.Related) > 0 {
+		flow := sarifThreadFlow{Locations: make([]sarifThreadFlowLocation, 0, len(d.Related))}
+		for _, r := range d.Related {
+			flow.Locations = append(flow.Locations, sarifThreadFlowLocation{Location: sarifLocation{
+				PhysicalLocation: newSARIFPhysicalLocation(fset.Position(r.Pos)),
+				Message:          &sarifMessage{Text: r.Message},
+			}})
+		}
+		result.CodeFlows = []sarifCodeFlow{{ThreadFlows: []sarifThreadFlow{flow}}}
+	}
+	return result
+}
+
+// newSARIFPhysicalLocation converts the position to a SARIF physical location, where the file is
+// made relative to the current working directory if possible.
+func newSARIFPhysicalLocation(position token.Position) sarifPhysicalLocation {
+	uri := position.Filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, uri); err == nil && !strings.HasPrefix(rel, "..") {
+			uri = rel
+		}
+	}
+	return sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(uri)},
+		Region:           sarifRegion{StartLine: position.Line, StartColumn: position.Column},
+	}
+}
@@ -0,0 +1,42 @@
+// Package boundaryonly tests reporting only the potential nil panics concerning the exported
+// sites.
+package boundaryonly
+
+// Exported returns nil for its result, which is an exported site.
+func Exported() *int {
+	return nil
+}
+
+func useExported() int {
+	return *Exported() //want "returned from `Exported\\(\\)`"
+}
+
+func internal() *int {
+	return nil
+}
+
+func useInternal() int {
+	return *internal()
+}
+
+// ExportedParam dereferences its param, which is an exported site.
+func ExportedParam(p *int) int {
+	return *p //want "passed as arg `p` to `ExportedParam\\(\\)`"
+}
+
+func passNil() int {
+	return ExportedParam(nil)
+}
+
+func internalParam(p *int) int {
+	return *p
+}
+
+func passNilInternal() int {
+	return internalParam(nil)
+}
+
+func local() int {
+	var p *int
+	return *p
+}
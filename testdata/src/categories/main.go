@@ -0,0 +1,39 @@
+// Package categories tests the categories of the diagnostics.
+package categories
+
+type S struct {
+	f *int
+}
+
+func get() *int {
+	return nil
+}
+
+func (s *S) get() *int {
+	return nil
+}
+
+func mapRead(m map[string]*int) int {
+	return *m["key"] //want "dereferenced"
+}
+
+func funcReturn() int {
+	return *get() //want "dereferenced"
+}
+
+func methodReturn(s *S) int {
+	return *s.get() //want "dereferenced"
+}
+
+func (s *S) reset() {
+	s.f = nil
+}
+
+func fieldAccess(s *S) int {
+	return *s.f //want "dereferenced"
+}
+
+func uncategorized() int {
+	var p *int
+	return *p //want "dereferenced"
+}
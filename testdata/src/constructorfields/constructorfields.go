@@ -0,0 +1,67 @@
+// Package constructorfields is meant to check that the nilability of the pointer fields of the
+// structs is seeded from their constructors if configured.
+package constructorfields
+
+import "errors"
+
+type T struct {
+	set    *int
+	unset  *int
+	mixed  *int
+	nilled *int
+}
+
+func NewT(p *int) *T {
+	return &T{set: p, mixed: p, nilled: nil}
+}
+
+func NewTFromValue(v int) (*T, error) {
+	if v < 0 {
+		return nil, errors.New("negative value")
+	}
+	return &T{set: &v}, nil
+}
+
+func testSet(t *T) int {
+	return *t.set
+}
+
+func testUnset(t *T) int {
+	return *t.unset //want "left nil by the constructor `NewT.*`"
+}
+
+func testMixed(t *T) int {
+	return *t.mixed //want "left nil by the constructor `NewTFromValue.*`"
+}
+
+func testNilled(t *T) int {
+	return *t.nilled //want "left nil by the constructor `NewT.*`"
+}
+
+type V struct {
+	p *int
+}
+
+func NewV(p *int) *V {
+	// The nil assigned into the nonnil field below is reported here.
+	return &V{p: p} //want "set by every constructor"
+}
+
+func testAssignNil(v *V) {
+	v.p = nil
+}
+
+// U is not built by a recognized constructor, since its constructor does not return a composite
+// literal directly.
+type U struct {
+	p *int
+}
+
+func NewU() *U {
+	u := &U{}
+	return u
+}
+
+func testNotConstructor(u *U) int {
+	return *u.p
+}
@@ -0,0 +1,26 @@
+// Package derefcolumns tests that the diagnostics point at the exact columns of the dereferences.
+package derefcolumns
+
+type C struct{ v int }
+
+type B struct{ c *C }
+
+type A struct{ b *B }
+
+func newA() *A { return &A{b: &B{}} }
+
+func selector() {
+	a := newA()
+	a.b = nil
+	print(a.b.c.v) //want "accessed field `c`"
+}
+
+func index() {
+	var s []int
+	print(s[0]) //want "sliced into"
+}
+
+func star() {
+	var p *int
+	print(1 + *p) //want "dereferenced"
+}
@@ -0,0 +1,9 @@
+// Package distrust is meant to check that the facts exported for the distrusted packages are
+// ignored if configured.
+package distrust
+
+import "distrust/upstream"
+
+func testDeref() int {
+	return *upstream.Get() //want "returned from `Get.*`"
+}
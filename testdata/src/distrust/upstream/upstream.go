@@ -0,0 +1,8 @@
+// Package upstream is meant to be imported by package distrust, such that the facts exported for
+// it can be ignored.
+package upstream
+
+// Get always returns nil, which is known to the downstream packages only by the exported facts.
+func Get() *int {
+	return nil
+}
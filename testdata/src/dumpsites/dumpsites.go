@@ -0,0 +1,12 @@
+// Package dumpsites is meant to check dumping the exported sites that match a regular expression.
+package dumpsites
+
+var Global *int
+
+func Nilable() *int {
+	return nil
+}
+
+func FromGlobal() *int {
+	return Global
+}
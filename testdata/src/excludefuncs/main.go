@@ -0,0 +1,40 @@
+// Package excludefuncs tests that the diagnostics reported within the excluded functions are
+// suppressed, while the diagnostics elsewhere caused by them are still reported.
+package excludefuncs
+
+var global *int
+
+func excluded() *int {
+	var x *int
+	print(*x)
+
+	// Closures are covered by their enclosing function.
+	func() {
+		var y *int
+		print(*y)
+	}()
+	return nil
+}
+
+func callsExcluded() {
+	// The nilable result of the excluded function is still reported here.
+	print(*excluded()) //want "result 0 of `excluded\\(\\)`"
+}
+
+type T struct{}
+
+func (*T) Method() {
+	var x *int
+	print(*x)
+}
+
+// Value is not excluded since the exclude entry only names Method.
+func (T) Value() {
+	var x *int
+	print(*x) //want "unassigned variable `x` dereferenced"
+}
+
+func included() {
+	var x *int
+	print(*x) //want "unassigned variable `x` dereferenced"
+}
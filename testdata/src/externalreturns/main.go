@@ -0,0 +1,40 @@
+// Package externalreturns tests that the pointer results of the functions in packages that are not
+// analyzed are treated as nilable if configured so.
+package externalreturns
+
+import "ignoredpkg1"
+
+func deref() int {
+	x := ignoredpkg1.NewInt()
+	return *x //want "result 0 of `NewInt\\(\\)`"
+}
+
+func checked() int {
+	x := ignoredpkg1.NewInt()
+	if x == nil {
+		return 0
+	}
+	return *x
+}
+
+func flow() int {
+	return *passThrough() //want "result 0 of `NewInt\\(\\)`"
+}
+
+func passThrough() *int {
+	return ignoredpkg1.NewInt()
+}
+
+func withErr() int {
+	x, err := ignoredpkg1.NewIntWithErr()
+	if err != nil {
+		return 0
+	}
+	// The result is guarded by the error following the error contract, hence it is still
+	// considered nonnil here.
+	return *x
+}
+
+func nonPointer() int {
+	return ignoredpkg1.Num() + 1
+}
@@ -0,0 +1,21 @@
+// Package noinfer tests that the pointer results of the functions in packages that are not
+// analyzed are treated as nilable if configured so, even if inference is disabled.
+//
+// <nilaway no inference>
+package noinfer
+
+import "ignoredpkg1"
+
+func deref() int {
+	x := ignoredpkg1.NewInt()
+	return *x //want "result 0 of `NewInt\\(\\)`"
+}
+
+// Without inference, the result of this function is nonnil by default.
+func passThrough() *int {
+	return ignoredpkg1.NewInt() //want "result 0 of `NewInt\\(\\)`"
+}
+
+func nonPointer() int {
+	return ignoredpkg1.Num() + 1
+}
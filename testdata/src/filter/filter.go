@@ -0,0 +1,19 @@
+// Package filter tests suppressing the diagnostics via the custom filters.
+package filter
+
+// nilable(f)
+type S struct {
+	f *int
+}
+
+func nilable() *int {
+	return nil
+}
+
+func fieldAccess(s *S) int {
+	return *s.f
+}
+
+func funcReturn() int {
+	return *nilable() //want "dereferenced"
+}
@@ -0,0 +1,17 @@
+// Package focus tests focusing the analysis on a single file or function.
+package focus
+
+func Focused() int {
+	// The annotation of the function in the unfocused file is still honored.
+	p := fromOther()
+	return *p //want "dereferenced"
+}
+
+func nilPtr() *int {
+	return nil
+}
+
+func alsoFocused() int {
+	p := nilPtr()
+	return *p //want "dereferenced"
+}
@@ -0,0 +1,11 @@
+package focus
+
+// nilable(result 0)
+func fromOther() *int {
+	return nil
+}
+
+func unfocused() int {
+	var p *int
+	return *p
+}
@@ -0,0 +1,82 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file tests the closure variables read by the deferred function literals, which are invoked
+// when the function returns.
+// <nilaway anonymous function enable>
+package anonymousfunction
+
+type D struct {
+	f int
+}
+
+func deferredReadsNil() {
+	p := &D{}
+	defer func() {
+		print(p.f) //want "literal `nil` passed as arg `p`"
+	}()
+	p = nil
+}
+
+func deferredReadsNilOnSomePath(cond bool) {
+	p := &D{}
+	defer func() {
+		print(p.f) //want "literal `nil` passed as arg `p`"
+	}()
+	if cond {
+		p = nil
+	}
+}
+
+func deferredReadsNilBeforeReturn(cond bool) int {
+	p := &D{}
+	defer func() {
+		print(p.f) //want "literal `nil` passed as arg `p`"
+	}()
+	if cond {
+		p = nil
+		return 1
+	}
+	return 0
+}
+
+func deferredReadsNonnil() {
+	p := &D{}
+	defer func() {
+		print(p.f)
+	}()
+	p = nil
+	p = &D{f: 1}
+}
+
+// The returns before the defer statements do not invoke the deferred function literals.
+func deferredAfterReturn(cond bool) {
+	if cond {
+		return
+	}
+	var p *D
+	p = &D{}
+	defer func() {
+		print(p.f)
+	}()
+}
+
+// The arguments of the deferred calls are evaluated at the defer statements.
+func deferredArg() {
+	p := &D{}
+	defer func(q *D) {
+		print(q.f)
+	}(p)
+	p = nil
+}
@@ -184,3 +184,53 @@ func testArrayAliasPtr(aPtr *blocks, a blocks, bPtr *blockSlice, b blockSlice) {
 	for range b {
 	}
 }
+
+// Test that the elements of local arrays that are never assigned hold the zero value nil.
+
+type elem struct{ f int }
+
+func testUnassignedLocalArray() int {
+	var a [3]*elem
+	return a[0].f //want "unassigned element of array `a` accessed field `f`"
+}
+
+func testUnassignedLocalArrayGuarded() int {
+	var a [3]*elem
+	if a[1] != nil {
+		return a[1].f
+	}
+	return 0
+}
+
+func testAssignedLocalArrayElem() int {
+	var a [3]*elem
+	a[2] = &elem{}
+	return a[2].f
+}
+
+func testPartialArrayLiteral() int {
+	a := [3]*elem{&elem{}, &elem{}}
+	return a[0].f + a[1].f + a[2].f //want "unassigned element of array `a` accessed field `f`"
+}
+
+func testFullArrayLiteral(x *elem) int {
+	a := [2]*elem{&elem{}, x}
+	return a[0].f + a[1].f
+}
+
+// nilable(x)
+func testArrayLiteralFromNilable(x *elem) int {
+	a := [2]*elem{&elem{}, x}
+	return a[0].f + a[1].f //want "function parameter `x` accessed field `f`"
+}
+
+func testKeyedArrayLiteral() int {
+	a := [4]*elem{1: &elem{}, &elem{}}
+	b := a
+	return a[1].f + a[2].f + b[3].f //want "unassigned element of array `a` accessed field `f`"
+}
+
+func testArrayLiteralNil() int {
+	a := [1]*elem{nil}
+	return a[0].f //want "literal `nil` accessed field `f`"
+}
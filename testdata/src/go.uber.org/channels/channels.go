@@ -654,3 +654,23 @@ func testSendRecvFuncRet() {
 
 	func(...any) {}(v1, v2)
 }
+
+// BELOW TESTS CHECK THE NILABILITY OF THE ELEMENTS RECEIVED FROM CHANNELS
+
+// nonnil(nonnilChanParam)
+func testDerefRecv(nonnilChanParam chan *int) {
+	print(*(<-nilableChan)) //want "dereferenced"
+	print(*(<-nonNilChan))
+	print(*(<-nonnilChanParam))
+}
+
+// nonnil(ch)
+func testOkRecvZeroValue(ch chan *int) {
+	v, ok := <-ch
+	if !ok {
+		// The channel is closed, so v is the zero value (nil) of the element type.
+		print(*v) //want "dereferenced"
+		return
+	}
+	print(*v)
+}
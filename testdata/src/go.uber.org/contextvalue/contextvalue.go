@@ -0,0 +1,72 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contextvalue tests the modeling of the values read from contexts by
+// `context.Context.Value`, which are nil if the keys are absent from the contexts.
+package contextvalue
+
+import "context"
+
+type key struct{}
+
+type user struct {
+	name string
+}
+
+func singleResult(ctx context.Context) string {
+	u := ctx.Value(key{}).(*user) //want "type-asserted without checking `ok`"
+	return u.name
+}
+
+func singleResultParen(ctx context.Context) *user {
+	return (ctx.Value(key{})).(*user) //want "type-asserted without checking `ok`"
+}
+
+func okChecked(ctx context.Context) string {
+	u, ok := ctx.Value(key{}).(*user)
+	if !ok {
+		return ""
+	}
+	return u.name
+}
+
+func okUnchecked(ctx context.Context) string {
+	u, _ := ctx.Value(key{}).(*user)
+	return u.name //want "lacking guarding"
+}
+
+func nilChecked(ctx context.Context) *user {
+	if v := ctx.Value(key{}); v != nil {
+		return v.(*user)
+	}
+	return nil
+}
+
+func typeSwitch(ctx context.Context) string {
+	switch u := ctx.Value(key{}).(type) {
+	case *user:
+		return u.name
+	default:
+		return ""
+	}
+}
+
+func fromStorage(ctx context.Context) string {
+	ctx = context.WithValue(ctx, key{}, &user{name: "abc"})
+	u, ok := ctx.Value(key{}).(*user)
+	if ok {
+		return u.name
+	}
+	return ""
+}
@@ -290,7 +290,7 @@ func testDeepNilStruct(s *S) *S {
 	case 6:
 		return s.g[0].g[0]
 	case 7:
-		return s.f[0].f[0] //want "deep read from field `f`" "returned"
+		return s.f[0].f[0] //want "deep read from field `f` accessed" "returned"
 	default:
 		return s.f[0].g[0] //want "deep read from field `f`"
 	}
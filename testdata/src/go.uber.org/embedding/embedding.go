@@ -0,0 +1,98 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+These tests check that accessing a field promoted through embedded pointers is treated as a
+dereference of each of the embedded pointers along the way.
+
+<nilaway no inference>
+*/
+package embedding
+
+type Inner struct {
+	f int
+}
+
+// nilable(Inner)
+type Base struct {
+	*Inner
+	g int
+}
+
+// nilable(Base)
+type A struct {
+	*Base
+}
+
+type B struct {
+	*Base
+}
+
+type C struct {
+	Base
+}
+
+func readPromoted(a *A) int {
+	return a.g // want "accessed field `g`"
+}
+
+func readPromotedGuarded(a *A) int {
+	if a.Base != nil {
+		return a.g
+	}
+	return 0
+}
+
+func readMultiLevel(b *B) int {
+	// `b.Base` is nonnil, but `b.Base.Inner` is nilable.
+	return b.f // want "accessed field `f`"
+}
+
+func readMultiLevelGuarded(b *B) int {
+	if b.Inner == nil {
+		return 0
+	}
+	return b.f
+}
+
+func readMultiLevelNilable(a *A) int {
+	if a.Base == nil {
+		return 0
+	}
+	return a.f // want "accessed field `f`"
+}
+
+func writePromoted(a *A) {
+	a.g = 1 // want "accessed field `g`"
+}
+
+func readThroughValue(c *C) int {
+	// Only `c.Base.Inner` is dereferenced, `c.Base` is a struct value.
+	if c.Inner != nil {
+		return c.f + c.g
+	}
+	return c.g
+}
+
+func explicit(a *A) int {
+	return a.Base.g // want "accessed field `g`"
+}
+
+func newB() *B {
+	return &B{Base: &Base{}}
+}
+
+func readFromCall() int {
+	return newB().f // want "accessed field `f`"
+}
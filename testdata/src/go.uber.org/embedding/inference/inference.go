@@ -0,0 +1,33 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inference checks that the nilability of the embedded pointers is inferred and flows to
+// the accesses of the promoted fields.
+package inference
+
+type Base struct {
+	g int
+}
+
+type A struct {
+	*Base
+}
+
+func reset(a *A) {
+	a.Base = nil
+}
+
+func read(a *A) int {
+	return a.g // want "accessed field `g`"
+}
@@ -0,0 +1,106 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errorsas tests the modeling of the targets of `errors.As`, which are nilable unless the
+// call is checked to return true.
+package errorsas
+
+import "errors"
+
+type myErr struct {
+	code int
+}
+
+func (e *myErr) Error() string { return "my error" }
+
+func checked(err error) int {
+	var target *myErr
+	if errors.As(err, &target) {
+		return target.code
+	}
+	return 0
+}
+
+func unchecked(err error) int {
+	var target *myErr
+	errors.As(err, &target)
+	return target.code //want "value assigned to the target of `errors.As` lacking guarding"
+}
+
+func negated(err error) int {
+	var target *myErr
+	if !errors.As(err, &target) {
+		return target.code //want "lacking guarding"
+	}
+	return target.code
+}
+
+func earlyReturn(err error) int {
+	var target *myErr
+	if !errors.As(err, &target) {
+		return 0
+	}
+	return target.code
+}
+
+func conjunction(err error) bool {
+	var target *myErr
+	if errors.As(err, &target) && target.code > 0 {
+		return true
+	}
+	return false
+}
+
+func okVar(err error) int {
+	var target *myErr
+	ok := errors.As(err, &target)
+	if ok {
+		return target.code
+	}
+	return 0
+}
+
+func okVarReassigned(err error) int {
+	var target *myErr
+	ok := errors.As(err, &target)
+	ok = true
+	if ok {
+		return target.code //want "lacking guarding"
+	}
+	return 0
+}
+
+// Even a non-nil target is left unchanged only if no error matches, hence it is nilable unless
+// checked as well.
+func presetTarget(err error) int {
+	target := &myErr{}
+	errors.As(err, &target)
+	return target.code //want "lacking guarding"
+}
+
+func reassignedAfter(err error) int {
+	var target *myErr
+	errors.As(err, &target)
+	target = &myErr{}
+	return target.code
+}
+
+// Only the recognizable pattern `errors.As(err, &target)` is modeled.
+func pointerToTarget(err error) int {
+	target := new(*myErr)
+	if errors.As(err, target) {
+		return (*target).code
+	}
+	return 0
+}
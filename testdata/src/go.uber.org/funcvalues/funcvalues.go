@@ -0,0 +1,108 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package funcvalues tests the tracking of the nilability of function values, i.e., calling a
+// possibly-nil function-typed variable, parameter, field or result.
+package funcvalues
+
+// nilable(hook)
+var hook func()
+
+var handler = func() {}
+
+func callNilVar() {
+	var fn func()
+	fn() //want "called as a function"
+}
+
+func callGuardedVar(fn func()) {
+	if fn != nil {
+		fn()
+	}
+}
+
+func callParam(fn func()) {
+	fn() //want "called as a function"
+}
+
+func passNil() {
+	callParam(nil)
+	callGuardedVar(nil)
+}
+
+func callGlobal() {
+	hook() //want "called as a function"
+	handler()
+	if hook != nil {
+		hook()
+	}
+}
+
+type callbacks struct {
+	onDone func(int) int
+}
+
+func callField(c *callbacks) int {
+	c.onDone = nil
+	return c.onDone(1) //want "called as a function"
+}
+
+func nilableFunc() func() {
+	return nil
+}
+
+func nonnilFunc() func() {
+	return func() {}
+}
+
+func callResult() {
+	nilableFunc()() //want "called as a function"
+	nonnilFunc()()
+	func() {}()
+}
+
+func callFromMap(handlers map[string]func()) {
+	handlers["a"]() //want "called as a function"
+	if h, ok := handlers["b"]; ok {
+		h()
+	}
+}
+
+type T struct {
+	x int
+}
+
+func (t *T) get() int {
+	return t.x
+}
+
+func callMethodValue() int {
+	t := &T{}
+	// the method value itself is never nil, even though it captures a receiver that may be nil
+	get := t.get
+	return get()
+}
+
+func generic[E any](e E) E {
+	return e
+}
+
+func callGeneric() int {
+	f := generic[int]
+	return generic[int](f(1))
+}
+
+func conversions(x int) int64 {
+	return int64(x) + int64(len("abc"))
+}
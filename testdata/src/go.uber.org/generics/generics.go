@@ -13,9 +13,10 @@
 // limitations under the License.
 
 // generics package tests NilAway's ability to handle generics introduced in Go 1.18.
-// Currently, we do not have support for generics yet, so this package simply tests that
+// Currently, we only have basic support for generics (the sites of a generic function are shared
+// by all of its instantiations, see the inference subpackage), so this package mostly tests that
 // NilAway should not panic when seeing ASTs related to generics.
-// TODO: Add support for generics.
+// TODO: Add per-instantiation support for generics.
 //
 // <nilaway no inference>
 package generics
@@ -0,0 +1,55 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inference tests that the nilability of the values of type parameters flows through
+// generic functions with inference enabled. Note that the sites of a generic function are shared
+// by all of its instantiations.
+package inference
+
+func first[T any](s []T) T {
+	var zero T
+	if len(s) == 0 {
+		return zero
+	}
+	return s[0]
+}
+
+func identity[T any](x T) T {
+	return x
+}
+
+type number interface {
+	~int | ~int64 | float64
+}
+
+func zeroNumber[T number]() T {
+	var zero T
+	return zero
+}
+
+func testFirst() {
+	p := first[*int](nil)
+	print(*p) //want "unassigned variable `zero` returned"
+}
+
+func testIdentity() {
+	var n *int
+	q := identity(n)
+	print(*q) //want "unassigned variable `n` passed"
+}
+
+func testNonNilTypeParam() int {
+	// Type parameters constrained to types that bar nilness are not nilable.
+	return zeroNumber[int]() + 1
+}
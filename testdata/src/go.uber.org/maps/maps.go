@@ -692,3 +692,25 @@ func testExplicitBool(mp map[int]*int, i int) *int {
 	}
 	return &i
 }
+
+// Writing to a nil map panics, while reading from it yields the zero value, and deleting from
+// (or clearing) it is a no-op.
+func testMapWritesAndReads(nilableIntMap map[int]int, i int) int {
+	switch i {
+	case 1:
+		nilableIntMap[0] = 1 //want "written to at an index"
+	case 2:
+		nilableIntMap[0] += 1 //want "written to at an index"
+	case 3:
+		nilableIntMap[0]++ //want "written to at an index"
+	case 4:
+		nilableIntMap[0], nilableIntMap[1] = 1, 2 //want "written to at an index" "written to at an index"
+	case 5:
+		delete(nilableIntMap, 0)
+	case 6:
+		for k, v := range nilableIntMap {
+			return k + v
+		}
+	}
+	return nilableIntMap[0] + len(nilableIntMap)
+}
@@ -0,0 +1,51 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This test file tests the affiliations witnessed at the arguments of variadic parameters and at
+// the values sent on channels, where the element types are interfaces.
+// <nilaway no inference>
+
+package methodimplementation
+
+type IA14 interface {
+	Get() *int //want "returned as result 0 from interface method `IA14.Get\\(\\)` \\(implemented by `A14.Get\\(\\)`\\)"
+}
+
+type IB14 interface {
+	Get() *int //want "returned as result 0 from interface method `IB14.Get\\(\\)` \\(implemented by `B14.Get\\(\\)`\\)"
+}
+
+type A14 struct{}
+
+// nilable(result 0)
+func (A14) Get() *int { return nil }
+
+type B14 struct{}
+
+// nilable(result 0)
+func (B14) Get() *int { return nil }
+
+func takesVariadic(prefix string, xs ...IA14) {}
+
+func variadicArgs() {
+	takesVariadic("prefix", A14{})
+	// The spread form passes the slice as is, which is handled like any other argument.
+	var xs []IA14
+	takesVariadic("prefix", xs...)
+}
+
+func sendOnChannel() {
+	ch := make(chan IB14, 1)
+	ch <- B14{}
+}
@@ -199,3 +199,75 @@ func oneTrueNonNil() *T {
 		return c
 	}
 }
+
+// The following tests check that each lhs of a multiple assignment is bound to the result at its
+// own index, such that only the nilable results are reported.
+
+func returnEachOfCenterNonNil() *T {
+	a, b, c := centerNonNil()
+	switch 0 {
+	case 1:
+		return a //want "returned"
+	case 2:
+		return b
+	default:
+		return c //want "returned"
+	}
+}
+
+func returnWithBlanks() *T {
+	_, b, _ := centerNonNil()
+	_, _, c := rightNonNil()
+	switch 0 {
+	case 1:
+		return b
+	default:
+		return c
+	}
+}
+
+// nilable(result 0)
+func nilableT() *T {
+	return nil
+}
+
+func nonnilT() *T {
+	return &T{}
+}
+
+func swappedCalls() *T {
+	var a, b *T
+	a, b = nilableT(), nonnilT()
+	switch 0 {
+	case 1:
+		return b
+	default:
+		return a //want "returned"
+	}
+}
+
+func swappedCallsReversed() *T {
+	var a, b *T
+	b, a = nilableT(), nonnilT()
+	switch 0 {
+	case 1:
+		return a
+	default:
+		return b //want "returned"
+	}
+}
+
+func unknownResults(fn func() (*T, *T)) *T {
+	// The results of calls to function variables are unknown, but the lhs are still assigned.
+	var a *T
+	_, a = fn()
+	b, c := fn()
+	switch 0 {
+	case 1:
+		return a
+	case 2:
+		return b
+	default:
+		return c
+	}
+}
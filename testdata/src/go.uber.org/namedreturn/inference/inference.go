@@ -0,0 +1,101 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inference tests the handling of the named results under inference, where the value of a
+// named result at each `return` (including bare returns) flows to the result of the function.
+package inference
+
+import "errors"
+
+type T struct {
+	f int
+}
+
+func neverAssigned() (p *T) {
+	return
+}
+
+func earlyBareReturn(c bool) (p *T) {
+	if c {
+		return
+	}
+	p = &T{}
+	return
+}
+
+func someBranches(c bool) (p *T) {
+	if c {
+		p = &T{}
+	}
+	return
+}
+
+func allBranches(c bool) (p *T) {
+	if c {
+		p = &T{}
+	} else {
+		p = &T{f: 1}
+	}
+	return
+}
+
+func shadowed(c bool) (p *T) {
+	if c {
+		p := &T{}
+		_ = p
+	}
+	return
+}
+
+func explicitReturnOfNamed(c bool) (p *T) {
+	if c {
+		return &T{}
+	}
+	return p
+}
+
+// The bare return with both results unassigned violates the error contract, i.e., the result is nil
+// even though the error is nil as well.
+func earlyBareReturnWithErr(c bool) (p *T, err error) {
+	if c {
+		return
+	}
+	p = &T{}
+	return
+}
+
+func earlyBareReturnWithAssignedErr(c bool) (p *T, err error) {
+	if c {
+		err = errors.New("some error")
+		return
+	}
+	p = &T{}
+	return
+}
+
+func use(c bool) {
+	print(neverAssigned().f)          //want "unassigned variable `p` returned from `neverAssigned\\(\\)` via named return `p`"
+	print(earlyBareReturn(c).f)       //want "unassigned variable `p` returned from `earlyBareReturn\\(\\)` via named return `p`"
+	print(someBranches(c).f)          //want "unassigned variable `p` returned from `someBranches\\(\\)` via named return `p`"
+	print(allBranches(c).f)           // safe, assigned on all branches
+	print(shadowed(c).f)              //want "unassigned variable `p` returned from `shadowed\\(\\)` via named return `p`"
+	print(explicitReturnOfNamed(c).f) //want "unassigned variable `p` returned from `explicitReturnOfNamed\\(\\)` in position 0"
+
+	if p, err := earlyBareReturnWithErr(c); err == nil {
+		print(p.f) //want "unassigned variable `p` returned from `earlyBareReturnWithErr\\(\\)` via named return `p`"
+	}
+	if p, err := earlyBareReturnWithAssignedErr(c); err == nil {
+		print(p.f)
+	}
+}
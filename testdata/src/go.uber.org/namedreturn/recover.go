@@ -0,0 +1,87 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file tests the named results assigned in the blocks that are only reachable after a panic
+// is recovered in the deferred functions.
+
+package namedreturn
+
+import "errors"
+
+func work() *int {
+	v := 1
+	return &v
+}
+
+func recoverToErr() (p *int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("recovered")
+		}
+	}()
+	p = work()
+	return p, nil
+}
+
+func recoverToNilAndErr() (p *int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// The nil result is fine since the error is non-nil.
+			p = nil
+			err = errors.New("recovered")
+		}
+	}()
+	return work(), nil
+}
+
+func recoverToNil() (p *int) {
+	defer func() {
+		if recover() != nil {
+			p = nil //want "literal `nil` returned from `recoverToNil.*` via named return `p`"
+		}
+	}()
+	return work()
+}
+
+func recoverToNilWithoutErr() (p *int, err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			p = nil //want "literal `nil` returned from `recoverToNilWithoutErr.*` via named return `p`"
+		}
+	}()
+	return work(), nil
+}
+
+// nilable(p)
+func recoverToNilable() (p *int) {
+	defer func() {
+		if r := recover(); r != nil {
+			p = nil
+		}
+	}()
+	return work()
+}
+
+func useRecovered() int {
+	p, err := recoverToErr()
+	if err != nil {
+		return 0
+	}
+	q, err := recoverToNilAndErr()
+	if err != nil {
+		return 0
+	}
+	return *p + *q
+}
@@ -0,0 +1,81 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nesteddeep tests the tracking of the nilability of nested containers (e.g., `[][]*T`,
+// `map[K][]*T` and `[]map[K]*T`) two levels deep, i.e., the nilability of the elements of the
+// elements of the containers.
+package nesteddeep
+
+var nestedGlobal = [][]*int{{new(int)}}
+
+func writeThenReadParam(x [][]*int) int {
+	x[0][0] = nil
+	return *x[1][1] //want "dereferenced"
+}
+
+func readParam(x [][]*int) int {
+	// without any nil flowing into the elements of `x`, its elements are nonnil
+	return *x[0][0]
+}
+
+func writeThenReadGlobal() int {
+	nestedGlobal[0][0] = nil
+	return *nestedGlobal[0][0] //want "dereferenced"
+}
+
+func writeGlobalElem(v *int) {
+	// assigning into the elements of the elements of `nestedGlobal` must not be treated as an
+	// assignment to `nestedGlobal` itself
+	nestedGlobal[0][0] = v
+}
+
+type container struct {
+	f [][]*int
+}
+
+func writeThenReadField(c *container) int {
+	c.f[0][0] = nil
+	return *c.f[1][1] //want "dereferenced"
+}
+
+func readGuardedMapOfSlices(m map[string][]*int) int {
+	if s, ok := m["a"]; ok {
+		return *s[0]
+	}
+	return 0
+}
+
+func readUnguardedSliceOfMaps(s []map[string]*int) int {
+	return *s[0]["a"] //want "lacking guarding"
+}
+
+func readGuardedSliceOfMaps(s []map[string]*int) int {
+	if v, ok := s[0]["a"]; ok {
+		return *v
+	}
+	return 0
+}
+
+func writeThenReadGuardedSliceOfMaps(s []map[string]*int) int {
+	s[0]["a"] = nil
+	if v, ok := s[1]["b"]; ok {
+		return *v //want "dereferenced"
+	}
+	return 0
+}
+
+func writeLocal() {
+	x := [][]*int{{new(int)}}
+	x[0][0] = nil
+}
@@ -0,0 +1,77 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nilablepackage tests the nilable package marker, which makes the unannotated sites of the
+// package nilable by default.
+//
+// nilaway:nilable-package
+package nilablepackage
+
+// nonnil(G)
+type S struct {
+	F *int
+	G *int
+	N int
+}
+
+func NewInt() *int {
+	return new(int)
+}
+
+// nonnil(result 0)
+func NewNonnilInt() *int {
+	return new(int)
+}
+
+func DerefParam(p *int) int {
+	return *p //want "dereferenced"
+}
+
+// nonnil(p)
+func DerefNonnilParam(p *int) int {
+	return *p
+}
+
+func DerefParamChecked(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func Use(s *S) int {
+	if s == nil {
+		return 0
+	}
+	switch s.N {
+	case 0:
+		return *NewInt() //want "dereferenced"
+	case 1:
+		return *NewNonnilInt()
+	case 2:
+		return *s.F //want "dereferenced"
+	default:
+		return *s.G
+	}
+}
+
+func PassNil() int {
+	// The param is nilable, so passing nil is safe.
+	return DerefParamChecked(nil)
+}
+
+// The receivers are not affected by the marker.
+func (s *S) Get() int {
+	return s.N
+}
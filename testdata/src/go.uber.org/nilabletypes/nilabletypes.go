@@ -66,7 +66,7 @@ func nilableTypesTest() interface{} {
 	case 8:
 		return i
 	case 9:
-		return f
+		return f //want "returned"
 	case 10:
 		return mi
 	case 11:
@@ -0,0 +1,61 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nolint checks that diagnostics can be suppressed by "//nolint" directives.
+package nolint
+
+func trailing() {
+	var x *int
+	print(*x) //nolint:nilaway // known false positive
+}
+
+func lineAbove() {
+	var x *int
+	//nolint:nilaway
+	print(*x)
+}
+
+func block() {
+	var x *int
+	//nolint:errcheck,nilaway
+	if true {
+		print(*x)
+		print(*x)
+	}
+	print(*x) //want "Potential nil panic"
+}
+
+//nolint:nilaway
+func wholeFunc() {
+	var x *int
+	print(*x)
+}
+
+func bare() {
+	var x *int
+	print(*x) //nolint
+}
+
+func otherLinter() {
+	var x *int
+	//nolint:errcheck
+	print(*x) //want "Potential nil panic"
+}
+
+func notAbove() {
+	var x *int
+	//nolint:nilaway
+
+	print(*x) //want "Potential nil panic"
+}
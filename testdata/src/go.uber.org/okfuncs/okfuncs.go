@@ -0,0 +1,84 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package okfuncs tests the modeling of the library functions with `(value, ok)` semantics, where
+// the value is nil if `ok` is false.
+package okfuncs
+
+import (
+	"math/big"
+	"sync"
+)
+
+// nonnil(result 0)
+func syncMapGuarded(m *sync.Map, k string) any {
+	v, ok := m.Load(k)
+	if ok {
+		return v
+	}
+	return 0
+}
+
+// nonnil(result 0)
+func syncMapEarlyReturn(m *sync.Map, k string) any {
+	v, ok := m.Load(k)
+	if !ok {
+		return 0
+	}
+	return v
+}
+
+// nonnil(result 0)
+func syncMapUnchecked(m *sync.Map, k string) any { //want "value returned from `\\(\\*sync.Map\\).Load` lacking guarding"
+	v, _ := m.Load(k)
+	return v
+}
+
+// nonnil(result 0)
+func syncMapLoadAndDelete(m *sync.Map, k string) any { //want "lacking guarding"
+	v, ok := m.LoadAndDelete(k)
+	if !ok {
+		return v
+	}
+	return v
+}
+
+func bigIntUnchecked(s string) big.Int {
+	z, _ := new(big.Int).SetString(s, 10)
+	return *z //want "lacking guarding"
+}
+
+func bigIntGuarded(s string) big.Int {
+	z, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return big.Int{}
+	}
+	return *z
+}
+
+func mapRead(m map[string]*int, k string) int {
+	v, ok := m[k]
+	if ok {
+		return *v
+	}
+	return 0
+}
+
+func chanRecv(ch chan *int) int {
+	v, ok := <-ch
+	if !ok {
+		return *v //want "lacking guarding"
+	}
+	return *v
+}
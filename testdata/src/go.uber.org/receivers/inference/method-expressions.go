@@ -0,0 +1,84 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file checks the receivers passed via method expressions (e.g., `(*B).foo(b)`) and captured
+// by method values (e.g., `f := b.foo`), which must be handled the same way as the receivers of
+// the method invocations.
+
+package inference
+
+type B struct {
+	f string
+}
+
+func (b *B) derefByExpr() string {
+	return b.f //want "literal `nil` used as receiver to call `derefByExpr.*`"
+}
+
+func (b *B) derefByValue() string {
+	return b.f //want "unassigned variable `b` used as receiver to call `derefByValue.*`"
+}
+
+func (b *B) derefWithArg(s *string) string {
+	return b.f + *s //want "literal `nil` used as receiver to call `derefWithArg.*`" "literal `nil` passed as arg `s`"
+}
+
+func (b *B) safe() string {
+	if b == nil {
+		return "<nil>"
+	}
+	return b.f
+}
+
+func (b B) byValue() string {
+	return b.f
+}
+
+type J interface {
+	foo() string
+}
+
+func testMethodExpr() {
+	// The first argument of a method expression is the receiver.
+	_ = (*B).derefByExpr(nil)
+	_ = (*B).safe(nil)
+	_ = (*B).derefWithArg(&B{}, nil)
+	_ = (*B).derefWithArg(nil, new(string))
+
+	// A value-receiver method called via a pointer implicitly dereferences the pointer.
+	_ = (*B).byValue(nil) //want "literal `nil` called `byValue.*`"
+	_ = B.byValue(B{})
+
+	// Calling an interface method on a nil interface panics.
+	_ = J.foo(nil) //want "literal `nil` called `foo.*`"
+
+	// The method expressions by themselves do not need any receivers.
+	f := (*B).derefByExpr
+	g := B.byValue
+	_, _ = f, g
+}
+
+func testMethodValue() {
+	var b *B
+
+	// The receiver is captured when the method value is created, and passed when it is called.
+	f := b.derefByValue
+	_ = f()
+	g := b.safe
+	_ = g()
+
+	// A value-receiver method value dereferences the pointer when it is created, even if it is
+	// never called.
+	_ = b.byValue //want "unassigned variable `b` called `byValue.*`"
+}
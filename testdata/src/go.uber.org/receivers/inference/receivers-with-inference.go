@@ -111,3 +111,59 @@ func testAffiliation() {
 	// FP since affiliations are not tracked for nilable receivers
 	newI2().foo() //want "result 0 of `newI2.*`"
 }
+
+// -----------------------------------
+// the below test checks that calling a pointer-receiver method on a nil receiver is only reported if the method
+// dereferences the receiver without a nil guard, including the methods of non-struct types. Calling a value-receiver
+// method via a nil pointer is always reported, since the pointer is implicitly dereferenced at the call site.
+
+type List struct {
+	next *List
+	val  int
+}
+
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return 1 + l.next.Len()
+}
+
+func (l *List) Val() int {
+	return l.val //want "read by method receiver"
+}
+
+func (l List) ValByValue() int {
+	return l.val
+}
+
+type Ints []int
+
+func (p *Ints) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(*p)
+}
+
+func (p *Ints) First() int {
+	return (*p)[0] //want "read by method receiver"
+}
+
+func (p Ints) Count() int {
+	return len(p)
+}
+
+func testNilSafeMethods() {
+	var l *List
+	print(l.Len())        // safe
+	print(l.Val())        // error
+	print(l.ValByValue()) //want "called `ValByValue.*`"
+
+	var p *Ints
+	print(p.Len())   // safe
+	print(p.First()) // error
+
+	var s Ints
+	print(s.Count()) // safe, since a nil slice is a valid value receiver
+}
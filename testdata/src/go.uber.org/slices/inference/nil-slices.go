@@ -0,0 +1,71 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+// The tests below make sure that only the operations panicking on nil slices (i.e., indexing and
+// non-zero slicing) are reported for the slices inferred to be nilable.
+
+func nilableSlice() []int {
+	if dummy {
+		return nil
+	}
+	return []int{1}
+}
+
+var dummy bool
+
+func testRangeOverNilable() int {
+	s := nilableSlice()
+	n := 0
+	for range s {
+		n++
+	}
+	for _, v := range nilableSlice() {
+		n += v
+	}
+	return n
+}
+
+func testAppendToNilable() []int {
+	s := nilableSlice()
+	return append(s, 1)
+}
+
+func testLenCapCopyOfNilable() int {
+	s := nilableSlice()
+	return len(s) + cap(s) + copy(s, []int{1}) + len(s[:0])
+}
+
+func testIndexNilable() int {
+	s := nilableSlice()
+	return s[0] //want "sliced into"
+}
+
+func takesSlice(s []int) int {
+	n := 0
+	for range s {
+		n++
+	}
+	s = append(s, 1)
+	return n + len(s) + s[0]
+}
+
+func takesSliceAndIndexes(s []int) int {
+	return s[0] //want "sliced into"
+}
+
+func testPassNilable() int {
+	return takesSlice(nil) + takesSliceAndIndexes(nil)
+}
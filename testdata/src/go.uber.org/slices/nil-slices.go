@@ -0,0 +1,112 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slices
+
+// The tests below make sure that only the operations panicking on nil slices (i.e., indexing and
+// non-zero slicing) are reported for nilable slices, while the ones that are safe on nil slices
+// (i.e., ranging, appending, len, cap, copy and zero slicing) are not.
+
+// nilable(s)
+func testRangeOverNilable(s []int) int {
+	n := 0
+	for range s {
+		n++
+	}
+	for i := range s {
+		n += i
+	}
+	for _, v := range s {
+		n += v
+	}
+	for i, v := range s {
+		n += i + v
+	}
+	return n
+}
+
+// nilable(s)
+func testAppendToNilable(s []int) []int {
+	s = append(s, 1)
+	return append(s, s...)
+}
+
+// nilable(s)
+func testLenCapOfNilable(s []int) int {
+	return len(s) + cap(s)
+}
+
+// nilable(s)
+func testCopyNilable(s []int) int {
+	return copy(s, nonNilSl) + copy(nonNilSl, s)
+}
+
+// nilable(s)
+func testZeroSlicingOfNilable(s []int) int {
+	return len(s[:0]) + len(s[0:]) + len(s[:])
+}
+
+// nilable(s)
+func testIndexNilable(s []int) int {
+	return s[0] //want "sliced into"
+}
+
+// nilable(s)
+func testIndexAssignNilable(s []int) {
+	s[0] = 1 //want "sliced into"
+}
+
+// nilable(s)
+func testSlicingOfNilable(s []int) []int {
+	return s[1:] //want "sliced into"
+}
+
+// nilable(s)
+func testIndexNilableAfterAppend(s []int) int {
+	s = append(s, 1)
+	return s[0]
+}
+
+// nilable(s)
+func testIndexNilableAfterLenCheck(s []int) int {
+	if len(s) > 0 {
+		return s[0]
+	}
+	return 0
+}
+
+// nilable(s)
+func testIndexNilableInRange(s []int) int {
+	n := 0
+	for i := range s {
+		n += s[i]
+	}
+	return n
+}
+
+// nilable(ss[])
+func testRangeOverDeeplyNilable(ss [][]int) int {
+	n := 0
+	for _, s := range ss {
+		for _, v := range s {
+			n += v
+		}
+		s = append(s, 1)
+		n += len(s)
+	}
+	for _, s := range ss {
+		n += s[0] //want "sliced into"
+	}
+	return n
+}
@@ -189,3 +189,5 @@ func NotEmpty(t TestingT, object interface{}, msgAndArgs ...interface{}) bool {
 
 // nilable(object)
 func NotEmptyf(t TestingT, object interface{}, msg string, args ...interface{}) bool { return true }
+
+func FailNow(t TestingT, failureMessage string, msgAndArgs ...interface{}) bool { return false }
@@ -0,0 +1,91 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package typealias tests the deep nilability of the aliases (`type T = U`) and the defined types
+(`type T U`) of annotated types. An alias denotes the aliased type itself and hence shares its
+site, while a defined type is a distinct site that inherits the annotation of the type it is
+defined from unless it has its own.
+
+<nilaway no inference>
+*/
+package typealias
+
+// nilable(A[])
+type A []*int
+
+type AAlias = A
+
+type ADef A
+
+type ADefDef ADef
+
+// nonnil(AOwn[])
+type AOwn A
+
+type B []*int
+
+// nilable(BDef[])
+type BDef B
+
+type BAlias = B
+
+// The annotation of an alias is ignored, since the alias has no site of its own.
+// nilable(PAlias[])
+type PAlias = []*int
+
+// nonnil(a)
+func readAlias(a AAlias) *int {
+	return a[0] //want "returned"
+}
+
+// nonnil(a)
+func readDefined(a ADef) *int {
+	return a[0] //want "returned"
+}
+
+// nonnil(a)
+func readDefinedOfDefined(a ADefDef) *int {
+	return a[0] //want "returned"
+}
+
+// nonnil(a)
+func readOverridden(a AOwn) *int {
+	return a[0]
+}
+
+// nonnil(b)
+func readOwn(b BDef) *int {
+	return b[0] //want "returned"
+}
+
+// nonnil(b)
+func readUnannotatedAlias(b BAlias) *int {
+	return b[0]
+}
+
+// nonnil(p)
+func readAnnotatedAlias(p PAlias) *int {
+	return p[0]
+}
+
+// nonnil(a)
+func writeAlias(a AAlias) {
+	a[0] = nil
+}
+
+// nonnil(a)
+func writeOverridden(a AOwn) {
+	a[0] = nil //want "assigned"
+}
@@ -0,0 +1,78 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package typeassertions tests the modeling of the type assertions, where the `v` in the
+// `v, ok := x.(*T)` form is nil if `ok` is false.
+package typeassertions
+
+type T struct {
+	f int
+}
+
+func panicking(x any) int {
+	v := x.(*T)
+	return v.f
+}
+
+func guarded(x any) int {
+	v, ok := x.(*T)
+	if ok {
+		return v.f
+	}
+	return 0
+}
+
+func guardedInit(x any) int {
+	if v, ok := x.(*T); ok {
+		return v.f
+	}
+	return 0
+}
+
+func falseBranch(x any) int {
+	v, ok := x.(*T)
+	if !ok {
+		return v.f //want "result of a type assertion to `\\*T` lacking guarding"
+	}
+	return 0
+}
+
+func earlyReturn(x any) int {
+	v, ok := x.(*T)
+	if !ok {
+		return 0
+	}
+	return v.f
+}
+
+func unchecked(x any) int {
+	v, _ := x.(*T)
+	return v.f //want "lacking guarding"
+}
+
+func reassigned(x any) int {
+	v, ok := x.(*T)
+	if ok {
+		v = &T{}
+	}
+	return v.f //want "lacking guarding"
+}
+
+func nonPointer(x any) int {
+	v, ok := x.(int)
+	if !ok {
+		return v
+	}
+	return v + 1
+}
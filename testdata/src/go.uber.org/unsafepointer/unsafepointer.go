@@ -0,0 +1,63 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package unsafepointer tests that the nilability is preserved across the conversions between
+// pointers and unsafe.Pointer, e.g., `(*T)(unsafe.Pointer(p))`.
+package unsafepointer
+
+import "unsafe"
+
+type A struct{ f int }
+
+type B struct{ f int }
+
+func nilA() *A { return nil }
+
+func testLocal() int {
+	var a *A
+	b := (*B)(unsafe.Pointer(a))
+	return b.f //want "unassigned variable `a` accessed field `f`"
+}
+
+func testResult() int {
+	b := (*B)(unsafe.Pointer(nilA()))
+	return b.f //want "literal `nil` returned from `nilA\\(\\)`"
+}
+
+func testChecked(a *A) int {
+	if a == nil {
+		return 0
+	}
+	b := (*B)(unsafe.Pointer(a))
+	return b.f
+}
+
+func convert(a *A) *B {
+	return (*B)(unsafe.Pointer(a))
+}
+
+func testParam() int {
+	return convert(nil).f //want "literal `nil` passed as arg `a` to `convert\\(\\)`"
+}
+
+func testNonNil() int {
+	b := (*B)(unsafe.Pointer(&A{}))
+	return b.f
+}
+
+func testUnsafePointer() int {
+	var p unsafe.Pointer
+	b := (*B)(p)
+	return b.f //want "unassigned variable `p` accessed field `f`"
+}
@@ -0,0 +1,121 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package variadic tests the nilability of variadic parameters under inference, where the site of a
+// variadic parameter stands for the elements of the slice it receives, and the slice itself is nil
+// if no argument is passed to it.
+package variadic
+
+type T struct {
+	f int
+}
+
+func derefAll(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		s += x.f //want "literal `nil` passed as arg `xs`"
+	}
+	return s
+}
+
+func derefGuarded(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		if x != nil {
+			s += x.f
+		}
+	}
+	return s
+}
+
+func derefAfterPrefix(prefix string, xs ...*T) int {
+	if len(xs) > 1 {
+		return xs[1].f //want "literal `nil` passed as arg `xs`"
+	}
+	return 0
+}
+
+func derefFirst(xs ...*T) int {
+	return xs[0].f //want "read directly from variadic parameter `xs`"
+}
+
+func callWithNil() {
+	derefAll(&T{}, nil)
+	derefGuarded(nil, nil)
+	derefAfterPrefix("prefix", &T{}, nil)
+}
+
+// Calling a variadic function without arguments for the variadic parameter passes a nil slice,
+// which is safe to range over, but not to index.
+func callWithoutArgs() {
+	derefAll()
+	derefGuarded()
+	derefAfterPrefix("prefix")
+	derefFirst()
+}
+
+func derefSpreadLit(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		s += x.f //want "literal `nil` passed as arg `xs`"
+	}
+	return s
+}
+
+func derefSpreadLitNonnil(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		s += x.f
+	}
+	return s
+}
+
+// The spread form passes the elements of the slice to the variadic parameter, so the elements of a
+// slice literal are treated like individual arguments.
+func callSpreadLit() {
+	derefSpreadLit([]*T{&T{}, nil}...)
+	derefSpreadLitNonnil([]*T{{}, &T{f: 1}}...)
+	derefSpreadLitNonnil(([]*T{})...)
+}
+
+func derefSpreadParam(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		s += x.f //want "deep read from parameter `ys` passed as arg `xs`"
+	}
+	return s
+}
+
+// For other slices, the site of the variadic parameter is tied to the deep site of the slice.
+func callSpreadParam(ys []*T) {
+	ys[0] = nil
+	derefSpreadParam(ys...)
+}
+
+func derefForwarded(xs ...*T) int {
+	s := 0
+	for _, x := range xs {
+		s += x.f //want "index of variadic parameter `xs` passed as arg `xs`"
+	}
+	return s
+}
+
+func forward(xs ...*T) int {
+	return derefForwarded(xs...)
+}
+
+func callForward() {
+	forward(nil)
+	forward()
+}
@@ -0,0 +1,33 @@
+// Package grouperrors tests grouping the diagnostics by their root nil sources.
+package grouperrors
+
+func source() *int {
+	return nil
+}
+
+func pass(x *int) *int {
+	return x
+}
+
+func direct() {
+	print(*source()) //want "dereferenced"
+}
+
+func indirect() {
+	print(*pass(source()))
+}
+
+func local() {
+	x := source()
+	y := x
+	print(*y)
+}
+
+func other() *int {
+	var x *int
+	return x
+}
+
+func unrelated() {
+	print(*other()) //want "dereferenced"
+}
@@ -7,3 +7,28 @@ func main() {
 	// Directly de-referencing a nil pointer, but it is OK since this package is ignored.
 	print(*GlobalVar)
 }
+
+// NewInt returns a pointer, whose nilability is unknown to the packages importing this package.
+func NewInt() *int {
+	return GlobalVar
+}
+
+// NewIntWithErr returns a pointer along with an error.
+func NewIntWithErr() (*int, error) {
+	return GlobalVar, nil
+}
+
+// Num returns a non-pointer result.
+func Num() int {
+	return 0
+}
+
+// Box holds a pointer.
+type Box struct {
+	Value *int
+}
+
+// Get returns the pointer in the box.
+func (b *Box) Get() *int {
+	return b.Value
+}
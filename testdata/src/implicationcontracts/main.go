@@ -0,0 +1,33 @@
+// Package implicationcontracts tests the function contracts written as `nilaway:contract` comments,
+// which are translated into implications between the params and results of the functions.
+package implicationcontracts
+
+// Since the result is nilable, the contract implies that nil may be passed as the param.
+//
+// nilaway:contract nonnil -> nonnil
+func lookup(x *int) *int {
+	print(*x) //want "dereferenced"
+	return nil
+}
+
+// nilaway:contract nonnil, _ -> nonnil
+// nilaway:contract _, nonnil -> nonnil
+func either(x, y *int) *int {
+	print(*x) //want "dereferenced"
+	print(*y) //want "dereferenced"
+	return nil
+}
+
+// The contract does not constrain the params if the result is nonnil.
+//
+// nilaway:contract nonnil -> nonnil
+func nonnilResult(x *int) *int {
+	print(*x)
+	return new(int)
+}
+
+// Without a contract, the param is inferred as nonnil and the callers are checked instead.
+func noContract(x *int) *int {
+	print(*x)
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package longchains tests suppressing the diagnostics whose nil flows are longer than the
+// configured maximum chain depth.
+package longchains
+
+func source() *int {
+	return nil
+}
+
+func a() *int {
+	return source()
+}
+
+func b() *int {
+	return a()
+}
+
+func long() int {
+	return *b()
+}
+
+func short() int {
+	var p *int
+	return *p
+}
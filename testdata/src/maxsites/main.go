@@ -0,0 +1,20 @@
+// Package maxsites tests truncating the analysis when the number of sites exceeds the limit.
+package maxsites // want "analysis truncated"
+
+func first(x *int) *int {
+	return x
+}
+
+func second(x *int) *int {
+	return x
+}
+
+func third(x *int) *int {
+	return x
+}
+
+func chain() {
+	// The nil flows through all three functions, but the implication between the parameter and
+	// the result of `second` is dropped due to the limit, hence the panic is missed.
+	print(*third(second(first(nil))))
+}
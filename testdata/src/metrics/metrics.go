@@ -0,0 +1,19 @@
+// Package metrics tests the metrics recorded for the analyzed packages.
+package metrics
+
+// nilable(f)
+type S struct {
+	f *int
+}
+
+func nilable() *int {
+	return nil
+}
+
+func fieldAccess(s *S) int {
+	return *s.f //want "dereferenced"
+}
+
+func funcReturn() int {
+	return *nilable() //want "dereferenced"
+}
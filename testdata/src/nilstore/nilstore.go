@@ -0,0 +1,63 @@
+// Package nilstore is meant to check the fields whose addresses are passed to the functions that
+// assign nil through the pointer parameters.
+package nilstore
+
+type T struct {
+	p *int
+	q *int
+	r *int
+	s *int
+	u *int
+}
+
+func reset(p **int) {
+	*p = nil
+}
+
+func resetSecond(_ int, p **int) {
+	if p != nil {
+		*p = nil
+	}
+}
+
+func set(p **int) {
+	v := 1
+	*p = &v
+}
+
+func reassigned(p **int) {
+	var local *int
+	p = &local
+	*p = nil
+}
+
+func (t *T) clear(p **int) {
+	*p = nil
+}
+
+func resetField(t *T) {
+	reset(&t.p)
+}
+
+func resetSecondField(t *T) {
+	resetSecond(1, &t.q)
+}
+
+func clearField(t *T) {
+	t.clear(&t.r)
+}
+
+func setField(t *T) {
+	set(&t.s)
+}
+
+func reassignedField(t *T) {
+	reassigned(&t.u)
+}
+
+func use(t *T) int {
+	x := *t.p //want "nil assigned through parameter `p` by `reset\\(\\)`"
+	y := *t.q //want "nil assigned through parameter `p` by `resetSecond\\(\\)`"
+	z := *t.r //want "nil assigned through parameter `p` by `clear\\(\\)`"
+	return x + y + z + *t.s + *t.u
+}
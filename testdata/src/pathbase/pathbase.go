@@ -0,0 +1,10 @@
+// Package pathbase tests printing the positions of the nil flows relative to the path base.
+package pathbase
+
+func nilable() *int {
+	return nil
+}
+
+func deref() int {
+	return *nilable() //want "-> testdata/src/pathbase/pathbase.go:5:9"
+}
@@ -0,0 +1,72 @@
+// Package redundantchecks tests reporting the nil checks on the sites determined to be nonnil.
+package redundantchecks
+
+// nonnil(p)
+func annotated(p *int) int {
+	if p != nil { //want "Redundant nil check: `p` is determined to be nonnil, so the check is always true"
+		return *p
+	}
+	return 0
+}
+
+// nonnil(p)
+func annotatedEql(p *int) int {
+	if p == nil { //want "always false"
+		return 0
+	}
+	return *p
+}
+
+// nonnil(p)
+func reassigned(p *int) int {
+	if p != nil {
+		p = nil
+	}
+	if p != nil {
+		return *p
+	}
+	return 0
+}
+
+func derefAfter(q *int) int {
+	x := *q
+	// The dereference above determines `q` to be nonnil.
+	if q != nil { //want "always true"
+		return x
+	}
+	return 0
+}
+
+func undetermined(q *int) int {
+	// `q` is never dereferenced unguarded, so its site is not determined.
+	if q != nil {
+		return *q
+	}
+	return 0
+}
+
+var g = new(int)
+
+func global() int {
+	return *g
+}
+
+func checkGlobal() bool {
+	return g != nil //want "always true"
+}
+
+func suppressed(q *int) int {
+	_ = *q
+	if q != nil { //nolint:nilaway
+		return 1
+	}
+	return 0
+}
+
+func local() int {
+	p := new(int)
+	if p != nil {
+		return *p
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+// Package reflectnilable is meant to check that the pointers asserted from the dynamic values of
+// `reflect.Value`s are considered nilable if configured.
+package reflectnilable
+
+import "reflect"
+
+type T struct {
+	f int
+}
+
+func testAssert(p *T) int {
+	q := reflect.ValueOf(p).Interface().(*T)
+	return q.f //want "asserted to `\\*T`"
+}
+
+func testAssertChecked(p *T) int {
+	q := reflect.ValueOf(p).Interface().(*T)
+	if q == nil {
+		return 0
+	}
+	return q.f
+}
+
+func testAssertInline(v reflect.Value) int {
+	return v.Interface().(*T).f //want "asserted to `\\*T`"
+}
+
+func testAssertOk(v reflect.Value) int {
+	// The pointer may be nil even if the assertion succeeds.
+	if q, ok := v.Interface().(*T); ok {
+		return q.f //want "asserted to `\\*T`"
+	}
+	return 0
+}
+
+func testAssertNonPointer(v reflect.Value) int {
+	t := v.Interface().(T)
+	return t.f
+}
+
+func testAssertOther(i any) int {
+	// Only the dynamic values of `reflect.Value`s are considered.
+	return i.(*T).f
+}
@@ -0,0 +1,11 @@
+// Package sarif is meant to check the SARIF output of the diagnostics.
+package sarif
+
+func retNil() *int {
+	return nil
+}
+
+func main() {
+	x := retNil()
+	print(*x) //want "Potential nil panic"
+}
@@ -0,0 +1,36 @@
+// Package strictexported tests reporting the exported sites whose nilability is not determined
+// after inference as errors, along with the potential nil panics.
+package strictexported
+
+type T struct {
+	F *int //want "Missing explicit nilability of exported field `Field F` \\(strict mode\\)"
+}
+
+func Exported(x *int) *int { //want "exported param `Param 0: 'x' of Function Exported`" "exported return `Result 0 of Function Exported`"
+	return x
+}
+
+func (t *T) Method() *int { //want "exported return `Result 0 of Function Method`"
+	return t.F
+}
+
+func (t *T) Self() *T { //want "exported receiver `Receiver of Method Self`" "exported return `Result 0 of Function Self`"
+	return t
+}
+
+// Nilable is determined since it returns nil, hence it is not reported.
+func Nilable() *int {
+	return nil
+}
+
+// The potential nil panics are still reported.
+func Deref() int {
+	return *Nilable() //want "dereferenced"
+}
+
+// Global variables are not checked in strict mode.
+var G *int
+
+func unexported(x *int) *int {
+	return x
+}
@@ -0,0 +1,32 @@
+// Package structtags tests the nilability annotations of the fields via struct tags.
+package structtags
+
+// nilable(Overridden)
+type S struct {
+	Nilable    *int `nilaway:"nilable"`
+	NonNil     *int `json:"nonnil,omitempty" nilaway:"nonnil"` //want "assigned into field `NonNil`"
+	Overridden *int `nilaway:"nonnil"`                         //want "assigned into field `Overridden`"
+	Invalid    *int `nilaway:"maybe"`                          //want "invalid value \"maybe\" for struct tag \"nilaway\""
+	Untagged   *int `json:"untagged"`
+}
+
+func readNilable(s *S) int {
+	return *s.Nilable //want "dereferenced"
+}
+
+func readNonNil(s *S) int {
+	return *s.NonNil
+}
+
+func writeNonNil(s *S) {
+	s.NonNil = nil
+}
+
+// The struct tag takes precedence over the annotation in the doc comment of the struct.
+func writeOverridden(s *S) {
+	s.Overridden = nil
+}
+
+func readOverridden(s *S) int {
+	return *s.Overridden
+}
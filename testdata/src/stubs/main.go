@@ -0,0 +1,30 @@
+// Package stubs tests overriding the nilability of the sites in packages that are not analyzed
+// with the stub file.
+package stubs
+
+import "ignoredpkg1"
+
+func funcResult() int {
+	return *ignoredpkg1.NewInt() //want "result 0 of `NewInt\\(\\)`"
+}
+
+func methodResult(b *ignoredpkg1.Box) int {
+	return *b.Get() //want "result 0 of `Get\\(\\)`"
+}
+
+func field(b *ignoredpkg1.Box) int {
+	return *b.Value //want "field `Value`"
+}
+
+func global() int {
+	return *ignoredpkg1.GlobalVar
+}
+
+// The results of the functions without stubs are still optimistically considered nonnil.
+func noStub() int {
+	x, err := ignoredpkg1.NewIntWithErr()
+	if err != nil {
+		return 0
+	}
+	return *x
+}
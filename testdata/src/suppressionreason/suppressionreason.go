@@ -0,0 +1,29 @@
+// Package suppressionreason tests reporting the nolint directives that are not justified by a reason.
+package suppressionreason
+
+func justified() {
+	var x *int
+	print(*x) //nolint:nilaway // reason: external API, see JIRA-123
+}
+
+func bare() {
+	var x *int
+	print(*x) //nolint:nilaway
+}
+
+func noDelimiter() {
+	var x *int
+	//nolint:errcheck,nilaway // external API
+	print(*x)
+}
+
+func tooShort() {
+	var x *int
+	print(*x) //nolint // reason: tbd
+}
+
+func otherLinter() {
+	var x *int
+	//nolint:errcheck
+	_ = x
+}
@@ -0,0 +1,11 @@
+// Package testfilemode tests the different modes of analyzing the test files.
+package testfilemode
+
+func get() (*int, error) {
+	return nil, nil
+}
+
+func deref() int {
+	var p *int
+	return *p
+}
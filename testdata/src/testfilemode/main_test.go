@@ -0,0 +1,28 @@
+package testfilemode
+
+import (
+	"testing"
+
+	"go.uber.org/testing/github.com/stretchr/testify/require"
+)
+
+// failNow stops the test via the interface, which is not known to never return, unlike the
+// direct calls on `*testing.T`.
+func failNow(tb testing.TB, p *int) int {
+	if p == nil {
+		tb.FailNow()
+	}
+	return *p
+}
+
+func requireFail(t *testing.T, p *int) int {
+	if p == nil {
+		require.FailNow(t, "nil")
+	}
+	return *p
+}
+
+func TestGet(t *testing.T) {
+	p, _ := get()
+	print(failNow(t, p), requireFail(t, p))
+}
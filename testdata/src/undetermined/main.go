@@ -0,0 +1,28 @@
+// Package undetermined tests reporting the exported sites whose nilability cannot be inferred.
+package undetermined
+
+type T struct {
+	F *int //want "Undetermined nilability of exported field site `Field F`"
+}
+
+func Exported(x *int) *int { //want "exported param site `Param 0: 'x' of Function Exported`" "exported return site `Result 0 of Function Exported`"
+	return x
+}
+
+// Nilable is determined since it returns nil, hence it is not reported.
+func Nilable() *int {
+	return nil
+}
+
+// Deref is determined since its param is dereferenced, hence it is not reported.
+func Deref(x *int) int {
+	return *x
+}
+
+func (t *T) Method() *int { //want "exported return site `Result 0 of Function Method`"
+	return t.F
+}
+
+func unexported(x *int) *int {
+	return x
+}
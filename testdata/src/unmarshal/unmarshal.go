@@ -0,0 +1,64 @@
+package unmarshal
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type Inner struct {
+	Q *int
+}
+
+type Base struct {
+	B *int
+}
+
+type Config struct {
+	Base
+	P       *int
+	Inner   Inner
+	Skipped *int `json:"-"`
+	hidden  *int
+	N       int
+}
+
+func testUnmarshal(data []byte) int {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return 0
+	}
+	if c.Inner.Q == nil || c.B == nil {
+		return 0
+	}
+	return *c.P + *c.Inner.Q + *c.B //want "value possibly absent from the input of `json.Unmarshal` assigned into field `P`"
+}
+
+func testNotPopulated() int {
+	c := Config{hidden: new(int), Skipped: new(int)}
+	return *c.hidden + *c.Skipped + c.N
+}
+
+type Message struct {
+	Header *Header
+}
+
+type Header struct {
+	ID int
+}
+
+func testDecode(r io.Reader) int {
+	m := &Message{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return 0
+	}
+	// Both calls populating the field are reported as nil sources.
+	return m.Header.ID //want "value possibly absent from the input of `json.Decoder.Decode` assigned into field `Header`" "value possibly absent from the input of `json.Decoder.Decode`"
+}
+
+func testCheckedDecode(r io.Reader) int {
+	var m Message
+	if err := json.NewDecoder(r).Decode(&m); err != nil || m.Header == nil {
+		return 0
+	}
+	return m.Header.ID
+}
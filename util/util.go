@@ -59,6 +59,19 @@ func TypeAsDeepType(t types.Type) (types.Type, bool) {
 	return nil, false
 }
 
+// UnaliasTypeName returns the type name of the named type that an alias denotes (e.g., `U` for
+// `type T = U`), such that the aliases share the sites of the aliased types. It returns the type
+// name itself if it is not an alias or the aliased type is not named (e.g., `type T = *U`).
+func UnaliasTypeName(name *types.TypeName) *types.TypeName {
+	if !name.IsAlias() {
+		return name
+	}
+	if named, ok := name.Type().(*types.Named); ok {
+		return named.Obj()
+	}
+	return name
+}
+
 // TypeIsSlice returns true if `t` is of slice type
 func TypeIsSlice(t types.Type) bool {
 	switch t.(type) {
@@ -169,7 +182,16 @@ func TypeOf(pass *analysis.Pass, expr ast.Expr) types.Type {
 // FuncIdentFromCallExpr return a function identified from a call expression, nil otherwise
 // nilable(result 0)
 func FuncIdentFromCallExpr(expr *ast.CallExpr) *ast.Ident {
-	switch fun := expr.Fun.(type) {
+	fun := expr.Fun
+	// Unwrap the explicit instantiation of generic functions, e.g., `foo[int](x)`.
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	switch fun := fun.(type) {
 	case *ast.Ident:
 		return fun
 	case *ast.SelectorExpr:
@@ -256,7 +278,7 @@ func TypeBarsNilness(t types.Type) bool {
 	case *types.Tuple:
 		return false
 	case *types.Signature:
-		return true // function-types are not inhabited by nil
+		return false
 	case *types.Map:
 		return false
 	case *types.Chan:
@@ -268,11 +290,52 @@ func TypeBarsNilness(t types.Type) bool {
 	case *types.Basic:
 		// all basic types except UntypedNil are not inhabited by nil
 		return t.Kind() != types.UntypedNil
+	case *types.TypeParam:
+		return typeParamBarsNilness(t)
 	default:
 		return true
 	}
 }
 
+// typeParamBarsNilness returns false iff the type parameter `t` can be instantiated with a type
+// inhabited by nil. Type parameters constrained by method sets only (e.g., `any` or `comparable`)
+// can be instantiated with any type, so they are considered inhabited by nil; while type
+// parameters whose type sets are restricted by type terms (e.g., `int64 | float64`) to types that
+// bar nilness also bar nilness.
+func typeParamBarsNilness(t *types.TypeParam) bool {
+	iface, ok := t.Constraint().Underlying().(*types.Interface)
+	return ok && constraintBarsNilness(iface)
+}
+
+// constraintBarsNilness returns true iff the type set of the constraint interface only contains
+// types that bar nilness. Since the type set is the intersection of the type sets of the embedded
+// elements, it suffices that one of the elements is restricted to such types.
+func constraintBarsNilness(iface *types.Interface) bool {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		if elementBarsNilness(iface.EmbeddedType(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// elementBarsNilness returns true iff the element (i.e., a union of terms, a single type term, or
+// an embedded interface) of a constraint interface only contains types that bar nilness.
+func elementBarsNilness(t types.Type) bool {
+	if union, ok := t.(*types.Union); ok {
+		for i := 0; i < union.Len(); i++ {
+			if !elementBarsNilness(union.Term(i).Type()) {
+				return false
+			}
+		}
+		return union.Len() > 0
+	}
+	if iface, ok := t.Underlying().(*types.Interface); ok {
+		return constraintBarsNilness(iface)
+	}
+	return TypeBarsNilness(t)
+}
+
 // ExprBarsNilness returns if the expression can never be nil for the simple reason that nil does
 // not inhabit its type.
 func ExprBarsNilness(pass *analysis.Pass, expr ast.Expr) bool {